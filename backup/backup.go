@@ -0,0 +1,234 @@
+// Package backup writes full exports of the ToDo lists to a directory, on
+// demand and at a regular interval, pruning the old ones.
+//
+// The lists are exported from a consistent snapshot of the store, taken at
+// once under its read lock: encoding and writing the backup happen afterwards
+// and never block the requests. Backups are named after their creation time,
+// todolist-backup-20240601T090000.000Z.json, and written to a temporary file
+// first so that a backup file is always complete.
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/efreddo/v1/todolist/logutils"
+	"github.com/efreddo/v1/todolist/model"
+)
+
+const (
+	filePrefix = "todolist-backup-"
+	fileSuffix = ".json"
+	timeLayout = "20060102T150405.000Z"
+)
+
+// ErrNotConfigured is returned when a backup is requested without a directory
+var ErrNotConfigured = errors.New("backups not configured, no backup directory")
+
+// Policy sets where and how often backups are written and how many are kept
+type Policy struct {
+	Dir string
+	// Interval between the scheduled backups, 0 for on demand backups only
+	Interval time.Duration
+	// Keep is the number of backups kept, 0 meaning no limit
+	Keep int
+	// MaxAge is the age beyond which backups are removed, 0 meaning no limit
+	MaxAge time.Duration
+}
+
+// Result describes a backup written
+type Result struct {
+	File      string
+	Size      int64
+	Lists     int
+	CreatedAt time.Time
+	// Pruned are the old backups removed afterwards
+	Pruned []string `json:",omitempty"`
+}
+
+// Status reports the last backup and whether backups are stale: scheduled
+// but none written for more than two intervals
+type Status struct {
+	Policy    Policy
+	Last      *Result `json:",omitempty"`
+	LastError string  `json:",omitempty"`
+	Stale     bool
+}
+
+var (
+	lock      sync.RWMutex
+	policy    Policy
+	since     time.Time
+	last      *Result
+	lastError string
+	// running serializes the backups, scheduled and on demand
+	running sync.Mutex
+	now     = time.Now
+)
+
+// SetPolicy sets the backup policy, effective from the next backup
+func SetPolicy(p Policy) error {
+	if p.Interval < 0 || p.Keep < 0 || p.MaxAge < 0 {
+		return fmt.Errorf("invalid backup policy %+v, expected non negative interval, count and age", p)
+	}
+	if p.Dir == "" && p.Interval > 0 {
+		return fmt.Errorf("invalid backup policy, scheduled backups need a directory")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	policy = p
+	since = now()
+	return nil
+}
+
+// CurrentPolicy returns the backup policy
+func CurrentPolicy() Policy {
+	lock.RLock()
+	defer lock.RUnlock()
+	return policy
+}
+
+// CurrentStatus returns the last backup and whether backups are stale
+func CurrentStatus() Status {
+	lock.RLock()
+	defer lock.RUnlock()
+	status := Status{Policy: policy, Last: last, LastError: lastError}
+	if policy.Interval > 0 {
+		reference := since
+		if last != nil && last.CreatedAt.After(reference) {
+			reference = last.CreatedAt
+		}
+		status.Stale = now().Sub(reference) > 2*policy.Interval
+	}
+	return status
+}
+
+// Run writes a backup of all the ToDo lists, then prunes the old backups
+func Run() (*Result, error) {
+	running.Lock()
+	defer running.Unlock()
+	p := CurrentPolicy()
+	if p.Dir == "" {
+		return nil, ErrNotConfigured
+	}
+
+	result, err := write(p.Dir, model.ExportAll())
+	if err == nil {
+		result.Pruned, err = prune(p, result.CreatedAt)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if result != nil {
+		last = result
+	}
+	lastError = ""
+	if err != nil {
+		lastError = err.Error()
+	}
+	return result, err
+}
+
+// Start runs the scheduled backups, every interval of the current policy,
+// until stop is closed. The outcome of each backup is logged.
+func Start(stop <-chan struct{}) {
+	interval := CurrentPolicy().Interval
+	if interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			result, err := Run()
+			if err != nil {
+				logutils.Error.Println(fmt.Sprintf("backup:: backup failed: %v", err))
+				continue
+			}
+			logutils.Info.Println(fmt.Sprintf(
+				"backup:: %d lists written to %s (%d bytes), %d old backups removed",
+				result.Lists, result.File, result.Size, len(result.Pruned)))
+		}
+	}
+}
+
+// write writes the export to a new backup file of the directory
+func write(dir string, export *model.ToDoListExport) (*Result, error) {
+	content, err := json.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	createdAt := now().UTC()
+	file := filepath.Join(dir, filePrefix+createdAt.Format(timeLayout)+fileSuffix)
+	tmp, err := os.CreateTemp(dir, ".tmp-"+filePrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return nil, err
+	}
+	return &Result{File: file, Size: int64(len(content)), Lists: len(export.Lists), CreatedAt: createdAt}, nil
+}
+
+// prune removes the backups beyond the number kept or older than the maximum
+// age, the latest one being always kept
+func prune(p Policy, latest time.Time) ([]string, error) {
+	entries, err := os.ReadDir(p.Dir)
+	if err != nil {
+		return nil, err
+	}
+	type backup struct {
+		file      string
+		createdAt time.Time
+	}
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		createdAt, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{filepath.Join(p.Dir, name), createdAt})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].createdAt.After(backups[j].createdAt)
+	})
+
+	var pruned []string
+	for i, b := range backups {
+		tooMany := p.Keep > 0 && i >= p.Keep
+		tooOld := p.MaxAge > 0 && latest.Sub(b.createdAt) > p.MaxAge
+		if i == 0 || (!tooMany && !tooOld) {
+			continue
+		}
+		if err := os.Remove(b.file); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, b.file)
+	}
+	return pruned, nil
+}
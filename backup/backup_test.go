@@ -0,0 +1,84 @@
+package backup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/efreddo/v1/todolist/model"
+)
+
+func at(minute int) func() time.Time {
+	return func() time.Time {
+		return time.Date(2024, 6, 1, 9, minute, 0, 0, time.UTC)
+	}
+}
+
+func TestSetPolicy_invalid_error(t *testing.T) {
+	if err := SetPolicy(Policy{Dir: "backups", Keep: -1}); err == nil {
+		t.Errorf("expected invalid policy error, got nil")
+	}
+	if err := SetPolicy(Policy{Interval: time.Hour}); err == nil {
+		t.Errorf("expected error scheduling backups without a directory, got nil")
+	}
+}
+
+func TestRun_notConfigured_error(t *testing.T) {
+	SetPolicy(Policy{})
+	if _, err := Run(); err != ErrNotConfigured {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+}
+
+func TestRun_prune_ok(t *testing.T) {
+	defer func() { now = time.Now }()
+	dir := t.TempDir()
+	model.CreateToDoList("BackupList")
+	SetPolicy(Policy{Dir: dir, Keep: 2, MaxAge: 25 * time.Minute})
+
+	var results []*Result
+	for _, minute := range []int{0, 10, 20, 40} {
+		now = at(minute)
+		result, err := Run()
+		if err != nil {
+			t.Fatalf("no error expected, got %v", err)
+		}
+		results = append(results, result)
+	}
+	if len(results[2].Pruned) != 1 || results[2].Pruned[0] != results[0].File {
+		t.Errorf("expected the first backup pruned beyond 2 backups, got %v", results[2].Pruned)
+	}
+	if len(results[3].Pruned) != 1 || results[3].Pruned[0] != results[1].File {
+		t.Errorf("expected the second backup pruned beyond 25 minutes, got %v", results[3].Pruned)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 backups left, got %v", files)
+	}
+	content, _ := os.ReadFile(results[3].File)
+	export := model.ToDoListExport{}
+	if err := json.Unmarshal(content, &export); err != nil || export.Version != model.ExportVersion || len(export.Lists) != results[3].Lists {
+		t.Errorf("expected a full export in the backup, got %v", err)
+	}
+	if int64(len(content)) != results[3].Size {
+		t.Errorf("expected size %d, got %d", len(content), results[3].Size)
+	}
+}
+
+func TestCurrentStatus_stale(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = at(0)
+	SetPolicy(Policy{Dir: t.TempDir(), Interval: 10 * time.Minute})
+	now = at(15)
+	if CurrentStatus().Stale {
+		t.Errorf("expected backups not stale within two intervals")
+	}
+	Run()
+	now = at(40)
+	if status := CurrentStatus(); !status.Stale || status.Last == nil {
+		t.Errorf("expected backups stale 25 minutes after the last one, got %+v", status)
+	}
+}
@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/efreddo/v1/todolist/backup"
+	"github.com/efreddo/v1/todolist/ipfilter"
+	"github.com/efreddo/v1/todolist/logutils"
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	ADMIN_BADREQUEST = 40;
+	ADMIN_CONFLICT = 41;
+	ADMIN_OPERATION_ERROR = 42;
+)
+
+/* 
+	request type: GET
+	url: /admin/ipfilter
+	Returns the CIDRs allowed, denied and trusted as proxies by the IP filter
+
+	Examples:
+
+	   req: GET /admin/ipfilter
+	   res: 200 {"Allow":["192.168.1.0/24"],"Deny":null,"TrustedProxies":["10.0.0.1"]}
+*/
+func GetIPFilter(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	writeJSON(w, ipfilter.CurrentRules())
+}
+
+/* 
+	request type: PUT
+	url: /admin/ipfilter {"Allow": ["192.168.1.0/24", "2001:db8::/32"], "Deny": [], "TrustedProxies": ["10.0.0.1"]}
+	Replaces the rules of the IP filter, effective from the next request. The current rules
+	are kept when a rule is invalid
+
+	Examples:
+
+	   req: PUT /admin/ipfilter {"Allow": ["192.168.1.0/33"]}
+	   res: 400 invalid CIDR
+
+	   req: PUT /admin/ipfilter {"Allow": ["192.168.1.0/24"]}
+	   res: 200
+*/
+func SetIPFilter(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	rules := ipfilter.Rules{}
+	if err := decodeBody(r, &rules); err != nil {
+		adminBadRequestError(w, "SetIPFilter", err)
+		return
+	}
+	if err := ipfilter.SetRules(rules); err != nil {
+		adminBadRequestError(w, "SetIPFilter", err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"SetIPFilter:: IP filter reloaded: %d allowed, %d denied, %d trusted proxies",
+		len(rules.Allow), len(rules.Deny), len(rules.TrustedProxies)))
+	writeJSON(w, ipfilter.CurrentRules())
+}
+
+/* 
+	request type: GET
+	url: /admin/orphans
+	Returns the index entries of the tasks that no ToDo list holds anymore, e.g. left behind
+	by a partial delete, with the index (completed or due) and the day they are filed under
+
+	Examples:
+
+	   req: GET /admin/orphans
+	   res: 200 {"Count":1,"Tasks":[{"List":"deletedlist","Title":"oktask","Index":"due","Day":"2024-06-01"}]}
+*/
+func GetOrphanedTasks(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	orphans := model.FindOrphanedTasks()
+	logutils.Info.Println(fmt.Sprintf("GetOrphanedTasks:: %d orphaned tasks found", len(orphans)))
+	writeOrphanedTasks(w, orphans)
+}
+
+/* 
+	request type: POST
+	url: /admin/orphans/cleanup
+	Removes the index entries of the tasks that no ToDo list holds anymore and returns them
+
+	Examples:
+
+	   req: POST /admin/orphans/cleanup
+	   res: 200 {"Count":1,"Tasks":[{"List":"deletedlist","Title":"oktask","Index":"due","Day":"2024-06-01"}]}
+*/
+func CleanupOrphanedTasks(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	orphans := model.CleanupOrphanedTasks()
+	logutils.Info.Println(fmt.Sprintf("CleanupOrphanedTasks:: %d orphaned tasks removed", len(orphans)))
+	writeOrphanedTasks(w, orphans)
+}
+
+/* 
+	request type: POST
+	url: /admin/compact
+	Compacts the store online: drops the expired tombstones, the orphaned index entries,
+	the task changes and access records left by the deleted tasks and ToDo lists, and the
+	events older than the history retention, returning the number of entries dropped of
+	each kind and an estimate of the bytes released
+
+	Examples:
+
+	   req: POST /admin/compact
+	   res: 200 {"Tombstones":12,"OrphanedTasks":0,"TaskChanges":3,"AccessRecords":1,"Events":250,"Bytes":31400}
+*/
+func CompactStore(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	compaction := model.Compact()
+	logutils.Info.Println(fmt.Sprintf("CompactStore:: %d entries dropped %+v", compaction.Dropped(), compaction))
+	writeJSON(w, compaction)
+}
+
+/* 
+	request type: GET
+	url: /admin/backup/
+	Returns the backup policy, the last backup written and whether backups are stale:
+	scheduled but none written for more than two intervals
+
+	Examples:
+
+	   req: GET /admin/backup/
+	   res: 200 {"Policy":{"Dir":"/var/backups/todolist","Interval":3600000000000,"Keep":24,"MaxAge":0},"Last":{"File":"/var/backups/todolist/todolist-backup-20240601T090000.000Z.json","Size":5120,"Lists":3,"CreatedAt":"2024-06-01T09:00:00Z"},"Stale":false}
+*/
+func GetBackupStatus(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	status := backup.CurrentStatus()
+	if status.Stale {
+		logutils.Warning.Println("GetBackupStatus:: backups are stale")
+	}
+	writeJSON(w, status)
+}
+
+/* 
+	request type: POST
+	url: /admin/backup/
+	Writes a backup of all the ToDo lists now, then prunes the old backups
+
+	Examples:
+
+	   req: POST /admin/backup/
+	   res: 409 backups not configured
+
+	   req: POST /admin/backup/
+	   res: 201 {"File":"/var/backups/todolist/todolist-backup-20240601T090000.000Z.json","Size":5120,"Lists":3,"CreatedAt":"2024-06-01T09:00:00Z"}
+*/
+func CreateBackup(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	result, err := backup.Run()
+	if err == backup.ErrNotConfigured {
+		HandleError(w, http.StatusConflict, ADMIN_CONFLICT, "CreateBackup",
+			"Backups not configured",
+			fmt.Sprintf("%v", err))
+		return
+	}
+	if err != nil && result == nil {
+		HandleError(w, http.StatusInternalServerError, ADMIN_OPERATION_ERROR, "CreateBackup",
+			"Error while writing the backup",
+			fmt.Sprintf("%v", err))
+		return
+	}
+	if err != nil {
+		logutils.Error.Println(fmt.Sprintf("CreateBackup:: backup written, pruning failed: %v", err))
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"CreateBackup:: %d lists written to %s (%d bytes)", result.Lists, result.File, result.Size))
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, result)
+}
+
+func writeOrphanedTasks(w http.ResponseWriter, orphans []model.OrphanedTask) {
+	writeJSON(w, struct {
+		Count int
+		Tasks []model.OrphanedTask
+	}{len(orphans), orphans})
+}
+
+func adminBadRequestError(w http.ResponseWriter, caller string, err error) {
+	if deprecationError(w, ADMIN_BADREQUEST, caller, err) {
+		return
+	}
+	HandleError(w, http.StatusBadRequest, ADMIN_BADREQUEST, caller,
+		"Invalid admin request",
+		fmt.Sprintf("Bad request received: %v", err))
+}
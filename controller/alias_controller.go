@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// fieldName matches the valid response field names and aliases
+var fieldName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// fieldAliases maps the response fields to the names they are written with,
+// see SetFieldAliases
+var fieldAliases = map[string]string{}
+
+// SetFieldAliases sets the names the response fields are written with, e.g.
+// {"Name": "title"} for a client expecting title, the canonical names being
+// kept for the other fields. The aliases rename the fields of every object of
+// the responses and affect the output only, the requests being parsed with the
+// canonical names. An alias can not be aliased itself nor shared by two fields.
+func SetFieldAliases(aliases map[string]string) error {
+	used := make(map[string]string, len(aliases))
+	for field, alias := range aliases {
+		switch {
+		case !fieldName.MatchString(field) || !fieldName.MatchString(alias):
+			return fmt.Errorf("invalid field alias %s=%s, expected letters, digits and underscores", field, alias)
+		case field == alias:
+			return fmt.Errorf("invalid field alias %s=%s, the field is aliased to itself", field, alias)
+		case used[alias] != "":
+			return fmt.Errorf("invalid field alias %s=%s, %s is already the alias of %s", field, alias, alias, used[alias])
+		}
+		if _, aliased := aliases[alias]; aliased {
+			return fmt.Errorf("invalid field alias %s=%s, %s is aliased itself", field, alias, alias)
+		}
+		used[alias] = field
+	}
+	fieldAliases = make(map[string]string, len(aliases))
+	for field, alias := range aliases {
+		fieldAliases[field] = alias
+	}
+	return nil
+}
+
+// FieldAliases returns the names the response fields are written with
+func FieldAliases() map[string]string {
+	aliases := make(map[string]string, len(fieldAliases))
+	for field, alias := range fieldAliases {
+		aliases[field] = alias
+	}
+	return aliases
+}
+
+// fieldAlias returns the name the response field is written with
+func fieldAlias(name string) string {
+	if alias, ok := fieldAliases[name]; ok {
+		return alias
+	}
+	return name
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestSetFieldAliases_invalid_error(t *testing.T) {
+	invalid := []map[string]string{
+		{"Name": ""},
+		{"Name": "list name"},
+		{"Name": "Name"},
+		{"Name": "title", "Title": "title"},
+		{"Name": "Title", "Title": "name"},
+	}
+	for _, aliases := range invalid {
+		if err := SetFieldAliases(aliases); err == nil {
+			t.Errorf("expected error with the aliases %v", aliases)
+		}
+	}
+	if len(FieldAliases()) != 0 {
+		t.Errorf("expected no alias set by the invalid aliases, got %v", FieldAliases())
+	}
+}
+
+func TestSetFieldAliases_output(t *testing.T) {
+	defer SetFieldAliases(nil)
+	if err := SetFieldAliases(map[string]string{"Name": "title", "Done": "completed"}); err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	model.CreateToDoListWithTasks("ControllerListAliases", []string{"Passport"})
+	model.SetTaskDetails("ControllerListAliases", "Passport", model.TaskDetails{Meta: map[string]string{"Name": "jira"}})
+	params := httprouter.Params{{Key: "list", Value: "ControllerListAliases"}}
+
+	res := httptest.NewRecorder()
+	GetToDoList(res, httptest.NewRequest("GET", "/lists/ControllerListAliases/", nil), params)
+	list := struct {
+		Title string `json:"title"`
+		Tasks []map[string]json.RawMessage
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&list); err != nil || list.Title != "ControllerListAliases" || len(list.Tasks) != 1 {
+		t.Fatalf("expected the list name as title, got %+v (%v)", list, err)
+	}
+	task := list.Tasks[0]
+	if _, ok := task["Done"]; ok || string(task["completed"]) != "false" || string(task["Meta"]) != `{"Name":"jira"}` {
+		t.Errorf("expected Done aliased and the Meta keys unchanged, got %v", task)
+	}
+
+	res = httptest.NewRecorder()
+	CreateTask(res, httptest.NewRequest("POST", "/lists/ControllerListAliases/tasks", strings.NewReader(`{"Title": "Tickets"}`)), params)
+	if !strings.Contains(res.Body.String(), `"Title":"Tickets"`) || !strings.Contains(res.Body.String(), `"completed":false`) {
+		t.Errorf("expected the request parsed with the canonical names, got %d %s", res.Code, res.Body.String())
+	}
+}
+
+func TestSetFieldAliases_queryLists(t *testing.T) {
+	defer SetFieldAliases(nil)
+	SetFieldAliases(map[string]string{"Name": "title", "Done": "completed"})
+	model.CreateToDoListWithTasks("ControllerListQueryAliases", []string{"Passport"})
+
+	for _, query := range []string{"name=ControllerListQueryAliases", "name=ControllerListQueryAliases&fields=Name,Tasks"} {
+		res := httptest.NewRecorder()
+		QueryLists(res, httptest.NewRequest("GET", "/lists/query?"+query, nil), nil)
+		result := struct {
+			Lists []map[string]json.RawMessage
+		}{}
+		if err := json.NewDecoder(res.Body).Decode(&result); err != nil || len(result.Lists) != 1 {
+			t.Fatalf("expected one list for %q, got %+v (%v)", query, result, err)
+		}
+		list := result.Lists[0]
+		if _, ok := list["Name"]; ok || string(list["title"]) != `"ControllerListQueryAliases"` ||
+			!strings.Contains(string(list["Tasks"]), `"completed":false`) {
+			t.Errorf("expected the names of the list and its tasks aliased for %q, got %v", query, list)
+		}
+	}
+}
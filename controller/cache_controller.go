@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Route categories of the cache policies
+const (
+	CacheLists     = "lists"
+	CacheTasks     = "tasks"
+	CacheStats     = "stats"
+	CacheTemplates = "templates"
+	CacheAdmin     = "admin"
+)
+
+// cachePolicies are the Cache-Control headers of the successful reads by
+// route category, see SetCachePolicy
+var cachePolicies = map[string]string{
+	CacheLists:     "no-cache",
+	CacheTasks:     "no-cache",
+	CacheStats:     "no-cache",
+	CacheTemplates: "no-cache",
+	CacheAdmin:     "no-store",
+}
+
+// SetCachePolicy sets the Cache-Control header of the successful reads of the
+// route category: no-cache (revalidated with the ETag), no-store, or a
+// number of seconds the responses can be cached for (max-age)
+func SetCachePolicy(category, policy string) error {
+	if _, ok := cachePolicies[category]; !ok {
+		return fmt.Errorf("unknown cache category %s, expected lists, tasks, stats, templates or admin", category)
+	}
+	switch policy {
+	case "no-cache", "no-store":
+		cachePolicies[category] = policy
+		return nil
+	}
+	seconds, err := strconv.Atoi(policy)
+	if err != nil || seconds < 0 {
+		return fmt.Errorf("invalid cache policy %s, expected no-cache, no-store or a number of seconds", policy)
+	}
+	cachePolicies[category] = fmt.Sprintf("max-age=%d", seconds)
+	return nil
+}
+
+// CachePolicies returns the Cache-Control headers by route category
+func CachePolicies() map[string]string {
+	policies := make(map[string]string, len(cachePolicies))
+	for category, policy := range cachePolicies {
+		policies[category] = policy
+	}
+	return policies
+}
+
+/*
+	CacheControl sets the Cache-Control header of the GET and HEAD responses after the
+	policy of their route category, unless the handler set its own: lists, tasks, stats
+	(statistics, reviews, digests, calendar and activity), templates and admin (admin,
+	configuration and metrics). Error responses are never stored.
+
+	Examples:
+
+	   req: GET /lists/oklist/
+	   res: 200 Cache-Control: no-cache
+
+	   req: GET /lists/wronglist/
+	   res: 404 Cache-Control: no-store
+*/
+func CacheControl(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		category := cacheCategory(r.URL.Path)
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || category == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&cacheWriter{ResponseWriter: w, policy: cachePolicies[category]}, r)
+	})
+}
+
+// cacheCategory returns the route category of the path, empty for the
+// routes without a cache policy
+func cacheCategory(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/admin/"), path == "/config", path == "/metrics":
+		return CacheAdmin
+	case strings.HasPrefix(path, "/tasktemplates/"):
+		return CacheTemplates
+	case strings.HasPrefix(path, "/stats/"), strings.HasPrefix(path, "/digest/"), path == "/review",
+		path == "/calendar", path == "/activity", strings.HasPrefix(path, "/lists/") && strings.Contains(path, "/stats/"):
+		return CacheStats
+	case strings.HasPrefix(path, "/tasks/"), path == "/overdue",
+		strings.HasPrefix(path, "/lists/") && (strings.Contains(path, "/tasks") || strings.HasSuffix(path, "/next")):
+		return CacheTasks
+	case strings.HasPrefix(path, "/lists/"):
+		return CacheLists
+	}
+	return ""
+}
+
+// cacheWriter sets the Cache-Control header when the response starts
+type cacheWriter struct {
+	http.ResponseWriter
+	policy      string
+	wroteHeader bool
+}
+
+func (cw *cacheWriter) WriteHeader(status int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		if cw.Header().Get("Cache-Control") == "" {
+			if status < 400 {
+				cw.Header().Set("Cache-Control", cw.policy)
+			} else {
+				cw.Header().Set("Cache-Control", "no-store")
+			}
+		}
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *cacheWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush flushes the response when the underlying writer supports it, for the
+// streamed responses
+func (cw *cacheWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
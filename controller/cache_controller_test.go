@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCacheControl(t *testing.T) {
+	defer SetCachePolicy(CacheStats, "no-cache")
+	if err := SetCachePolicy(CacheStats, "300"); err != nil {
+		t.Fatalf("expected the stats policy set, got %v", err)
+	}
+	handler := CacheControl(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/lists/missing/" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte("{}"))
+	}))
+	expected := []struct {
+		method, path, cacheControl string
+	}{
+		{"GET", "/lists/oklist/", "no-cache"},
+		{"GET", "/lists/oklist/tasks/oktask", "no-cache"},
+		{"GET", "/lists/oklist/stats/history", "max-age=300"},
+		{"GET", "/activity", "max-age=300"},
+		{"GET", "/admin/ipfilter", "no-store"},
+		{"GET", "/lists/missing/", "no-store"},
+		{"POST", "/lists/", ""},
+		{"GET", "/test/", ""},
+	}
+	for _, e := range expected {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(e.method, e.path, nil))
+		if cacheControl := res.Header().Get("Cache-Control"); cacheControl != e.cacheControl {
+			t.Errorf("expected Cache-Control %q for %s %s, got %q", e.cacheControl, e.method, e.path, cacheControl)
+		}
+	}
+
+	for _, policy := range []string{"lists=-1", "public"} {
+		if err := SetCachePolicy(CacheLists, policy); err == nil {
+			t.Errorf("expected the policy %s rejected", policy)
+		}
+	}
+	if err := SetCachePolicy("users", "no-cache"); err == nil {
+		t.Errorf("expected an unknown category rejected")
+	}
+}
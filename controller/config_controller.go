@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/* 
+	request type: GET
+	url: /config
+	Returns the resolved settings affecting the responses: the default page sizes of the
+	paginated endpoints (0 meaning no limit), the maximum number of tasks listed without
+	pagination, the maximum size of the import bodies, the Cache-Control headers of the
+	reads by route category, the null handling of the empty optional fields and the aliases
+	of the response fields
+
+	Examples:
+
+	   req: GET /config
+	   res: 200 {"PageSizes":{"Lists":20,"Tasks":100,"Search":50},"MaxUnpaginatedTasks":500,"MaxImportSize":33554432,
+	             "CacheControl":{"admin":"no-store","lists":"no-cache","stats":"max-age=300",...},
+	             "NullHandling":"omit","FieldAliases":{"Name":"title"}}
+*/
+func GetConfig(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	writeJSON(w, struct {
+		PageSizes           PageSizes
+		MaxUnpaginatedTasks int
+		MaxImportSize       int64
+		CacheControl        map[string]string
+		NullHandling        string
+		FieldAliases        map[string]string
+	}{CurrentPageSizes(), maxUnpaginatedTasks, maxImportSize, CachePolicies(), NullHandling(), FieldAliases()})
+}
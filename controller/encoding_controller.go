@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	BODY_BADREQUEST = 50;
+	BODY_UNSUPPORTED_ENCODING = 51;
+)
+
+// maxImportSize is the maximum size, in bytes, of the decompressed body of the
+// import requests
+var maxImportSize int64 = 32 << 20
+
+// SetMaxImportSize sets the maximum size, in bytes, of the decompressed body of
+// the import requests
+func SetMaxImportSize(size int64) error {
+	if size < 1 {
+		return fmt.Errorf("invalid maximum import size %d", size)
+	}
+	maxImportSize = size
+	return nil
+}
+
+/*
+	ContentEncoding honors the Content-Encoding header of the requests: import requests,
+	selected by compressed, may send gzip bodies, decompressed transparently, every other
+	request must send identity bodies. The decompressed body of import requests is limited
+	to the maximum import size, reads failing beyond it.
+
+	Examples:
+
+	   req: PUT /lists/oklist  Content-Encoding: gzip
+	   res: 415 unsupported content encoding
+
+	   req: POST /lists/archive  Content-Encoding: br
+	   res: 415 unsupported content encoding
+
+	   req: POST /lists/archive  Content-Encoding: gzip, body not in gzip format
+	   res: 400 corrupt gzip stream
+
+	   req: POST /lists/archive  Content-Encoding: gzip
+	   res: the import response
+*/
+func ContentEncoding(next http.Handler, compressed func(r *http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+		if encoding == "" || encoding == "identity" {
+			if compressed(r) {
+				r.Body = &limitedBody{Reader: r.Body, Closer: r.Body, remaining: maxImportSize}
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if encoding != "gzip" || !compressed(r) {
+			HandleError(w, http.StatusUnsupportedMediaType, BODY_UNSUPPORTED_ENCODING, "ContentEncoding",
+				fmt.Sprintf("Unsupported content encoding %s", encoding),
+				fmt.Sprintf("Content-Encoding %s not accepted by %s %s", encoding, r.Method, r.URL.Path))
+			return
+		}
+
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			HandleError(w, http.StatusBadRequest, BODY_BADREQUEST, "ContentEncoding",
+				"Corrupt gzip stream",
+				fmt.Sprintf("Bad request received: %v", err))
+			return
+		}
+		defer gz.Close()
+		r.Body = &limitedBody{Reader: gz, Closer: r.Body, remaining: maxImportSize}
+		r.Header.Del("Content-Encoding")
+		r.ContentLength = -1
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitedBody fails the reads beyond the remaining bytes, rather than
+// truncating the body as io.LimitReader does
+type limitedBody struct {
+	io.Reader
+	io.Closer
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.Reader.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		return n, fmt.Errorf("request body larger than %d bytes", maxImportSize)
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
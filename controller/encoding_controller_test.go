@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipped(t *testing.T, data []byte) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatalf("no error expected compressing the fixture, got %v", err)
+	}
+	gz.Close()
+	return buf
+}
+
+// readBody answers with the body read, or 400 with the read error
+func readBody(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Write(body)
+}
+
+func encodingHandler() http.Handler {
+	return ContentEncoding(http.HandlerFunc(readBody), func(r *http.Request) bool {
+		return r.URL.Path == "/lists/archive"
+	})
+}
+
+func TestContentEncoding_gzip_ok(t *testing.T) {
+	archive := `{"Version":1,"List":{"Name":"Compressed"}}`
+	req := httptest.NewRequest("POST", "/lists/archive", gzipped(t, []byte(archive)))
+	req.Header.Set("Content-Encoding", "gzip")
+	res := httptest.NewRecorder()
+
+	encodingHandler().ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK || res.Body.String() != archive {
+		t.Errorf("expected the decompressed body, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestContentEncoding_unsupported_error(t *testing.T) {
+	expected := []struct {
+		path     string
+		encoding string
+	}{{"/lists/", "gzip"}, {"/lists/archive", "br"}}
+	for _, e := range expected {
+		req := httptest.NewRequest("POST", e.path, gzipped(t, []byte(`{}`)))
+		req.Header.Set("Content-Encoding", e.encoding)
+		res := httptest.NewRecorder()
+
+		encodingHandler().ServeHTTP(res, req)
+
+		if res.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("expected status 415 for %s %s, got %d", e.encoding, e.path, res.Code)
+		}
+	}
+}
+
+func TestContentEncoding_corrupt_error(t *testing.T) {
+	req := httptest.NewRequest("POST", "/lists/archive", strings.NewReader(`{"Version":1}`))
+	req.Header.Set("Content-Encoding", "gzip")
+	res := httptest.NewRecorder()
+
+	encodingHandler().ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "Corrupt gzip stream") {
+		t.Errorf("expected status 400 for a corrupt stream, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestContentEncoding_bomb_cutOff(t *testing.T) {
+	defer SetMaxImportSize(maxImportSize)
+	SetMaxImportSize(1 << 20)
+	// 64 MB of zeros compress to a few tens of KB
+	bomb := gzipped(t, make([]byte, 64<<20))
+	req := httptest.NewRequest("POST", "/lists/archive", bomb)
+	req.Header.Set("Content-Encoding", "gzip")
+	res := httptest.NewRecorder()
+
+	encodingHandler().ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "larger than 1048576 bytes") {
+		t.Errorf("expected the body cut off at the limit, got %d: %.100s", res.Code, res.Body.String())
+	}
+}
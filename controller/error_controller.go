@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/efreddo/v1/todolist/logutils"
+	"github.com/efreddo/v1/todolist/validation"
 )
 
 
@@ -16,28 +17,51 @@ type CustomError struct {
 	Code  int  
 	ErrorMessage string 
 	TechnicalReason  string   
+	Field string `json:",omitempty"`
+	Rule  string `json:",omitempty"`
+	Value interface{} `json:",omitempty"`
 }
 
 
 func HandleError(w http.ResponseWriter, httpCode, internalCode int, caller,  message, techReason string)  {
-
-	var errorString string
-	listErrors := new(ListError)
-	errors := make([]CustomError, 1)	
-	errors[0] = CustomError{ 
+	writeErrors(w, httpCode, caller, []CustomError{{
 		Code: internalCode,
 		ErrorMessage: message,
-		TechnicalReason: techReason}
-	listErrors.Errors = errors
+		TechnicalReason: techReason}})
+}
+
+// HandleValidationError reports all the rule violations of a request body at
+// once, with an error per violation detailing the field, the rule and the
+// offending value
+func HandleValidationError(w http.ResponseWriter, internalCode int, caller string, violations []validation.Violation) {
+	errors := make([]CustomError, len(violations))
+	for i, v := range violations {
+		errors[i] = CustomError{
+			Code: internalCode,
+			ErrorMessage: fmt.Sprintf("Invalid field %s", v.Field),
+			TechnicalReason: fmt.Sprintf("Validation failed: %s", v),
+			Field: v.Field,
+			Rule: v.Rule,
+			Value: v.Value}
+	}
+	writeErrors(w, http.StatusBadRequest, caller, errors)
+}
+
+func writeErrors(w http.ResponseWriter, httpCode int, caller string, errors []CustomError) {
+
+	var errorString string
+	listErrors := &ListError{Errors: errors}
 
 	errorJson, err := json.Marshal(listErrors)
 	if err != nil {
 		fmt.Printf("Error: %s", err)
-		errorString = message;
+		errorString = errors[0].ErrorMessage;
 	}else{
 		errorString = string(errorJson)
 	}
-	logutils.Error.Println(fmt.Sprintf("%s:: %s. Reason={%s}",caller, message, techReason))
+	for _, e := range errors {
+		logutils.Error.Println(fmt.Sprintf("%s:: %s. Reason={%s}",caller, e.ErrorMessage, e.TechnicalReason))
+	}
 	http.Error(w, errorString, httpCode)
 
 }
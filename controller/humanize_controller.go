@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/efreddo/v1/todolist/dateutils"
+	"github.com/efreddo/v1/todolist/model"
+)
+
+// RelativeTimes are the relative time fields added to the tasks with
+// ?humanize=true, next to the absolute timestamps
+type RelativeTimes struct {
+	DueIn        string `json:",omitempty"`
+	CreatedAgo   string `json:",omitempty"`
+	CompletedAgo string `json:",omitempty"`
+}
+
+// humanizedTask is a task with its relative time fields
+type humanizedTask struct {
+	*model.Task
+	RelativeTimes
+}
+
+// humanizedNumberedTask is a listed task with its relative time fields
+type humanizedNumberedTask struct {
+	model.NumberedTask
+	RelativeTimes
+}
+
+// requestHumanize parses the humanize query parameter, returning the
+// current time in the requested time zone when set
+func requestHumanize(r *http.Request) (bool, time.Time, error) {
+	switch r.URL.Query().Get("humanize") {
+	case "", "false":
+		return false, time.Time{}, nil
+	case "true":
+	default:
+		return false, time.Time{}, fmt.Errorf("invalid humanize %s, expected true or false", r.URL.Query().Get("humanize"))
+	}
+	loc, err := requestLocation(r)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	return true, time.Now().In(loc), nil
+}
+
+// relativeTimes returns the relative time fields of the task at now
+func relativeTimes(t *model.Task, now time.Time) RelativeTimes {
+	var times RelativeTimes
+	if t.DueDate != nil {
+		times.DueIn = dateutils.Humanize(*t.DueDate, now)
+	}
+	if !t.CreatedAt.IsZero() {
+		times.CreatedAgo = dateutils.Humanize(t.CreatedAt, now)
+	}
+	if t.CompletedAt != nil {
+		times.CompletedAgo = dateutils.Humanize(*t.CompletedAt, now)
+	}
+	return times
+}
+
+// humanizeTasks adds the relative time fields to the listed tasks
+func humanizeTasks(tasks []model.NumberedTask, now time.Time) []humanizedNumberedTask {
+	humanized := make([]humanizedNumberedTask, len(tasks))
+	for i, t := range tasks {
+		humanized[i] = humanizedNumberedTask{t, relativeTimes(t.Task, now)}
+	}
+	return humanized
+}
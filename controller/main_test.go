@@ -0,0 +1,14 @@
+package controller
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/efreddo/v1/todolist/logutils"
+)
+
+func TestMain(m *testing.M) {
+	logutils.InitLogs(ioutil.Discard, ioutil.Discard, ioutil.Discard, ioutil.Discard)
+	os.Exit(m.Run())
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/efreddo/v1/todolist/backup"
+	"github.com/efreddo/v1/todolist/limiter"
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+/* 
+	request type: GET
+	url: /metrics
+	Returns the server metrics in the Prometheus text format: requests in flight and
+	rejected, sequence number of the last event, time and size of the last backup and whether backups are stale
+
+	Examples:
+
+	   req: GET /metrics
+	   res: 200 todolist_inflight_requests 3 ...
+*/
+func GetMetrics(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintf(w, "# HELP todolist_inflight_requests Requests being served.\n")
+	fmt.Fprintf(w, "# TYPE todolist_inflight_requests gauge\n")
+	fmt.Fprintf(w, "todolist_inflight_requests %d\n", limiter.InFlight())
+	fmt.Fprintf(w, "# HELP todolist_inflight_requests_max Maximum requests served concurrently, 0 for no limit.\n")
+	fmt.Fprintf(w, "# TYPE todolist_inflight_requests_max gauge\n")
+	fmt.Fprintf(w, "todolist_inflight_requests_max %d\n", limiter.MaxInFlight())
+	fmt.Fprintf(w, "# HELP todolist_rejected_requests_total Requests rejected with 503 by the concurrency limit.\n")
+	fmt.Fprintf(w, "# TYPE todolist_rejected_requests_total counter\n")
+	fmt.Fprintf(w, "todolist_rejected_requests_total %d\n", limiter.Rejected())
+	fmt.Fprintf(w, "# HELP todolist_event_seq Sequence number of the last recorded event.\n")
+	fmt.Fprintf(w, "# TYPE todolist_event_seq counter\n")
+	fmt.Fprintf(w, "todolist_event_seq %d\n", model.CurrentSeq())
+
+	status := backup.CurrentStatus()
+	if status.Last != nil {
+		fmt.Fprintf(w, "# HELP todolist_backup_last_timestamp_seconds Creation time of the last backup.\n")
+		fmt.Fprintf(w, "# TYPE todolist_backup_last_timestamp_seconds gauge\n")
+		fmt.Fprintf(w, "todolist_backup_last_timestamp_seconds %d\n", status.Last.CreatedAt.Unix())
+		fmt.Fprintf(w, "# HELP todolist_backup_last_size_bytes Size of the last backup.\n")
+		fmt.Fprintf(w, "# TYPE todolist_backup_last_size_bytes gauge\n")
+		fmt.Fprintf(w, "todolist_backup_last_size_bytes %d\n", status.Last.Size)
+	}
+	stale := 0
+	if status.Stale {
+		stale = 1
+	}
+	fmt.Fprintf(w, "# HELP todolist_backup_stale 1 when no scheduled backup was written for more than two intervals.\n")
+	fmt.Fprintf(w, "# TYPE todolist_backup_stale gauge\n")
+	fmt.Fprintf(w, "todolist_backup_stale %d\n", stale)
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"net/http"
+)
+
+// ItemStatus is the outcome of an item of a bulk request: its HTTP status,
+// the identifier of the item and the error when it failed
+type ItemStatus struct {
+	Id     string
+	Status int
+	Error  string `json:",omitempty"`
+}
+
+// MultiStatus is the response of the bulk requests, with the outcome of
+// each item in the order of the request
+type MultiStatus struct {
+	Succeeded int
+	Failed    int
+	Items     []ItemStatus
+}
+
+// newMultiStatus counts the items succeeded, with a 2xx status, and failed
+func newMultiStatus(items []ItemStatus) MultiStatus {
+	status := MultiStatus{Items: items}
+	for _, item := range items {
+		if item.Status >= 200 && item.Status < 300 {
+			status.Succeeded++
+		} else {
+			status.Failed++
+		}
+	}
+	return status
+}
+
+// writeMultiStatus writes the outcome of a bulk request: 200 when all the
+// items succeeded, 207 Multi-Status otherwise
+func writeMultiStatus(w http.ResponseWriter, items []ItemStatus) {
+	status := newMultiStatus(items)
+	if status.Failed > 0 {
+		w.WriteHeader(http.StatusMultiStatus)
+	}
+	writeJSON(w, status)
+}
@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// JSON null handling modes of the optional fields of the responses
+const (
+	NullsOmit    = "omit"
+	NullsInclude = "include"
+)
+
+// includeNulls is set when the empty optional fields are returned as null,
+// see SetNullHandling
+var includeNulls bool
+
+// SetNullHandling selects how the empty optional fields of the responses are
+// returned: omitted (omit, the default) or as null (include), for the clients
+// relying on the presence of the fields
+func SetNullHandling(mode string) error {
+	switch mode {
+	case NullsOmit:
+		includeNulls = false
+	case NullsInclude:
+		includeNulls = true
+	default:
+		return fmt.Errorf("unknown null handling %s, expected omit or include", mode)
+	}
+	return nil
+}
+
+// NullHandling returns how the empty optional fields of the responses are
+// returned, omit or include
+func NullHandling() string {
+	if includeNulls {
+		return NullsInclude
+	}
+	return NullsOmit
+}
+
+// writeJSON encodes v as the response, after the null handling and the
+// field aliases
+func writeJSON(w io.Writer, v interface{}) error {
+	if !includeNulls && len(fieldAliases) == 0 {
+		return json.NewEncoder(w).Encode(v)
+	}
+	b, err := marshalJSON(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// marshalJSON returns the JSON encoding of v, the empty optional fields of
+// its structs being added as null and the fields renamed after their aliases
+// when so configured
+func marshalJSON(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || !includeNulls && len(fieldAliases) == 0 {
+		return b, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out bytes.Buffer
+	if err := projectJSON(dec, &out, reflect.TypeOf(v)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// jsonField is a field of the JSON encoding of a struct
+type jsonField struct {
+	name      string
+	typ       reflect.Type
+	omitEmpty bool
+}
+
+// jsonFields returns the fields of the JSON encoding of the struct type, the
+// fields of the embedded structs being promoted as encoding/json does
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if comma := strings.Index(tag, ","); comma >= 0 {
+			name, options = tag[:comma], tag[comma:]
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(ft)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{name, f.Type, strings.Contains(options, ",omitempty")})
+	}
+	return fields
+}
+
+// projectJSON copies the next JSON value of dec to out, renaming the fields
+// after their aliases and, when the nulls are included, adding the omitted
+// fields of the objects encoding a struct of type t as null
+func projectJSON(dec *json.Decoder, out *bytes.Buffer, t reflect.Type) error {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch token {
+	case json.Delim('{'):
+		var fields []jsonField
+		isStruct := t != nil && t.Kind() == reflect.Struct
+		if isStruct {
+			fields = jsonFields(t)
+		}
+		// only the fields of the structs are aliased, not the keys of the maps
+		alias := func(name string) string {
+			if isStruct {
+				return fieldAlias(name)
+			}
+			return name
+		}
+		seen := map[string]bool{}
+		out.WriteByte('{')
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if len(seen) > 0 {
+				out.WriteByte(',')
+			}
+			name := key.(string)
+			seen[name] = true
+			k, _ := json.Marshal(alias(name))
+			out.Write(k)
+			out.WriteByte(':')
+			var valueType reflect.Type
+			if t != nil && t.Kind() == reflect.Map {
+				valueType = t.Elem()
+			}
+			for _, f := range fields {
+				if f.name == name {
+					valueType = f.typ
+					break
+				}
+			}
+			if err := projectJSON(dec, out, valueType); err != nil {
+				return err
+			}
+		}
+		for _, f := range fields {
+			if includeNulls && f.omitEmpty && !seen[f.name] {
+				if len(seen) > 0 {
+					out.WriteByte(',')
+				}
+				seen[f.name] = true
+				k, _ := json.Marshal(alias(f.name))
+				out.Write(k)
+				out.WriteString(":null")
+			}
+		}
+		_, err = dec.Token()
+		out.WriteByte('}')
+		return err
+	case json.Delim('['):
+		var elemType reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elemType = t.Elem()
+		}
+		out.WriteByte('[')
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := projectJSON(dec, out, elemType); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		out.WriteByte(']')
+		return err
+	}
+	if number, ok := token.(json.Number); ok {
+		out.WriteString(number.String())
+		return nil
+	}
+	b, err := json.Marshal(token)
+	out.Write(b)
+	return err
+}
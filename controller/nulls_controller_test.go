@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestSetNullHandling_include(t *testing.T) {
+	defer SetNullHandling(NullHandling())
+	if err := SetNullHandling("always"); err == nil {
+		t.Errorf("expected error with an unknown mode")
+	}
+	model.CreateToDoListWithTasks("ControllerListNulls", []string{"Passport"})
+	params := httprouter.Params{{Key: "list", Value: "ControllerListNulls"}, {Key: "task", Value: "Passport"}}
+
+	res := httptest.NewRecorder()
+	GetTask(res, httptest.NewRequest("GET", "/lists/ControllerListNulls/tasks/Passport", nil), params)
+	if strings.Contains(res.Body.String(), "null") {
+		t.Errorf("expected the empty fields omitted by default, got %s", res.Body.String())
+	}
+
+	SetNullHandling(NullsInclude)
+	res = httptest.NewRecorder()
+	GetTask(res, httptest.NewRequest("GET", "/lists/ControllerListNulls/tasks/Passport", nil), params)
+	task := map[string]json.RawMessage{}
+	if err := json.NewDecoder(res.Body).Decode(&task); err != nil {
+		t.Fatalf("expected a JSON task, got %v", err)
+	}
+	for _, field := range []string{"Description", "DueDate", "Tags", "CompletedAt", "ChecklistProgress"} {
+		if value, ok := task[field]; !ok || string(value) != "null" {
+			t.Errorf("expected %s null, got %s", field, value)
+		}
+	}
+	if string(task["Title"]) != `"Passport"` || string(task["Number"]) != "1" {
+		t.Errorf("expected the fields set unchanged, got %s and %s", task["Title"], task["Number"])
+	}
+
+	res = httptest.NewRecorder()
+	GetToDoList(res, httptest.NewRequest("GET", "/lists/ControllerListNulls/", nil), params[:1])
+	if body := res.Body.String(); !strings.Contains(body, `"Description":null`) || !strings.Contains(body, `"Tasks":[{`) ||
+		!strings.Contains(body, `"WaitingOn":null`) {
+		t.Errorf("expected the empty fields of the list and its tasks null, got %s", body)
+	}
+}
+
+func TestSetNullHandling_queryLists(t *testing.T) {
+	defer SetNullHandling(NullHandling())
+	SetNullHandling(NullsInclude)
+	model.CreateToDoListWithTasks("ControllerListQueryNulls", []string{"Passport"})
+
+	for _, query := range []string{"name=ControllerListQueryNulls", "name=ControllerListQueryNulls&fields=Name,Description,Tasks"} {
+		res := httptest.NewRecorder()
+		QueryLists(res, httptest.NewRequest("GET", "/lists/query?"+query, nil), nil)
+		if body := res.Body.String(); !strings.Contains(body, `"Description":null`) || !strings.Contains(body, `"WaitingOn":null`) {
+			t.Errorf("expected the empty fields of the lists and their tasks null for %q, got %s", query, body)
+		}
+	}
+}
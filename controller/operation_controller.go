@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	OPERATION_BADREQUEST = 70;
+	OPERATION_CONFLICT = 71;
+	OPERATION_UNPROCESSABLE = 72;
+)
+
+// DefaultOperationTTL is how long the outcome of a bulk request with an
+// operationId is kept for its retries by default
+const DefaultOperationTTL = 24 * time.Hour
+
+// MaxOperationIdLength is the maximum length of the operation ids
+const MaxOperationIdLength = 128
+
+// operation is a bulk request with an operationId, its response being kept
+// once done. request is the method and path of the request, fingerprint
+// identifying it along with its other parameters and its body.
+type operation struct {
+	request     string
+	fingerprint string
+	done        bool
+	status      int
+	contentType string
+	body        []byte
+	at          time.Time
+}
+
+// completedOperation is an operation in completion order, for pruning
+type completedOperation struct {
+	id string
+	at time.Time
+}
+
+var (
+	operationsMu sync.Mutex
+	operationTTL = DefaultOperationTTL
+	// operations maps the operation ids to their requests, operationQueue
+	// holding the completed ones in completion order
+	operations     = map[string]*operation{}
+	operationQueue []completedOperation
+)
+
+// SetOperationTTL sets how long the outcome of a bulk request with an
+// operationId is kept for its retries. 0 disables the operation ids.
+func SetOperationTTL(ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf("invalid operation TTL %s, expected a non negative duration", ttl)
+	}
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+	operationTTL = ttl
+	pruneOperations()
+	return nil
+}
+
+/*
+	Idempotent makes a bulk request retryable with ?operationId=<id>: the outcome of the
+	first request with the id is kept for the operation TTL and returned to its retries,
+	flagged with X-Operation-Replayed, instead of applying them again. Requests failed with
+	a 5xx status are not kept, their retries being applied. A retry while the operation is
+	in progress gets 409, the reuse of the id by another request (another route, other
+	parameters or another body) 422. A request whose handler panics is forgotten, as
+	the failed ones.
+
+	Examples:
+
+	   req: PATCH /lists/oklist/tasks/priority?operationId=sync-42 {"Ids": ["oktask", "wrongtask"], "Priority": 1}
+	   res: 207 {"Succeeded":1,"Failed":1,"Items":[...]}
+
+	   req: PATCH /lists/oklist/tasks/priority?operationId=sync-42 {"Ids": ["oktask", "wrongtask"], "Priority": 1}
+	   res: 207 X-Operation-Replayed: true {"Succeeded":1,"Failed":1,"Items":[...]}
+
+	   req: POST /lists/bulk-archive?operationId=sync-42 {"Keys": ["oklist"]}
+	   res: 422 operation id already used by another request
+*/
+func Idempotent(h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+		id := r.URL.Query().Get("operationId")
+		if id == "" {
+			h(w, r, param)
+			return
+		}
+		if len(id) > MaxOperationIdLength {
+			HandleError(w, http.StatusBadRequest, OPERATION_BADREQUEST, "Idempotent",
+				"Invalid operation id",
+				fmt.Sprintf("Bad request received: operation id longer than %d characters", MaxOperationIdLength))
+			return
+		}
+
+		fingerprint, err := requestFingerprint(r)
+		if err != nil {
+			HandleError(w, http.StatusBadRequest, OPERATION_BADREQUEST, "Idempotent",
+				"Invalid request body",
+				fmt.Sprintf("Bad request received: %v", err))
+			return
+		}
+		operationsMu.Lock()
+		if operationTTL == 0 {
+			operationsMu.Unlock()
+			h(w, r, param)
+			return
+		}
+		pruneOperations()
+		op, found := operations[id]
+		if !found {
+			op = &operation{request: r.Method + " " + r.URL.Path, fingerprint: fingerprint}
+			operations[id] = op
+		}
+		// the operation is copied under the lock, its completion changing it
+		kept := *op
+		operationsMu.Unlock()
+
+		switch {
+		case found && kept.fingerprint != fingerprint:
+			HandleError(w, http.StatusUnprocessableEntity, OPERATION_UNPROCESSABLE, "Idempotent",
+				"Operation id already used",
+				fmt.Sprintf("Operation %s already used by another request: %s", id, kept.request))
+		case found && !kept.done:
+			HandleError(w, http.StatusConflict, OPERATION_CONFLICT, "Idempotent",
+				"Operation in progress",
+				fmt.Sprintf("Operation %s in progress, to be retried later", id))
+		case found:
+			if kept.contentType != "" {
+				w.Header().Set("Content-Type", kept.contentType)
+			}
+			w.Header().Set("X-Operation-Replayed", "true")
+			w.WriteHeader(kept.status)
+			w.Write(kept.body)
+		default:
+			rec := &operationRecorder{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed {
+					forgetOperation(id, op)
+				}
+			}()
+			h(rec, r, param)
+			completeOperation(id, op, rec)
+			completed = true
+		}
+	}
+}
+
+// requestFingerprint identifies the request by its method, path, parameters
+// other than operationId and the SHA-256 of its body, the body being kept for
+// the handler
+func requestFingerprint(r *http.Request) (string, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			return "", err
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	query := r.URL.Query()
+	query.Del("operationId")
+	sum := sha256.Sum256(body)
+	return r.Method + " " + r.URL.Path + "?" + query.Encode() + " " + hex.EncodeToString(sum[:]), nil
+}
+
+// forgetOperation drops the operation not completed, e.g. when its handler
+// panicked, so that it can be retried
+func forgetOperation(id string, op *operation) {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+	if operations[id] == op {
+		delete(operations, id)
+	}
+}
+
+// completeOperation keeps the response of the operation, or forgets the
+// operation when it failed with a 5xx status
+func completeOperation(id string, op *operation, rec *operationRecorder) {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+	if rec.status >= 500 {
+		if operations[id] == op {
+			delete(operations, id)
+		}
+		return
+	}
+	op.done = true
+	op.status = rec.status
+	op.contentType = rec.Header().Get("Content-Type")
+	op.body = rec.body.Bytes()
+	op.at = time.Now()
+	operationQueue = append(operationQueue, completedOperation{id, op.at})
+}
+
+// pruneOperations drops the completed operations older than the TTL
+func pruneOperations() {
+	expired := time.Now().Add(-operationTTL)
+	for len(operationQueue) > 0 && (operationTTL == 0 || operationQueue[0].at.Before(expired)) {
+		c := operationQueue[0]
+		if op, ok := operations[c.id]; ok && op.done && op.at.Equal(c.at) {
+			delete(operations, c.id)
+		}
+		operationQueue = operationQueue[1:]
+	}
+}
+
+// operationRecorder records the status and the body of a response while
+// writing it
+type operationRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *operationRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.wroteHeader = true
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *operationRecorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestIdempotent_replay(t *testing.T) {
+	model.CreateToDoListWithTasks("ControllerListOperation", []string{"Report"})
+	params := httprouter.Params{{Key: "list", Value: "ControllerListOperation"}, {Key: "task", Value: "priority"}}
+	handler := Idempotent(SetTasksPriority)
+	path := "/lists/ControllerListOperation/tasks/priority?operationId=op-replay"
+
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest("PATCH", path, strings.NewReader(`{"Ids": ["Report", "missing"], "Priority": 2}`)), params)
+	if res.Code != http.StatusMultiStatus || res.Header().Get("X-Operation-Replayed") != "" {
+		t.Fatalf("expected status 207 applied, got %d %s", res.Code, res.Body.String())
+	}
+	first := res.Body.String()
+
+	model.AddTask("ControllerListOperation", "missing")
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest("PATCH", path, strings.NewReader(`{"Ids": ["Report", "missing"], "Priority": 2}`)), params)
+	if res.Code != http.StatusMultiStatus || res.Header().Get("X-Operation-Replayed") != "true" || res.Body.String() != first {
+		t.Errorf("expected the first outcome replayed, got %d %s", res.Code, res.Body.String())
+	}
+	if task, _ := model.GetTask("ControllerListOperation", "missing"); task.Priority != 0 {
+		t.Errorf("expected the retry not applied, got priority %d", task.Priority)
+	}
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest("PATCH", path, strings.NewReader(`{"Ids": ["Report", "missing"], "Priority": 3}`)), params)
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 reusing the operation id with another body, got %d", res.Code)
+	}
+	res = httptest.NewRecorder()
+	Idempotent(ArchiveToDoLists)(res, httptest.NewRequest("POST", "/lists/bulk-archive?operationId=op-replay", strings.NewReader(`{"Keys": ["ControllerListOperation"]}`)), nil)
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 reusing the operation id, got %d", res.Code)
+	}
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest("PATCH", "/lists/ControllerListOperation/tasks/priority?operationId="+strings.Repeat("x", MaxOperationIdLength+1),
+		strings.NewReader(`{"Ids": ["Report"], "Priority": 2}`)), params)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 with a too long operation id, got %d", res.Code)
+	}
+}
+
+func TestIdempotent_disabled(t *testing.T) {
+	defer SetOperationTTL(DefaultOperationTTL)
+	if err := SetOperationTTL(-1); err == nil {
+		t.Errorf("expected error with a negative TTL")
+	}
+	SetOperationTTL(0)
+	model.CreateToDoListWithTasks("ControllerListOperationOff", []string{"Report"})
+	params := httprouter.Params{{Key: "list", Value: "ControllerListOperationOff"}, {Key: "task", Value: "priority"}}
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		Idempotent(SetTasksPriority)(res, httptest.NewRequest("PATCH", "/lists/ControllerListOperationOff/tasks/priority?operationId=op-off",
+			strings.NewReader(`{"Ids": ["Report"], "Priority": 2}`)), params)
+		if res.Code != http.StatusOK || res.Header().Get("X-Operation-Replayed") != "" {
+			t.Errorf("expected the request applied without operation ids, got %d", res.Code)
+		}
+	}
+}
+
+func TestIdempotent_panic_forgotten(t *testing.T) {
+	path := "/lists/bulk-archive?operationId=op-panic"
+	func() {
+		defer func() { recover() }()
+		Idempotent(func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+			panic("handler failure")
+		})(httptest.NewRecorder(), httptest.NewRequest("POST", path, strings.NewReader(`{"Keys": ["x"]}`)), nil)
+	}()
+
+	res := httptest.NewRecorder()
+	Idempotent(func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	})(res, httptest.NewRequest("POST", path, strings.NewReader(`{"Keys": ["x"]}`)), nil)
+	if res.Code != http.StatusOK || res.Header().Get("X-Operation-Replayed") != "" {
+		t.Errorf("expected the retry applied after the panic, got %d %s", res.Code, res.Body.String())
+	}
+}
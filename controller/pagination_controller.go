@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// PageSizes are the default limits of the paginated endpoints, applied when
+// the request has no limit parameter, 0 meaning no limit
+type PageSizes struct {
+	Lists  int
+	Tasks  int
+	Search int
+}
+
+var pageSizes PageSizes
+
+// DefaultMaxUnpaginatedTasks is the default maximum number of tasks listed
+// without pagination parameters
+const DefaultMaxUnpaginatedTasks = 500
+
+// maxUnpaginatedTasks caps the tasks listed without pagination parameters,
+// see SetMaxUnpaginatedTasks
+var maxUnpaginatedTasks = DefaultMaxUnpaginatedTasks
+
+// SetPageSizes sets the default limits of the paginated endpoints
+func SetPageSizes(sizes PageSizes) error {
+	if sizes.Lists < 0 || sizes.Tasks < 0 || sizes.Search < 0 {
+		return fmt.Errorf("invalid page sizes %+v, expected non negative integers", sizes)
+	}
+	pageSizes = sizes
+	return nil
+}
+
+// CurrentPageSizes returns the default limits of the paginated endpoints
+func CurrentPageSizes() PageSizes {
+	return pageSizes
+}
+
+// SetMaxUnpaginatedTasks sets the maximum number of tasks listed when the
+// request has neither offset nor limit and no default limit applies, 0
+// meaning no maximum
+func SetMaxUnpaginatedTasks(max int) error {
+	if max < 0 {
+		return fmt.Errorf("invalid maximum of unpaginated tasks %d, expected a non negative integer", max)
+	}
+	maxUnpaginatedTasks = max
+	return nil
+}
+
+// MaxUnpaginatedTasks returns the maximum number of tasks listed without
+// pagination, 0 meaning no maximum
+func MaxUnpaginatedTasks() int {
+	return maxUnpaginatedTasks
+}
+
+// truncatePage caps at max the items of an unpaginated request, total being
+// their number, and signals the truncation with the X-Truncated, X-Total-Count
+// and Link (next page) headers. It returns the limit to apply.
+func truncatePage(w http.ResponseWriter, r *http.Request, total, limit, max int) int {
+	query := r.URL.Query()
+	if limit > 0 || max == 0 || total <= max || query.Get("offset") != "" || query.Get("limit") != "" {
+		return limit
+	}
+	query.Set("offset", strconv.Itoa(max))
+	query.Set("limit", strconv.Itoa(max))
+	next := *r.URL
+	next.RawQuery = query.Encode()
+	w.Header().Set("X-Truncated", "true")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	return max
+}
+
+// embedTasksRequest returns a copy of the request to a ToDo list with
+// embed=tasks and without pagination, for the Link of its truncated tasks
+func embedTasksRequest(r *http.Request) *http.Request {
+	u := *r.URL
+	query := u.Query()
+	query.Set("embed", "tasks")
+	query.Del("offset")
+	query.Del("limit")
+	u.RawQuery = query.Encode()
+	embedded := r.WithContext(r.Context())
+	embedded.URL = &u
+	return embedded
+}
+
+// requestPage returns the offset and limit query parameters, the limit being
+// defaultLimit when missing, limit 0 meaning no limit
+func requestPage(r *http.Request, defaultLimit int) (int, int, error) {
+	offset, limit := 0, defaultLimit
+	var err error
+	query := r.URL.Query()
+	if query.Get("offset") != "" {
+		if offset, err = strconv.Atoi(query.Get("offset")); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %s, expected a non negative integer", query.Get("offset"))
+		}
+	}
+	if query.Get("limit") != "" {
+		if limit, err = strconv.Atoi(query.Get("limit")); err != nil || limit < 1 {
+			return 0, 0, fmt.Errorf("invalid limit %s, expected a positive integer", query.Get("limit"))
+		}
+	}
+	return offset, limit, nil
+}
+
+// pageBounds returns the bounds of the page in a collection of total items
+func pageBounds(total, offset, limit int) (int, int) {
+	if offset > total {
+		offset = total
+	}
+	end := total
+	// compared to the remaining items, offset+limit overflowing for huge limits
+	if limit > 0 && limit < total-offset {
+		end = offset + limit
+	}
+	return offset, end
+}
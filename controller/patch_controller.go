@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/efreddo/v1/todolist/jsonpatch"
+)
+
+// jsonPatchMediaType is the media type of the JSON Patch (RFC 6902) documents
+const jsonPatchMediaType = "application/json-patch+json"
+
+// server-managed locations of the JSON representations, JSON patches
+// modifying them are rejected with 409
+var (
+	toDoListManagedPaths = []string{"/Tasks", "/TaskNumber", "/LastTaskNumber", "/EffectiveDefaultPriority", "/PercentComplete", "/Notifications", "/UpdatedAt"}
+	taskManagedPaths     = []string{"/ToDoList", "/Number", "/CreatedAt", "/CompletedAt", "/ChecklistProgress", "/Progress", "/Deferred", "/TimeSpent", "/UpdatedAt", "/ReminderFired"}
+)
+
+// patchApplier applies a JSON Patch to the representation of a resource,
+// keeping the error of the patch apart from the ones of the update
+type patchApplier struct {
+	patch jsonpatch.Patch
+	err   error
+}
+
+func (a *patchApplier) apply(doc []byte) ([]byte, error) {
+	patched, err := a.patch.Apply(doc)
+	a.err = err
+	return patched, err
+}
+
+// managedPath returns the first of the managed locations modified by the
+// patch, empty when the patch leaves them untouched
+func managedPath(patch jsonpatch.Patch, managed []string) string {
+	for _, location := range managed {
+		if patch.Modifies(location) {
+			return location
+		}
+	}
+	return ""
+}
+
+// jsonPatchError reports a patch that could not be applied: 409 when a test
+// operation failed or a managed location is modified, 422 otherwise
+func jsonPatchError(w http.ResponseWriter, caller string, conflictCode, unprocessableCode int, err error) {
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		HandleError(w, http.StatusConflict, conflictCode, caller,
+			"JSON Patch test failed, the resource was not modified",
+			fmt.Sprintf("%v", err))
+		return
+	}
+	HandleError(w, http.StatusUnprocessableEntity, unprocessableCode, caller,
+		"JSON Patch not applicable, the resource was not modified",
+		fmt.Sprintf("%v", err))
+}
+
+// managedPathError reports a patch modifying a server-managed location
+func managedPathError(w http.ResponseWriter, caller string, conflictCode int, location string) {
+	HandleError(w, http.StatusConflict, conflictCode, caller,
+		fmt.Sprintf("%s is managed by the server and can not be patched", location),
+		fmt.Sprintf("JSON Patch modifying %s", location))
+}
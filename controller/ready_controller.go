@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+// WarmupRetryAfter is the Retry-After, in seconds, of the requests rejected
+// while the store is loading
+var WarmupRetryAfter = 1
+
+/*
+	Readiness rejects the requests with 503 and a Retry-After header while the store is
+	loading at startup, rather than serving incomplete data. /readyz is always served.
+
+	Examples:
+
+	   req: GET /lists/ (while loading)
+	   res: 503 Retry-After: 1
+*/
+func Readiness(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" && !model.Ready() {
+			w.Header().Set("Retry-After", strconv.Itoa(WarmupRetryAfter))
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+/*
+	request type: GET
+	url: /readyz
+	Returns whether the store finished loading at startup: 200 once ready, 503 with a
+	Retry-After header before
+
+	Examples:
+
+	   req: GET /readyz
+	   res: 200 {"Ready":true}
+
+	   req: GET /readyz (while loading)
+	   res: 503 {"Ready":false} Retry-After: 1
+*/
+func GetReadiness(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	ready := model.Ready()
+	if !ready {
+		w.Header().Set("Retry-After", strconv.Itoa(WarmupRetryAfter))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, struct{ Ready bool }{ready})
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/efreddo/v1/todolist/model"
+)
+
+func TestReadiness_loading_serviceUnavailable(t *testing.T) {
+	defer model.SetReady(model.Ready())
+	model.SetReady(false)
+	handler := Readiness(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/lists/", nil))
+	if res.Code != http.StatusServiceUnavailable || res.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected status 503 with Retry-After 1 while loading, got %d %q", res.Code, res.Header().Get("Retry-After"))
+	}
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if res.Code != http.StatusOK {
+		t.Errorf("expected /readyz served while loading, got %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	GetReadiness(res, httptest.NewRequest(http.MethodGet, "/readyz", nil), nil)
+	if res.Code != http.StatusServiceUnavailable || res.Body.String() != "{\"Ready\":false}\n" {
+		t.Errorf("expected 503 {\"Ready\":false}, got %d %s", res.Code, res.Body.String())
+	}
+
+	model.SetReady(true)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/lists/", nil))
+	if res.Code != http.StatusOK {
+		t.Errorf("expected status 200 once loaded, got %d", res.Code)
+	}
+}
@@ -0,0 +1,320 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/efreddo/v1/todolist/dateutils"
+	"github.com/efreddo/v1/todolist/logutils"
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	STATS_BADREQUEST = 30;
+	STATS_OPERATION_ERROR = 31;
+)
+
+// maxActivityLimit is the largest page of the activity feed
+const maxActivityLimit = 500
+
+/* 
+	request type: GET
+	url: /lists/:list/stats/history?days=30&until=2024-06-01&tz=Europe/Rome
+	url: /stats/history?days=30&until=2024-06-01&tz=Europe/Rome
+	Returns the per-day series of tasks created, completed and still open at the end
+	of the day, for the ToDo list or across all the lists. The series covers the given
+	number of days (default 30, max 366) ending with until (default today), older
+	periods are retrieved moving until backwards. Days follow the tz parameter (default UTC)
+
+	Examples:
+
+	   req: GET /stats/history?days=1000
+	   res: 400 invalid number of days
+
+	   req: GET /lists/wronglist/stats/history
+	   res: 404 ToDo list not found
+
+	   req: GET /lists/oklist/stats/history?days=7
+	   res: 200 [{"Day":"2024-05-26","Created":2,"Completed":1,"Open":4}, ...]
+*/
+func GetStatsHistory(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	query := r.URL.Query()
+
+	loc, err := requestLocation(r)
+	if err != nil {
+		statsBadRequestError(w, "GetStatsHistory", "tz", err)
+		return
+	}
+
+	days := 30
+	if query.Get("days") != "" {
+		if days, err = strconv.Atoi(query.Get("days")); err != nil || days < 1 || days > model.MaxHistoryDays {
+			statsBadRequestError(w, "GetStatsHistory", "days", fmt.Errorf("expected 1 to %d days, got %s", model.MaxHistoryDays, query.Get("days")))
+			return
+		}
+	}
+
+	until := time.Now().In(loc)
+	if query.Get("until") != "" {
+		if until, err = dateutils.Parse(query.Get("until"), requestDateMode(r), loc); err != nil {
+			statsBadRequestError(w, "GetStatsHistory", "until", err)
+			return
+		}
+	}
+
+	series, err := model.StatsHistory(key, days, until, loc)
+	if err != nil {
+		HandleError(w, http.StatusNotFound, STATS_OPERATION_ERROR, "GetStatsHistory",
+			fmt.Sprintf("Error while computing the history of ToDo list = {%s}", key),
+			fmt.Sprintf("%v", err))
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetStatsHistory:: retrieved %d days of history for ToDo list '%s'", len(series), key))
+	writeJSON(w, series)
+}
+
+/* 
+	request type: GET
+	url: /review?week=2024-W23&stale=4&weekStart=monday&tz=Europe/Rome
+	Returns the weekly review of every ToDo list: tasks completed and added during the
+	week, open tasks neither changed nor touched for more than stale weeks (default 4)
+	and tasks overdue at the end of the week. The week (default the current one) starts on weekStart (monday
+	or sunday, default monday) in the tz time zone (default UTC)
+
+	Examples:
+
+	   req: GET /review?week=2024-23
+	   res: 400 invalid week
+
+	   req: GET /review?weekStart=friday
+	   res: 400 invalid week start
+
+	   req: GET /review?week=2024-W23
+	   res: 200
+*/
+func GetWeeklyReview(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	query := r.URL.Query()
+
+	loc, err := requestLocation(r)
+	if err != nil {
+		statsBadRequestError(w, "GetWeeklyReview", "tz", err)
+		return
+	}
+
+	weekStart, err := requestWeekStart(r)
+	if err != nil {
+		statsBadRequestError(w, "GetWeeklyReview", "weekStart", err)
+		return
+	}
+
+	staleWeeks := 4
+	if query.Get("stale") != "" {
+		if staleWeeks, err = strconv.Atoi(query.Get("stale")); err != nil || staleWeeks < 1 {
+			statsBadRequestError(w, "GetWeeklyReview", "stale", fmt.Errorf("expected a positive number of weeks, got %s", query.Get("stale")))
+			return
+		}
+	}
+
+	start := model.WeekStart(time.Now(), weekStart, loc)
+	if query.Get("week") != "" {
+		if start, err = model.ParseISOWeek(query.Get("week"), weekStart, loc); err != nil {
+			statsBadRequestError(w, "GetWeeklyReview", "week", err)
+			return
+		}
+	}
+
+	review, err := model.WeeklyReviewOf(start, staleWeeks)
+	if err != nil {
+		statsBadRequestError(w, "GetWeeklyReview", "stale", err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetWeeklyReview:: review of week %s for %d ToDo lists", review.Week, len(review.Lists)))
+	writeJSON(w, review)
+}
+
+/* 
+	request type: GET
+	url: /digest/weekly?weekStart=monday&tz=Europe/Rome
+	Returns the digest of the current week across all the ToDo lists: tasks completed since
+	the start of the week, open tasks overdue and due by the end of the next week, and the
+	progress of each list. The week starts on weekStart (monday or sunday, default the
+	configured one) in the tz time zone (default UTC)
+
+	Examples:
+
+	   req: GET /digest/weekly?tz=Unknown/Zone
+	   res: 400 invalid time zone
+
+	   req: GET /digest/weekly
+	   res: 200 {"Week":"2024-W23","Start":...,"Completed":[...],"Overdue":[...],"Upcoming":[...],"Lists":[...]}
+*/
+func GetWeeklyDigest(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	loc, err := requestLocation(r)
+	if err != nil {
+		statsBadRequestError(w, "GetWeeklyDigest", "tz", err)
+		return
+	}
+
+	var digest *model.WeeklyDigest
+	if r.URL.Query().Get("weekStart") == "" {
+		timed := timeModel(r, "GenerateWeeklyDigest")
+		digest = model.GenerateWeeklyDigest(loc)
+		timed()
+	} else {
+		weekStart, err := requestWeekStart(r)
+		if err != nil {
+			statsBadRequestError(w, "GetWeeklyDigest", "weekStart", err)
+			return
+		}
+		timed := timeModel(r, "WeeklyDigestAt")
+		digest = model.WeeklyDigestAt(time.Now(), weekStart, loc)
+		timed()
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetWeeklyDigest:: digest of week %s for %d ToDo lists", digest.Week, len(digest.Lists)))
+	writeJSON(w, digest)
+}
+
+/* 
+	request type: GET
+	url: /calendar?from=2024-06-01&to=2024-06-30&list=oklist&tz=Europe/Rome
+	Returns, for each day from from to to (included, up to 92 days), the tasks due and
+	completed that day with their counts, for the list parameter or across all the lists.
+	Days follow the tz parameter (default UTC), days without tasks are reported too
+
+	Examples:
+
+	   req: GET /calendar?from=2024-06-30&to=2024-06-01
+	   res: 400 invalid range
+
+	   req: GET /calendar?from=2024-01-01&to=2024-12-31
+	   res: 400 invalid range
+
+	   req: GET /calendar?from=2024-06-01&to=2024-06-30&list=wronglist
+	   res: 404 ToDo list not found
+
+	   req: GET /calendar?from=2024-06-01&to=2024-06-30
+	   res: 200 [{"Day":"2024-06-01","Due":[...],"Completed":[],"DueCount":1,"CompletedCount":0}, ...]
+*/
+func GetCalendar(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	query := r.URL.Query()
+	key := query.Get("list")
+
+	loc, err := requestLocation(r)
+	if err != nil {
+		statsBadRequestError(w, "GetCalendar", "tz", err)
+		return
+	}
+	from, err := dateutils.Parse(query.Get("from"), requestDateMode(r), loc)
+	if err != nil {
+		statsBadRequestError(w, "GetCalendar", "from", err)
+		return
+	}
+	to, err := dateutils.Parse(query.Get("to"), requestDateMode(r), loc)
+	if err != nil {
+		statsBadRequestError(w, "GetCalendar", "to", err)
+		return
+	}
+	start, _ := model.DayBounds(from, loc)
+	if last, _ := model.DayBounds(to, loc); last.Before(start) || last.After(start.AddDate(0, 0, model.MaxCalendarDays-1)) {
+		statsBadRequestError(w, "GetCalendar", "from or to",
+			fmt.Errorf("expected to on or after from, up to %d days", model.MaxCalendarDays))
+		return
+	}
+
+	calendar, err := model.Calendar(key, from, to, loc)
+	if err != nil {
+		HandleError(w, http.StatusNotFound, STATS_OPERATION_ERROR, "GetCalendar",
+			fmt.Sprintf("Error while computing the calendar of ToDo list = {%s}", key),
+			fmt.Sprintf("%v", err))
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetCalendar:: retrieved %d days of calendar for ToDo list '%s'", len(calendar), key))
+	writeJSON(w, calendar)
+}
+
+/* 
+	request type: GET
+	url: /activity?limit=50&before=120&list=oklist&type=task.created,task.completed
+	Returns the events of all the ToDo lists, the most recent first, with a readable summary:
+	task.created, task.completed, task.reopened, task.deleted, list.created, list.renamed,
+	list.deleted, list.archived and list.unarchived. Events are filtered by list and by type
+	(comma separated) and paginated with limit (default 50, max 500) and before, the Next
+	cursor of the previous page. Next is missing on the last page. The cursors stay valid as
+	new events are recorded, the Seq numbers not being reused while the server runs; the
+	pages end early once the compaction drops the events older than the history retention
+
+	Examples:
+
+	   req: GET /activity?type=task.moved
+	   res: 400 unknown event type
+
+	   req: GET /activity?limit=2
+	   res: 200 {"Events":[{"Seq":120,"Type":"task.completed","List":"oklist","Task":"oktask","At":"2024-06-01T09:00:00Z",
+	             "Summary":"Task \"oktask\" completed in \"oklist\""}, ...],"Next":119}
+*/
+func GetActivity(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	query := r.URL.Query()
+	filter := model.ActivityFilter{List: query.Get("list")}
+	if query.Get("type") != "" {
+		filter.Types = strings.Split(query.Get("type"), ",")
+		for _, t := range filter.Types {
+			if !model.IsEventType(t) {
+				statsBadRequestError(w, "GetActivity", "type", fmt.Errorf("unknown event type %s", t))
+				return
+			}
+		}
+	}
+
+	limit, before := 50, 0
+	var err error
+	if query.Get("limit") != "" {
+		if limit, err = strconv.Atoi(query.Get("limit")); err != nil || limit < 1 || limit > maxActivityLimit {
+			statsBadRequestError(w, "GetActivity", "limit", fmt.Errorf("expected 1 to %d events, got %s", maxActivityLimit, query.Get("limit")))
+			return
+		}
+	}
+	if query.Get("before") != "" {
+		if before, err = strconv.Atoi(query.Get("before")); err != nil || before < 1 {
+			statsBadRequestError(w, "GetActivity", "before", fmt.Errorf("invalid cursor %s", query.Get("before")))
+			return
+		}
+	}
+
+	events, next := model.ActivityFeed(filter, before, limit)
+	logutils.Info.Println(fmt.Sprintf("GetActivity:: retrieved %d events", len(events)))
+	writeJSON(w, struct {
+		Events []model.Activity
+		Next   int `json:",omitempty"`
+	}{events, next})
+}
+
+// requestWeekStart returns the first day of the week requested with the
+// weekStart query parameter, monday by default
+func requestWeekStart(r *http.Request) (time.Weekday, error) {
+	switch r.URL.Query().Get("weekStart") {
+	case "", "monday":
+		return time.Monday, nil
+	case "sunday":
+		return time.Sunday, nil
+	}
+	return time.Monday, fmt.Errorf("unknown week start %s, expected monday or sunday", r.URL.Query().Get("weekStart"))
+}
+
+func statsBadRequestError(w http.ResponseWriter, caller, name string, err error) {
+	HandleError(w, http.StatusBadRequest, STATS_BADREQUEST, caller,
+		fmt.Sprintf("Invalid parameter %s", name),
+		fmt.Sprintf("Bad request received: %v", err))
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/efreddo/v1/todolist/logutils"
+)
+
+// Deprecated behaviors, tolerated for compatibility unless in strict mode
+const (
+	// DeprecatedUnknownFields are the fields of the request bodies not
+	// matching any attribute, ignored
+	DeprecatedUnknownFields = "unknown-fields"
+)
+
+// deprecations describes the deprecated behaviors in the errors of the
+// strict mode
+var deprecations = map[string]string{
+	DeprecatedUnknownFields: "request body fields not matching any attribute",
+}
+
+// strict rejects the requests relying on deprecated behaviors, see SetStrict
+var strict bool
+
+// SetStrict enables or disables the strict mode, rejecting the requests
+// relying on deprecated behaviors
+func SetStrict(enabled bool) {
+	strict = enabled
+}
+
+// DeprecationError reports a request rejected in strict mode
+type DeprecationError struct {
+	Deprecation string
+	Reason      string
+}
+
+func (e *DeprecationError) Error() string {
+	return fmt.Sprintf("deprecated %s (%s) rejected in strict mode: %s", e.Deprecation, deprecations[e.Deprecation], e.Reason)
+}
+
+// deprecated reports the use of the deprecated behavior by the request: in
+// strict mode a *DeprecationError is returned, and logged, nil otherwise
+func deprecated(r *http.Request, deprecation string, reason string) error {
+	if !strict {
+		return nil
+	}
+	err := &DeprecationError{deprecation, reason}
+	logutils.Warning.Println(fmt.Sprintf("Strict:: %s %s blocked: %v", r.Method, r.URL.Path, err))
+	return err
+}
+
+// deprecationError replies 400 to a request rejected in strict mode, naming
+// the deprecated behavior, when err is a *DeprecationError. It returns false,
+// without replying, otherwise.
+func deprecationError(w http.ResponseWriter, internalCode int, caller string, err error) bool {
+	deprecation, ok := err.(*DeprecationError)
+	if !ok {
+		return false
+	}
+	HandleError(w, http.StatusBadRequest, internalCode, caller,
+		fmt.Sprintf("Deprecated %s rejected in strict mode", deprecations[deprecation.Deprecation]),
+		deprecation.Error())
+	return true
+}
+
+// decodeBody decodes the JSON body of the request into v
+func decodeBody(r *http.Request, v interface{}) error {
+	return decodeJSON(r, r.Body, v)
+}
+
+// decodeJSON decodes the JSON content of the request into v, the unknown
+// fields being rejected in strict mode
+func decodeJSON(r *http.Request, content io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(content)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	return unknownFieldError(r, decoder.Decode(v))
+}
+
+// unknownFieldError returns the error of the decoding of the request, the
+// unknown fields rejected in strict mode being reported as deprecated
+func unknownFieldError(r *http.Request, err error) error {
+	if err != nil && strict && strings.HasPrefix(err.Error(), "json: unknown field ") {
+		return deprecated(r, DeprecatedUnknownFields, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return err
+}
+
+// strictUnmarshal decodes the JSON b into v, the unknown fields being rejected
+// in strict mode, for the UnmarshalJSON of the nested objects of the requests
+func strictUnmarshal(b []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder.Decode(v)
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestStrict_unknownFields(t *testing.T) {
+	model.CreateToDoList("ControllerListStrict")
+	params := httprouter.Params{{Key: "list", Value: "ControllerListStrict"}}
+	create := func(title string) *httptest.ResponseRecorder {
+		body := `{"Title": "` + title + `", "Priorty": 2}`
+		res := httptest.NewRecorder()
+		CreateTask(res, httptest.NewRequest("POST", "/lists/ControllerListStrict/tasks", strings.NewReader(body)), params)
+		return res
+	}
+
+	if res := create("Tolerated"); res.Code != http.StatusCreated {
+		t.Errorf("expected the unknown field tolerated, got %d: %s", res.Code, res.Body.String())
+	}
+
+	SetStrict(true)
+	defer SetStrict(false)
+	res := create("Rejected")
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), DeprecatedUnknownFields) ||
+		!strings.Contains(res.Body.String(), "Priorty") {
+		t.Errorf("expected the unknown field rejected, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	UpdateToDoList(res, httptest.NewRequest("PUT", "/lists/ControllerListStrict",
+		strings.NewReader(`{"Name": "ControllerListStrict", "Colour": "red"}`)), params)
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "Colour") {
+		t.Errorf("expected the unknown field of the PUT rejected, got %d: %s", res.Code, res.Body.String())
+	}
+	if strings.Contains(res.Body.String(), "Missing") || !strings.Contains(res.Body.String(), "rejected in strict mode") {
+		t.Errorf("expected the deprecation reported, got %s", res.Body.String())
+	}
+
+	// the initial tasks of the lists
+	res = httptest.NewRecorder()
+	CreateToDoList(res, httptest.NewRequest("POST", "/lists/",
+		strings.NewReader(`{"Name": "ControllerListStrictSeeded", "Tasks": [{"Title": "a", "Priorty": 2}]}`)), nil)
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "Priorty") {
+		t.Errorf("expected the unknown field of the initial task rejected, got %d: %s", res.Code, res.Body.String())
+	}
+	res = httptest.NewRecorder()
+	UpdateToDoList(res, httptest.NewRequest("PUT", "/lists/ControllerListStrict",
+		strings.NewReader(`{"Name": "ControllerListStrict", "Tasks": ["a", {"Title": "b", "Priorty": 2}]}`)), params)
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "Priorty") {
+		t.Errorf("expected the unknown field of the initial task of the PUT rejected, got %d: %s", res.Code, res.Body.String())
+	}
+
+	// the archives, the computed fields of the downloaded ones being accepted
+	res = httptest.NewRecorder()
+	DownloadToDoListArchive(res, httptest.NewRequest("GET", "/lists/ControllerListStrict/archive", nil), params)
+	archive := res.Body.String()
+	res = httptest.NewRecorder()
+	UploadToDoListArchive(res, httptest.NewRequest("POST", "/lists/archive?name=ControllerListStrictRestored", strings.NewReader(archive)), nil)
+	if res.Code != http.StatusOK {
+		t.Errorf("expected the downloaded archive restored, got %d: %s", res.Code, res.Body.String())
+	}
+	res = httptest.NewRecorder()
+	UploadToDoListArchive(res, httptest.NewRequest("POST", "/lists/archive?name=ControllerListStrictUnknown",
+		strings.NewReader(strings.Replace(archive, `"Name"`, `"Colour":"red","Name"`, 1))), nil)
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "Colour") {
+		t.Errorf("expected the unknown field of the archive rejected, got %d: %s", res.Code, res.Body.String())
+	}
+}
@@ -1,13 +1,21 @@
 package controller
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/efreddo/v1/todolist/dateutils"
+	"github.com/efreddo/v1/todolist/jsonpatch"
 	"github.com/efreddo/v1/todolist/model"
 	"github.com/efreddo/v1/todolist/logutils"
+	"github.com/efreddo/v1/todolist/validation"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -15,12 +23,34 @@ import (
 const (
 	TASK_BADREQUEST = 20;
 	TASK_OPERATION_ERROR = 21;
+	TASK_UNPROCESSABLE = 22;
+	TASK_CONFLICT = 23;
+	TASK_UNSUPPORTED_MEDIA_TYPE = 24;
 )
 
 /* 
 	request type: POST
-	url: /lists/:list/tasks {"Title": "New Task"}
-	The request body must contain a JSON object with a Title field
+	url: /lists/:list/tasks {"Title": "New Task", "Description": "Details", "DueDate": "2024-06-01T18:00:00Z",
+	                         "Location": {"Label": "Bakery", "Lat": 41.9028, "Lon": 12.4964}, "Meta": {"source": "jira"},
+	                         "Checklist": [{"Text": "Buy flour", "Done": false}]}
+	The request body must contain a JSON object with a Title field and optional Description, DueDate,
+	Location (a label with optional coordinates, in degrees), Meta (string key/value pairs),
+	Checklist (inline steps, the response reporting their ChecklistProgress), Priority
+	(1 urgent, 2 high, 3 medium, 4 low, 0 or missing for none), Tags and WaitingOn (the
+	external blocker of the task, e.g. "Bob's reply", omitted when empty). With AllDay the
+	task is due on the day of its DueDate, stored at midnight UTC, and overdue only once
+	that day ends in the time zone of the request; AllDay needs a DueDate. ReminderLead (a
+	duration such as 30m, needing a DueDate) fires the reminder of the task that long before
+	it is due, the response then flagging the task with ReminderFired.
+	Descriptions longer than the configured maximum are rejected or truncated, with a warning
+	in the Warnings field of the response, depending on the configured policy.
+	DueDate is parsed in strict mode (RFC 3339 or ISO date) unless lenient mode is configured
+	or requested with the X-Date-Parsing: lenient header (epoch seconds and a few unambiguous
+	formats), dates without time zone are interpreted in the tz parameter time zone (default UTC).
+	The response always reports the due date in RFC 3339. The Location header of the
+	response is the URL of the new task. With ifNotExists=true, when the list already holds
+	a task with the same title ignoring case and spacing, that task is returned with 200,
+	untouched and with its original title, instead of creating a new one
 
 	Examples:
 
@@ -33,18 +63,53 @@ const (
 	   req: POST /lists/:list/tasks {"Title": "Task already inserted"}
 	   res: 404 Task already present in List
 
+	   req: POST /lists/oklist/tasks {"Title": "New Task", "Description": "<too long>"}
+	   res: 422 description too long
+
+	   req: POST /lists/oklist/tasks {"Title": "", "Location": {"Label": "Pole", "Lat": 91, "Lon": 0}}
+	   res: 400 with an error for each invalid field (Title, Location.Lat)
+
+	   req: POST /lists/oklist/tasks {"Title": "New Task", "DueDate": "01/06/2024"}  X-Date-Parsing: lenient
+	   res: 400 ambiguous date
+
 	   req: POST /lists/oklist/tasks {"Title": "New Task"}
-	   res: 200
+	   res: 201 Location: /lists/oklist/tasks/New%20Task
+
+	   req: POST /lists/oklist/tasks?ifNotExists=true {"Title": "new  task"}
+	   res: 200 {"ToDoList":"oklist","Title":"New Task",...}
 */	   
 func CreateTask(w http.ResponseWriter, r *http.Request, param httprouter.Params)  {
 	key := param.ByName("list")
-	req := struct{ Title string }{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil  || key == "" || req.Title == "" {
+	req := struct{ 
+		Title string `validate:"required,max=500"`
+		DueDate *dateutils.Raw
+		model.TaskDetails }{}
+	if err := decodeBody(r, &req); err != nil  || key == "" {
 		taskBadRequestError(w, "CreateTask", err)		
 		return		
 	}
+	if violations := validation.Validate(&req); len(violations) > 0 {
+		HandleValidationError(w, TASK_BADREQUEST, "CreateTask", violations)
+		return
+	}
+	dueDate, err := requestDate(r, req.DueDate)
+	if err != nil {
+		taskInvalidParameterError(w, "CreateTask", "DueDate", err)
+		return
+	}
+	req.TaskDetails.DueDate = dueDate
 	
-	task, err :=  model.AddTask(key, req.Title)
+	if r.URL.Query().Get("ifNotExists") == "true" {
+		createTaskIfNotExists(w, r, key, req.Title, req.TaskDetails)
+		return
+	}
+	timed := timeModel(r, "AddTaskWithDetails")
+	task, warnings, err :=  model.AddTaskWithDetails(key, req.Title, req.TaskDetails)
+	timed()
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "CreateTask", req.Title, key, err)
+		return
+	}
 	if err != nil {
 		taskOperationError(w, "CreateTask", req.Title, key, err)
 		return
@@ -52,7 +117,34 @@ func CreateTask(w http.ResponseWriter, r *http.Request, param httprouter.Params)
 
 	logutils.Info.Println(fmt.Sprintf(
 		"CreateTask:: new task added to ToDoList '%s': task={title: %s, done=%t}",key, task.Title, task.Done ))
-	json.NewEncoder(w).Encode(task)
+	writeCreated(w, taskURL(task.ToDoList, task.Title))
+	writeTask(w, task, warnings)
+}
+
+// createTaskIfNotExists creates the task unless the list holds one with the
+// same title ignoring case and spacing, returned then
+func createTaskIfNotExists(w http.ResponseWriter, r *http.Request, key string, title string, details model.TaskDetails) {
+	timed := timeModel(r, "AddTaskIfNotExists")
+	task, created, warnings, err := model.AddTaskIfNotExists(key, title, details)
+	timed()
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "CreateTask", title, key, err)
+		return
+	}
+	if err != nil {
+		taskOperationError(w, "CreateTask", title, key, err)
+		return
+	}
+	if !created {
+		logutils.Info.Println(fmt.Sprintf(
+			"CreateTask:: task '%s' already in ToDoList '%s' as '%s'", title, key, task.Title))
+		writeTask(w, task, nil)
+		return
+	}
+	logutils.Info.Println(fmt.Sprintf(
+		"CreateTask:: new task added to ToDoList '%s': task={title: %s, done=%t}",key, task.Title, task.Done ))
+	writeCreated(w, taskURL(task.ToDoList, task.Title))
+	writeTask(w, task, warnings)
 }
 
 
@@ -68,6 +160,9 @@ func CreateTask(w http.ResponseWriter, r *http.Request, param httprouter.Params)
 	   req: POST /lists/wronglist/tasks/oktask
 	   res: 404 ToDo list not found
 
+	   req: DELETE /lists/oklist/tasks/protectedtask
+	   res: 422 rejected by a hook
+
 	   req: DELETE /lists/oklist/tasks/oktask
 	   res: 200
 */	   
@@ -79,7 +174,13 @@ func DeleteTask(w http.ResponseWriter, r *http.Request, param httprouter.Params)
 		taskBadRequestError(w, "DeleteTask", errors.New("Missing mandatory information: todolist name or task title"))		
 		return	
 	}
+	timed := timeModel(r, "RemoveTask")
 	task, err :=  model.RemoveTask(key, title)
+	timed()
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "DeleteTask", title, key, err)
+		return
+	}
 	if err != nil {
 		taskOperationError(w, "DeleteTask", title, key, err)
 		return
@@ -87,13 +188,17 @@ func DeleteTask(w http.ResponseWriter, r *http.Request, param httprouter.Params)
 
 	logutils.Info.Println(fmt.Sprintf(
 		"DeleteTask:: task removed from  ToDoList '%s': task={title: %s, done=%t}",key, task.Title, task.Done ))
-	json.NewEncoder(w).Encode(task)
+	writeJSON(w, task)
 }
 
 
 /* 
 	request type: GET
-	url: /lists/:list/tasks/:task
+	url: /lists/:list/tasks/:task?humanize=true&tz=Europe/Rome
+	The ETag header of the response identifies the task content: with an If-None-Match
+	header matching it, 304 is returned without body. With humanize=true the due, creation
+	and completion dates are also returned relative to now (DueIn, CreatedAgo, CompletedAgo),
+	in calendar days of the tz time zone beyond a day, without ETag
 
 	Examples:
 
@@ -103,6 +208,12 @@ func DeleteTask(w http.ResponseWriter, r *http.Request, param httprouter.Params)
 	   req: POST /lists/wronglist/tasks/oktitle
 	   res: 404 ToDo list not found
 
+	   req:  GET /lists/oklist/tasks/oktitle  If-None-Match: "<current ETag>"
+	   res: 304
+
+	   req:  GET /lists/oklist/tasks/oktitle?humanize=true
+	   res: 200 {"Title":"oktitle",...,"DueIn":"tomorrow","CreatedAgo":"3 hours ago"}
+
 	   req:  GET /lists/oklist/tasks/oktitle
 	   res: 200
 */	   
@@ -114,50 +225,133 @@ func GetTask(w http.ResponseWriter, r *http.Request, param httprouter.Params)  {
 		return
 	}
 	
+	humanize, now, err := requestHumanize(r)
+	if err != nil {
+		taskInvalidParameterError(w, "GetTask", "humanize or tz", err)
+		return
+	}
+	
+	timed := timeModel(r, "GetTask")
 	task, err :=  model.GetTask(key, title)
+	timed()
 	if err != nil {
 		taskOperationError(w, "GetTask", title, key, err)
 		return
 	}
+	// the relative times change with the time, humanized responses are not tagged
+	if !humanize {
+		etag := model.TaskETag(task)
+		w.Header().Set("ETag", etag)
+		if etagMatch(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
 	
 	logutils.Info.Println(fmt.Sprintf(
 		"GetTask:: task retrieved from ToDoList '%s': task={title: %s, done=%t}",key, task.Title, task.Done ))
-	json.NewEncoder(w).Encode(task)
+	if humanize {
+		writeJSON(w, humanizedTask{task, relativeTimes(task, now)})
+		return
+	}
+	writeJSON(w, task)
+}
+
+/* 
+	request type: GET
+	url: /lists/:list/tasks/:task/history
+	Returns the changes of the task in chronological order: its creation, the changes of its
+	done state and of each field of its title and details, with the values before and after
+	(null when unset). The latest 100 changes are retained per task, and they are dropped
+	along with the task
+
+	Examples:
+
+	   req: GET /lists/oklist/tasks/wrongtitle/history
+	   res: 404 Task not found
+
+	   req: GET /lists/oklist/tasks/oktitle/history
+	   res: 200 {"Changes":[{"Type":"task.created","At":"2024-06-01T09:00:00Z"},
+	             {"Type":"task.updated","Field":"Priority","Before":null,"After":2,"At":"2024-06-01T09:05:00Z"},
+	             {"Type":"task.completed","Field":"Done","Before":false,"After":true,"At":"2024-06-02T18:00:00Z"}]}
+*/
+func GetTaskHistory(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	title := param.ByName("task")
+
+	changes, err := model.TaskHistory(key, title)
+	if err != nil {
+		taskOperationError(w, "GetTaskHistory", title, key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetTaskHistory:: retrieved %d changes of task '%s' from ToDoList '%s'", len(changes), title, key))
+	writeJSON(w, struct {
+		Changes []model.TaskChange
+	}{changes})
 }
 
 /* 
 	request type: PUT
-	url: /lists/:list/tasks/:task {"Title": "New Title", "Done": true}
-	The request body must contain a JSON object with  Done fields and optional a Title with the new title
+	url: /lists/:list/tasks/:task {"Title": "New Title", "Done": true, "Description": "Details", "DueDate": "2024-06-01T18:00:00Z",
+	                               "Location": {"Label": "Bakery", "Lat": 41.9028, "Lon": 12.4964}, "Meta": {"source": "jira"},
+	                               "Checklist": [{"Text": "Buy flour", "Done": true}]}
+	The request body must contain a JSON object with the Done field and optionally a Title with
+	the new title. The task is replaced: its details (Description, DueDate, Location, Meta,
+	Checklist, Priority, Tags, WaitingOn, AllDay and ReminderLead) are set to the sent ones,
+	the ones missing from the body being removed. Use PATCH to change only some fields.
+	The title, the done state and the details are changed together: on error none is changed.
 
 	Examples:
 
-	   req: POST /oklist/addtask/ {"Title": "", "Done": true}
-	   res: 400 empty title
+	   req: PUT /lists/oklist/tasks/oktask {"Title": "", "Done": "yes"}
+	   res: 400 invalid body
 
-	   req: POST /addtask/badlist/ {"Title": "", "Done": true}
+	   req: PUT /lists/badlist/tasks/oktask {"Done": true}
 	   res: 404 ToDo list not found
 
-	   req: POST /addtask/oklist/ {"Title": "Task already inserted", "Done": true}
-	   res: 406 Task unknown. Use addtask request to add the task
+	   req: PUT /lists/oklist/tasks/deletedtask {"Done": true}
+	   res: 410 Task deleted
 
-	   req: POST /oklist/show/: {"Title": "New Task", "Done": true}
-	   res: 200
+	   req: PUT /lists/oklist/tasks/oktask {"Done": true, "ReminderLead": "forever"}
+	   res: 422 invalid details, the task being left unchanged
+
+	   req: PUT /lists/oklist/tasks/oktask {"Title": "New Task", "Done": true}
+	   res: 200 task renamed and done, its details removed
 */	   
 func UpdateTask(w http.ResponseWriter, r *http.Request, param httprouter.Params)  {
 	key := param.ByName("list")	
 	title := param.ByName("task")
 	req := struct{ 
-		Title string
-		Done  bool }{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || key == "" || title == "" {
+		Title string `validate:"max=500"`
+		Done  bool
+		DueDate *dateutils.Raw
+		model.TaskDetails }{}
+	if err := decodeBody(r, &req); err != nil || key == "" || title == "" {
 		taskBadRequestError(w, "UpdateTask", err)		
 		return
 	}
+	if violations := validation.Validate(&req); len(violations) > 0 {
+		HandleValidationError(w, TASK_BADREQUEST, "UpdateTask", violations)
+		return
+	}
+	dueDate, err := requestDate(r, req.DueDate)
+	if err != nil {
+		taskInvalidParameterError(w, "UpdateTask", "DueDate", err)
+		return
+	}
+	req.TaskDetails.DueDate = dueDate
 	if req.Title == "" {
 		req.Title = title
 	}
-	task, err :=  model.UpdateTask(key, title, req.Title, req.Done)
+	timed := timeModel(r, "UpdateTask")
+	task, warnings, err := model.UpdateTaskWithDetails(key, title, req.Title, req.Done, req.TaskDetails)
+	timed()
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "UpdateTask", title, key, err)
+		return
+	}
 	if err != nil {
 		taskOperationError(w, "UpdateTask", title, key, err)
 		return
@@ -165,17 +359,1086 @@ func UpdateTask(w http.ResponseWriter, r *http.Request, param httprouter.Params)
 
 	logutils.Info.Println(fmt.Sprintf(
 		"UpdateTask:: task updated in  ToDoList '%s': task={title: %s, done=%t}",key, task.Title, task.Done ))
-	json.NewEncoder(w).Encode(task)
+	writeTask(w, task, warnings)
 }
 
-func taskBadRequestError(w http.ResponseWriter, caller string, err error){
-	HandleError(w, http.StatusBadRequest, TASK_BADREQUEST, caller,
-		"Missing ToDo list name or task title",  
-		fmt.Sprintf("Bad request received: Missing mandatory parameters list or title. %v", err))
+/* 
+	request type: POST
+	url: /lists/:list/tasks/:task/done {"Expected": false, "Done": true}
+	Sets the done state of the task to Done only if it is currently Expected,
+	the state being checked and changed atomically. Returns the resulting task.
+
+	Examples:
+
+	   req: POST /lists/oklist/tasks/oktask/done {"Done": true}
+	   res: 400 missing Expected
+
+	   req: POST /lists/wronglist/tasks/oktask/done {"Expected": false, "Done": true}
+	   res: 404 ToDo list not found
+
+	   req: POST /lists/oklist/tasks/donetask/done {"Expected": false, "Done": true}
+	   res: 409 task done state does not match the expected one
+
+	   req: POST /lists/oklist/tasks/oktask/done {"Expected": false, "Done": true}
+	   res: 200
+*/
+func CompareAndSetTaskDone(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	title := param.ByName("task")
+	req := struct {
+		Expected *bool `validate:"required"`
+		Done     *bool `validate:"required"`
+	}{}
+	if err := decodeBody(r, &req); err != nil || key == "" || title == "" {
+		taskBadRequestError(w, "CompareAndSetTaskDone", err)
+		return
+	}
+	if violations := validation.Validate(&req); len(violations) > 0 {
+		HandleValidationError(w, TASK_BADREQUEST, "CompareAndSetTaskDone", violations)
+		return
+	}
+	task, err := model.CompareAndSetTaskDone(key, title, *req.Expected, *req.Done)
+	if err == model.ErrTaskDoneConflict {
+		HandleError(w, http.StatusConflict, TASK_CONFLICT, "CompareAndSetTaskDone",
+			fmt.Sprintf("Conflict while updating task = {%s}, ToDo list = {%s}", title, key),
+			fmt.Sprintf("%v", err))
+		return
+	}
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "CompareAndSetTaskDone", title, key, err)
+		return
+	}
+	if err != nil {
+		taskOperationError(w, "CompareAndSetTaskDone", title, key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"CompareAndSetTaskDone:: task updated in  ToDoList '%s': task={title: %s, done=%t}", key, task.Title, task.Done))
+	writeTask(w, task, nil)
 }
 
-func taskOperationError(w http.ResponseWriter, caller, task, todolist string, err error){
-	HandleError(w, http.StatusNotFound, TASK_OPERATION_ERROR, caller,
-		fmt.Sprintf("Error while performing operation on task = {%s}, ToDo list = {%s}", task, todolist),  
-		fmt.Sprintf("%v",err))
-}
\ No newline at end of file
+/* 
+	request type: PATCH
+	url: /lists/:list/tasks/:task
+	Content-Type: application/json-patch+json
+	Applies a JSON Patch (RFC 6902) as a whole to the JSON representation of the task, array
+	fields like Tags and Checklist being addressed by index ("/Tags/0", "/Tags/-" to append).
+	409 is returned, and the task left untouched, when a test operation fails, when an operation
+	modifies ToDoList, Number, CreatedAt, CompletedAt or ChecklistProgress, or when the new title is
+	already used in the list
+
+	Examples:
+
+	   req: PATCH /lists/oklist/tasks/oktask  Content-Type: application/json
+	   res: 415 unsupported media type
+
+	   req: PATCH /lists/oklist/tasks/oktask  [{"op": "replace", "path": "/CreatedAt", "value": "2024-01-01T00:00:00Z"}]
+	   res: 409 server-managed path
+
+	   req: PATCH /lists/oklist/tasks/oktask  [{"op": "test", "path": "/Done", "value": true}]
+	   res: 409 test failed
+
+	   req: PATCH /lists/oklist/tasks/oktask  [{"op": "add", "path": "/Priority", "value": 9}]
+	   res: 422 invalid priority
+
+	   req: PATCH /lists/oklist/tasks/oktask  [{"op": "add", "path": "/Tags/-", "value": "home"}]
+	   res: 200
+*/
+func PatchTask(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	title := param.ByName("task")
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != jsonPatchMediaType {
+		HandleError(w, http.StatusUnsupportedMediaType, TASK_UNSUPPORTED_MEDIA_TYPE, "PatchTask",
+			"Unsupported media type, application/json-patch+json expected",
+			fmt.Sprintf("Content-Type received: %s", r.Header.Get("Content-Type")))
+		return
+	}
+	patch, err := jsonpatch.Decode(r.Body)
+	if err != nil || key == "" || title == "" {
+		taskBadRequestError(w, "PatchTask", err)
+		return
+	}
+	if location := managedPath(patch, taskManagedPaths); location != "" {
+		managedPathError(w, "PatchTask", TASK_CONFLICT, location)
+		return
+	}
+	if _, err := model.GetTask(key, title); err != nil {
+		taskOperationError(w, "PatchTask", title, key, err)
+		return
+	}
+
+	applier := &patchApplier{patch: patch}
+	task, warnings, err := model.ApplyTaskPatch(key, title, applier.apply)
+	if applier.err != nil {
+		jsonPatchError(w, "PatchTask", TASK_CONFLICT, TASK_UNPROCESSABLE, applier.err)
+		return
+	}
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "PatchTask", title, key, err)
+		return
+	}
+	if err != nil {
+		HandleError(w, http.StatusConflict, TASK_CONFLICT, "PatchTask",
+			fmt.Sprintf("Error while patching task = {%s}, ToDo list = {%s}", title, key),
+			fmt.Sprintf("%v", err))
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"PatchTask:: task patched in ToDoList '%s' with %d operations: task={title: %s, done=%t}", key, len(patch), task.Title, task.Done))
+	writeTask(w, task, warnings)
+}
+
+/* 
+	request type: PATCH
+	url: /lists/:list/tasks/priority {"Ids": ["task 1", "task-7"], "Priority": 2}
+	Sets the priority of the tasks with the given ids, their titles or short references, and
+	returns the outcome of each task: 200 when all are updated, 207 Multi-Status when some
+	are not found or rejected by a hook, the others being updated anyway. The priority goes
+	from 0 (none) to 4, 422 is returned otherwise
+
+	Examples:
+
+	   req: PATCH /lists/oklist/tasks/priority {"Ids": [], "Priority": 1}
+	   res: 400 empty list of ids
+
+	   req: PATCH /lists/oklist/tasks/priority {"Ids": ["oktask"], "Priority": 9}
+	   res: 422 invalid priority
+
+	   req: PATCH /lists/wronglist/tasks/priority {"Ids": ["oktask"], "Priority": 1}
+	   res: 404 ToDo list not found
+
+	   req: PATCH /lists/oklist/tasks/priority {"Ids": ["oktask", "wrongtask"], "Priority": 1}
+	   res: 207 {"Succeeded":1,"Failed":1,"Items":[{"Id":"oktask","Status":200},{"Id":"wrongtask","Status":404,"Error":"Task not found"}]}
+*/
+func SetTasksPriority(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	req := struct {
+		Ids      []string
+		Priority *int
+	}{}
+	if err := decodeBody(r, &req); err != nil || len(req.Ids) == 0 || req.Priority == nil {
+		taskBadRequestError(w, "SetTasksPriority", err)
+		return
+	}
+
+	summary, err := model.SetTasksPriority(key, req.Ids, *req.Priority)
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "SetTasksPriority", "priority", key, err)
+		return
+	}
+	if err != nil {
+		taskOperationError(w, "SetTasksPriority", "priority", key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"SetTasksPriority:: priority %d set on %d tasks of ToDoList '%s', %d not found, %d rejected",
+		*req.Priority, summary.Updated, key, len(summary.NotFound), len(summary.Rejected)))
+	failures := make(map[string]ItemStatus, len(summary.NotFound)+len(summary.Rejected))
+	for _, id := range summary.NotFound {
+		failures[id] = ItemStatus{Id: id, Status: http.StatusNotFound, Error: "Task not found"}
+	}
+	for _, e := range summary.Rejected {
+		failures[e.Key] = ItemStatus{Id: e.Key, Status: http.StatusUnprocessableEntity, Error: e.Error}
+	}
+	items := []ItemStatus{}
+	seen := make(map[string]bool, len(req.Ids))
+	for _, id := range req.Ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if failure, failed := failures[id]; failed {
+			items = append(items, failure)
+		} else {
+			items = append(items, ItemStatus{Id: id, Status: http.StatusOK})
+		}
+	}
+	writeMultiStatus(w, items)
+}
+
+/* 
+	request type: PATCH
+	url: /lists/:list/tasks/:task/checklist/:index {"Done": true}
+	Sets the done state of the checklist item at index (starting from 0), the item being
+	toggled when Done is missing. Returns the task with its checklist progress
+
+	Examples:
+
+	   req: PATCH /lists/oklist/tasks/oktask/checklist/first
+	   res: 400 invalid index
+
+	   req: PATCH /lists/oklist/tasks/oktask/checklist/99
+	   res: 404 checklist item not found
+
+	   req: PATCH /lists/oklist/tasks/oktask/checklist/0 {"Done": true}
+	   res: 200
+*/
+func PatchChecklistItem(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	title := param.ByName("task")
+	index, err := strconv.Atoi(param.ByName("index"))
+	if err != nil {
+		taskInvalidParameterError(w, "PatchChecklistItem", "index", err)
+		return
+	}
+	req := struct{ Done *bool }{}
+	if err := decodeBody(r, &req); err != nil && err != io.EOF {
+		taskBadRequestError(w, "PatchChecklistItem", err)
+		return
+	}
+
+	task, err := model.SetChecklistItemDone(key, title, index, req.Done)
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "PatchChecklistItem", title, key, err)
+		return
+	}
+	if err != nil {
+		taskOperationError(w, "PatchChecklistItem", title, key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"PatchChecklistItem:: checklist item %d of task '%s' in ToDoList '%s' set to done=%t",
+		index, task.Title, key, task.Checklist[index].Done))
+	writeTask(w, task, nil)
+}
+
+/* 
+	request type: PATCH
+	url: /lists/:list/tasks/:task/progress {"Progress": 40}
+	Sets the completion percentage of the task, from 0 to 100: 100 completes the task, a
+	lower progress reopens it. Done tasks have a progress of 100, and the lists report the
+	average progress of their tasks as PercentComplete
+
+	Examples:
+
+	   req: PATCH /lists/oklist/tasks/oktask/progress {"Progress": 120}
+	   res: 422 invalid progress
+
+	   req: PATCH /lists/oklist/tasks/wrongtask/progress {"Progress": 40}
+	   res: 404 Task not found
+
+	   req: PATCH /lists/oklist/tasks/oktask/progress {"Progress": 40}
+	   res: 200 {"ToDoList":"oklist","Title":"oktask","Number":1,"Done":false,"Progress":40,...}
+*/
+func PatchTaskProgress(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	title := param.ByName("task")
+	req := struct{ Progress *int }{}
+	if err := decodeBody(r, &req); err != nil || req.Progress == nil {
+		taskBadRequestError(w, "PatchTaskProgress", err)
+		return
+	}
+
+	task, err := model.SetTaskProgress(key, title, *req.Progress)
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "PatchTaskProgress", title, key, err)
+		return
+	}
+	if err != nil {
+		taskOperationError(w, "PatchTaskProgress", title, key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"PatchTaskProgress:: progress of task '%s' in ToDoList '%s' set to %d, done=%t", task.Title, key, task.Progress, task.Done))
+	writeTask(w, task, nil)
+}
+
+/* 
+	request type: PUT
+	url: /lists/:list/tasks/:task/deferred {"Deferred": true}
+	Parks the task as someday/maybe, or brings it back with {"Deferred": false}. Deferred
+	tasks are hidden from the tasks listed by default and left out of the next task, the
+	overdue tasks, the calendar, the digests and the reviews. Completing a task un-defers
+	it, done tasks can not be deferred
+
+	Examples:
+
+	   req: PUT /lists/oklist/tasks/oktask/deferred {}
+	   res: 400 missing Deferred
+
+	   req: PUT /lists/oklist/tasks/donetask/deferred {"Deferred": true}
+	   res: 422 the task is done
+
+	   req: PUT /lists/oklist/tasks/wrongtask/deferred {"Deferred": true}
+	   res: 404 Task not found
+
+	   req: PUT /lists/oklist/tasks/oktask/deferred {"Deferred": true}
+	   res: 200 {"ToDoList":"oklist","Title":"oktask","Number":1,"Done":false,"Deferred":true,...}
+*/
+func SetTaskDeferred(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	title := param.ByName("task")
+	req := struct{ Deferred *bool }{}
+	if err := decodeBody(r, &req); err != nil || req.Deferred == nil {
+		taskBadRequestError(w, "SetTaskDeferred", err)
+		return
+	}
+
+	task, err := model.SetTaskDeferred(key, title, *req.Deferred)
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "SetTaskDeferred", title, key, err)
+		return
+	}
+	if err != nil {
+		taskOperationError(w, "SetTaskDeferred", title, key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"SetTaskDeferred:: task '%s' in ToDoList '%s' set to deferred=%t", task.Title, key, task.Deferred))
+	writeTask(w, task, nil)
+}
+
+/* 
+	request type: POST
+	url: /lists/:list/tasks/:task/timelog {"Minutes": 30}
+	Logs time spent on the task, from 1 minute to a day at once, added to its TimeSpent
+	in minutes. GET /lists/:list/timespent sums the time spent on the tasks of the list
+
+	Examples:
+
+	   req: POST /lists/oklist/tasks/oktask/timelog {}
+	   res: 400 missing Minutes
+
+	   req: POST /lists/oklist/tasks/oktask/timelog {"Minutes": 0}
+	   res: 422 invalid minutes
+
+	   req: POST /lists/oklist/tasks/wrongtask/timelog {"Minutes": 30}
+	   res: 404 Task not found
+
+	   req: POST /lists/oklist/tasks/oktask/timelog {"Minutes": 30}
+	   res: 200 {"ToDoList":"oklist","Title":"oktask",...,"TimeSpent":90,...}
+*/
+func LogTaskTime(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	title := param.ByName("task")
+	req := struct{ Minutes *int }{}
+	if err := decodeBody(r, &req); err != nil || req.Minutes == nil {
+		taskBadRequestError(w, "LogTaskTime", err)
+		return
+	}
+
+	task, err := model.LogTaskTime(key, title, *req.Minutes)
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "LogTaskTime", title, key, err)
+		return
+	}
+	if err != nil {
+		taskOperationError(w, "LogTaskTime", title, key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"LogTaskTime:: %d minutes logged on task '%s' in ToDoList '%s', %d in total", *req.Minutes, task.Title, key, task.TimeSpent))
+	writeTask(w, task, nil)
+}
+
+/* 
+	request type: POST
+	url: /lists/:list/tasks/:task/touch
+	Bumps the UpdatedAt of the task without changing it otherwise, its history included,
+	and returns the task
+
+	Examples:
+
+	   req: POST /lists/oklist/tasks/wrongtask/touch
+	   res: 404 Task not found
+
+	   req: POST /lists/oklist/tasks/oktask/touch
+	   res: 200 {"ToDoList":"oklist","Title":"oktask",...,"UpdatedAt":"2024-06-01T09:00:00Z",...}
+*/
+func TouchTask(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	title := param.ByName("task")
+	task, err := model.TouchTask(key, title)
+	if err != nil {
+		taskOperationError(w, "TouchTask", title, key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf("TouchTask:: task '%s' in ToDoList '%s' touched", title, key))
+	writeTask(w, task, nil)
+}
+
+/* 
+	request type: GET
+	url: /lists/:list/timespent
+	Returns the time spent on the tasks of the ToDo list, in minutes
+
+	Examples:
+
+	   req: GET /lists/wronglist/timespent
+	   res: 404 ToDo list not found
+
+	   req: GET /lists/oklist/timespent
+	   res: 200 {"List":"oklist","TimeSpent":150}
+*/
+func GetListTimeSpent(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	total, err := model.ListTimeSpent(key)
+	if err != nil {
+		taskOperationError(w, "GetListTimeSpent", "all", key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf("GetListTimeSpent:: %d minutes spent on ToDoList '%s'", total, key))
+	writeJSON(w, struct {
+		List      string
+		TimeSpent int
+	}{key, total})
+}
+
+/* 
+	request type: POST
+	url: /lists/:list/tasks/import.csv?atomic=true
+	The request body is a CSV file whose header row names the columns among Title (required),
+	Done, Description, DueDate, Priority and Tags, case insensitive, see ImportTasks. Tasks are created in file order,
+	invalid rows being reported with their line without failing the others, unless atomic=true:
+	no task is created then when any row is invalid. Returns the summary of the import
+
+	Examples:
+
+	   req: POST /lists/oklist/tasks/import.csv  "Name\nTask"
+	   res: 400 unknown CSV column
+
+	   req: POST /lists/wronglist/tasks/import.csv
+	   res: 404 ToDo list not found
+
+	   req: POST /lists/oklist/tasks/import.csv?atomic=true  "Title,Priority\nTask,high"
+	   res: 422 {"Created":0,"Failed":1,"Errors":[{"Line":2,"Error":"invalid priority high, expected 0 to 4"}]}
+
+	   req: POST /lists/oklist/tasks/import.csv  "Title,Priority\nTask1,1\nTask2,high"
+	   res: 200 {"Created":1,"Failed":1,"Errors":[{"Line":3,"Error":"invalid priority high, expected 0 to 4"}]}
+*/
+func ImportTasksCSV(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	atomic := false
+	if value := r.URL.Query().Get("atomic"); value != "" {
+		var err error
+		if atomic, err = strconv.ParseBool(value); err != nil {
+			taskInvalidParameterError(w, "ImportTasksCSV", "atomic", err)
+			return
+		}
+	}
+	importTasksCSV(w, r, "ImportTasksCSV", param.ByName("list"), atomic)
+}
+
+/* 
+	request type: POST
+	url: /lists/:list/import?format=csv&mode=strict
+	The request body is a CSV file with a header row naming the columns, case insensitive:
+	Title (required), Status (done or open), Priority, Due Date, Tags (comma separated) and
+	Notes. Fields are separated by commas or semicolons, detected from the header. Tasks are
+	created in file order, invalid rows being reported with their line without failing the
+	others, unless mode=strict: no task is created then when any row is invalid
+
+	Examples:
+
+	   req: POST /lists/oklist/import?format=xlsx
+	   res: 400 unsupported format
+
+	   req: POST /lists/wronglist/import?format=csv
+	   res: 404 ToDo list not found
+
+	   req: POST /lists/oklist/import?format=csv&mode=strict  "Title;Status\nTask;maybe"
+	   res: 422 {"Created":0,"Failed":1,"Errors":[{"Line":2,"Error":"invalid status maybe, expected done or open"}]}
+
+	   req: POST /lists/oklist/import?format=csv  "Title;Status\nTask1;done\nTask2;maybe"
+	   res: 200 {"Created":1,"Failed":1,"Errors":[{"Line":3,"Error":"invalid status maybe, expected done or open"}]}
+*/
+func ImportTasks(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	query := r.URL.Query()
+	if format := query.Get("format"); format != "csv" {
+		taskInvalidParameterError(w, "ImportTasks", "format", fmt.Errorf("unsupported format %s, expected csv", format))
+		return
+	}
+	mode := query.Get("mode")
+	if mode != "" && mode != "strict" && mode != "lenient" {
+		taskInvalidParameterError(w, "ImportTasks", "mode", fmt.Errorf("unknown mode %s, expected strict or lenient", mode))
+		return
+	}
+	importTasksCSV(w, r, "ImportTasks", param.ByName("list"), mode == "strict")
+}
+
+// importTasksCSV imports the CSV request body in the ToDo list, answering with
+// the summary of the import, 422 when atomic and some rows are invalid
+func importTasksCSV(w http.ResponseWriter, r *http.Request, caller, key string, atomic bool) {
+	if _, err := model.GetToDoList(key); err != nil {
+		taskOperationError(w, caller, "import", key, err)
+		return
+	}
+
+	summary, err := model.ImportTasksCSV(key, r.Body, atomic)
+	if err != nil {
+		taskInvalidParameterError(w, caller, "CSV file", err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"%s:: imported %d tasks in ToDoList '%s', %d rows failed", caller, summary.Created, key, summary.Failed))
+	if atomic && summary.Failed > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	writeJSON(w, summary)
+}
+
+/* 
+	request type: GET
+	url: /lists/:list/tasks/?sort=dueDate&order=desc&meta.source=jira&waiting=true&deferred=true&createdAfter=2024-06-03&createdBefore=2024-06-10&offset=0&limit=50
+	Returns the tasks of the ToDo list, in the order of the list (insertion order, or its
+	AutoSort) or sorted by due date
+	(ascending by default), tasks without a due date being always listed last.
+	Each meta.<key>=<value> parameter keeps the tasks whose metadata contains the
+	key/value pair, multiple meta filters being combined in AND. waiting=true keeps
+	the tasks waiting on an external blocker (with a WaitingOn), waiting=false the
+	other ones. Deferred (someday/maybe) tasks are hidden unless deferred=true, which
+	keeps only them, or deferred=all. createdAfter and createdBefore, both optional, keep the tasks created
+	at or after and before the given dates, in the tz time zone when without one.
+	humanize=true adds the relative times of the tasks, as for a single task. Tasks are paginated
+	with the offset and limit parameters, the configured tasks page size by default.
+	Without offset, limit and default page size, at most the configured maximum of
+	unpaginated tasks (500 by default) are returned: the truncation is signalled by the
+	X-Truncated: true header, along with X-Total-Count and the Link to the next page.
+	Each task has a DisplayNumber, its position (from 1) in the sorted and filtered
+	tasks before pagination: the number follows the listing, it is not an identifier
+
+	Examples:
+
+	   req: GET /lists/oklist/tasks/?sort=unknown
+	   res: 400 invalid sort
+
+	   req: GET /lists/oklist/tasks/?meta.=jira
+	   res: 400 invalid meta filter
+
+	   req: GET /lists/oklist/tasks/?waiting=maybe
+	   res: 400 invalid waiting
+
+	   req: GET /lists/oklist/tasks/?deferred=maybe
+	   res: 400 invalid deferred
+
+	   req: GET /lists/oklist/tasks/?limit=0
+	   res: 400 invalid limit
+
+	   req: GET /lists/oklist/tasks/?createdAfter=yesterday
+	   res: 400 invalid createdAfter
+
+	   req: GET /lists/wronglist/tasks/
+	   res: 404 ToDo list not found
+
+	   req: GET /lists/biglist/tasks/
+	   res: 200 X-Truncated: true  X-Total-Count: 1200
+	            Link: </lists/biglist/tasks/?limit=500&offset=500>; rel="next"
+
+	   req: GET /lists/oklist/tasks/?sort=dueDate
+	   res: 200
+*/
+func GetTasks(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	query := r.URL.Query()
+	if key == "" {
+		taskBadRequestError(w, "GetTasks", errors.New("Missing mandatory information: todolist name"))
+		return
+	}
+	if sort := query.Get("sort"); sort != "" && sort != "dueDate" {
+		taskInvalidParameterError(w, "GetTasks", "sort", fmt.Errorf("unknown sort %s", sort))
+		return
+	}
+	if order := query.Get("order"); order != "" && order != "asc" && order != "desc" {
+		taskInvalidParameterError(w, "GetTasks", "order", fmt.Errorf("unknown order %s", order))
+		return
+	}
+
+	waiting := query.Get("waiting")
+	if waiting != "" && waiting != "true" && waiting != "false" {
+		taskInvalidParameterError(w, "GetTasks", "waiting", fmt.Errorf("invalid waiting %s, expected true or false", waiting))
+		return
+	}
+	deferred := query.Get("deferred")
+	if deferred != "" && deferred != "true" && deferred != "false" && deferred != "all" {
+		taskInvalidParameterError(w, "GetTasks", "deferred", fmt.Errorf("invalid deferred %s, expected true, false or all", deferred))
+		return
+	}
+
+	metaFilters := map[string]string{}
+	for name, values := range query {
+		if !strings.HasPrefix(name, "meta.") {
+			continue
+		}
+		if name == "meta." || len(values) != 1 {
+			taskInvalidParameterError(w, "GetTasks", name, fmt.Errorf("expected a single meta.<key>=<value> filter per key"))
+			return
+		}
+		metaFilters[strings.TrimPrefix(name, "meta.")] = values[0]
+	}
+	createdAfter, createdBefore, err := requestCreatedRange(r)
+	if err != nil {
+		taskInvalidParameterError(w, "GetTasks", "createdAfter or createdBefore", err)
+		return
+	}
+	createdRange := !createdAfter.IsZero() || !createdBefore.IsZero()
+	humanize, now, err := requestHumanize(r)
+	if err != nil {
+		taskInvalidParameterError(w, "GetTasks", "humanize or tz", err)
+		return
+	}
+	offset, limit, err := requestPage(r, pageSizes.Tasks)
+	if err != nil {
+		taskInvalidParameterError(w, "GetTasks", "offset or limit", err)
+		return
+	}
+
+	var tasks []*model.Task
+	if waiting == "true" {
+		timed := timeModel(r, "GetWaitingTasks")
+		tasks, err = model.GetWaitingTasks(key)
+		timed()
+	} else if createdRange {
+		timed := timeModel(r, "GetTasksByCreatedRange")
+		tasks, err = model.GetTasksByCreatedRange(key, createdAfter, createdBefore)
+		timed()
+	} else if deferred == "true" {
+		timed := timeModel(r, "GetDeferredTasks")
+		tasks, err = model.GetDeferredTasks(key)
+		timed()
+	} else {
+		timed := timeModel(r, "GetTasks")
+		tasks, err = model.GetTasks(key)
+		timed()
+	}
+	if err != nil {
+		taskOperationError(w, "GetTasks", "all", key, err)
+		return
+	}
+	if waiting == "false" {
+		tasks = model.FilterWaitingTasks(tasks, false)
+	}
+	if waiting == "true" && createdRange {
+		tasks = model.FilterTasksByCreatedRange(tasks, createdAfter, createdBefore)
+	}
+	if deferred != "all" {
+		tasks = model.FilterDeferredTasks(tasks, deferred == "true")
+	}
+	for metaKey, metaValue := range metaFilters {
+		tasks = model.FilterTasksByMeta(tasks, metaKey, metaValue)
+	}
+	if query.Get("sort") == "dueDate" {
+		model.SortTasksByDueDate(tasks, query.Get("order") == "desc")
+	}
+	numbered := model.NumberTasks(tasks)
+	limit = truncatePage(w, r, len(numbered), limit, maxUnpaginatedTasks)
+	start, end := pageBounds(len(numbered), offset, limit)
+	numbered = numbered[start:end]
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetTasks:: retrieved %d tasks from ToDoList '%s'", len(numbered), key))
+	if humanize {
+		writeJSON(w, humanizeTasks(numbered, now))
+		return
+	}
+	writeJSON(w, numbered)
+}
+
+/* 
+	request type: GET
+	url: /lists/:list/next
+	Returns the single pending task to work on next: the highest priority first, tasks
+	without priority last, then the earliest due date, then the order of the list
+
+	Examples:
+
+	   req: GET /lists/wronglist/next
+	   res: 404 ToDo list not found
+
+	   req: GET /lists/donelist/next
+	   res: 204 nothing to do
+
+	   req: GET /lists/oklist/next
+	   res: 200
+*/
+func GetNextTask(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	task, err := model.NextActionableTask(key)
+	if err != nil {
+		taskOperationError(w, "GetNextTask", "next", key, err)
+		return
+	}
+	if task == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetNextTask:: next task of ToDoList '%s': task={title: %s, priority=%d}", key, task.Title, task.Priority))
+	writeJSON(w, task)
+}
+
+/* 
+	request type: GET
+	url: /lists/:list/tasks/duplicates?tags=false
+	Returns the groups of duplicate tasks of the list: tasks whose titles only differ by case
+	and spacing, with the same tags too (ignoring case and order) when tags=true. The groups
+	can be reviewed, then merged with POST /lists/:list/tasks/duplicates/merge
+
+	Examples:
+
+	   req: GET /lists/wronglist/tasks/duplicates
+	   res: 404 ToDo list not found
+
+	   req: GET /lists/oklist/tasks/duplicates
+	   res: 200 [{"Key":"buy milk","Tasks":[{"Title":"Buy milk",...},{"Title":"buy milk",...}]}]
+*/
+func GetDuplicateTasks(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	find := model.FindDuplicateTasks
+	if r.URL.Query().Get("tags") == "true" {
+		find = model.FindDuplicateTasksByTags
+	}
+	groups, err := find(key)
+	if err != nil {
+		taskOperationError(w, "GetDuplicateTasks", "duplicates", key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetDuplicateTasks:: found %d groups of duplicate tasks in ToDoList '%s'", len(groups), key))
+	writeJSON(w, groups)
+}
+
+/* 
+	request type: GET
+	url: /lists/:list/tasks/upcoming?days=7&tz=Europe/Rome
+	Returns the open tasks of the list due within the next days days (default 7, at most
+	366), today included, in the tz time zone (default UTC), sorted by due date. The
+	overdue and the deferred tasks are left out
+
+	Examples:
+
+	   req: GET /lists/oklist/tasks/upcoming?days=0
+	   res: 400 invalid days
+
+	   req: GET /lists/wronglist/tasks/upcoming
+	   res: 404 ToDo list not found
+
+	   req: GET /lists/oklist/tasks/upcoming?days=3
+	   res: 200 [{"ToDoList":"oklist","Title":"Pay rent","DueDate":"2024-06-02T00:00:00Z",...}]
+*/
+func GetUpcomingTasks(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	loc, err := requestLocation(r)
+	if err != nil {
+		taskInvalidParameterError(w, "GetUpcomingTasks", "tz", err)
+		return
+	}
+	days := 7
+	if value := r.URL.Query().Get("days"); value != "" {
+		if days, err = strconv.Atoi(value); err != nil || days < 1 || days > model.MaxUpcomingDays {
+			taskInvalidParameterError(w, "GetUpcomingTasks", "days", fmt.Errorf("expected 1 to %d days, got %s", model.MaxUpcomingDays, value))
+			return
+		}
+	}
+
+	tasks, err := model.UpcomingTasks(key, days, loc)
+	if err != nil {
+		taskOperationError(w, "GetUpcomingTasks", "upcoming", key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetUpcomingTasks:: %d tasks of ToDoList '%s' due in the next %d days", len(tasks), key, days))
+	writeJSON(w, tasks)
+}
+
+/* 
+	request type: POST
+	url: /lists/:list/tasks/duplicates/merge?tags=false {"Keys": ["buy milk"]}
+	Collapses each group of duplicate tasks, as returned by GET /lists/:list/tasks/duplicates
+	with the same tags parameter, into its first task, the others being removed. Only the
+	groups with the given Keys are merged, all of them without a body or Keys. The kept task
+	gets the tags, checklist items and metadata of the removed ones, their description and
+	location when it has none, the earliest due date and the highest priority; it stays done
+	only when all the duplicates are done
+
+	Examples:
+
+	   req: POST /lists/wronglist/tasks/duplicates/merge
+	   res: 404 ToDo list not found
+
+	   req: POST /lists/oklist/tasks/duplicates/merge {"Keys": ["buy milk"]}
+	   res: 200 [{"Key":"buy milk","Task":{"Title":"Buy milk",...},"Removed":["buy milk"]}]
+*/
+func MergeDuplicateTasks(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	req := struct{ Keys []string }{}
+	if err := decodeBody(r, &req); err != nil && err != io.EOF {
+		taskInvalidParameterError(w, "MergeDuplicateTasks", "Keys", err)
+		return
+	}
+
+	merges, err := model.MergeDuplicateTasks(key, req.Keys, r.URL.Query().Get("tags") == "true")
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "MergeDuplicateTasks", "duplicates", key, err)
+		return
+	}
+	if err != nil {
+		taskOperationError(w, "MergeDuplicateTasks", "duplicates", key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"MergeDuplicateTasks:: merged %d groups of duplicate tasks in ToDoList '%s'", len(merges), key))
+	writeJSON(w, merges)
+}
+
+/* 
+	request type: GET
+	url: /tasks/completed?on=2024-06-01&tz=Europe/Rome
+	Returns the tasks completed on the given day (default today) across all the lists,
+	with the number of completed tasks per list. The day boundaries follow the tz
+	parameter (default UTC)
+
+	Examples:
+
+	   req: GET /tasks/completed?on=01/06/2024
+	   res: 400 invalid day
+
+	   req: GET /tasks/completed?tz=Unknown/Zone
+	   res: 400 invalid time zone
+
+	   req: GET /tasks/completed?on=2024-06-01
+	   res: 200
+*/
+func GetCompletedTasks(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	loc, err := requestLocation(r)
+	if err != nil {
+		taskInvalidParameterError(w, "GetCompletedTasks", "tz", err)
+		return
+	}
+
+	day := time.Now().In(loc)
+	if on := r.URL.Query().Get("on"); on != "" {
+		if day, err = dateutils.Parse(on, requestDateMode(r), loc); err != nil {
+			taskInvalidParameterError(w, "GetCompletedTasks", "on", err)
+			return
+		}
+	}
+
+	completed, err := model.CompletedTasksOn(day, loc)
+	if err != nil {
+		taskOperationError(w, "GetCompletedTasks", "all", "all", err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetCompletedTasks:: retrieved %d tasks completed on %s", completed.Count, completed.Day))
+	writeJSON(w, completed)
+}
+
+/* 
+	request type: GET
+	url: /tasks/nearby?lat=41.9028&lon=12.4964&radius_m=500
+	Returns the pending tasks of all the lists located within radius_m meters (default 500,
+	max 100000) from the given point, the closest first, with their Distance in meters.
+	Tasks without coordinates are excluded
+
+	Examples:
+
+	   req: GET /tasks/nearby?lat=91&lon=0
+	   res: 400 invalid latitude
+
+	   req: GET /tasks/nearby?lat=41.9&lon=12.5&radius_m=1000000000
+	   res: 400 invalid radius
+
+	   req: GET /tasks/nearby?lat=41.9&lon=12.5
+	   res: 200
+*/
+func GetNearbyTasks(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	query := r.URL.Query()
+	lat, err := strconv.ParseFloat(query.Get("lat"), 64)
+	if err != nil {
+		taskInvalidParameterError(w, "GetNearbyTasks", "lat", err)
+		return
+	}
+	lon, err := strconv.ParseFloat(query.Get("lon"), 64)
+	if err != nil {
+		taskInvalidParameterError(w, "GetNearbyTasks", "lon", err)
+		return
+	}
+	radius := 500.0
+	if query.Get("radius_m") != "" {
+		if radius, err = strconv.ParseFloat(query.Get("radius_m"), 64); err != nil {
+			taskInvalidParameterError(w, "GetNearbyTasks", "radius_m", err)
+			return
+		}
+	}
+
+	nearby, err := model.NearbyTasks(lat, lon, radius)
+	if err != nil {
+		taskInvalidParameterError(w, "GetNearbyTasks", "lat, lon or radius_m", err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetNearbyTasks:: retrieved %d tasks within %.0f m from (%f, %f)", len(nearby), radius, lat, lon))
+	writeJSON(w, nearby)
+}
+
+/* 
+	request type: GET
+	url: /overdue?tz=Europe/Rome&offset=0&limit=50
+	Returns the incomplete tasks of all the lists whose due date has passed, the most
+	overdue first, grouped by list, with the number of calendar days elapsed since their
+	due day in the tz time zone (default UTC). Tasks are paginated with the offset and
+	limit parameters, Total being the number of overdue tasks
+
+	Examples:
+
+	   req: GET /overdue?tz=Unknown/Zone
+	   res: 400 invalid time zone
+
+	   req: GET /overdue?limit=0
+	   res: 400 invalid limit
+
+	   req: GET /overdue?limit=20
+	   res: 200 with the 20 most overdue tasks
+*/
+func GetOverdueTasks(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	loc, err := requestLocation(r)
+	if err != nil {
+		taskInvalidParameterError(w, "GetOverdueTasks", "tz", err)
+		return
+	}
+	offset, limit, err := requestPage(r, pageSizes.Search)
+	if err != nil {
+		taskInvalidParameterError(w, "GetOverdueTasks", "offset or limit", err)
+		return
+	}
+
+	overdue, err := model.AllOverdueTasks(loc)
+	if err != nil {
+		taskOperationError(w, "GetOverdueTasks", "all", "all", err)
+		return
+	}
+	start, end := pageBounds(len(overdue), offset, limit)
+
+	logutils.Info.Println(fmt.Sprintf(
+		"GetOverdueTasks:: retrieved %d overdue tasks", len(overdue)))
+	writeJSON(w, struct {
+		Lists []model.OverdueList
+		Total int
+	}{model.GroupOverdueTasks(overdue[start:end]), len(overdue)})
+}
+
+// requestDateMode returns the date parsing mode requested with the X-Date-Parsing
+// header (strict or lenient), the configured default otherwise
+func requestDateMode(r *http.Request) dateutils.Mode {
+	if mode, err := dateutils.ParseMode(r.Header.Get("X-Date-Parsing")); err == nil {
+		return mode
+	}
+	return dateutils.DefaultMode()
+}
+
+// requestCreatedRange parses the optional createdAfter and createdBefore
+// query parameters, zero when not received
+func requestCreatedRange(r *http.Request) (after, before time.Time, err error) {
+	loc, err := requestLocation(r)
+	if err != nil {
+		return after, before, err
+	}
+	query := r.URL.Query()
+	if value := query.Get("createdAfter"); value != "" {
+		if after, err = dateutils.Parse(value, requestDateMode(r), loc); err != nil {
+			return after, before, err
+		}
+	}
+	if value := query.Get("createdBefore"); value != "" {
+		if before, err = dateutils.Parse(value, requestDateMode(r), loc); err != nil {
+			return after, before, err
+		}
+	}
+	if !after.IsZero() && !before.IsZero() && !before.After(after) {
+		return after, before, fmt.Errorf("expected createdBefore after createdAfter")
+	}
+	return after, before, nil
+}
+
+// requestDate parses a date received in the request body, dates without a time
+// zone being interpreted in the time zone requested with the tz query parameter
+func requestDate(r *http.Request, raw *dateutils.Raw) (*time.Time, error) {
+	loc, err := requestLocation(r)
+	if err != nil {
+		return nil, err
+	}
+	return raw.Parse(requestDateMode(r), loc)
+}
+
+// requestLocation returns the time zone requested with the tz query parameter, UTC by default
+func requestLocation(r *http.Request) (*time.Location, error) {
+	tz := r.URL.Query().Get("tz")
+	if tz == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(tz)
+}
+
+func taskInvalidParameterError(w http.ResponseWriter, caller, name string, err error){
+	if deprecationError(w, TASK_BADREQUEST, caller, err) {
+		return
+	}
+	HandleError(w, http.StatusBadRequest, TASK_BADREQUEST, caller,
+		fmt.Sprintf("Invalid parameter %s", name),
+		fmt.Sprintf("Bad request received: %v", err))
+}
+
+// taskURL returns the canonical URL of the task
+func taskURL(list, title string) string {
+	return listURL(list) + "tasks/" + url.PathEscape(title)
+}
+
+// etagMatch tells whether the If-None-Match header matches the entity tag,
+// weak tags matching as well
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTask encodes the task along with the warnings raised while storing it
+func writeTask(w http.ResponseWriter, task *model.Task, warnings []string) {
+	writeJSON(w, struct {
+		*model.Task
+		Warnings []string `json:",omitempty"`
+	}{task, warnings})
+}
+
+func taskBadRequestError(w http.ResponseWriter, caller string, err error){
+	if deprecationError(w, TASK_BADREQUEST, caller, err) {
+		return
+	}
+	HandleError(w, http.StatusBadRequest, TASK_BADREQUEST, caller,
+		"Missing ToDo list name or task title",  
+		fmt.Sprintf("Bad request received: Missing mandatory parameters list or title. %v", err))
+}
+
+func taskOperationError(w http.ResponseWriter, caller, task, todolist string, err error){
+	status := http.StatusNotFound
+	if _, gone := err.(*model.GoneError); gone {
+		status = http.StatusGone
+	}
+	HandleError(w, status, TASK_OPERATION_ERROR, caller,
+		fmt.Sprintf("Error while performing operation on task = {%s}, ToDo list = {%s}", task, todolist),  
+		fmt.Sprintf("%v",err))
+}
+
+func taskUnprocessableError(w http.ResponseWriter, caller, task, todolist string, err error){
+	HandleError(w, http.StatusUnprocessableEntity, TASK_UNPROCESSABLE, caller,
+		fmt.Sprintf("Invalid attributes for task = {%s}, ToDo list = {%s}", task, todolist),
+		fmt.Sprintf("%v",err))
+}
@@ -0,0 +1,670 @@
+package controller
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+/*******************************
+	CREATE Task
+*******************************/
+func TestCreateTask_invalidFields_multipleErrors(t *testing.T) {
+	model.CreateToDoList("ControllerListValidation")
+	body := `{"Title": "", "Location": {"Label": "Pole", "Lat": 91, "Lon": -181}}`
+	req := httptest.NewRequest("POST", "/lists/ControllerListValidation/tasks", strings.NewReader(body))
+	res := httptest.NewRecorder()
+
+	CreateTask(res, req, httprouter.Params{{Key: "list", Value: "ControllerListValidation"}})
+
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", res.Code)
+	}
+	listErrors := ListError{}
+	if err := json.NewDecoder(res.Body).Decode(&listErrors); err != nil {
+		t.Fatalf("expected a JSON error list, got %v", err)
+	}
+	expected := []CustomError{
+		{Field: "Title", Rule: "required", Value: ""},
+		{Field: "Location.Lat", Rule: "max=90", Value: 91.0},
+		{Field: "Location.Lon", Rule: "min=-180", Value: -181.0},
+	}
+	if len(listErrors.Errors) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), listErrors.Errors)
+	}
+	for i, e := range expected {
+		got := listErrors.Errors[i]
+		if got.Code != TASK_BADREQUEST || got.Field != e.Field || got.Rule != e.Rule || got.Value != e.Value {
+			t.Errorf("expected %+v, got %+v", e, got)
+		}
+	}
+}
+
+func TestCreateTask_lenientDueDate_ok(t *testing.T) {
+	model.CreateToDoList("ControllerListDates")
+	body := `{"Title": "Epoch", "DueDate": 1717264800}`
+	req := httptest.NewRequest("POST", "/lists/ControllerListDates/tasks", strings.NewReader(body))
+	req.Header.Set("X-Date-Parsing", "lenient")
+	res := httptest.NewRecorder()
+
+	CreateTask(res, req, httprouter.Params{{Key: "list", Value: "ControllerListDates"}})
+
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", res.Code, res.Body.String())
+	}
+	if !strings.Contains(res.Body.String(), `"DueDate":"2024-06-01T18:00:00Z"`) {
+		t.Errorf("expected the canonical due date in the response, got %s", res.Body.String())
+	}
+}
+
+func TestCreateTask_ambiguousDueDate_error(t *testing.T) {
+	body := `{"Title": "Ambiguous", "DueDate": "01/06/2024"}`
+	req := httptest.NewRequest("POST", "/lists/ControllerListDates/tasks", strings.NewReader(body))
+	req.Header.Set("X-Date-Parsing", "lenient")
+	res := httptest.NewRecorder()
+
+	CreateTask(res, req, httprouter.Params{{Key: "list", Value: "ControllerListDates"}})
+
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", res.Code)
+	}
+	if !strings.Contains(res.Body.String(), "ambiguous") {
+		t.Errorf("expected the ambiguity explained, got %s", res.Body.String())
+	}
+}
+
+func TestCreateTask_ifNotExists(t *testing.T) {
+	model.CreateToDoList("ControllerListIfNotExists")
+	model.AddTask("ControllerListIfNotExists", "Buy Milk")
+	params := httprouter.Params{{Key: "list", Value: "ControllerListIfNotExists"}}
+
+	res := httptest.NewRecorder()
+	CreateTask(res, httptest.NewRequest("POST", "/lists/ControllerListIfNotExists/tasks?ifNotExists=true",
+		strings.NewReader(`{"Title": "buy  milk"}`)), params)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"Title":"Buy Milk"`) {
+		t.Errorf("expected the existing task with its original title, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	CreateTask(res, httptest.NewRequest("POST", "/lists/ControllerListIfNotExists/tasks?ifNotExists=true",
+		strings.NewReader(`{"Title": "Buy Bread"}`)), params)
+	if res.Code != http.StatusCreated {
+		t.Errorf("expected the missing task created, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestCompareAndSetTaskDone_conflict(t *testing.T) {
+	model.CreateToDoList("ControllerListCAS")
+	model.AddTask("ControllerListCAS", "Task1")
+	params := httprouter.Params{{Key: "list", Value: "ControllerListCAS"}, {Key: "task", Value: "Task1"}}
+
+	req := httptest.NewRequest("POST", "/lists/ControllerListCAS/tasks/Task1/done", strings.NewReader(`{"Expected": false, "Done": true}`))
+	res := httptest.NewRecorder()
+	CompareAndSetTaskDone(res, req, params)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if !strings.Contains(res.Body.String(), `"Done":true`) {
+		t.Errorf("expected the completed task in the response, got %s", res.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/lists/ControllerListCAS/tasks/Task1/done", strings.NewReader(`{"Expected": false, "Done": true}`))
+	res = httptest.NewRecorder()
+	CompareAndSetTaskDone(res, req, params)
+	if res.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", res.Code)
+	}
+}
+
+func TestGetTasks_defaultPageSize_ok(t *testing.T) {
+	defer SetPageSizes(CurrentPageSizes())
+	SetPageSizes(PageSizes{Tasks: 2})
+	model.CreateToDoList("ControllerListPages")
+	for _, title := range []string{"Task1", "Task2", "Task3"} {
+		model.AddTask("ControllerListPages", title)
+	}
+	params := httprouter.Params{{Key: "list", Value: "ControllerListPages"}}
+
+	// a huge limit must not overflow the bounds of the page
+	huge := "?offset=1&limit=" + strconv.Itoa(math.MaxInt)
+	expected := map[string]int{"": 2, "?limit=3": 3, "?offset=2": 1, huge: 2}
+	for query, count := range expected {
+		req := httptest.NewRequest("GET", "/lists/ControllerListPages/tasks/"+query, nil)
+		res := httptest.NewRecorder()
+		GetTasks(res, req, params)
+
+		tasks := []model.Task{}
+		if err := json.NewDecoder(res.Body).Decode(&tasks); err != nil || len(tasks) != count {
+			t.Errorf("expected %d tasks for %q, got %d (%v)", count, query, len(tasks), err)
+		}
+	}
+}
+
+func TestGetTasks_maxUnpaginated_truncated(t *testing.T) {
+	defer SetMaxUnpaginatedTasks(MaxUnpaginatedTasks())
+	SetMaxUnpaginatedTasks(2)
+	model.CreateToDoListWithTasks("ControllerListTruncated", []string{"Task1", "Task2", "Task3"})
+	params := httprouter.Params{{Key: "list", Value: "ControllerListTruncated"}}
+
+	res := httptest.NewRecorder()
+	GetTasks(res, httptest.NewRequest("GET", "/lists/ControllerListTruncated/tasks/?sort=dueDate", nil), params)
+	tasks := []model.Task{}
+	if err := json.NewDecoder(res.Body).Decode(&tasks); err != nil || len(tasks) != 2 {
+		t.Errorf("expected the first 2 tasks, got %d (%v)", len(tasks), err)
+	}
+	if res.Header().Get("X-Truncated") != "true" || res.Header().Get("X-Total-Count") != "3" ||
+		res.Header().Get("Link") != `</lists/ControllerListTruncated/tasks/?limit=2&offset=2&sort=dueDate>; rel="next"` {
+		t.Errorf("expected the truncation headers, got %v", res.Header())
+	}
+
+	for _, query := range []string{"?limit=3", "?offset=0"} {
+		res = httptest.NewRecorder()
+		GetTasks(res, httptest.NewRequest("GET", "/lists/ControllerListTruncated/tasks/"+query, nil), params)
+		tasks = []model.Task{}
+		if err := json.NewDecoder(res.Body).Decode(&tasks); err != nil || len(tasks) != 3 || res.Header().Get("X-Truncated") != "" {
+			t.Errorf("expected the 3 tasks without truncation for %q, got %d (%v)", query, len(tasks), err)
+		}
+	}
+}
+
+func TestGetTasks_displayNumber_ok(t *testing.T) {
+	model.CreateToDoList("ControllerListNumbers")
+	model.AddTaskWithDetails("ControllerListNumbers", "Later", dueIn(48))
+	model.AddTask("ControllerListNumbers", "NoDue")
+	model.AddTaskWithDetails("ControllerListNumbers", "Sooner", dueIn(24))
+	params := httprouter.Params{{Key: "list", Value: "ControllerListNumbers"}}
+
+	req := httptest.NewRequest("GET", "/lists/ControllerListNumbers/tasks/?sort=dueDate&offset=1&limit=2", nil)
+	res := httptest.NewRecorder()
+	GetTasks(res, req, params)
+
+	tasks := []model.NumberedTask{}
+	if err := json.NewDecoder(res.Body).Decode(&tasks); err != nil || len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d (%v)", len(tasks), err)
+	}
+	if tasks[0].Title != "Later" || tasks[0].DisplayNumber != 2 || tasks[1].Title != "NoDue" || tasks[1].DisplayNumber != 3 {
+		t.Errorf("expected Later as 2 and NoDue as 3, got %s as %d and %s as %d",
+			tasks[0].Title, tasks[0].DisplayNumber, tasks[1].Title, tasks[1].DisplayNumber)
+	}
+}
+
+func TestGetTasks_waiting_ok(t *testing.T) {
+	model.CreateToDoList("ControllerListWaiting")
+	model.AddTaskWithDetails("ControllerListWaiting", "Contract", model.TaskDetails{WaitingOn: "Bob's reply"})
+	model.AddTask("ControllerListWaiting", "Draft")
+	params := httprouter.Params{{Key: "list", Value: "ControllerListWaiting"}}
+
+	expected := map[string]string{"?waiting=true": "Contract", "?waiting=false": "Draft"}
+	for query, title := range expected {
+		res := httptest.NewRecorder()
+		GetTasks(res, httptest.NewRequest("GET", "/lists/ControllerListWaiting/tasks/"+query, nil), params)
+
+		tasks := []model.Task{}
+		if err := json.NewDecoder(res.Body).Decode(&tasks); err != nil || len(tasks) != 1 || tasks[0].Title != title {
+			t.Errorf("expected %s for %q, got %v (%v)", title, query, tasks, err)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	GetTasks(res, httptest.NewRequest("GET", "/lists/ControllerListWaiting/tasks/?waiting=maybe", nil), params)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", res.Code)
+	}
+}
+
+func TestGetTasks_createdRange_ok(t *testing.T) {
+	model.CreateToDoList("ControllerListCreated")
+	model.AddTask("ControllerListCreated", "Report")
+	params := httprouter.Params{{Key: "list", Value: "ControllerListCreated"}}
+
+	expected := map[string]int{"?createdAfter=2000-01-01": 1, "?createdBefore=2000-01-01": 0,
+		"?createdAfter=2000-01-01T00:00:00Z&createdBefore=2100-01-01&waiting=false": 1}
+	for query, count := range expected {
+		res := httptest.NewRecorder()
+		GetTasks(res, httptest.NewRequest("GET", "/lists/ControllerListCreated/tasks/"+query, nil), params)
+
+		tasks := []model.Task{}
+		if err := json.NewDecoder(res.Body).Decode(&tasks); err != nil || len(tasks) != count {
+			t.Errorf("expected %d tasks for %q, got %v (%v)", count, query, tasks, err)
+		}
+	}
+
+	for _, query := range []string{"?createdAfter=yesterday", "?createdAfter=2024-06-10&createdBefore=2024-06-03"} {
+		res := httptest.NewRecorder()
+		GetTasks(res, httptest.NewRequest("GET", "/lists/ControllerListCreated/tasks/"+query, nil), params)
+		if res.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %q, got %d", query, res.Code)
+		}
+	}
+}
+
+func TestSetTaskDeferred(t *testing.T) {
+	model.CreateToDoListWithTasks("ControllerListDeferred", []string{"Someday", "Today"})
+	params := httprouter.Params{{Key: "list", Value: "ControllerListDeferred"}, {Key: "task", Value: "Someday"}}
+
+	res := httptest.NewRecorder()
+	SetTaskDeferred(res, httptest.NewRequest("PUT", "/lists/ControllerListDeferred/tasks/Someday/deferred", strings.NewReader(`{"Deferred": true}`)), params)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"Deferred":true`) {
+		t.Fatalf("expected the task deferred, got %d %s", res.Code, res.Body.String())
+	}
+
+	expected := map[string]string{"": "Today", "?deferred=true": "Someday"}
+	for query, title := range expected {
+		res = httptest.NewRecorder()
+		GetTasks(res, httptest.NewRequest("GET", "/lists/ControllerListDeferred/tasks/"+query, nil), params[:1])
+		tasks := []model.Task{}
+		if err := json.NewDecoder(res.Body).Decode(&tasks); err != nil || len(tasks) != 1 || tasks[0].Title != title {
+			t.Errorf("expected %s for %q, got %v (%v)", title, query, tasks, err)
+		}
+	}
+	res = httptest.NewRecorder()
+	GetTasks(res, httptest.NewRequest("GET", "/lists/ControllerListDeferred/tasks/?deferred=all", nil), params[:1])
+	if tasks := []model.Task{}; json.NewDecoder(res.Body).Decode(&tasks) != nil || len(tasks) != 2 {
+		t.Errorf("expected both tasks with deferred=all, got %v", tasks)
+	}
+
+	model.UpdateTask("ControllerListDeferred", "Today", "Today", true)
+	bodies := map[string]int{`{}`: http.StatusBadRequest, `{"Deferred": true}`: http.StatusUnprocessableEntity}
+	for body, code := range bodies {
+		res = httptest.NewRecorder()
+		SetTaskDeferred(res, httptest.NewRequest("PUT", "/lists/ControllerListDeferred/tasks/Today/deferred", strings.NewReader(body)),
+			httprouter.Params{params[0], {Key: "task", Value: "Today"}})
+		if res.Code != code {
+			t.Errorf("expected status %d for %s, got %d", code, body, res.Code)
+		}
+	}
+}
+
+func TestLogTaskTime(t *testing.T) {
+	model.CreateToDoListWithTasks("ControllerListTimeLog", []string{"Report", "Review"})
+	params := httprouter.Params{{Key: "list", Value: "ControllerListTimeLog"}, {Key: "task", Value: "Report"}}
+
+	bodies := map[string]int{`{}`: http.StatusBadRequest, `{"Minutes": 0}`: http.StatusUnprocessableEntity, `{"minutes": 30}`: http.StatusOK}
+	for body, code := range bodies {
+		res := httptest.NewRecorder()
+		LogTaskTime(res, httptest.NewRequest("POST", "/lists/ControllerListTimeLog/tasks/Report/timelog", strings.NewReader(body)), params)
+		if res.Code != code {
+			t.Errorf("expected status %d for %s, got %d", code, body, res.Code)
+		}
+	}
+	res := httptest.NewRecorder()
+	LogTaskTime(res, httptest.NewRequest("POST", "/lists/ControllerListTimeLog/tasks/Report/timelog", strings.NewReader(`{"Minutes": 45}`)), params)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"TimeSpent":75`) {
+		t.Errorf("expected 75 minutes spent, got %d %s", res.Code, res.Body.String())
+	}
+	res = httptest.NewRecorder()
+	LogTaskTime(res, httptest.NewRequest("POST", "/lists/ControllerListTimeLog/tasks/invalid/timelog", strings.NewReader(`{"Minutes": 45}`)),
+		httprouter.Params{params[0], {Key: "task", Value: "invalid"}})
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 with a missing task, got %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	GetListTimeSpent(res, httptest.NewRequest("GET", "/lists/ControllerListTimeLog/timespent", nil), params[:1])
+	if res.Code != http.StatusOK || strings.TrimSpace(res.Body.String()) != `{"List":"ControllerListTimeLog","TimeSpent":75}` {
+		t.Errorf("expected 75 minutes spent on the list, got %d %s", res.Code, res.Body.String())
+	}
+	res = httptest.NewRecorder()
+	GetListTimeSpent(res, httptest.NewRequest("GET", "/lists/invalid/timespent", nil), httprouter.Params{{Key: "list", Value: "invalid"}})
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 with a missing list, got %d", res.Code)
+	}
+}
+
+func TestGetTaskHistory(t *testing.T) {
+	model.CreateToDoList("ControllerListHistory")
+	model.AddTask("ControllerListHistory", "Report")
+	model.UpdateTask("ControllerListHistory", "Report", "Report", true)
+
+	res := httptest.NewRecorder()
+	GetTaskHistory(res, httptest.NewRequest("GET", "/lists/ControllerListHistory/tasks/Report/history", nil),
+		httprouter.Params{{Key: "list", Value: "ControllerListHistory"}, {Key: "task", Value: "Report"}})
+	body := struct{ Changes []model.TaskChange }{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil || len(body.Changes) != 2 || body.Changes[1].Type != "task.completed" {
+		t.Errorf("expected the creation and completion of the task, got %+v (%v)", body.Changes, err)
+	}
+
+	res = httptest.NewRecorder()
+	GetTaskHistory(res, httptest.NewRequest("GET", "/lists/ControllerListHistory/tasks/Missing/history", nil),
+		httprouter.Params{{Key: "list", Value: "ControllerListHistory"}, {Key: "task", Value: "Missing"}})
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a missing task, got %d", res.Code)
+	}
+}
+
+func TestSetTasksPriority_multiStatus(t *testing.T) {
+	model.CreateToDoList("ControllerListPriority")
+	model.AddTask("ControllerListPriority", "Triage")
+	params := httprouter.Params{{Key: "list", Value: "ControllerListPriority"}, {Key: "task", Value: "priority"}}
+
+	res := httptest.NewRecorder()
+	SetTasksPriority(res, httptest.NewRequest("PATCH", "/lists/ControllerListPriority/tasks/priority",
+		strings.NewReader(`{"ids": ["Triage", "Missing"], "priority": 1}`)), params)
+
+	status := MultiStatus{}
+	if err := json.NewDecoder(res.Body).Decode(&status); err != nil || res.Code != http.StatusMultiStatus ||
+		status.Succeeded != 1 || status.Items[1].Status != http.StatusNotFound {
+		t.Errorf("expected 207 with Missing not found, got %d %+v (%v)", res.Code, status, err)
+	}
+	if task, _ := model.GetTask("ControllerListPriority", "Triage"); task.Priority != model.PriorityUrgent {
+		t.Errorf("expected the priority set, got %d", task.Priority)
+	}
+
+	res = httptest.NewRecorder()
+	SetTasksPriority(res, httptest.NewRequest("PATCH", "/lists/ControllerListPriority/tasks/priority",
+		strings.NewReader(`{"ids": ["Triage"], "priority": 7}`)), params)
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 with an invalid priority, got %d", res.Code)
+	}
+}
+
+func TestPatchTaskProgress(t *testing.T) {
+	model.CreateToDoList("ControllerListProgress")
+	model.AddTask("ControllerListProgress", "Draft")
+	params := httprouter.Params{{Key: "list", Value: "ControllerListProgress"}, {Key: "task", Value: "Draft"}}
+
+	res := httptest.NewRecorder()
+	PatchTaskProgress(res, httptest.NewRequest("PATCH", "/lists/ControllerListProgress/tasks/Draft/progress",
+		strings.NewReader(`{"Progress": 100}`)), params)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"Done":true,"Progress":100`) {
+		t.Errorf("expected the task completed, got %d %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	PatchTaskProgress(res, httptest.NewRequest("PATCH", "/lists/ControllerListProgress/tasks/Draft/progress",
+		strings.NewReader(`{"Progress": 120}`)), params)
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 with an invalid progress, got %d", res.Code)
+	}
+}
+
+// dueIn returns task details due in the given number of hours
+func dueIn(hours int) model.TaskDetails {
+	due := time.Now().Add(time.Duration(hours) * time.Hour)
+	return model.TaskDetails{DueDate: &due}
+}
+
+func TestGetUpcomingTasks(t *testing.T) {
+	model.CreateToDoListWithTasks("ControllerListUpcoming", []string{"Soon", "Later"})
+	model.SetTaskDetails("ControllerListUpcoming", "Soon", dueIn(30))
+	model.SetTaskDetails("ControllerListUpcoming", "Later", dueIn(24*20))
+	params := httprouter.Params{{Key: "list", Value: "ControllerListUpcoming"}, {Key: "task", Value: "upcoming"}}
+
+	res := httptest.NewRecorder()
+	GetUpcomingTasks(res, httptest.NewRequest("GET", "/lists/ControllerListUpcoming/tasks/upcoming", nil), params)
+	tasks := []model.Task{}
+	if err := json.NewDecoder(res.Body).Decode(&tasks); err != nil || len(tasks) != 1 || tasks[0].Title != "Soon" {
+		t.Errorf("expected Soon upcoming within 7 days, got %v (%v)", tasks, err)
+	}
+	res = httptest.NewRecorder()
+	GetUpcomingTasks(res, httptest.NewRequest("GET", "/lists/ControllerListUpcoming/tasks/upcoming?days=30", nil), params)
+	if tasks = []model.Task{}; json.NewDecoder(res.Body).Decode(&tasks) != nil || len(tasks) != 2 {
+		t.Errorf("expected both tasks upcoming within 30 days, got %v", tasks)
+	}
+
+	for _, query := range []string{"?days=0", "?days=x", "?tz=Unknown/Zone"} {
+		res = httptest.NewRecorder()
+		GetUpcomingTasks(res, httptest.NewRequest("GET", "/lists/ControllerListUpcoming/tasks/upcoming"+query, nil), params)
+		if res.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %q, got %d", query, res.Code)
+		}
+	}
+	res = httptest.NewRecorder()
+	GetUpcomingTasks(res, httptest.NewRequest("GET", "/lists/invalid/tasks/upcoming", nil), httprouter.Params{{Key: "list", Value: "invalid"}})
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 with a missing list, got %d", res.Code)
+	}
+}
+
+func TestCreateTask_location_ok(t *testing.T) {
+	model.CreateToDoList("Controller List/Created")
+	req := httptest.NewRequest("POST", "/lists/Controller%20List%2FCreated/tasks", strings.NewReader(`{"Title": "New task?"}`))
+	res := httptest.NewRecorder()
+
+	CreateTask(res, req, httprouter.Params{{Key: "list", Value: "Controller List/Created"}})
+
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", res.Code, res.Body.String())
+	}
+	if location := res.Header().Get("Location"); location != "/lists/Controller%20List%2FCreated/tasks/New%20task%3F" {
+		t.Errorf("expected the escaped task URL, got %s", location)
+	}
+	if !strings.Contains(res.Body.String(), `"Title":"New task?"`) {
+		t.Errorf("expected the created task in the response, got %s", res.Body.String())
+	}
+}
+
+func TestGetTask_humanize_ok(t *testing.T) {
+	model.CreateToDoList("ControllerListHumanize")
+	model.AddTaskWithDetails("ControllerListHumanize", "Report", dueIn(72))
+	params := httprouter.Params{{Key: "list", Value: "ControllerListHumanize"}, {Key: "task", Value: "Report"}}
+
+	res := httptest.NewRecorder()
+	GetTask(res, httptest.NewRequest("GET", "/lists/ControllerListHumanize/tasks/Report?humanize=true", nil), params)
+	task := struct {
+		DueDate *time.Time
+		RelativeTimes
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&task); err != nil || task.DueDate == nil {
+		t.Fatalf("expected the task with its due date, got %v", err)
+	}
+	if task.DueIn != "in 3 days" || task.CreatedAgo != "just now" || task.CompletedAgo != "" {
+		t.Errorf("expected due in 3 days and created just now, got %+v", task.RelativeTimes)
+	}
+	if res.Header().Get("ETag") != "" {
+		t.Errorf("expected no ETag with humanize, got %s", res.Header().Get("ETag"))
+	}
+
+	res = httptest.NewRecorder()
+	GetTasks(res, httptest.NewRequest("GET", "/lists/ControllerListHumanize/tasks/?humanize=true", nil), params[:1])
+	tasks := []RelativeTimes{}
+	if err := json.NewDecoder(res.Body).Decode(&tasks); err != nil || len(tasks) != 1 || tasks[0].DueIn != "in 3 days" {
+		t.Errorf("expected the listed task due in 3 days, got %v (%v)", tasks, err)
+	}
+
+	res = httptest.NewRecorder()
+	GetTask(res, httptest.NewRequest("GET", "/lists/ControllerListHumanize/tasks/Report?humanize=yes", nil), params)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", res.Code)
+	}
+}
+
+func TestGetTask_deleted_gone(t *testing.T) {
+	model.CreateToDoListWithTasks("ControllerListGone", []string{"Deleted"})
+	model.RemoveTask("ControllerListGone", "Deleted")
+
+	expected := map[string]int{"Deleted": http.StatusGone, "Never": http.StatusNotFound}
+	for title, code := range expected {
+		res := httptest.NewRecorder()
+		GetTask(res, httptest.NewRequest("GET", "/lists/ControllerListGone/tasks/"+title, nil),
+			httprouter.Params{{Key: "list", Value: "ControllerListGone"}, {Key: "task", Value: title}})
+		if res.Code != code {
+			t.Errorf("expected status %d for %s, got %d", code, title, res.Code)
+		}
+		res = httptest.NewRecorder()
+		UpdateTask(res, httptest.NewRequest("PUT", "/lists/ControllerListGone/tasks/"+title, strings.NewReader(`{"Done": true}`)),
+			httprouter.Params{{Key: "list", Value: "ControllerListGone"}, {Key: "task", Value: title}})
+		if res.Code != code {
+			t.Errorf("expected status %d updating %s, got %d", code, title, res.Code)
+		}
+	}
+
+	model.DeleteToDoList("ControllerListGone")
+	res := httptest.NewRecorder()
+	GetToDoList(res, httptest.NewRequest("GET", "/lists/ControllerListGone/", nil), httprouter.Params{{Key: "list", Value: "ControllerListGone"}})
+	if res.Code != http.StatusGone {
+		t.Errorf("expected status 410 for the deleted list, got %d", res.Code)
+	}
+}
+
+func TestGetTask_ifNoneMatch(t *testing.T) {
+	model.CreateToDoList("ControllerListETag")
+	model.AddTask("ControllerListETag", "Task1")
+	params := httprouter.Params{{Key: "list", Value: "ControllerListETag"}, {Key: "task", Value: "Task1"}}
+
+	res := httptest.NewRecorder()
+	GetTask(res, httptest.NewRequest("GET", "/lists/ControllerListETag/tasks/Task1", nil), params)
+	etag := res.Header().Get("ETag")
+	if res.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected status 200 with an ETag, got %d %q", res.Code, etag)
+	}
+
+	expected := map[string]int{
+		etag:                 http.StatusNotModified,
+		`"other", W/` + etag: http.StatusNotModified,
+		`"other"`:            http.StatusOK,
+	}
+	for ifNoneMatch, code := range expected {
+		req := httptest.NewRequest("GET", "/lists/ControllerListETag/tasks/Task1", nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		res = httptest.NewRecorder()
+		GetTask(res, req, params)
+		if res.Code != code {
+			t.Errorf("expected status %d for If-None-Match %s, got %d", code, ifNoneMatch, res.Code)
+		}
+		if code == http.StatusNotModified && res.Body.Len() != 0 {
+			t.Errorf("expected no body with 304, got %s", res.Body.String())
+		}
+	}
+
+	model.UpdateTask("ControllerListETag", "Task1", "Task1", true)
+	req := httptest.NewRequest("GET", "/lists/ControllerListETag/tasks/Task1", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	GetTask(res, req, params)
+	if res.Code != http.StatusOK {
+		t.Errorf("expected status 200 once the task changed, got %d", res.Code)
+	}
+}
+
+func TestPatchTask_jsonPatch(t *testing.T) {
+	model.CreateToDoList("ControllerListJSONPatch")
+	model.AddTaskWithDetails("ControllerListJSONPatch", "Task1", model.TaskDetails{Tags: []string{"home"}})
+	params := httprouter.Params{{Key: "list", Value: "ControllerListJSONPatch"}, {Key: "task", Value: "Task1"}}
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/lists/ControllerListJSONPatch/tasks/Task1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json-patch+json")
+		res := httptest.NewRecorder()
+		PatchTask(res, req, params)
+		return res
+	}
+
+	expected := map[string]int{
+		`[{"op": "replace", "path": "/CreatedAt", "value": "2024-01-01T00:00:00Z"}]`:                          http.StatusConflict,
+		`[{"op": "add", "path": "/Tags/-", "value": "work"}, {"op": "test", "path": "/Done", "value": true}]`: http.StatusConflict,
+		`[{"op": "remove", "path": "/Description"}]`:                                                           http.StatusUnprocessableEntity,
+		`[{"op": "add", "path": "/Priority", "value": 9}]`:                                                     http.StatusUnprocessableEntity,
+		`[{"op": "add", "path": "/Unknown", "value": 1}]`:                                                      http.StatusUnprocessableEntity,
+	}
+	for body, code := range expected {
+		if res := patch(body); res.Code != code {
+			t.Errorf("expected status %d for %s, got %d: %s", code, body, res.Code, res.Body.String())
+		}
+	}
+
+	res := patch(`[{"op": "test", "path": "/Tags/0", "value": "home"}, {"op": "add", "path": "/Tags/0", "value": "work"}, {"op": "replace", "path": "/Done", "value": true}]`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	task, _ := model.GetTask("ControllerListJSONPatch", "Task1")
+	if len(task.Tags) != 2 || task.Tags[0] != "work" || !task.Done || task.CompletedAt == nil {
+		t.Errorf("expected the patch applied, got %+v", task)
+	}
+}
+
+func TestTaskRef_shortReference_ok(t *testing.T) {
+	model.CreateToDoList("ControllerListRefs")
+	model.AddTask("ControllerListRefs", "Task1")
+	model.AddTask("ControllerListRefs", "Task2")
+	params := httprouter.Params{{Key: "list", Value: "ControllerListRefs"}, {Key: "task", Value: "task-2"}}
+	res := httptest.NewRecorder()
+
+	TaskRef(GetTask)(res, httptest.NewRequest("GET", "/lists/ControllerListRefs/tasks/task-2", nil), params)
+
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"Title":"Task2"`) {
+		t.Errorf("expected Task2, got %d: %s", res.Code, res.Body.String())
+	}
+	if params[1].Value != "task-2" {
+		t.Errorf("expected the route params untouched, got %v", params)
+	}
+}
+
+func TestDuplicateTasks_mergeConfirmed(t *testing.T) {
+	model.CreateToDoList("ControllerListDuplicates")
+	model.AddTask("ControllerListDuplicates", "Buy milk")
+	model.AddTask("ControllerListDuplicates", "buy  milk")
+	model.AddTask("ControllerListDuplicates", "Call Bob")
+	model.AddTask("ControllerListDuplicates", "call bob")
+	params := httprouter.Params{{Key: "list", Value: "ControllerListDuplicates"}}
+
+	res := httptest.NewRecorder()
+	GetDuplicateTasks(res, httptest.NewRequest("GET", "/lists/ControllerListDuplicates/tasks/duplicates", nil), params)
+	groups := []model.DuplicateGroup{}
+	if err := json.NewDecoder(res.Body).Decode(&groups); err != nil || len(groups) != 2 {
+		t.Fatalf("expected two groups, got %v, %v", groups, err)
+	}
+
+	res = httptest.NewRecorder()
+	body := strings.NewReader(`{"Keys": ["` + groups[0].Key + `"]}`)
+	MergeDuplicateTasks(res, httptest.NewRequest("POST", "/lists/ControllerListDuplicates/tasks/duplicates/merge", body), params)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"Removed":["buy  milk"]`) {
+		t.Fatalf("expected the milk tasks merged, got %d: %s", res.Code, res.Body.String())
+	}
+	if list, _ := model.GetToDoList("ControllerListDuplicates"); list.TaskNumber != 3 {
+		t.Errorf("expected the other group left, got %d tasks", list.TaskNumber)
+	}
+
+	res = httptest.NewRecorder()
+	MergeDuplicateTasks(res, httptest.NewRequest("POST", "/lists/wronglist/tasks/duplicates/merge", nil),
+		httprouter.Params{{Key: "list", Value: "wronglist"}})
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", res.Code)
+	}
+}
+
+func TestCreateTask_vetoedByHook(t *testing.T) {
+	model.CreateToDoList("ControllerListHooks")
+	defer model.RegisterHook(model.EventTaskCreated, model.RequiredTagRule("ControllerListHooks", "ticket:"))()
+
+	res := httptest.NewRecorder()
+	CreateTask(res, httptest.NewRequest("POST", "/lists/ControllerListHooks/tasks", strings.NewReader(`{"Title": "Untracked"}`)),
+		httprouter.Params{{Key: "list", Value: "ControllerListHooks"}})
+	if res.Code != http.StatusUnprocessableEntity || !strings.Contains(res.Body.String(), "rejected by hook") {
+		t.Errorf("expected status 422 with the hook message, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestUpdateTask_replacesDetails(t *testing.T) {
+	model.CreateToDoListWithTasks("ControllerListReplace", []string{"Task1"})
+	model.SetTaskDetails("ControllerListReplace", "Task1", model.TaskDetails{Description: "kept", Tags: []string{"home"}})
+	params := httprouter.Params{{Key: "list", Value: "ControllerListReplace"}, {Key: "task", Value: "Task1"}}
+
+	res := httptest.NewRecorder()
+	UpdateTask(res, httptest.NewRequest("PUT", "/lists/ControllerListReplace/tasks/Task1",
+		strings.NewReader(`{"Title": "Renamed", "Done": true, "ReminderLead": "forever"}`)), params)
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d: %s", res.Code, res.Body.String())
+	}
+	if task, _ := model.GetTask("ControllerListReplace", "Task1"); task == nil || task.Done || task.Description != "kept" {
+		t.Errorf("expected the task untouched, got %+v", task)
+	}
+
+	res = httptest.NewRecorder()
+	UpdateTask(res, httptest.NewRequest("PUT", "/lists/ControllerListReplace/tasks/Task1",
+		strings.NewReader(`{"Done": true, "Priority": 2}`)), params)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	task, _ := model.GetTask("ControllerListReplace", "Task1")
+	if !task.Done || task.Priority != model.PriorityHigh || task.Description != "" || task.Tags != nil {
+		t.Errorf("expected the unsent details removed, got %+v", task)
+	}
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+/*
+	TaskRef lets the task routes address a task by its short reference within the
+	list, task-<Number>, as well as by its title: the task parameter is replaced by the
+	title of the referenced task before calling handle. A task titled like a short
+	reference is addressed by its title.
+
+	Examples:
+
+	   req: GET /lists/oklist/tasks/task-7
+	   res: the response of GET /lists/oklist/tasks/<title of task 7>
+
+	   req: GET /lists/oklist/tasks/task-99
+	   res: 404 Task not found
+*/
+func TaskRef(handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+		ref := param.ByName("task")
+		title := model.ResolveTaskTitle(param.ByName("list"), ref)
+		if title == ref {
+			handle(w, r, param)
+			return
+		}
+		resolved := make(httprouter.Params, len(param))
+		for i, p := range param {
+			if p.Key == "task" {
+				p.Value = title
+			}
+			resolved[i] = p
+		}
+		handle(w, r, resolved)
+	}
+}
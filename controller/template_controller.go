@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/efreddo/v1/todolist/dateutils"
+	"github.com/efreddo/v1/todolist/logutils"
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/efreddo/v1/todolist/validation"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	TEMPLATE_BADREQUEST = 60;
+	TEMPLATE_OPERATION_ERROR = 61;
+	TEMPLATE_UNPROCESSABLE = 62;
+	TEMPLATE_CONFLICT = 63;
+)
+
+/*
+	request type: POST
+	url: /tasktemplates/ {"Name": "rent", "Title": "Pay rent {month}", "Priority": 2, "Tags": ["finance"],
+	                      "DueRule": "first of next month"}
+	Creates a task template: a name, a title pattern, the default attributes of the task (as
+	for task creation, DueDate excepted) and an optional DueRule relative to the instantiation
+	day: today, tomorrow, in N days|weeks|months, next <weekday> or first|last of this|next month.
+	The {date}, {month} and {year} placeholders of the title are replaced at instantiation
+
+	Examples:
+
+	   req: POST /tasktemplates/ {"Name": "rent"}
+	   res: 422 missing title
+
+	   req: POST /tasktemplates/ {"Name": "rent", "Title": "Pay rent", "DueRule": "someday"}
+	   res: 422 invalid relative date
+
+	   req: POST /tasktemplates/ {"Name": "existingtemplate", "Title": "Pay rent"}
+	   res: 409 task template already present
+
+	   req: POST /tasktemplates/ {"Name": "rent", "Title": "Pay rent"}
+	   res: 201 Location: /tasktemplates/rent
+*/
+func CreateTaskTemplate(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	req := model.TaskTemplate{}
+	if !decodeTaskTemplate(w, r, "CreateTaskTemplate", &req) {
+		return
+	}
+	tmpl, err := model.CreateTaskTemplate(req)
+	if err != nil {
+		templateError(w, "CreateTaskTemplate", req.Name, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf("CreateTaskTemplate:: new task template '%s'", tmpl.Name))
+	writeCreated(w, templateURL(tmpl.Name))
+	writeJSON(w, tmpl)
+}
+
+/*
+	request type: GET
+	url: /tasktemplates/
+	Returns the task templates sorted by name
+
+	Examples:
+
+	   req: GET /tasktemplates/
+	   res: 200 [{"Name":"rent","Title":"Pay rent {month}","Priority":2,"DueRule":"first of next month"}]
+*/
+func GetAllTaskTemplates(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	writeJSON(w, model.GetAllTaskTemplates())
+}
+
+/*
+	request type: GET
+	url: /tasktemplates/:template
+
+	Examples:
+
+	   req: GET /tasktemplates/wrongtemplate
+	   res: 404 task template not found
+
+	   req: GET /tasktemplates/rent
+	   res: 200 {"Name":"rent","Title":"Pay rent {month}","Priority":2,"DueRule":"first of next month"}
+*/
+func GetTaskTemplate(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	name := param.ByName("template")
+	tmpl, err := model.GetTaskTemplate(name)
+	if err != nil {
+		templateError(w, "GetTaskTemplate", name, err)
+		return
+	}
+	writeJSON(w, tmpl)
+}
+
+/*
+	request type: PUT
+	url: /tasktemplates/:template {"Name": "rent", "Title": "Pay the rent", "DueRule": "last of this month"}
+	Replaces the task template, renaming it when Name differs. Tasks already created
+	from the template are not changed
+
+	Examples:
+
+	   req: PUT /tasktemplates/wrongtemplate {"Name": "rent", "Title": "Pay rent"}
+	   res: 404 task template not found
+
+	   req: PUT /tasktemplates/rent {"Name": "existingtemplate", "Title": "Pay rent"}
+	   res: 409 task template already present
+
+	   req: PUT /tasktemplates/rent {"Name": "rent", "Title": "Pay the rent"}
+	   res: 200
+*/
+func UpdateTaskTemplate(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	name := param.ByName("template")
+	req := model.TaskTemplate{}
+	if !decodeTaskTemplate(w, r, "UpdateTaskTemplate", &req) {
+		return
+	}
+	tmpl, err := model.UpdateTaskTemplate(name, req)
+	if err != nil {
+		templateError(w, "UpdateTaskTemplate", name, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf("UpdateTaskTemplate:: task template '%s' updated as '%s'", name, tmpl.Name))
+	writeJSON(w, tmpl)
+}
+
+/*
+	request type: DELETE
+	url: /tasktemplates/:template
+
+	Examples:
+
+	   req: DELETE /tasktemplates/wrongtemplate
+	   res: 404 task template not found
+
+	   req: DELETE /tasktemplates/rent
+	   res: 200
+*/
+func DeleteTaskTemplate(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	name := param.ByName("template")
+	tmpl, err := model.DeleteTaskTemplate(name)
+	if err != nil {
+		templateError(w, "DeleteTaskTemplate", name, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf("DeleteTaskTemplate:: task template '%s' removed", name))
+	writeJSON(w, tmpl)
+}
+
+/*
+	request type: POST
+	url: /lists/:list/tasks/from-template/:template/?tz=Europe/Rome {"Title": "Pay rent early", "Priority": 1}
+	Adds to the ToDo list a task built from the template. The optional body overrides the
+	title and the attributes of the template, as for task creation. The due rule of the
+	template is evaluated on the current day in the tz parameter time zone (default UTC),
+	unless a DueDate is given. The task is validated and rejected when already present,
+	as with task creation
+
+	Examples:
+
+	   req: POST /lists/oklist/tasks/from-template/wrongtemplate/
+	   res: 404 task template not found
+
+	   req: POST /lists/oklist/tasks/from-template/rent/ {"Priority": 9}
+	   res: 400 invalid Priority
+
+	   req: POST /lists/oklist/tasks/from-template/rent/
+	   res: 201 Location: /lists/oklist/tasks/Pay%20rent%20June
+*/
+func CreateTaskFromTemplate(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	name := param.ByName("template")
+	req := struct {
+		Title   string `validate:"max=500"`
+		DueDate *dateutils.Raw
+		model.TaskDetails
+	}{}
+	if err := decodeBody(r, &req); err != nil && err != io.EOF {
+		taskBadRequestError(w, "CreateTaskFromTemplate", err)
+		return
+	}
+	if violations := validation.Validate(&req); len(violations) > 0 {
+		HandleValidationError(w, TASK_BADREQUEST, "CreateTaskFromTemplate", violations)
+		return
+	}
+	loc, err := requestLocation(r)
+	if err != nil {
+		taskInvalidParameterError(w, "CreateTaskFromTemplate", "tz", err)
+		return
+	}
+	dueDate, err := requestDate(r, req.DueDate)
+	if err != nil {
+		taskInvalidParameterError(w, "CreateTaskFromTemplate", "DueDate", err)
+		return
+	}
+	req.TaskDetails.DueDate = dueDate
+
+	task, warnings, err := model.InstantiateTaskTemplate(key, name, req.Title, req.TaskDetails, time.Now().In(loc))
+	if _, invalid := err.(*model.ValidationError); invalid {
+		taskUnprocessableError(w, "CreateTaskFromTemplate", name, key, err)
+		return
+	}
+	if err != nil {
+		taskOperationError(w, "CreateTaskFromTemplate", name, key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"CreateTaskFromTemplate:: new task added to ToDoList '%s' from template '%s': task={title: %s}", key, name, task.Title))
+	writeCreated(w, taskURL(task.ToDoList, task.Title))
+	writeTask(w, task, warnings)
+}
+
+// decodeTaskTemplate decodes and validates the template of the request body,
+// writing the error response when it is invalid
+func decodeTaskTemplate(w http.ResponseWriter, r *http.Request, caller string, tmpl *model.TaskTemplate) bool {
+	if err := decodeBody(r, tmpl); deprecationError(w, TEMPLATE_BADREQUEST, caller, err) {
+		return false
+	} else if err != nil {
+		HandleError(w, http.StatusBadRequest, TEMPLATE_BADREQUEST, caller,
+			"Invalid task template",
+			fmt.Sprintf("Bad request received: %v", err))
+		return false
+	}
+	if violations := validation.Validate(tmpl); len(violations) > 0 {
+		HandleValidationError(w, TEMPLATE_BADREQUEST, caller, violations)
+		return false
+	}
+	return true
+}
+
+// templateURL returns the canonical URL of the task template
+func templateURL(name string) string {
+	return "/tasktemplates/" + url.PathEscape(name)
+}
+
+func templateError(w http.ResponseWriter, caller, name string, err error) {
+	if _, invalid := err.(*model.ValidationError); invalid {
+		HandleError(w, http.StatusUnprocessableEntity, TEMPLATE_UNPROCESSABLE, caller,
+			fmt.Sprintf("Invalid task template = {%s}", name),
+			fmt.Sprintf("%v", err))
+		return
+	}
+	if err == model.ErrTaskTemplateConflict {
+		HandleError(w, http.StatusConflict, TEMPLATE_CONFLICT, caller,
+			fmt.Sprintf("Task template = {%s} already present", name),
+			fmt.Sprintf("%v", err))
+		return
+	}
+	HandleError(w, http.StatusNotFound, TEMPLATE_OPERATION_ERROR, caller,
+		fmt.Sprintf("Error while performing operation on task template = {%s}", name),
+		fmt.Sprintf("%v", err))
+}
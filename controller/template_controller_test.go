@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+/*******************************
+	Task TEMPLATES
+*******************************/
+func TestCreateTaskTemplate_invalidRule_error(t *testing.T) {
+	req := httptest.NewRequest("POST", "/tasktemplates/", strings.NewReader(`{"Name": "Someday", "Title": "Someday", "DueRule": "someday"}`))
+	res := httptest.NewRecorder()
+
+	CreateTaskTemplate(res, req, nil)
+
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestCreateTaskTemplate_conflict_error(t *testing.T) {
+	model.CreateTaskTemplate(model.TaskTemplate{Name: "Controller conflict", Title: "Conflict"})
+	model.CreateTaskTemplate(model.TaskTemplate{Name: "Controller renamed", Title: "Renamed"})
+
+	res := httptest.NewRecorder()
+	CreateTaskTemplate(res, httptest.NewRequest("POST", "/tasktemplates/", strings.NewReader(`{"Name": "Controller conflict", "Title": "Conflict"}`)), nil)
+	if res.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	UpdateTaskTemplate(res, httptest.NewRequest("PUT", "/tasktemplates/Controller%20renamed", strings.NewReader(`{"Name": "Controller conflict", "Title": "Renamed"}`)),
+		httprouter.Params{{Key: "template", Value: "Controller renamed"}})
+	if res.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestCreateTaskFromTemplate_ok(t *testing.T) {
+	model.CreateToDoList("ControllerListTemplate")
+	req := httptest.NewRequest("POST", "/tasktemplates/", strings.NewReader(`{"Name": "Controller rent", "Title": "Pay rent", "Priority": 2, "DueRule": "tomorrow"}`))
+	res := httptest.NewRecorder()
+	CreateTaskTemplate(res, req, nil)
+	if res.Code != http.StatusCreated || res.Header().Get("Location") != "/tasktemplates/Controller%20rent" {
+		t.Fatalf("expected status 201 with the template URL, got %d %q", res.Code, res.Header().Get("Location"))
+	}
+
+	params := httprouter.Params{{Key: "list", Value: "ControllerListTemplate"}, {Key: "template", Value: "Controller rent"}}
+	req = httptest.NewRequest("POST", "/lists/ControllerListTemplate/tasks/from-template/Controller%20rent/", nil)
+	res = httptest.NewRecorder()
+	CreateTaskFromTemplate(res, req, params)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", res.Code, res.Body.String())
+	}
+	if !strings.Contains(res.Body.String(), `"Priority":2`) || !strings.Contains(res.Body.String(), `"DueDate"`) {
+		t.Errorf("expected the template attributes in the response, got %s", res.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/lists/ControllerListTemplate/tasks/from-template/Controller%20rent/", strings.NewReader(`{"Title": "Pay rent early", "Priority": 9}`))
+	res = httptest.NewRecorder()
+	CreateTaskFromTemplate(res, req, params)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid override, got %d", res.Code)
+	}
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// debug enables the debugging parameters of the requests, see SetDebug
+var debug bool
+
+// SetDebug enables or disables the debugging parameters of the requests
+// (?debug=timing), never to be enabled in production
+func SetDebug(enabled bool) {
+	debug = enabled
+}
+
+// ModelTiming is the duration of a model operation of a request
+type ModelTiming struct {
+	Operation string
+	Ms        float64
+}
+
+// RequestTiming is the _timing object of the responses with ?debug=timing
+type RequestTiming struct {
+	TotalMs float64
+	ModelMs float64
+	Model   []ModelTiming
+}
+
+type timingKey struct{}
+
+// requestTimings collects the timings of the model operations of a request
+type requestTimings struct {
+	mu    sync.Mutex
+	model []ModelTiming
+}
+
+/*
+	Timing serves ?debug=timing when the debug flag is on: the response is buffered and the
+	time spent serving it and in its model operations is added, as a _timing object, to
+	JSON object responses, and as a Server-Timing header to every response. The parameter
+	is ignored when the debug flag is off.
+
+	Examples:
+
+	   req: GET /lists/okname/?debug=timing
+	   res: 200 {"Name":"okname",...,"_timing":{"TotalMs":0.41,"ModelMs":0.12,"Model":[{"Operation":"GetToDoListWithTasks","Ms":0.12}]}}
+	        Server-Timing: model;dur=0.12, total;dur=0.41
+*/
+func Timing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !debug || r.URL.Query().Get("debug") != "timing" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		timings := &requestTimings{}
+		rec := &timingRecorder{header: http.Header{}, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), timingKey{}, timings)))
+
+		timing := RequestTiming{TotalMs: milliseconds(time.Since(start)), Model: append([]ModelTiming{}, timings.model...)}
+		for _, t := range timing.Model {
+			timing.ModelMs += t.Ms
+		}
+		for key, values := range rec.header {
+			w.Header()[key] = values
+		}
+		w.Header().Set("Server-Timing", fmt.Sprintf("model;dur=%g, total;dur=%g", timing.ModelMs, timing.TotalMs))
+		body := rec.body.Bytes()
+		if strings.Contains(w.Header().Get("Content-Type"), "json") || w.Header().Get("Content-Type") == "" {
+			body = withTiming(body, timing)
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(rec.status)
+		w.Write(body)
+	})
+}
+
+// timeModel starts timing a model operation of the request, the returned
+// function stopping it. It does nothing when the timings are not requested.
+func timeModel(r *http.Request, operation string) (done func()) {
+	timings, ok := r.Context().Value(timingKey{}).(*requestTimings)
+	if !ok {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		elapsed := milliseconds(time.Since(start))
+		timings.mu.Lock()
+		defer timings.mu.Unlock()
+		timings.model = append(timings.model, ModelTiming{operation, elapsed})
+	}
+}
+
+// withTiming adds the _timing member to the body when it is a JSON object
+func withTiming(body []byte, timing RequestTiming) []byte {
+	trimmed := bytes.TrimRight(body, " \r\n\t")
+	if len(trimmed) < 2 || trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' {
+		return body
+	}
+	encoded, err := json.Marshal(timing)
+	if err != nil {
+		return body
+	}
+	var b bytes.Buffer
+	b.Write(trimmed[:len(trimmed)-1])
+	if len(bytes.TrimSpace(trimmed[1:len(trimmed)-1])) > 0 {
+		b.WriteByte(',')
+	}
+	b.WriteString(`"_timing":`)
+	b.Write(encoded)
+	b.WriteByte('}')
+	b.Write(body[len(trimmed):])
+	return b.Bytes()
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// timingRecorder buffers a response, to be completed with its timings
+type timingRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *timingRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *timingRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+}
+
+func (rec *timingRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.body.Write(b)
+}
+
+// Flush is a no-op, the streamed responses being buffered too
+func (rec *timingRecorder) Flush() {}
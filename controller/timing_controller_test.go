@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+func timingHandler() http.Handler {
+	return Timing(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		GetToDoList(w, r, httprouter.Params{{Key: "list", Value: "Controller Timed List"}})
+	}))
+}
+
+func TestTiming_debug_ok(t *testing.T) {
+	model.CreateToDoList("Controller Timed List")
+	SetDebug(true)
+	defer SetDebug(false)
+	res := httptest.NewRecorder()
+
+	timingHandler().ServeHTTP(res, httptest.NewRequest("GET", "/lists/Controller%20Timed%20List/?debug=timing", nil))
+
+	body := struct {
+		Name   string
+		Timing *RequestTiming `json:"_timing"`
+	}{}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil || body.Name != "Controller Timed List" || body.Timing == nil {
+		t.Fatalf("expected the list with its timings, got %v: %s", err, res.Body.String())
+	}
+	if len(body.Timing.Model) != 1 || body.Timing.Model[0].Operation != "GetToDoListWithTasks" {
+		t.Errorf("expected the model operation timed, got %+v", body.Timing)
+	}
+	if res.Header().Get("ETag") == "" || !strings.HasPrefix(res.Header().Get("Server-Timing"), "model;dur=") {
+		t.Errorf("expected the handler headers and Server-Timing, got %v", res.Header())
+	}
+}
+
+func TestTiming_debugOff_stripped(t *testing.T) {
+	model.CreateToDoList("Controller Timed List")
+	res := httptest.NewRecorder()
+
+	timingHandler().ServeHTTP(res, httptest.NewRequest("GET", "/lists/Controller%20Timed%20List/?debug=timing", nil))
+
+	if strings.Contains(res.Body.String(), "_timing") || res.Header().Get("Server-Timing") != "" {
+		t.Errorf("expected no timings with the debug flag off, got %v: %s", res.Header(), res.Body.String())
+	}
+}
+
+func TestWithTiming(t *testing.T) {
+	timing := RequestTiming{TotalMs: 1, Model: []ModelTiming{}}
+	expected := []struct {
+		body     string
+		expected string
+	}{
+		{"{}\n", `{"_timing":{"TotalMs":1,"ModelMs":0,"Model":[]}}` + "\n"},
+		{`{"Name":"list"}`, `{"Name":"list","_timing":{"TotalMs":1,"ModelMs":0,"Model":[]}}`},
+		{"[1,2]\n", "[1,2]\n"},
+	}
+	for _, e := range expected {
+		if body := string(withTiming([]byte(e.body), timing)); body != e.expected {
+			t.Errorf("expected %s for %s, got %s", e.expected, e.body, body)
+		}
+	}
+}
@@ -4,53 +4,240 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"net/url"
+	"strings"
 
+	"github.com/efreddo/v1/todolist/dateutils"
+	"github.com/efreddo/v1/todolist/jsonpatch"
 	"github.com/efreddo/v1/todolist/model"
 	"github.com/efreddo/v1/todolist/logutils"
+	"github.com/efreddo/v1/todolist/validation"
 	"github.com/julienschmidt/httprouter"
 )
 
 const (
 	TODOLIST_BADREQUEST = 10;
 	TODOLIST_OPERATION_ERROR = 11;
+	TODOLIST_UNSUPPORTED_MEDIA_TYPE = 12;
+	TODOLIST_CONFLICT = 13;
+	TODOLIST_UNPROCESSABLE = 14;
+	TODOLIST_PRECONDITION_FAILED = 15;
 )
 
 /* 
 	request type: POST
 	url: /lists/ {"Name": "New ToDo list"}
-	The request body must contain a JSON object with a Name field
+	The request body must contain a JSON object with a Name field, and optionally the
+	initial Tasks of the list: titles or task objects as for POST /lists/:list/tasks. The
+	list is created along with its tasks as a whole: 422 is returned, with an error for each
+	invalid task, and nothing created otherwise. The reserved names (query, export, archive,
+	bulk-archive and import by default, ignoring case) are rejected with 422. The Location header
+	of the response is the URL of the new list
 
 	Examples:
 
 	   req: POST /lists/ {"Name": ""}
 	   res: 400 empty name
 
+	   req: POST /lists/ {"Name": "Export"}
+	   res: 422 the ToDo list name Export is reserved
+
+	   req: POST /lists/ {"Name": "Trip", "Tasks": ["Passport", {"Title": "", "Priority": 9}]}
+	   res: 422 {"Errors":[{"Code":14,...,"Field":"Tasks[1].Title","Rule":"required"},{"Code":14,...,"Field":"Tasks[1].Priority","Rule":"max=4"}]}
+
 	   req: POST /create/ {"name": "New ToDo List"}
-	   res: 200
+	   res: 201 Location: /lists/New%20ToDo%20List/
+
+	   req: POST /lists/ {"Name": "Trip", "Tasks": ["Passport", {"Title": "Tickets", "DueDate": "2024-06-01"}]}
+	   res: 201 Location: /lists/Trip/ {"Name":"Trip","Tasks":[...],"TaskNumber":2,"LastTaskNumber":2}
 */
 func CreateToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
-	req := struct{ Name string }{}
+	req := struct{
+		Name string `validate:"required,max=200"`
+		DefaultPriority int `validate:"min=0,max=4"`
+		Tasks []taskSeedRequest }{}
 	
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+	if err := decodeBody(r, &req); err != nil {
 		todolistBadRequestError(w, "CreateToDoList", err)		
 		return		
 	}
+	listViolations, taskViolations := splitTaskViolations(validation.Validate(&req))
+	if len(listViolations) > 0 {
+		HandleValidationError(w, TODOLIST_BADREQUEST, "CreateToDoList", listViolations)
+		return
+	}
+	seeds, taskErrors := taskSeeds(r, req.Tasks, taskViolations)
+	if len(taskErrors) > 0 {
+		writeErrors(w, http.StatusUnprocessableEntity, "CreateToDoList", taskErrors)
+		return
+	}
 
-	toDoList, err :=  model.CreateToDoList(req.Name)
+	timed := timeModel(r, "SeedToDoListWithPriority")
+	toDoList, warnings, err :=  model.SeedToDoListWithPriority(req.Name, req.DefaultPriority, seeds)
+	timed()
+	if seedErr, invalid := err.(*model.TaskSeedError); invalid {
+		taskSeedError(w, "CreateToDoList", seedErr)
+		return
+	}
+	if _, invalid := err.(*model.ValidationError); invalid {
+		todolistUnprocessableError(w, "CreateToDoList", req.Name, err)
+		return
+	}
 	if err != nil {				
 		todolistOperationError(w, "CreateToDoList", req.Name, err)
 		return
 	}
 
 	logutils.Info.Println(fmt.Sprintf(
-		"CreateToDoList:: new ToDo '%s' list created", toDoList.Name ))
-	json.NewEncoder(w).Encode(toDoList)
+		"CreateToDoList:: new ToDo '%s' list created with %d tasks", toDoList.Name, toDoList.TaskNumber ))
+	writeCreated(w, listURL(toDoList.Name))
+	writeToDoList(w, toDoList, warnings)
 }	
 
+/* 
+	request type: POST
+	url: /lists/import/text?name=Trip
+	The request body is a plain text or markdown checklist: the ToDo list is created with
+	a task for each non-empty line, in text order, blank lines being ignored and titles
+	trimmed. Markdown bullets are removed, checked boxes ("- [x]") creating done tasks and
+	unchecked ones ("- [ ]") open tasks. As for POST /lists/ nothing is created when any
+	task is invalid, e.g. given twice
+
+	Examples:
+
+	   req: POST /lists/import/text
+	   res: 400 missing name
+
+	   req: POST /lists/import/text?name=Trip  "- [ ] Passport\n- [ ] Passport"
+	   res: 422 {"Errors":[{"Code":14,...,"Field":"Tasks[1]"}]}
+
+	   req: POST /lists/import/text?name=Trip  "- [x] Passport\n\n- [ ] Tickets\nHotel"
+	   res: 201 Location: /lists/Trip/ {"Name":"Trip","Tasks":[{"Title":"Passport","Done":true,...},...],"TaskNumber":3,...}
+*/
+func ImportChecklistText(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		todolistBadRequestError(w, "ImportChecklistText", errors.New("Missing mandatory information: todolist name"))
+		return
+	}
+
+	timed := timeModel(r, "ImportChecklistText")
+	toDoList, err := model.ImportChecklistText(name, r.Body)
+	timed()
+	if seedErr, invalid := err.(*model.TaskSeedError); invalid {
+		taskSeedError(w, "ImportChecklistText", seedErr)
+		return
+	}
+	if _, invalid := err.(*model.ValidationError); invalid {
+		todolistUnprocessableError(w, "ImportChecklistText", name, err)
+		return
+	}
+	if err != nil {
+		todolistOperationError(w, "ImportChecklistText", name, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"ImportChecklistText:: new ToDo '%s' list imported with %d tasks", toDoList.Name, toDoList.TaskNumber))
+	writeCreated(w, listURL(toDoList.Name))
+	writeToDoList(w, toDoList, nil)
+}
+
+// taskSeedRequest is an initial task of a ToDo list in the request body: its
+// title or the task object as for POST /lists/:list/tasks
+type taskSeedRequest struct {
+	Title string `validate:"required,max=500"`
+	DueDate *dateutils.Raw
+	model.TaskDetails
+}
+
+func (t *taskSeedRequest) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &t.Title); err == nil {
+		return nil
+	}
+	type task taskSeedRequest
+	return strictUnmarshal(b, (*task)(t))
+}
+
+// splitTaskViolations separates the violations of the initial tasks from the
+// ones of the list
+func splitTaskViolations(violations []validation.Violation) (list, tasks []validation.Violation) {
+	for _, v := range violations {
+		if strings.HasPrefix(v.Field, "Tasks[") {
+			tasks = append(tasks, v)
+		} else {
+			list = append(list, v)
+		}
+	}
+	return list, tasks
+}
+
+// taskSeeds returns the initial tasks of the request, along with an error for
+// each rule violation and invalid due date
+func taskSeeds(r *http.Request, requests []taskSeedRequest, violations []validation.Violation) ([]model.TaskSeed, []CustomError) {
+	var invalid []CustomError
+	for _, v := range violations {
+		invalid = append(invalid, CustomError{
+			Code: TODOLIST_UNPROCESSABLE,
+			ErrorMessage: fmt.Sprintf("Invalid field %s", v.Field),
+			TechnicalReason: fmt.Sprintf("Validation failed: %s", v),
+			Field: v.Field,
+			Rule: v.Rule,
+			Value: v.Value})
+	}
+	seeds := make([]model.TaskSeed, len(requests))
+	for i, t := range requests {
+		dueDate, err := requestDate(r, t.DueDate)
+		if err != nil {
+			invalid = append(invalid, CustomError{
+				Code: TODOLIST_UNPROCESSABLE,
+				ErrorMessage: fmt.Sprintf("Invalid field Tasks[%d].DueDate", i),
+				TechnicalReason: fmt.Sprintf("%v", err),
+				Field: fmt.Sprintf("Tasks[%d].DueDate", i)})
+			continue
+		}
+		seeds[i] = model.TaskSeed{Title: t.Title, TaskDetails: t.TaskDetails}
+		seeds[i].DueDate = dueDate
+	}
+	return seeds, invalid
+}
+
+// taskSeedError reports the initial tasks rejected by the model, with 422
+func taskSeedError(w http.ResponseWriter, caller string, err *model.TaskSeedError) {
+	invalid := make([]CustomError, len(err.Failures))
+	for i, f := range err.Failures {
+		invalid[i] = CustomError{
+			Code: TODOLIST_UNPROCESSABLE,
+			ErrorMessage: fmt.Sprintf("Invalid initial task %s", f.Title),
+			TechnicalReason: f.Error,
+			Field: fmt.Sprintf("Tasks[%d]", f.Index)}
+	}
+	writeErrors(w, http.StatusUnprocessableEntity, caller, invalid)
+}
+
+// writeToDoList encodes the list along with the warnings raised while storing
+// its tasks
+func writeToDoList(w http.ResponseWriter, list *model.ToDoList, warnings []string) {
+	// the list marshals itself, the warnings are added to its fields
+	fields := map[string]json.RawMessage{}
+	b, _ := marshalJSON(list)
+	json.Unmarshal(b, &fields)
+	if len(warnings) > 0 {
+		fields[fieldAlias("Warnings")], _ = json.Marshal(warnings)
+	}
+	writeJSON(w, fields)
+}
+
 /* 
 	request type: DELETE
-	url: /lists/:list/ 
+	url: /lists/:list/?includeTasks=false&onlyIfEmpty=false
+	With includeTasks=true the response is the deleted list with all its tasks, enough to
+	restore it. With onlyIfEmpty=true a list still having tasks is not deleted: 409 is
+	returned
 
 	Examples:
 
@@ -62,6 +249,12 @@ func CreateToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Par
 
 	   req: POST /lists/oklist/ 
 	   res: 200
+
+	   req: DELETE /lists/oklist/?includeTasks=true
+	   res: 200 {"Name":"oklist","Tasks":[{"ToDoList":"oklist","Title":"oktask",...}],"TaskNumber":1}
+
+	   req: DELETE /lists/oklist/?onlyIfEmpty=true
+	   res: 409 ToDo list not empty
 */
 func DeleteToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
 	key := param.ByName("list")
@@ -70,7 +263,24 @@ func DeleteToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Par
 		return
 	}
 
-	list, err :=  model.DeleteToDoList(key)
+	deleteList := model.DeleteToDoList
+	if r.URL.Query().Get("includeTasks") == "true" {
+		deleteList = model.DeleteToDoListWithTasks
+	}
+	if r.URL.Query().Get("onlyIfEmpty") == "true" {
+		deleteList = model.DeleteEmptyToDoList
+	}
+	list, err :=  deleteList(key)
+	if err == model.ErrToDoListNotEmpty {
+		HandleError(w, http.StatusConflict, TODOLIST_CONFLICT, "DeleteToDoList",
+			"ToDo list not empty",
+			fmt.Sprintf("ToDo list %s still has tasks, not deleted with onlyIfEmpty", key))
+		return
+	}
+	if _, invalid := err.(*model.ValidationError); invalid {
+		todolistUnprocessableError(w, "DeleteToDoList", key, err)
+		return
+	}
 	if err != nil {
 		todolistOperationError(w, "DeleteToDoList", key, err)
 		return
@@ -78,14 +288,19 @@ func DeleteToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Par
 
 	logutils.Info.Println(fmt.Sprintf(
 		"DeleteToDoList:: ToDo list '%s' deleted", list.Name ))
-	json.NewEncoder(w).Encode(list)	
+	writeJSON(w, list)	
 }	
 
 
 /* 
 	request type: PUT
-	url: /lists/:list/
-	The request body must contain a JSON object with a Name field
+	url: /lists/:list/?replaceTasks=false
+	The request body must contain a JSON object with a Name field, and optionally the
+	Description, Color, Archived, DefaultPriority, AutoSort and Tasks fields. The list is created when absent (201), its
+	Name matching the url, along with its initial Tasks as for POST /lists/, or updated (200),
+	its tasks being replaced by the given ones only with replaceTasks=true. With If-None-Match: * the list is only
+	created, with If-Match only updated when it matches the ETag (or *): 412 otherwise.
+	The ETag header of the response identifies the resulting list
 
 	Examples:
 
@@ -101,31 +316,133 @@ func DeleteToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Par
 	   req: PUT /lists/okname/ 	{"Name": "New name"}
 	   res: 200
 
+	   req: PUT /lists/newname/ 	{"Name": "newname", "Color": "red"}
+	   res: 201 Location: /lists/newname/
+
+	   req: PUT /lists/okname/  If-None-Match: *	{"Name": "okname"}
+	   res: 412 ToDo list precondition failed
+
 */
 func UpdateToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
 	key := param.ByName("list")
-	req := struct{ Name string }{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" || key == "" {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil || key == "" {
 		todolistBadRequestError(w, "UpdateToDoList", err)	
 		return
 	}
+	req := struct{
+		Name string `validate:"required,max=200"`
+		Tasks []taskSeedRequest }{}
+	fields := map[string]interface{}{}
+	if err := unknownFieldError(r, json.Unmarshal(body, &req)); err != nil {
+		todolistBadRequestError(w, "UpdateToDoList", err)	
+		return
+	}
+	json.Unmarshal(body, &fields)
+	listViolations, taskViolations := splitTaskViolations(validation.Validate(&req))
+	if len(listViolations) > 0 {
+		HandleValidationError(w, TODOLIST_BADREQUEST, "UpdateToDoList", listViolations)
+		return
+	}
+	seeds, taskErrors := taskSeeds(r, req.Tasks, taskViolations)
+	if len(taskErrors) > 0 {
+		writeErrors(w, http.StatusUnprocessableEntity, "UpdateToDoList", taskErrors)
+		return
+	}
+	patch := map[string]interface{}{}
+	for field, value := range fields {
+		switch strings.ToLower(field) {
+		case "name", "description", "color", "archived", "defaultpriority", "autosort":
+			patch[field] = value
+		case "tasks":
+		default:
+			if err := deprecated(r, DeprecatedUnknownFields, fmt.Sprintf("unknown field %q", field)); err != nil {
+				todolistBadRequestError(w, "UpdateToDoList", err)
+				return
+			}
+		}
+	}
 
-	list, err :=  model.UpdateToDoList(key, req.Name)
+	replaceTasks := r.URL.Query().Get("replaceTasks") == "true"
+	list, created, warnings, err :=  model.UpsertToDoList(key, patch, seeds, replaceTasks, listPrecondition(r))
+	if err == model.ErrToDoListPrecondition {
+		HandleError(w, http.StatusPreconditionFailed, TODOLIST_PRECONDITION_FAILED, "UpdateToDoList",
+			"ToDo list precondition failed, the list was not modified",
+			fmt.Sprintf("%v", err))
+		return
+	}
+	if seedErr, invalid := err.(*model.TaskSeedError); invalid {
+		taskSeedError(w, "UpdateToDoList", seedErr)
+		return
+	}
+	if _, invalid := err.(*model.ValidationError); invalid {
+		todolistUnprocessableError(w, "UpdateToDoList", key, err)
+		return
+	}
 	if err != nil {
 		todolistOperationError(w, "UpdateToDoList", key, err)
 		return
 	}
-	logutils.Info.Println(fmt.Sprintf(
-		"UpdateToDoList:: Retrieved ToDoList '%s'. Number of task={%d}",key, list.TaskNumber ))
-	json.NewEncoder(w).Encode(list)
+	w.Header().Set("ETag", model.ToDoListETag(list))
+	if created {
+		logutils.Info.Println(fmt.Sprintf(
+			"UpdateToDoList:: Created ToDoList '%s'", list.Name))
+		writeCreated(w, listURL(list.Name))
+	} else {
+		logutils.Info.Println(fmt.Sprintf(
+			"UpdateToDoList:: Retrieved ToDoList '%s'. Number of task={%d}",key, list.TaskNumber ))
+	}
+	writeToDoList(w, list, warnings)
 }	
 
+// listPrecondition returns the precondition of the If-None-Match: * and
+// If-Match headers on the current list, nil without them
+func listPrecondition(r *http.Request) func(current *model.ToDoList) bool {
+	ifNoneMatch := strings.TrimSpace(r.Header.Get("If-None-Match"))
+	ifMatch := r.Header.Get("If-Match")
+	if ifNoneMatch != "*" && ifMatch == "" {
+		return nil
+	}
+	return func(current *model.ToDoList) bool {
+		if ifNoneMatch == "*" && current != nil {
+			return false
+		}
+		if ifMatch != "" && (current == nil || !etagMatch(ifMatch, model.ToDoListETag(current))) {
+			return false
+		}
+		return true
+	}
+}
+
 /* 
 	request type: GET
-	url: /lists/
+	url: /lists/?offset=0&limit=50&archived=false
+	Returns the ToDo lists ordered by name, paginated with the offset and limit parameters,
+	the configured lists page size by default. Archived lists are returned only, and alone,
+	with archived=true
+
+	url: /lists/?withOpenCount=true
+	With withOpenCount=true the lists are returned without their tasks, along with their
+	number of incomplete tasks, e.g. for the badges of a sidebar
+
+	url: /lists/?names=groceries,work&embed=tasks&offset=0&limit=50
+	With names the named lists are returned in the order of the names, each with its
+	status: missing lists are reported as not found without failing the request. The
+	embed, offset and limit parameters apply to each list as in GET /lists/:list/, except
+	that the tasks are not capped without pagination: the truncation headers describe a
+	single list
 
 	Examples:
 
+	   req: GET /lists/?offset=-1
+	   res: 400 invalid offset
+
+	   req: GET /lists/?withOpenCount=true
+	   res: 200 [{"Name":"groceries","TaskNumber":5,"OpenTasks":2},{"Name":"work","Color":"red","TaskNumber":3,"OpenTasks":3}]
+
+	   req: GET /lists/?names=groceries,missing
+	   res: 200 [{"Name":"groceries","Status":200,"List":{...}},{"Name":"missing","Status":404,"Error":"ToDo list not found"}]
+
 	   req: GET /lists/
 	   res: 404 Error while retrieving lists
 
@@ -134,58 +451,945 @@ func UpdateToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Par
 	   
 */
 func GetAllToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	if names, ok := r.URL.Query()["names"]; ok {
+		getNamedToDoLists(w, r, strings.Split(strings.Join(names, ","), ","))
+		return
+	}
+	offset, limit, err := requestPage(r, pageSizes.Lists)
+	if err != nil {
+		todolistBadRequestError(w, "GetAllToDoList", err)
+		return
+	}
+	timed := timeModel(r, "GetAllToDoList")
 	todoList, err :=  model.GetAllToDoList()
+	timed()
 	if err != nil {
 		todolistOperationError(w, "GetAllToDoList", "all", err)
 		return
 	}	
+	archived := r.URL.Query().Get("archived") == "true"
+	shown := todoList[:0]
+	for _, list := range todoList {
+		if list.Archived == archived {
+			shown = append(shown, list)
+		}
+	}
+	todoList = shown
+	start, end := pageBounds(len(todoList), offset, limit)
+	todoList = todoList[start:end]
 	logutils.Info.Println(fmt.Sprintf(
 		"GetAllToDoList:: retrieved %d todo list", len(todoList) ))
-	json.NewEncoder(w).Encode(todoList)
+	if r.URL.Query().Get("withOpenCount") == "true" {
+		counted := make([]OpenCountToDoList, len(todoList))
+		for i, list := range todoList {
+			counted[i] = OpenCountToDoList{list.Name, list.Description, list.Color, list.Archived, list.TaskNumber, list.OpenTasks()}
+		}
+		writeJSON(w, counted)
+		return
+	}
+	writeJSON(w, todoList)
 }	
 
+// OpenCountToDoList is a ToDo list listed with its number of incomplete tasks,
+// without the tasks
+type OpenCountToDoList struct {
+	Name        string
+	Description string `json:",omitempty"`
+	Color       string `json:",omitempty"`
+	Archived    bool   `json:",omitempty"`
+	TaskNumber  int
+	OpenTasks   int
+}
+
+// NamedToDoList is the outcome of a ToDo list requested by name
+type NamedToDoList struct {
+	Name   string
+	Status int
+	List   *model.ToDoList `json:",omitempty"`
+	Error  string          `json:",omitempty"`
+}
+
+// getNamedToDoLists returns the named lists, taken from one snapshot of the store
+func getNamedToDoLists(w http.ResponseWriter, r *http.Request, names []string) {
+	embed := r.URL.Query().Get("embed")
+	if embed != "" && embed != "tasks" {
+		todolistBadRequestError(w, "GetAllToDoList", fmt.Errorf("unknown embed %s", embed))
+		return
+	}
+	offset, limit, err := requestPage(r, pageSizes.Tasks)
+	if err != nil {
+		todolistBadRequestError(w, "GetAllToDoList", err)
+		return
+	}
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+
+	results := make([]NamedToDoList, len(names))
+	for i, list := range model.GetToDoLists(names) {
+		results[i].Name = names[i]
+		if list == nil {
+			results[i].Status = http.StatusNotFound
+			results[i].Error = "ToDo list not found"
+			continue
+		}
+		if embed == "tasks" {
+			start, end := pageBounds(len(list.Tasks), offset, limit)
+			list.Tasks = list.Tasks[start:end]
+		}
+		results[i].Status = http.StatusOK
+		results[i].List = list
+	}
+	logutils.Info.Println(fmt.Sprintf(
+		"GetAllToDoList:: retrieved %d named todo list", len(results)))
+	writeJSON(w, results)
+}
+
 /* 
 	request type: GET
-	url: /lists/:list/
+	url: /lists/query?archived=false&name=trip&color=red&tag=travel&sort=name&order=asc&fields=Name,TaskNumber&offset=0&limit=50&counts=true
+	Returns the ToDo lists selected by the query, all the parameters being optional:
+	archived (true, false by default, or any), name (contained in the list name, ignoring
+	case), color, tag (lists with a task tagged with it), sort (name by default, or
+	taskNumber), order (asc or desc), fields (the list fields returned, all by default),
+	offset and limit (the configured lists page size by default). Total is the number of
+	lists selected. With counts=true the response has the Counts of the filters badges,
+	taken with the page: TotalArchived, the archived lists whatever the archived parameter,
+	and Tags, the lists with each tag whatever the tag parameter, the other parameters
+	applying. Malformed parameters are all reported at once with 400
+
+	Examples:
+
+	   req: GET /lists/query?sort=color&fields=Owner
+	   res: 400 {"Errors":[{"Code":10,...,"Field":"sort"},{"Code":10,...,"Field":"fields"}]}
+
+	   req: GET /lists/query?tag=travel&sort=taskNumber&order=desc&fields=Name,TaskNumber
+	   res: 200 {"Total":2,"Lists":[{"Name":"Trip","TaskNumber":5},{"Name":"Weekend","TaskNumber":2}]}
+
+	   req: GET /lists/query?color=red&fields=Name&counts=true
+	   res: 200 {"Total":1,"Lists":[{"Name":"Trip"}],"Counts":{"TotalArchived":2,"Tags":{"travel":1}}}
+*/
+func QueryLists(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	q, fields, invalid := requestListQuery(r)
+	if len(invalid) > 0 {
+		writeErrors(w, http.StatusBadRequest, "QueryLists", invalid)
+		return
+	}
+	var result *model.ListQueryResult
+	var counts *model.ListCounts
+	var err error
+	if r.URL.Query().Get("counts") == "true" {
+		timed := timeModel(r, "ListsWithCounts")
+		result, counts, err = model.ListsWithCounts(q)
+		timed()
+	} else {
+		timed := timeModel(r, "QueryLists")
+		result, err = model.QueryLists(q)
+		timed()
+	}
+	if err != nil {
+		todolistBadRequestError(w, "QueryLists", err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"QueryLists:: retrieved %d of %d todo list", len(result.Lists), result.Total))
+	// the lists are typed for writeJSON to apply the null handling and the
+	// field aliases, the projected ones being already
+	if fields == nil {
+		writeJSON(w, struct {
+			Total  int
+			Lists  []*model.ToDoList
+			Counts *model.ListCounts `json:",omitempty"`
+		}{result.Total, result.Lists, counts})
+		return
+	}
+	lists := make([]map[string]json.RawMessage, len(result.Lists))
+	for i, list := range result.Lists {
+		lists[i] = projectFields(list, fields)
+	}
+	writeJSON(w, struct {
+		Total  int
+		Lists  []map[string]json.RawMessage
+		Counts *model.ListCounts `json:",omitempty"`
+	}{result.Total, lists, counts})
+}
+
+// listFields are the fields of the ToDo lists that can be projected
+var listFields = []string{"Name", "Tasks", "TaskNumber", "Description", "Color", "Archived", "LastTaskNumber",
+	"DefaultPriority", "AutoSort", "EffectiveDefaultPriority", "UpdatedAt"}
+
+// requestListQuery returns the list query and the projected fields, nil for
+// all, along with an error for each malformed parameter
+func requestListQuery(r *http.Request) (model.ListQuery, []string, []CustomError) {
+	query := r.URL.Query()
+	var invalid []CustomError
+	reject := func(name string, err error) {
+		invalid = append(invalid, CustomError{
+			Code: TODOLIST_BADREQUEST,
+			ErrorMessage: fmt.Sprintf("Invalid parameter %s", name),
+			TechnicalReason: fmt.Sprintf("Bad request received: %v", err),
+			Field: name})
+	}
+
+	q := model.ListQuery{
+		Name: query.Get("name"),
+		Color: query.Get("color"),
+		Tag: query.Get("tag"),
+		Sort: query.Get("sort"),
+		Desc: query.Get("order") == "desc"}
+	switch archived := query.Get("archived"); archived {
+	case "", "false", "true":
+		selected := archived == "true"
+		q.Archived = &selected
+	case "any":
+	default:
+		reject("archived", fmt.Errorf("unknown archived %s, expected true, false or any", archived))
+	}
+	if sort := q.Sort; sort != "" && sort != model.ListSortName && sort != model.ListSortTaskNumber {
+		reject("sort", fmt.Errorf("unknown sort %s", sort))
+	}
+	if order := query.Get("order"); order != "" && order != "asc" && order != "desc" {
+		reject("order", fmt.Errorf("unknown order %s", order))
+	}
+	if counts := query.Get("counts"); counts != "" && counts != "true" && counts != "false" {
+		reject("counts", fmt.Errorf("invalid counts %s, expected true or false", counts))
+	}
+	offset, limit, err := requestPage(r, pageSizes.Lists)
+	if err != nil {
+		reject("offset or limit", err)
+	}
+	q.Offset, q.Limit = offset, limit
+
+	var fields []string
+	if query.Get("fields") != "" {
+		for _, name := range strings.Split(query.Get("fields"), ",") {
+			field := canonicalField(strings.TrimSpace(name), listFields)
+			if field == "" {
+				reject("fields", fmt.Errorf("unknown field %s", name))
+				continue
+			}
+			fields = append(fields, field)
+		}
+	}
+	return q, fields, invalid
+}
+
+// canonicalField returns the field named name, ignoring case, empty when
+// there is none
+func canonicalField(name string, fields []string) string {
+	for _, field := range fields {
+		if strings.EqualFold(name, field) {
+			return field
+		}
+	}
+	return ""
+}
+
+// projectFields returns the JSON representation of v reduced to the fields
+func projectFields(v interface{}, fields []string) map[string]json.RawMessage {
+	all := map[string]json.RawMessage{}
+	content, _ := marshalJSON(v)
+	json.Unmarshal(content, &all)
+	projected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[fieldAlias(field)]; ok {
+			projected[fieldAlias(field)] = value
+		}
+	}
+	return projected
+}
+
+/* 
+	request type: GET
+	url: /lists/:list/?embed=tasks&offset=0&limit=50
+	With embed=tasks the embedded tasks are paginated with the offset and limit parameters,
+	the configured tasks page size by default. As for GET /lists/:list/tasks/, the tasks of
+	a request without pagination are capped at the maximum of unpaginated tasks, signalled
+	by the X-Truncated, X-Total-Count and Link headers, the Link being an embed=tasks page
+	when embed is missing. The ETag header identifies the whole list, for the If-Match
+	header of PUT /lists/:list/
 
 	Examples:
 
 	   req: GET /lists// 
 	   res: 400 wrong name
 	   
+	   req: GET /lists/okname/?embed=notes
+	   res: 400 unknown embed
+	   
 	   req: GET /lists/wrongname/ 
 	   res: 404 ToDo list not found
 
 	   req: GET /lists/okname/ 
 	   res: 200
+
+	   req: GET /lists/okname/?embed=tasks&limit=20
+	   res: 200 with the first 20 tasks
+
+	   req: GET /lists/hugelist/
+	   res: 200 X-Truncated: true, with the first 500 tasks
 */
 func GetToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
 	key := param.ByName("list")
+	embed := r.URL.Query().Get("embed")
 
 	if key == "" {
 		todolistBadRequestError(w, "GetToDoList", errors.New("Missing mandatory information: todolist name."))	
 		return
 	}
+	if embed != "" && embed != "tasks" {
+		todolistBadRequestError(w, "GetToDoList", fmt.Errorf("unknown embed %s", embed))
+		return
+	}
+	offset, limit, err := requestPage(r, pageSizes.Tasks)
+	if err != nil {
+		todolistBadRequestError(w, "GetToDoList", err)
+		return
+	}
 
-	list, err :=  model.GetToDoList(key)
+	timed := timeModel(r, "GetToDoListWithTasks")
+	list, err := model.GetToDoListWithTasks(key)
+	timed()
 	if err != nil {
 		todolistOperationError(w, "GetToDoList", key, err)
 		return
 	}
+	w.Header().Set("ETag", model.ToDoListETag(list))
+	if embed == "tasks" {
+		limit = truncatePage(w, r, len(list.Tasks), limit, maxUnpaginatedTasks)
+	} else {
+		// without embed the tasks are not paginated, but capped all the same,
+		// the next ones being served by the pages of embed=tasks
+		offset, limit = 0, truncatePage(w, embedTasksRequest(r), len(list.Tasks), 0, maxUnpaginatedTasks)
+	}
+	start, end := pageBounds(len(list.Tasks), offset, limit)
+	list.Tasks = list.Tasks[start:end]
 
 	logutils.Info.Println(fmt.Sprintf(
 		"GetToDoList:: Retrieved ToDoList '%s'. Number of task={%d}",key, list.TaskNumber ))
-	json.NewEncoder(w).Encode(list)
+	writeJSON(w, list)
 }	
 
+/* 
+	request type: PATCH
+	url: /lists/:list/
+	Content-Type: application/merge-patch+json
+	Content-Type: application/json-patch+json
+	The request body must contain a JSON Merge Patch (RFC 7386): absent fields are untouched,
+	a null value clears the field (Description, Color, Archived). A JSON Patch (RFC 6902) is
+	applied as a whole to the JSON representation of the list: 409 is returned, and the list
+	left untouched, when a test operation fails or an operation modifies Tasks, TaskNumber or
+	LastTaskNumber
+
+	Examples:
+
+	   req: PATCH /lists/okname/  Content-Type: application/json
+	   res: 415 unsupported media type
+
+	   req: PATCH /lists/okname/  Content-Type: application/json-patch+json
+	        [{"op": "test", "path": "/Color", "value": "red"}, {"op": "replace", "path": "/Color", "value": "blue"}]
+	   res: 409 test failed
+
+	   req: PATCH /lists/okname/  Content-Type: application/json-patch+json  [{"op": "remove", "path": "/Tasks/0"}]
+	   res: 409 server-managed path
+
+	   req: PATCH /lists/okname/  Content-Type: application/json-patch+json  [{"op": "remove", "path": "/Color"}]
+	   res: 422 member not found
+
+	   req: PATCH /lists/okname/  {"Name": null}
+	   res: 400 invalid patch
+
+	   req: PATCH /lists/okname/  {"Name": "archive"}
+	   res: 422 the ToDo list name archive is reserved
+
+	   req: PATCH /lists/wrongname/  {"Color": "red"}
+	   res: 404 ToDo list not found
+
+	   req: PATCH /lists/okname/  {"Description": "Weekly shopping", "Color": null}
+	   res: 200
+*/
+func PatchToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err == nil && mediaType == jsonPatchMediaType {
+		jsonPatchToDoList(w, r, key)
+		return
+	}
+	if err != nil || mediaType != "application/merge-patch+json" {
+		HandleError(w, http.StatusUnsupportedMediaType, TODOLIST_UNSUPPORTED_MEDIA_TYPE, "PatchToDoList",
+			"Unsupported media type, application/merge-patch+json or application/json-patch+json expected",
+			fmt.Sprintf("Content-Type received: %s", r.Header.Get("Content-Type")))
+		return
+	}
+
+	patch := map[string]interface{}{}
+	if err := decodeBody(r, &patch); err != nil || key == "" {
+		todolistBadRequestError(w, "PatchToDoList", err)
+		return
+	}
+
+	if _, err := model.GetToDoList(key); err != nil {
+		todolistOperationError(w, "PatchToDoList", key, err)
+		return
+	}
+
+	list, err := model.MergePatchToDoList(key, patch)
+	if _, invalid := err.(*model.ValidationError); invalid {
+		todolistUnprocessableError(w, "PatchToDoList", key, err)
+		return
+	}
+	if err != nil {
+		HandleError(w, http.StatusBadRequest, TODOLIST_BADREQUEST, "PatchToDoList",
+			"Invalid merge patch",
+			fmt.Sprintf("%v", err))
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"PatchToDoList:: ToDoList '%s' patched", list.Name))
+	writeJSON(w, list)
+}
+
+/* 
+	request type: POST
+	url: /lists/:list/touch
+	Bumps the UpdatedAt of the list without changing it otherwise, e.g. to keep it fresh in
+	the views sorted by recency, and returns the list
+
+	Examples:
+
+	   req: POST /lists/wronglist/touch
+	   res: 404 ToDo list not found
+
+	   req: POST /lists/oklist/touch
+	   res: 200 {"Name":"oklist",...,"UpdatedAt":"2024-06-01T09:00:00Z",...}
+*/
+func TouchToDoList(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	list, err := model.Touch(key)
+	if err != nil {
+		todolistOperationError(w, "TouchToDoList", key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf("TouchToDoList:: ToDoList '%s' touched", key))
+	writeJSON(w, list)
+}
+
+// jsonPatchToDoList applies the JSON Patch of the request body to the ToDo list
+func jsonPatchToDoList(w http.ResponseWriter, r *http.Request, key string) {
+	patch, err := jsonpatch.Decode(r.Body)
+	if err != nil || key == "" {
+		todolistBadRequestError(w, "PatchToDoList", err)
+		return
+	}
+	if location := managedPath(patch, toDoListManagedPaths); location != "" {
+		managedPathError(w, "PatchToDoList", TODOLIST_CONFLICT, location)
+		return
+	}
+	if _, err := model.GetToDoList(key); err != nil {
+		todolistOperationError(w, "PatchToDoList", key, err)
+		return
+	}
+
+	applier := &patchApplier{patch: patch}
+	list, err := model.ApplyToDoListPatch(key, applier.apply)
+	if applier.err != nil {
+		jsonPatchError(w, "PatchToDoList", TODOLIST_CONFLICT, TODOLIST_UNPROCESSABLE, applier.err)
+		return
+	}
+	if _, invalid := err.(*model.ValidationError); invalid {
+		todolistUnprocessableError(w, "PatchToDoList", key, err)
+		return
+	}
+	if err != nil {
+		HandleError(w, http.StatusBadRequest, TODOLIST_BADREQUEST, "PatchToDoList",
+			"Invalid JSON patch",
+			fmt.Sprintf("%v", err))
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"PatchToDoList:: ToDoList '%s' patched with %d operations", list.Name, len(patch)))
+	writeJSON(w, list)
+}
+
+/* 
+	request type: POST
+	url: /lists/export {"Keys": ["list 1", "list 2"]}
+	Returns a single document bundling the requested lists and their tasks.
+	Lists not found are reported in the Errors section without failing the export
+
+	Examples:
+
+	   req: POST /lists/export {"Keys": []}
+	   res: 400 empty list of keys
+
+	   req: POST /lists/export {"Keys": ["oklist", "wronglist"]}
+	   res: 200 {"Version":1,"Lists":[...],"Errors":[{"Key":"wronglist","Error":"ToDo list not found"}]}
+*/
+func ExportToDoLists(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	req := struct{ Keys []string }{}
+	if err := decodeBody(r, &req); err != nil || len(req.Keys) == 0 {
+		todolistBadRequestError(w, "ExportToDoLists", err)
+		return
+	}
+
+	export, err := model.ExportLists(req.Keys)
+	if err != nil {
+		todolistOperationError(w, "ExportToDoLists", "export", err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"ExportToDoLists:: exported %d ToDo lists, %d not found", len(export.Lists), len(export.Errors)))
+	writeJSON(w, export)
+}
+
+/* 
+	request type: POST
+	url: /lists/bulk-archive?atomic=true {"Keys": ["list 1", "list 2"]}
+	Archives the requested lists, hiding them from the list of all the ToDo lists, and
+	returns the outcome of each list: 200 when all are archived, 207 Multi-Status when some
+	are not found. With atomic=true no list is archived when any is missing: 404 is returned,
+	the lists found being reported with status 424. Lists are unarchived with a merge patch
+	setting Archived to false
+
+	Examples:
+
+	   req: POST /lists/bulk-archive {"Keys": []}
+	   res: 400 empty list of keys
+
+	   req: POST /lists/bulk-archive?atomic=true {"Keys": ["oklist", "wronglist"]}
+	   res: 404 {"Succeeded":0,"Failed":2,"Items":[{"Id":"oklist","Status":424,"Error":"not archived, atomic request failed"},
+	             {"Id":"wronglist","Status":404,"Error":"ToDo list not found"}]}
+
+	   req: POST /lists/bulk-archive {"Keys": ["oklist", "wronglist"]}
+	   res: 207 {"Succeeded":1,"Failed":1,"Items":[{"Id":"oklist","Status":200},{"Id":"wronglist","Status":404,"Error":"ToDo list not found"}]}
+
+	   req: POST /lists/bulk-archive {"Keys": ["oklist"]}
+	   res: 200 {"Succeeded":1,"Failed":0,"Items":[{"Id":"oklist","Status":200}]}
+*/
+func ArchiveToDoLists(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	req := struct{ Keys []string }{}
+	if err := decodeBody(r, &req); err != nil || len(req.Keys) == 0 {
+		todolistBadRequestError(w, "ArchiveToDoLists", err)
+		return
+	}
+	atomic := r.URL.Query().Get("atomic") == "true"
+
+	summary, err := model.ArchiveLists(req.Keys, atomic)
+	if err != nil {
+		todolistOperationError(w, "ArchiveToDoLists", "bulk-archive", err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"ArchiveToDoLists:: archived %d ToDo lists, %d not found", summary.Archived, summary.NotFound))
+	failed := atomic && summary.NotFound > 0
+	notFound := make(map[string]string, len(summary.Errors))
+	for _, e := range summary.Errors {
+		notFound[e.Key] = e.Error
+	}
+	items := []ItemStatus{}
+	seen := make(map[string]bool, len(req.Keys))
+	for _, key := range req.Keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		switch e, missing := notFound[key]; {
+		case missing:
+			items = append(items, ItemStatus{Id: key, Status: http.StatusNotFound, Error: e})
+		case failed:
+			items = append(items, ItemStatus{Id: key, Status: http.StatusFailedDependency, Error: "not archived, atomic request failed"})
+		default:
+			items = append(items, ItemStatus{Id: key, Status: http.StatusOK})
+		}
+	}
+	if failed {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSON(w, newMultiStatus(items))
+		return
+	}
+	writeMultiStatus(w, items)
+}
+
+/* 
+	request type: GET
+	url: /lists/:list/archive
+	Returns a self-contained document of the list with its settings and tasks, to be
+	downloaded. The list has the format of the entries of the export document
+
+	Examples:
+
+	   req: GET /lists/wronglist/archive
+	   res: 404 ToDo list not found
+
+	   req: GET /lists/oklist/archive
+	   res: 200 {"Version":1,"List":{"Name":"oklist","Tasks":[...],"TaskNumber":2}}
+*/
+func DownloadToDoListArchive(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	archive, err := model.ArchiveToDoList(key)
+	if err != nil {
+		todolistOperationError(w, "DownloadToDoListArchive", key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"DownloadToDoListArchive:: archived ToDoList '%s' with %d tasks", key, len(archive.List.Tasks)))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition",
+		mime.FormatMediaType("attachment", map[string]string{"filename": key + ".todolist.json"}))
+	writeJSON(w, archive)
+}
+
+/* 
+	request type: GET
+	url: /lists/:list/notifications
+	Returns the notification preferences applying to the list: the event types muted for the
+	integrations notified of its changes, the list's own or the default ones
+
+	Examples:
+
+	   req: GET /lists/wronglist/notifications
+	   res: 404 ToDo list not found
+
+	   req: GET /lists/oklist/notifications
+	   res: 200 {"Muted":["task.created"]}
+*/
+func GetListNotifications(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	prefs, err := model.ListNotificationPrefs(key)
+	if err != nil {
+		todolistOperationError(w, "GetListNotifications", key, err)
+		return
+	}
+	writeJSON(w, prefs)
+}
+
+/* 
+	request type: PUT
+	url: /lists/:list/notifications {"Muted": ["task.created", "task.updated"]}
+	Sets the notification preferences of the list: the events of the muted types (see GET
+	/activity, and task.updated) are not notified, e.g. to quiet a busy list. A null body
+	restores the default preferences (TODOLIST_MUTED_EVENTS, nothing muted by default)
+
+	Examples:
+
+	   req: PUT /lists/oklist/notifications {"Muted": ["task.moved"]}
+	   res: 422 unknown event type
+
+	   req: PUT /lists/oklist/notifications {"Muted": ["task.created"]}
+	   res: 200 {"Muted":["task.created"]}
+
+	   req: PUT /lists/oklist/notifications null
+	   res: 200 {"Muted":[]}
+*/
+func SetListNotifications(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	var req *model.NotificationPrefs
+	if err := decodeBody(r, &req); err != nil {
+		todolistBadRequestError(w, "SetListNotifications", err)
+		return
+	}
+
+	prefs, err := model.SetListNotificationPrefs(key, req)
+	if _, invalid := err.(*model.ValidationError); invalid {
+		todolistUnprocessableError(w, "SetListNotifications", key, err)
+		return
+	}
+	if err != nil {
+		todolistOperationError(w, "SetListNotifications", key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"SetListNotifications:: events muted in ToDoList '%s': %v", key, prefs.Muted))
+	writeJSON(w, prefs)
+}
+
+/* 
+	request type: GET
+	url: /lists/:list/export.md?annotations=true
+	Returns the list as a markdown checklist, for notes apps and pull requests: the list
+	name as a heading and a "- [x]" or "- [ ]" line for each task, in the order of the list. With
+	annotations=true the priority and the tags of the tasks follow their titles
+
+	Examples:
+
+	   req: GET /lists/wronglist/export.md
+	   res: 404 ToDo list not found
+
+	   req: GET /lists/oklist/export.md?annotations=true
+	   res: 200 Content-Type: text/markdown; charset=utf-8
+	        # oklist
+
+	        - [x] Book the flight
+	        - [ ] Book the hotel (priority: high) #travel
+*/
+func ExportToDoListMarkdown(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	annotations := r.URL.Query().Get("annotations")
+	if annotations != "" && annotations != "true" && annotations != "false" {
+		exportInvalidParameterError(w, "ExportToDoListMarkdown", "annotations",
+			fmt.Errorf("invalid annotations %s, expected true or false", annotations))
+		return
+	}
+
+	timed := timeModel(r, "ExportMarkdown")
+	md, err := model.ExportMarkdown(key, annotations == "true")
+	timed()
+	if err != nil {
+		todolistOperationError(w, "ExportToDoListMarkdown", key, err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf("ExportToDoListMarkdown:: exported ToDoList '%s' as markdown", key))
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	io.WriteString(w, md)
+}
+
+/* 
+	request type: GET
+	url: /lists/:list/export?format=json|xlsx&tz=Europe/Rome
+	Returns the list name and its tasks, the tasks being encoded and sent one at a time
+	(chunked transfer encoding) so that the memory used does not grow with the size of
+	the list. The tasks are those of the list when the export starts. With format=xlsx
+	the tasks are returned as an xlsx workbook, as for GET /lists/export?format=xlsx
+
+	Examples:
+
+	   req: GET /lists/wronglist/export
+	   res: 404 ToDo list not found
+
+	   req: GET /lists/oklist/export?format=csv
+	   res: 400 unsupported format
+
+	   req: GET /lists/oklist/export?format=xlsx
+	   res: 200 Content-Type: application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+
+	   req: GET /lists/oklist/export
+	   res: 200 {"Name":"oklist","Tasks":[{"ToDoList":"oklist","Title":"oktask",...},...]}
+*/
+func StreamToDoListExport(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	key := param.ByName("list")
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+	case "xlsx":
+		streamToDoListXLSX(w, r, key)
+		return
+	default:
+		exportInvalidParameterError(w, "StreamToDoListExport", "format",
+			fmt.Errorf("unsupported format %q, expected json or xlsx", format))
+		return
+	}
+	name, _ := json.Marshal(key)
+	flusher, _ := w.(http.Flusher)
+	count := 0
+	err := model.StreamTasks(key, func(task model.Task) error {
+		if count == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"Name":%s,"Tasks":[`, name)
+		} else {
+			io.WriteString(w, ",")
+		}
+		content, err := marshalJSON(&task)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(content); err != nil {
+			return err
+		}
+		count++
+		if flusher != nil && count%streamFlushTasks == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil && count == 0 {
+		todolistOperationError(w, "StreamToDoListExport", key, err)
+		return
+	}
+	if err != nil {
+		logutils.Error.Println(fmt.Sprintf(
+			"StreamToDoListExport:: export of ToDoList '%s' interrupted after %d tasks: %v", key, count, err))
+		return
+	}
+	if count == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"Name":%s,"Tasks":[`, name)
+	}
+	io.WriteString(w, "]}\n")
+
+	logutils.Info.Println(fmt.Sprintf(
+		"StreamToDoListExport:: exported ToDoList '%s' with %d tasks", key, count))
+}
+
+// streamFlushTasks is the number of tasks sent between two flushes of a
+// streamed export
+const streamFlushTasks = 100
+
+/* 
+	request type: POST
+	url: /lists/archive?name=New name&onConflict=fail|rename {"Version":1,"List":{"Name":"oklist","Tasks":[...]}}
+	url: /lists/archive?onConflict=fail|rename {"Version":1,"Lists":[{"Name":"oklist","Tasks":[...]},...]}
+	Creates a list from an archive, under the name parameter or the archived name. When the
+	name is taken the upload fails with 409, unless onConflict=rename: the list is then created
+	as "<name> (2)", "<name> (3)"... The export documents of POST /lists/export and of the
+	backups are accepted as well: their Lists are restored under their names, with the same
+	conflict policy, all or none of them, and returned in Lists
+
+	Examples:
+
+	   req: POST /lists/archive?onConflict=overwrite
+	   res: 400 invalid onConflict
+
+	   req: POST /lists/archive {"Version":99,"List":{"Name":"newlist"}}
+	   res: 422 unsupported archive version
+
+	   req: POST /lists/archive {"Version":1,"List":{"Name":"oklist"}}
+	   res: 409 list already present
+
+	   req: POST /lists/archive?onConflict=rename {"Version":1,"List":{"Name":"oklist"}}
+	   res: 200 {"Name":"oklist (2)",...}
+
+	   req: POST /lists/archive?onConflict=rename {"Version":1,"Lists":[{"Name":"oklist"},{"Name":"newlist"}]}
+	   res: 200 {"Lists":[{"Name":"oklist (2)",...},{"Name":"newlist",...}]}
+*/
+func UploadToDoListArchive(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	query := r.URL.Query()
+	onConflict := query.Get("onConflict")
+	if onConflict != "" && onConflict != "fail" && onConflict != "rename" {
+		todolistBadRequestError(w, "UploadToDoListArchive", fmt.Errorf("invalid onConflict %s, expected fail or rename", onConflict))
+		return
+	}
+	req := archiveRequest{}
+	if err := decodeBody(r, &req); err != nil {
+		todolistBadRequestError(w, "UploadToDoListArchive", err)
+		return
+	}
+	if req.Lists != nil {
+		restoreToDoLists(w, r, &req, onConflict == "rename")
+		return
+	}
+	if req.List == nil {
+		todolistBadRequestError(w, "UploadToDoListArchive", errors.New("missing List or Lists"))
+		return
+	}
+
+	archive := &model.ToDoListArchive{Version: req.Version, List: req.List.ToDoList}
+	list, warnings, err := model.RestoreToDoList(archive, query.Get("name"), onConflict == "rename")
+	if err == model.ErrToDoListConflict {
+		HandleError(w, http.StatusConflict, TODOLIST_CONFLICT, "UploadToDoListArchive",
+			"ToDo list already present, use onConflict=rename to restore it under a new name",
+			fmt.Sprintf("%v", err))
+		return
+	}
+	if _, invalid := err.(*model.ValidationError); invalid {
+		HandleError(w, http.StatusUnprocessableEntity, TODOLIST_UNPROCESSABLE, "UploadToDoListArchive",
+			"Invalid ToDo list archive",
+			fmt.Sprintf("%v", err))
+		return
+	}
+	if err != nil {
+		todolistBadRequestError(w, "UploadToDoListArchive", err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"UploadToDoListArchive:: restored ToDoList '%s' with %d tasks", list.Name, list.TaskNumber))
+	writeToDoList(w, list, warnings)
+}
+
+// restoreToDoLists restores the lists of the export document of the request,
+// answering with the restored lists
+func restoreToDoLists(w http.ResponseWriter, r *http.Request, req *archiveRequest, rename bool) {
+	if r.URL.Query().Get("name") != "" {
+		todolistBadRequestError(w, "UploadToDoListArchive", errors.New("name can not be given with the Lists of an export"))
+		return
+	}
+	export := &model.ToDoListExport{Version: req.Version, Lists: make([]model.ToDoList, len(req.Lists))}
+	for i, list := range req.Lists {
+		export.Lists[i] = list.ToDoList
+	}
+	lists, warnings, err := model.RestoreToDoLists(export, rename)
+	if err == model.ErrToDoListConflict {
+		HandleError(w, http.StatusConflict, TODOLIST_CONFLICT, "UploadToDoListArchive",
+			"ToDo list already present, use onConflict=rename to restore it under a new name",
+			fmt.Sprintf("%v", err))
+		return
+	}
+	if _, invalid := err.(*model.ValidationError); invalid {
+		HandleError(w, http.StatusUnprocessableEntity, TODOLIST_UNPROCESSABLE, "UploadToDoListArchive",
+			"Invalid ToDo list export",
+			fmt.Sprintf("%v", err))
+		return
+	}
+	if err != nil {
+		todolistBadRequestError(w, "UploadToDoListArchive", err)
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"UploadToDoListArchive:: restored %d ToDoLists", len(lists)))
+	writeJSON(w, struct {
+		Lists    []*model.ToDoList
+		Warnings []string `json:",omitempty"`
+	}{lists, warnings})
+}
+
+// archivedList is a ToDo list of an archive or of an export in the request
+// body, the computed fields of the downloaded ones being accepted, and ignored
+type archivedList struct {
+	model.ToDoList
+	EffectiveDefaultPriority int
+	PercentComplete          int
+}
+
+// archiveRequest is the archive of a ToDo list, or the export document of
+// several ones (Lists), in the request body
+type archiveRequest struct {
+	Version int
+	List    *archivedList
+	Lists   []archivedList
+	Errors  []model.ExportError
+}
+
+// listURL returns the canonical URL of the ToDo list
+func listURL(name string) string {
+	return "/lists/" + url.PathEscape(name) + "/"
+}
+
+// writeCreated reports the creation of the resource at location
+func writeCreated(w http.ResponseWriter, location string) {
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusCreated)
+}
+
 func todolistBadRequestError(w http.ResponseWriter, caller string, err error){
+	if deprecationError(w, TODOLIST_BADREQUEST, caller, err) {
+		return
+	}
 	HandleError(w, http.StatusBadRequest, TODOLIST_BADREQUEST, caller,
 		"Missing ToDo list name",  
 		fmt.Sprintf("Bad request received: Missing mandatory parameters list name. %v", err))
 }
 
+func todolistUnprocessableError(w http.ResponseWriter, caller, todolist string, err error){
+	HandleError(w, http.StatusUnprocessableEntity, TODOLIST_UNPROCESSABLE, caller,
+		fmt.Sprintf("Invalid attributes for ToDo list = {%s}", todolist),
+		fmt.Sprintf("%v",err))
+}
+
 func todolistOperationError(w http.ResponseWriter, caller, todolist string, err error){
-	HandleError(w, http.StatusNotFound, TODOLIST_OPERATION_ERROR, caller,
+	status := http.StatusNotFound
+	if _, gone := err.(*model.GoneError); gone {
+		status = http.StatusGone
+	}
+	HandleError(w, status, TODOLIST_OPERATION_ERROR, caller,
 		fmt.Sprintf("Error while performing operation on ToDo list = {%s}", todolist),  
 		fmt.Sprintf("%v",err))
 }
\ No newline at end of file
@@ -0,0 +1,613 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestCreateToDoList_location_ok(t *testing.T) {
+	req := httptest.NewRequest("POST", "/lists/", strings.NewReader(`{"Name": "Controller List Created"}`))
+	res := httptest.NewRecorder()
+
+	CreateToDoList(res, req, nil)
+
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", res.Code, res.Body.String())
+	}
+	if location := res.Header().Get("Location"); location != "/lists/Controller%20List%20Created/" {
+		t.Errorf("expected the list URL, got %s", location)
+	}
+	if !strings.Contains(res.Body.String(), `"Name":"Controller List Created"`) {
+		t.Errorf("expected the created list in the response, got %s", res.Body.String())
+	}
+}
+
+func TestImportChecklistText(t *testing.T) {
+	req := httptest.NewRequest("POST", "/lists/import/text?name=ControllerListChecklist", strings.NewReader("- [x] Passport\n\n- [ ] Tickets\n"))
+	res := httptest.NewRecorder()
+	ImportChecklistText(res, req, nil)
+	if res.Code != http.StatusCreated || res.Header().Get("Location") != "/lists/ControllerListChecklist/" {
+		t.Fatalf("expected status 201 with the list URL, got %d %s", res.Code, res.Body.String())
+	}
+	list := model.ToDoList{}
+	if err := json.NewDecoder(res.Body).Decode(&list); err != nil || len(list.Tasks) != 2 || !list.Tasks[0].Done || list.Tasks[1].Done {
+		t.Errorf("expected Passport done and Tickets open, got %v (%v)", list.Tasks, err)
+	}
+
+	expected := map[string]int{"": http.StatusBadRequest, "?name=ControllerListChecklistInvalid": http.StatusUnprocessableEntity}
+	for query, code := range expected {
+		res = httptest.NewRecorder()
+		ImportChecklistText(res, httptest.NewRequest("POST", "/lists/import/text"+query, strings.NewReader("Passport\nPassport")), nil)
+		if res.Code != code {
+			t.Errorf("expected status %d for %q, got %d", code, query, res.Code)
+		}
+	}
+}
+
+func TestCreateToDoList_defaultPriority(t *testing.T) {
+	body := `{"Name": "Controller List Prioritized", "DefaultPriority": 2, "Tasks": ["Passport", {"Title": "Tickets", "Priority": 4}]}`
+	res := httptest.NewRecorder()
+	CreateToDoList(res, httptest.NewRequest("POST", "/lists/", strings.NewReader(body)), nil)
+
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", res.Code, res.Body.String())
+	}
+	if !strings.Contains(res.Body.String(), `"EffectiveDefaultPriority":2`) {
+		t.Errorf("expected the effective default priority in the response, got %s", res.Body.String())
+	}
+	list, _ := model.GetToDoListWithTasks("Controller List Prioritized")
+	if list.Tasks[0].Priority != model.PriorityHigh || list.Tasks[1].Priority != model.PriorityLow {
+		t.Errorf("expected the default priority for Passport only, got %v", list.Tasks)
+	}
+
+	res = httptest.NewRecorder()
+	CreateToDoList(res, httptest.NewRequest("POST", "/lists/", strings.NewReader(`{"Name": "Controller List Misprioritized", "DefaultPriority": 5}`)), nil)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 with an invalid default priority, got %d", res.Code)
+	}
+}
+
+func TestCreateToDoList_tasks(t *testing.T) {
+	body := `{"Name": "Controller List Seeded", "Tasks": ["Passport", {"Title": "Tickets", "DueDate": "2031-06-01", "Priority": 2}]}`
+	res := httptest.NewRecorder()
+	CreateToDoList(res, httptest.NewRequest("POST", "/lists/", strings.NewReader(body)), nil)
+
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", res.Code, res.Body.String())
+	}
+	list := model.ToDoList{}
+	json.NewDecoder(res.Body).Decode(&list)
+	if list.TaskNumber != 2 || list.Tasks[0].Title != "Passport" || list.Tasks[1].DueDate == nil || list.Tasks[1].Priority != 2 {
+		t.Errorf("expected Passport and Tickets created, got %+v", list.Tasks)
+	}
+}
+
+func TestCreateToDoList_invalidTasks_error(t *testing.T) {
+	body := `{"Name": "Controller List Not Seeded", "Tasks": ["Passport", {"Title": "", "Priority": 9}, {"Title": "Hotel", "DueDate": "someday"}, "Passport"]}`
+	res := httptest.NewRecorder()
+	CreateToDoList(res, httptest.NewRequest("POST", "/lists/", strings.NewReader(body)), nil)
+
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d: %s", res.Code, res.Body.String())
+	}
+	listErrors := ListError{}
+	json.NewDecoder(res.Body).Decode(&listErrors)
+	fields := []string{"Tasks[1].Title", "Tasks[1].Priority", "Tasks[2].DueDate"}
+	if len(listErrors.Errors) != len(fields) {
+		t.Fatalf("expected errors on %v, got %+v", fields, listErrors.Errors)
+	}
+	for i, field := range fields {
+		if listErrors.Errors[i].Field != field {
+			t.Errorf("expected error %d on %s, got %+v", i, field, listErrors.Errors[i])
+		}
+	}
+	if list, _ := model.GetToDoList("Controller List Not Seeded"); list != nil {
+		t.Errorf("expected no list created")
+	}
+
+	body = `{"Name": "Controller List Not Seeded", "Tasks": ["Passport", "Passport"]}`
+	res = httptest.NewRecorder()
+	CreateToDoList(res, httptest.NewRequest("POST", "/lists/", strings.NewReader(body)), nil)
+	if res.Code != http.StatusUnprocessableEntity || !strings.Contains(res.Body.String(), `"Field":"Tasks[1]"`) {
+		t.Errorf("expected status 422 on the duplicated task, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestQueryLists_fields(t *testing.T) {
+	model.SeedToDoList("ControllerListQuery", []model.TaskSeed{{Title: "Task1", TaskDetails: model.TaskDetails{Tags: []string{"controllerquery"}}}})
+	res := httptest.NewRecorder()
+	QueryLists(res, httptest.NewRequest("GET", "/lists/query?tag=controllerquery&fields=name,TaskNumber", nil), nil)
+
+	expected := `{"Total":1,"Lists":[{"Name":"ControllerListQuery","TaskNumber":1}]}`
+	if res.Code != http.StatusOK || strings.TrimSpace(res.Body.String()) != expected {
+		t.Errorf("expected %s, got %d: %s", expected, res.Code, res.Body.String())
+	}
+}
+
+func TestQueryLists_counts(t *testing.T) {
+	model.SeedToDoList("ControllerListCounts", []model.TaskSeed{{Title: "Task1", TaskDetails: model.TaskDetails{Tags: []string{"controllercounts"}}}})
+	res := httptest.NewRecorder()
+	QueryLists(res, httptest.NewRequest("GET", "/lists/query?name=ControllerListCounts&fields=name&counts=true", nil), nil)
+
+	expected := `{"Total":1,"Lists":[{"Name":"ControllerListCounts"}],"Counts":{"TotalArchived":0,"Tags":{"controllercounts":1}}}`
+	if res.Code != http.StatusOK || strings.TrimSpace(res.Body.String()) != expected {
+		t.Errorf("expected %s, got %d: %s", expected, res.Code, res.Body.String())
+	}
+}
+
+func TestQueryLists_invalid_error(t *testing.T) {
+	res := httptest.NewRecorder()
+	QueryLists(res, httptest.NewRequest("GET", "/lists/query?archived=maybe&sort=color&fields=Owner&limit=0", nil), nil)
+
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", res.Code)
+	}
+	listErrors := ListError{}
+	json.NewDecoder(res.Body).Decode(&listErrors)
+	fields := []string{"archived", "sort", "offset or limit", "fields"}
+	if len(listErrors.Errors) != len(fields) {
+		t.Fatalf("expected errors on %v, got %+v", fields, listErrors.Errors)
+	}
+	for i, field := range fields {
+		if listErrors.Errors[i].Field != field {
+			t.Errorf("expected error %d on %s, got %+v", i, field, listErrors.Errors[i])
+		}
+	}
+}
+
+func TestExportToDoListMarkdown(t *testing.T) {
+	model.CreateToDoListWithTasks("ControllerListMarkdown", []string{"Passport"})
+	params := httprouter.Params{{Key: "list", Value: "ControllerListMarkdown"}}
+	res := httptest.NewRecorder()
+	ExportToDoListMarkdown(res, httptest.NewRequest("GET", "/lists/ControllerListMarkdown/export.md", nil), params)
+	if res.Code != http.StatusOK || res.Header().Get("Content-Type") != "text/markdown; charset=utf-8" ||
+		res.Body.String() != "# ControllerListMarkdown\n\n- [ ] Passport\n" {
+		t.Errorf("expected the markdown checklist, got %d %q %q", res.Code, res.Header().Get("Content-Type"), res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	ExportToDoListMarkdown(res, httptest.NewRequest("GET", "/lists/wronglist/export.md", nil), httprouter.Params{{Key: "list", Value: "wronglist"}})
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", res.Code)
+	}
+}
+
+func TestStreamToDoListExport_manyTasks(t *testing.T) {
+	seeds := make([]model.TaskSeed, 2500)
+	for i := range seeds {
+		seeds[i].Title = fmt.Sprintf("Task %d", i)
+	}
+	model.SeedToDoList("Controller \"Streamed\" List", seeds)
+	params := httprouter.Params{{Key: "list", Value: "Controller \"Streamed\" List"}}
+	res := httptest.NewRecorder()
+	StreamToDoListExport(res, httptest.NewRequest("GET", "/lists/x/export", nil), params)
+
+	export := struct {
+		Name  string
+		Tasks []model.Task
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&export); err != nil {
+		t.Fatalf("expected a JSON document, got %v", err)
+	}
+	if export.Name != "Controller \"Streamed\" List" || len(export.Tasks) != len(seeds) || export.Tasks[2499].Title != "Task 2499" {
+		t.Errorf("expected the %d tasks in order, got %d", len(seeds), len(export.Tasks))
+	}
+
+	model.CreateToDoList("ControllerListStreamedEmpty")
+	res = httptest.NewRecorder()
+	StreamToDoListExport(res, httptest.NewRequest("GET", "/lists/x/export", nil), httprouter.Params{{Key: "list", Value: "ControllerListStreamedEmpty"}})
+	if strings.TrimSpace(res.Body.String()) != `{"Name":"ControllerListStreamedEmpty","Tasks":[]}` {
+		t.Errorf("expected an empty export, got %s", res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	StreamToDoListExport(res, httptest.NewRequest("GET", "/lists/x/export", nil), httprouter.Params{{Key: "list", Value: "ControllerListMissing"}})
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", res.Code)
+	}
+}
+
+func TestToDoListArchive_roundTrip(t *testing.T) {
+	model.CreateToDoList("ControllerListArchive")
+	model.AddTask("ControllerListArchive", "Task1")
+
+	req := httptest.NewRequest("GET", "/lists/ControllerListArchive/archive", nil)
+	res := httptest.NewRecorder()
+	DownloadToDoListArchive(res, req, httprouter.Params{{Key: "list", Value: "ControllerListArchive"}})
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if disposition := res.Header().Get("Content-Disposition"); disposition != `attachment; filename=ControllerListArchive.todolist.json` {
+		t.Errorf("expected the archive downloaded as ControllerListArchive.todolist.json, got %s", disposition)
+	}
+	archive := res.Body.String()
+
+	req = httptest.NewRequest("POST", "/lists/archive", strings.NewReader(archive))
+	res = httptest.NewRecorder()
+	UploadToDoListArchive(res, req, nil)
+	if res.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", res.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/lists/archive?onConflict=rename", strings.NewReader(archive))
+	res = httptest.NewRecorder()
+	UploadToDoListArchive(res, req, nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if !strings.Contains(res.Body.String(), `"Name":"ControllerListArchive (2)"`) {
+		t.Errorf("expected the list restored as ControllerListArchive (2), got %s", res.Body.String())
+	}
+}
+
+func TestToDoListExport_roundTrip(t *testing.T) {
+	model.CreateToDoListWithTasks("ControllerListExported1", []string{"Task1"})
+	model.CreateToDoList("ControllerListExported2")
+
+	req := httptest.NewRequest("POST", "/lists/export", strings.NewReader(`{"Keys": ["ControllerListExported1", "ControllerListExported2"]}`))
+	res := httptest.NewRecorder()
+	ExportToDoLists(res, req, nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	export := res.Body.String()
+
+	req = httptest.NewRequest("POST", "/lists/archive", strings.NewReader(export))
+	res = httptest.NewRecorder()
+	UploadToDoListArchive(res, req, nil)
+	if res.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d: %s", res.Code, res.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/lists/archive?name=ControllerListExported3", strings.NewReader(export))
+	res = httptest.NewRecorder()
+	UploadToDoListArchive(res, req, nil)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d: %s", res.Code, res.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/lists/archive?onConflict=rename", strings.NewReader(export))
+	res = httptest.NewRecorder()
+	UploadToDoListArchive(res, req, nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	restored := struct{ Lists []model.ToDoList }{}
+	json.NewDecoder(res.Body).Decode(&restored)
+	if len(restored.Lists) != 2 || restored.Lists[0].Name != "ControllerListExported1 (2)" || restored.Lists[1].Name != "ControllerListExported2 (2)" {
+		t.Fatalf("expected the lists restored as ControllerListExported1 (2) and ControllerListExported2 (2), got %+v", restored.Lists)
+	}
+	if list, err := model.GetToDoList("ControllerListExported1 (2)"); err != nil || len(list.Tasks) != 1 || list.Tasks[0].Title != "Task1" {
+		t.Errorf("expected the restored list with Task1, got %+v (%v)", list, err)
+	}
+}
+
+func TestArchiveToDoLists_hidden(t *testing.T) {
+	model.CreateToDoList("ControllerListBulkArchive")
+	req := httptest.NewRequest("POST", "/lists/bulk-archive?atomic=true", strings.NewReader(`{"Keys": ["ControllerListBulkArchive", "ControllerListMissing"]}`))
+	res := httptest.NewRecorder()
+	ArchiveToDoLists(res, req, nil)
+	if res.Code != http.StatusNotFound || !strings.Contains(res.Body.String(), `"Status":424`) {
+		t.Fatalf("expected status 404 with the summary, got %d: %s", res.Code, res.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/lists/bulk-archive", strings.NewReader(`{"Keys": ["ControllerListBulkArchive"]}`))
+	res = httptest.NewRecorder()
+	ArchiveToDoLists(res, req, nil)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"Succeeded":1`) {
+		t.Fatalf("expected status 200 with 1 list archived, got %d: %s", res.Code, res.Body.String())
+	}
+
+	expected := map[string]bool{"/lists/?limit=1000": false, "/lists/?archived=true&limit=1000": true}
+	for url, listed := range expected {
+		res = httptest.NewRecorder()
+		GetAllToDoList(res, httptest.NewRequest("GET", url, nil), nil)
+		if strings.Contains(res.Body.String(), `"ControllerListBulkArchive"`) != listed {
+			t.Errorf("expected the archived list listed=%t for %s, got %s", listed, url, res.Body.String())
+		}
+	}
+}
+
+func TestArchiveToDoLists_multiStatus(t *testing.T) {
+	model.CreateToDoList("ControllerListMultiStatus")
+	req := httptest.NewRequest("POST", "/lists/bulk-archive", strings.NewReader(`{"Keys": ["ControllerListMultiStatus", "ControllerListMissing"]}`))
+	res := httptest.NewRecorder()
+	ArchiveToDoLists(res, req, nil)
+
+	if res.Code != http.StatusMultiStatus {
+		t.Fatalf("expected status 207, got %d: %s", res.Code, res.Body.String())
+	}
+	status := MultiStatus{}
+	json.NewDecoder(res.Body).Decode(&status)
+	expected := []ItemStatus{
+		{Id: "ControllerListMultiStatus", Status: http.StatusOK},
+		{Id: "ControllerListMissing", Status: http.StatusNotFound, Error: "ToDo list not found"},
+	}
+	if status.Succeeded != 1 || status.Failed != 1 || len(status.Items) != 2 || status.Items[0] != expected[0] || status.Items[1] != expected[1] {
+		t.Errorf("expected %+v, got %+v", expected, status)
+	}
+}
+
+func TestGetToDoList_maxUnpaginated_truncated(t *testing.T) {
+	defer SetMaxUnpaginatedTasks(MaxUnpaginatedTasks())
+	SetMaxUnpaginatedTasks(2)
+	model.CreateToDoListWithTasks("ControllerListTruncatedList", []string{"Task1", "Task2", "Task3"})
+	params := httprouter.Params{{Key: "list", Value: "ControllerListTruncatedList"}}
+
+	expected := map[string]string{
+		"":                     `</lists/ControllerListTruncatedList/?embed=tasks&limit=2&offset=2>; rel="next"`,
+		"?embed=tasks":         `</lists/ControllerListTruncatedList/?embed=tasks&limit=2&offset=2>; rel="next"`,
+		"?limit=3":             `</lists/ControllerListTruncatedList/?embed=tasks&limit=2&offset=2>; rel="next"`,
+		"?embed=tasks&limit=3": "",
+	}
+	for query, link := range expected {
+		res := httptest.NewRecorder()
+		GetToDoList(res, httptest.NewRequest("GET", "/lists/ControllerListTruncatedList/"+query, nil), params)
+		list := model.ToDoList{}
+		if err := json.NewDecoder(res.Body).Decode(&list); err != nil || list.TaskNumber != 3 {
+			t.Fatalf("expected the list with 3 tasks for %q, got %+v (%v)", query, list, err)
+		}
+		if link == "" {
+			if len(list.Tasks) != 3 || res.Header().Get("X-Truncated") != "" {
+				t.Errorf("expected the 3 tasks without truncation for %q, got %d", query, len(list.Tasks))
+			}
+			continue
+		}
+		if len(list.Tasks) != 2 || res.Header().Get("X-Truncated") != "true" || res.Header().Get("X-Total-Count") != "3" ||
+			res.Header().Get("Link") != link {
+			t.Errorf("expected the first 2 tasks and the truncation headers for %q, got %d %v", query, len(list.Tasks), res.Header())
+		}
+	}
+}
+
+func TestGetAllToDoList_names(t *testing.T) {
+	model.CreateToDoList("ControllerListNamed")
+	model.AddTask("ControllerListNamed", "Task1")
+	model.AddTask("ControllerListNamed", "Task2")
+	req := httptest.NewRequest("GET", "/lists/?names=ControllerListMissing,ControllerListNamed&embed=tasks&limit=1", nil)
+	res := httptest.NewRecorder()
+	GetAllToDoList(res, req, nil)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	results := []NamedToDoList{}
+	json.NewDecoder(res.Body).Decode(&results)
+	if len(results) != 2 || results[0].Status != http.StatusNotFound || results[0].List != nil {
+		t.Fatalf("expected the missing list first and not found, got %+v", results)
+	}
+	if results[1].Status != http.StatusOK || results[1].List == nil || results[1].List.Name != "ControllerListNamed" || len(results[1].List.Tasks) != 1 {
+		t.Errorf("expected ControllerListNamed with its first task, got %+v", results[1])
+	}
+}
+
+func TestUpdateToDoList_upsert(t *testing.T) {
+	put := func(name, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/lists/"+name, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		res := httptest.NewRecorder()
+		UpdateToDoList(res, req, httprouter.Params{{Key: "list", Value: name}})
+		return res
+	}
+
+	res := put("ControllerListUpsert", `{"Name": "ControllerListUpsert", "Color": "red"}`, map[string]string{"If-None-Match": "*"})
+	if res.Code != http.StatusCreated || res.Header().Get("Location") != "/lists/ControllerListUpsert/" {
+		t.Fatalf("expected status 201 with the location, got %d: %s", res.Code, res.Body.String())
+	}
+	etag := res.Header().Get("ETag")
+
+	if res = put("ControllerListUpsert", `{"Name": "ControllerListUpsert"}`, map[string]string{"If-None-Match": "*"}); res.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status 412 creating an existing list, got %d", res.Code)
+	}
+	if res = put("ControllerListUpsertMissing", `{"Name": "ControllerListUpsertMissing"}`, map[string]string{"If-Match": "*"}); res.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status 412 updating a missing list, got %d", res.Code)
+	}
+	res = put("ControllerListUpsert", `{"Name": "ControllerListUpsert", "Description": "updated"}`, map[string]string{"If-Match": etag})
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"Color":"red"`) {
+		t.Errorf("expected status 200 keeping the color, got %d: %s", res.Code, res.Body.String())
+	}
+	if res = put("ControllerListUpsert", `{"Name": "ControllerListUpsert"}`, map[string]string{"If-Match": etag}); res.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status 412 with a stale ETag, got %d", res.Code)
+	}
+}
+
+func TestPatchToDoList_jsonPatch(t *testing.T) {
+	model.CreateToDoList("ControllerListJSONPatch2")
+	model.AddTask("ControllerListJSONPatch2", "Task1")
+	params := httprouter.Params{{Key: "list", Value: "ControllerListJSONPatch2"}}
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/lists/ControllerListJSONPatch2/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json-patch+json")
+		res := httptest.NewRecorder()
+		PatchToDoList(res, req, params)
+		return res
+	}
+
+	if res := patch(`[{"op": "remove", "path": "/Tasks/0"}]`); res.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for a managed path, got %d", res.Code)
+	}
+	if res := patch(`[{"op": "add", "path": "/Color", "value": "red"}, {"op": "test", "path": "/Color", "value": "blue"}]`); res.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for a failed test, got %d", res.Code)
+	}
+	if list, _ := model.GetToDoList("ControllerListJSONPatch2"); list.Color != "" {
+		t.Errorf("expected the list untouched, got %+v", list)
+	}
+
+	res := patch(`[{"op": "add", "path": "/Color", "value": "red"}, {"op": "copy", "from": "/Color", "path": "/Description"}]`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if list, _ := model.GetToDoList("ControllerListJSONPatch2"); list.Color != "red" || list.Description != "red" || list.TaskNumber != 1 {
+		t.Errorf("expected the patch applied, got %+v", list)
+	}
+}
+
+func TestCreateToDoList_reservedName_error(t *testing.T) {
+	res := httptest.NewRecorder()
+	CreateToDoList(res, httptest.NewRequest("POST", "/lists/", strings.NewReader(`{"Name": "Bulk-Archive"}`)), nil)
+
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 with a reserved name, got %d: %s", res.Code, res.Body.String())
+	}
+	if _, err := model.GetToDoList("Bulk-Archive"); err == nil {
+		t.Errorf("expected no list created with a reserved name")
+	}
+}
+
+func TestEmptyCollections_emptyArrays(t *testing.T) {
+	model.CreateToDoList("Controller List Empty")
+	params := httprouter.Params{{Key: "list", Value: "Controller List Empty"}}
+	expected := []struct {
+		handle   httprouter.Handle
+		url      string
+		expected string
+	}{
+		{GetAllToDoList, "/lists/", `"Name":"Controller List Empty","Tasks":[]`},
+		{GetToDoList, "/lists/Controller%20List%20Empty/?embed=tasks", `"Tasks":[]`},
+		{GetTasks, "/lists/Controller%20List%20Empty/tasks/", `[]`},
+		{GetTasks, "/lists/Controller%20List%20Empty/tasks/?waiting=true", `[]`},
+		{GetDuplicateTasks, "/lists/Controller%20List%20Empty/tasks/duplicates", `[]`},
+		{GetActivity, "/activity?list=Controller%20List%20Missing", `{"Events":[]}`},
+	}
+	for _, e := range expected {
+		res := httptest.NewRecorder()
+		e.handle(res, httptest.NewRequest("GET", e.url, nil), params)
+		if !strings.Contains(res.Body.String(), e.expected) || strings.Contains(res.Body.String(), "null") {
+			t.Errorf("expected %s from %s, got %s", e.expected, e.url, res.Body.String())
+		}
+	}
+}
+
+func TestSetListNotifications(t *testing.T) {
+	model.CreateToDoList("Controller List Notified")
+	params := httprouter.Params{{Key: "list", Value: "Controller List Notified"}}
+
+	res := httptest.NewRecorder()
+	SetListNotifications(res, httptest.NewRequest("PUT", "/lists/Controller%20List%20Notified/notifications",
+		strings.NewReader(`{"Muted": ["task.updated"]}`)), params)
+	if res.Code != http.StatusOK || strings.TrimSpace(res.Body.String()) != `{"Muted":["task.updated"]}` {
+		t.Errorf("expected task.updated muted, got %d %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	SetListNotifications(res, httptest.NewRequest("PUT", "/lists/Controller%20List%20Notified/notifications",
+		strings.NewReader(`{"Muted": ["task.moved"]}`)), params)
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 with an unknown event type, got %d", res.Code)
+	}
+}
+
+func TestTouch(t *testing.T) {
+	model.CreateToDoListWithTasks("ControllerListTouch", []string{"Report"})
+	before, _ := model.GetTask("ControllerListTouch", "Report")
+	updatedAt := before.UpdatedAt
+
+	res := httptest.NewRecorder()
+	TouchTask(res, httptest.NewRequest("POST", "/lists/ControllerListTouch/tasks/Report/touch", nil),
+		httprouter.Params{{Key: "list", Value: "ControllerListTouch"}, {Key: "task", Value: "Report"}})
+	task := model.Task{}
+	if err := json.NewDecoder(res.Body).Decode(&task); err != nil || res.Code != http.StatusOK || task.UpdatedAt.Before(updatedAt) {
+		t.Errorf("expected the task touched, got %d %v (%v)", res.Code, task, err)
+	}
+
+	res = httptest.NewRecorder()
+	TouchToDoList(res, httptest.NewRequest("POST", "/lists/ControllerListTouch/touch", nil), httprouter.Params{{Key: "list", Value: "ControllerListTouch"}})
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"UpdatedAt"`) {
+		t.Errorf("expected the list touched, got %d %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	TouchToDoList(res, httptest.NewRequest("POST", "/lists/invalid/touch", nil), httprouter.Params{{Key: "list", Value: "invalid"}})
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 with a missing list, got %d", res.Code)
+	}
+	res = httptest.NewRecorder()
+	TouchTask(res, httptest.NewRequest("POST", "/lists/ControllerListTouch/tasks/invalid/touch", nil),
+		httprouter.Params{{Key: "list", Value: "ControllerListTouch"}, {Key: "task", Value: "invalid"}})
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 with a missing task, got %d", res.Code)
+	}
+}
+
+func TestGetAllToDoList_withOpenCount(t *testing.T) {
+	model.CreateToDoListWithTasks("ControllerListOpenCount", []string{"Open", "Done"})
+	model.UpdateTask("ControllerListOpenCount", "Done", "Done", true)
+
+	res := httptest.NewRecorder()
+	GetAllToDoList(res, httptest.NewRequest("GET", "/lists/?withOpenCount=true&limit=1000", nil), nil)
+	lists := []map[string]json.RawMessage{}
+	if err := json.NewDecoder(res.Body).Decode(&lists); err != nil {
+		t.Fatalf("expected the lists, got %v", err)
+	}
+	for _, list := range lists {
+		if string(list["Name"]) != `"ControllerListOpenCount"` {
+			continue
+		}
+		if _, ok := list["Tasks"]; ok || string(list["OpenTasks"]) != "1" || string(list["TaskNumber"]) != "2" {
+			t.Errorf("expected 1 open task out of 2 without the tasks, got %v", list)
+		}
+		return
+	}
+	t.Errorf("expected ControllerListOpenCount listed, got %d lists", len(lists))
+}
+
+func TestDeleteToDoList_onlyIfEmpty(t *testing.T) {
+	model.CreateToDoListWithTasks("ControllerListNotEmpty", []string{"Task1"})
+	model.CreateToDoListWithTasks("ControllerListEmpty", nil)
+
+	res := httptest.NewRecorder()
+	DeleteToDoList(res, httptest.NewRequest("DELETE", "/lists/ControllerListNotEmpty/?onlyIfEmpty=true", nil),
+		httprouter.Params{{Key: "list", Value: "ControllerListNotEmpty"}})
+	if res.Code != http.StatusConflict {
+		t.Errorf("expected status 409 with a list having tasks, got %d", res.Code)
+	}
+	if _, err := model.GetToDoList("ControllerListNotEmpty"); err != nil {
+		t.Errorf("expected the list not deleted, got %v", err)
+	}
+
+	res = httptest.NewRecorder()
+	DeleteToDoList(res, httptest.NewRequest("DELETE", "/lists/ControllerListEmpty/?onlyIfEmpty=true", nil),
+		httprouter.Params{{Key: "list", Value: "ControllerListEmpty"}})
+	if res.Code != http.StatusOK {
+		t.Errorf("expected status 200 with an empty list, got %d %s", res.Code, res.Body.String())
+	}
+	if _, err := model.GetToDoList("ControllerListEmpty"); err == nil {
+		t.Errorf("expected the list deleted, got nil error")
+	}
+
+	res = httptest.NewRecorder()
+	DeleteToDoList(res, httptest.NewRequest("DELETE", "/lists/ControllerListNotEmpty/", nil),
+		httprouter.Params{{Key: "list", Value: "ControllerListNotEmpty"}})
+	if res.Code != http.StatusOK {
+		t.Errorf("expected the unconditional delete to succeed, got %d", res.Code)
+	}
+}
+
+func TestUpdateToDoList_autoSort(t *testing.T) {
+	model.CreateToDoList("ControllerListAutoSort")
+	res := httptest.NewRecorder()
+	UpdateToDoList(res, httptest.NewRequest("PUT", "/lists/ControllerListAutoSort", strings.NewReader(`{"Name": "ControllerListAutoSort", "AutoSort": "priority"}`)),
+		httprouter.Params{{Key: "list", Value: "ControllerListAutoSort"}})
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d %s", res.Code, res.Body.String())
+	}
+	if list, _ := model.GetToDoList("ControllerListAutoSort"); list.AutoSort != model.AutoSortPriority {
+		t.Errorf("expected the list sorted by priority, got %q", list.AutoSort)
+	}
+
+	res = httptest.NewRecorder()
+	UpdateToDoList(res, httptest.NewRequest("PUT", "/lists/ControllerListAutoSort", strings.NewReader(`{"Name": "ControllerListAutoSort", "AutoSort": "title"}`)),
+		httprouter.Params{{Key: "list", Value: "ControllerListAutoSort"}})
+	if res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 with an unknown sorting, got %d", res.Code)
+	}
+}
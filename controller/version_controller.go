@@ -0,0 +1,26 @@
+package controller
+
+import "net/http"
+
+// APIVersion is the version of the API contract, raised on every change of
+// the routes or of the representations the clients may depend on
+const APIVersion = "1"
+
+/*
+	Versioning sets the X-API-Version header of every response to APIVersion, so that
+	the clients can detect a server newer or older than the one they expect.
+
+	Examples:
+
+	   req: GET /lists/
+	   res: 200 X-API-Version: 1
+
+	   req: GET /lists/wronglist/
+	   res: 404 X-API-Version: 1
+*/
+func Versioning(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-API-Version", APIVersion)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestVersioning_header(t *testing.T) {
+	model.CreateToDoList("ControllerListVersion")
+	routes := map[string]httprouter.Handle{
+		"/lists/":                       GetAllToDoList,
+		"/lists/ControllerListVersion/": GetToDoList,
+		"/lists/invalid/":               GetToDoList,
+		"/config":                       GetConfig,
+	}
+	for path, route := range routes {
+		handler := Versioning(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route(w, r, httprouter.Params{{Key: "list", Value: strings.Trim(strings.TrimPrefix(r.URL.Path, "/lists"), "/")}})
+		}))
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		if version := res.Header().Get("X-API-Version"); version != APIVersion {
+			t.Errorf("expected X-API-Version %s on %s (%d), got %q", APIVersion, path, res.Code, version)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/efreddo/v1/todolist/logutils"
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/efreddo/v1/todolist/xlsx"
+	"github.com/julienschmidt/httprouter"
+)
+
+// taskColumns is the header row of the task sheets
+var taskColumns = []string{"Number", "Title", "Done", "Priority", "Description", "Tags", "Due date", "Created at", "Completed at"}
+
+// taskWorkbook streams the tasks of ToDo lists as an xlsx workbook, one
+// sheet per list, the response being started with the first sheet
+type taskWorkbook struct {
+	w       http.ResponseWriter
+	book    *xlsx.Writer
+	loc     *time.Location
+	flusher http.Flusher
+	rows    int
+}
+
+func newTaskWorkbook(w http.ResponseWriter, loc *time.Location, filename string) *taskWorkbook {
+	w.Header().Set("Content-Type", xlsx.ContentType)
+	w.Header().Set("Content-Disposition",
+		mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	flusher, _ := w.(http.Flusher)
+	return &taskWorkbook{w: w, book: xlsx.NewWriter(w), loc: loc, flusher: flusher}
+}
+
+// writeList adds the sheet of the ToDo list, started tells whether anything
+// was written when an error is returned
+func (b *taskWorkbook) writeList(key string) (started bool, err error) {
+	err = model.StreamTasks(key, func(task model.Task) error {
+		if !started {
+			if _, err := b.book.AddSheet(key, taskColumns); err != nil {
+				return err
+			}
+			started = true
+		}
+		return b.writeTask(task)
+	})
+	if err == nil && !started {
+		_, err = b.book.AddSheet(key, taskColumns)
+		started = true
+	}
+	return started, err
+}
+
+func (b *taskWorkbook) writeTask(task model.Task) error {
+	var priority, description interface{}
+	if task.Priority != model.PriorityNone {
+		priority = task.Priority
+	}
+	if task.Description != "" {
+		description = task.Description
+	}
+	err := b.book.WriteRow(task.Number, task.Title, task.Done, priority, description,
+		strings.Join(task.Tags, ", "), b.time(task.DueDate), b.time(&task.CreatedAt), b.time(task.CompletedAt))
+	if err != nil {
+		return err
+	}
+	b.rows++
+	if b.rows%streamFlushTasks == 0 {
+		if err := b.book.Flush(); err != nil {
+			return err
+		}
+		if b.flusher != nil {
+			b.flusher.Flush()
+		}
+	}
+	return nil
+}
+
+// time returns the time in the requested time zone, nil when unset
+func (b *taskWorkbook) time(t *time.Time) interface{} {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.In(b.loc)
+}
+
+func (b *taskWorkbook) close() error {
+	return b.book.Close()
+}
+
+// streamToDoListXLSX streams the tasks of the ToDo list as an xlsx workbook
+func streamToDoListXLSX(w http.ResponseWriter, r *http.Request, key string) {
+	loc, err := requestLocation(r)
+	if err != nil {
+		exportInvalidParameterError(w, "StreamToDoListExport", "tz", err)
+		return
+	}
+	if _, err := model.GetToDoList(key); err != nil {
+		todolistOperationError(w, "StreamToDoListExport", key, err)
+		return
+	}
+
+	book := newTaskWorkbook(w, loc, key+".xlsx")
+	if _, err := book.writeList(key); err == nil {
+		err = book.close()
+	}
+	if err != nil {
+		logutils.Error.Println(fmt.Sprintf(
+			"StreamToDoListExport:: xlsx export of ToDoList '%s' interrupted after %d tasks: %v", key, book.rows, err))
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"StreamToDoListExport:: exported ToDoList '%s' with %d tasks as xlsx", key, book.rows))
+}
+
+/*
+	request type: GET
+	url: /lists/export?format=xlsx&tz=Europe/Rome
+	Returns an xlsx workbook with a sheet for each ToDo list, ordered by name, archived
+	ones included. The sheets have a header row with an auto-filter and a row for each
+	task, its due, creation and completion times being date cells in the tz parameter
+	time zone (default UTC). The workbook is streamed, one task at a time. Lists deleted
+	while the export runs are left out
+
+	Examples:
+
+	   req: GET /lists/export
+	   res: 400 unsupported format, expected xlsx
+
+	   req: GET /lists/export?format=xlsx
+	   res: 200 Content-Type: application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+*/
+func ExportAllToDoListsXLSX(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	if format := r.URL.Query().Get("format"); format != "xlsx" {
+		exportInvalidParameterError(w, "ExportAllToDoListsXLSX", "format",
+			fmt.Errorf("unsupported format %q, expected xlsx", format))
+		return
+	}
+	loc, err := requestLocation(r)
+	if err != nil {
+		exportInvalidParameterError(w, "ExportAllToDoListsXLSX", "tz", err)
+		return
+	}
+	lists, _ := model.GetAllToDoList()
+
+	book := newTaskWorkbook(w, loc, "todolists.xlsx")
+	sheets := 0
+	for _, list := range lists {
+		started, err := book.writeList(list.Name)
+		if err != nil && started {
+			logutils.Error.Println(fmt.Sprintf(
+				"ExportAllToDoListsXLSX:: xlsx export interrupted at ToDoList '%s': %v", list.Name, err))
+			return
+		}
+		if started {
+			sheets++
+		}
+	}
+	if err := book.close(); err != nil {
+		logutils.Error.Println(fmt.Sprintf("ExportAllToDoListsXLSX:: xlsx export not completed: %v", err))
+		return
+	}
+
+	logutils.Info.Println(fmt.Sprintf(
+		"ExportAllToDoListsXLSX:: exported %d ToDo lists with %d tasks as xlsx", sheets, book.rows))
+}
+
+func exportInvalidParameterError(w http.ResponseWriter, caller, name string, err error) {
+	HandleError(w, http.StatusBadRequest, TODOLIST_BADREQUEST, caller,
+		fmt.Sprintf("Invalid parameter %s", name),
+		fmt.Sprintf("Bad request received: %v", err))
+}
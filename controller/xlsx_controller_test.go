@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/efreddo/v1/todolist/model"
+	"github.com/efreddo/v1/todolist/xlsx"
+	"github.com/julienschmidt/httprouter"
+)
+
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Style  int    `xml:"s,attr"`
+	Value  string `xml:"v"`
+	Inline string `xml:"is>t"`
+}
+
+// readXLSX returns the parts of the workbook and the rows of its sheets
+func readXLSX(t *testing.T, b []byte) (map[string]string, [][][]xlsxCell) {
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("expected an xlsx workbook, got %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range r.File {
+		rc, _ := f.Open()
+		content, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+	}
+	var sheets [][][]xlsxCell
+	for i := 1; parts[fmt.Sprintf("xl/worksheets/sheet%d.xml", i)] != ""; i++ {
+		var sheet struct {
+			Rows []struct {
+				Cells []xlsxCell `xml:"c"`
+			} `xml:"sheetData>row"`
+		}
+		if err := xml.Unmarshal([]byte(parts[fmt.Sprintf("xl/worksheets/sheet%d.xml", i)]), &sheet); err != nil {
+			t.Fatalf("expected the sheet %d, got %v", i, err)
+		}
+		rows := make([][]xlsxCell, len(sheet.Rows))
+		for j, row := range sheet.Rows {
+			rows[j] = row.Cells
+		}
+		sheets = append(sheets, rows)
+	}
+	return parts, sheets
+}
+
+func TestStreamToDoListExport_xlsx(t *testing.T) {
+	due := time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC)
+	model.CreateToDoList("ControllerListXLSX")
+	model.AddTaskWithDetails("ControllerListXLSX", "Passport", model.TaskDetails{DueDate: &due, Priority: 2, Tags: []string{"travel", "docs"}})
+	for i := 0; i < 2500; i++ {
+		model.AddTask("ControllerListXLSX", fmt.Sprintf("Task%d", i))
+	}
+	req := httptest.NewRequest("GET", "/lists/ControllerListXLSX/export?format=xlsx&tz=Europe/Rome", nil)
+	res := httptest.NewRecorder()
+	StreamToDoListExport(res, req, httprouter.Params{{Key: "list", Value: "ControllerListXLSX"}})
+
+	if res.Code != http.StatusOK || res.Header().Get("Content-Type") != xlsx.ContentType {
+		t.Fatalf("expected status 200 with an xlsx workbook, got %d: %v", res.Code, res.Header())
+	}
+	parts, sheets := readXLSX(t, res.Body.Bytes())
+	if !strings.Contains(parts["xl/workbook.xml"], `<sheet name="ControllerListXLSX"`) || len(sheets) != 1 {
+		t.Fatalf("expected the sheet of the list, got %s", parts["xl/workbook.xml"])
+	}
+	rows := sheets[0]
+	if len(rows) != 2502 || rows[0][1].Inline != "Title" || rows[0][6].Inline != "Due date" {
+		t.Fatalf("expected the header and 2501 tasks, got %d rows", len(rows))
+	}
+	passport := map[string]xlsxCell{}
+	for _, c := range rows[1] {
+		passport[strings.TrimRight(c.Ref, "0123456789")] = c
+	}
+	if passport["A"].Value != "1" || passport["B"].Inline != "Passport" || passport["C"].Type != "b" || passport["C"].Value != "0" {
+		t.Errorf("expected the number, title and done cells, got %+v", rows[1])
+	}
+	if passport["D"].Value != "2" || passport["F"].Inline != "travel, docs" {
+		t.Errorf("expected the priority and tags cells, got %+v", rows[1])
+	}
+	// 18:00 UTC is 20:00 in Rome on 2024-06-01, the day 45444 of the workbooks
+	if due := passport["G"]; due.Type != "" || due.Style == 0 || !strings.HasPrefix(due.Value, "45444.833") {
+		t.Errorf("expected a date cell for the due date, got %+v", due)
+	}
+	if _, ok := passport["I"]; ok {
+		t.Errorf("expected no completion time, got %+v", passport["I"])
+	}
+	if !strings.Contains(parts["xl/worksheets/sheet1.xml"], `<autoFilter ref="A1:I2502"/>`) {
+		t.Errorf("expected the auto-filter over all the rows")
+	}
+}
+
+func TestStreamToDoListExport_xlsxError(t *testing.T) {
+	export := func(list, query string) int {
+		res := httptest.NewRecorder()
+		StreamToDoListExport(res, httptest.NewRequest("GET", "/lists/"+list+"/export?"+query, nil),
+			httprouter.Params{{Key: "list", Value: list}})
+		return res.Code
+	}
+	model.CreateToDoList("ControllerListXLSXError")
+	if code := export("wronglist", "format=xlsx"); code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", code)
+	}
+	if code := export("ControllerListXLSXError", "format=csv"); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 with an unsupported format, got %d", code)
+	}
+	if code := export("ControllerListXLSXError", "format=xlsx&tz=Unknown/Zone"); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 with an unknown time zone, got %d", code)
+	}
+}
+
+func TestExportAllToDoListsXLSX(t *testing.T) {
+	model.CreateToDoList("ControllerListXLSXAll")
+	model.AddTask("ControllerListXLSXAll", "Task1")
+
+	res := httptest.NewRecorder()
+	ExportAllToDoListsXLSX(res, httptest.NewRequest("GET", "/lists/export", nil), nil)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without the xlsx format, got %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	ExportAllToDoListsXLSX(res, httptest.NewRequest("GET", "/lists/export?format=xlsx", nil), nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	parts, sheets := readXLSX(t, res.Body.Bytes())
+	lists, _ := model.GetAllToDoList()
+	if len(sheets) != len(lists) || !strings.Contains(parts["xl/workbook.xml"], `<sheet name="ControllerListXLSXAll"`) {
+		t.Errorf("expected a sheet for each of the %d lists, got %d", len(lists), len(sheets))
+	}
+}
@@ -0,0 +1,164 @@
+// Package dateutils parses the dates received by the server.
+//
+// Strict mode accepts RFC 3339 timestamps (2024-06-01T18:00:00Z) and ISO
+// dates (2024-06-01). Lenient mode additionally accepts epoch seconds and a
+// small set of unambiguous formats. Day/month numeric dates (01/06/2024) are
+// accepted only when a single interpretation is possible, they are never
+// guessed.
+package dateutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode selects the formats accepted by Parse
+type Mode int
+
+const (
+	Strict Mode = iota
+	Lenient
+)
+
+// defaultMode is the mode used when the request does not select one
+var defaultMode = Strict
+
+// SetDefaultMode sets the parsing mode used when the request does not select one
+func SetDefaultMode(mode Mode) {
+	defaultMode = mode
+}
+
+// DefaultMode returns the parsing mode used when the request does not select one
+func DefaultMode() Mode {
+	return defaultMode
+}
+
+// ParseMode parses a mode name, strict or lenient
+func ParseMode(name string) (Mode, error) {
+	switch strings.ToLower(name) {
+	case "strict":
+		return Strict, nil
+	case "lenient":
+		return Lenient, nil
+	}
+	return Strict, fmt.Errorf("unknown date parsing mode %s, expected strict or lenient", name)
+}
+
+// layouts accepted in lenient mode, besides the strict ones. Dates without a
+// time zone are interpreted in the location given to Parse.
+var lenientLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Jan 2 2006",
+	"Jan 2, 2006",
+	"January 2 2006",
+	"January 2, 2006",
+	"02-Jan-2006",
+}
+
+var (
+	epoch   = regexp.MustCompile(`^-?[0-9]+$`)
+	numeric = regexp.MustCompile(`^([0-9]{1,2})[/.-]([0-9]{1,2})[/.-]([0-9]{4})$`)
+)
+
+// Parse parses the date with the given mode, dates without a time zone being
+// interpreted in loc
+func Parse(s string, mode Mode, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return t, nil
+	}
+	if mode != Lenient {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected RFC 3339 (2024-06-01T18:00:00Z) or ISO date (2024-06-01), other formats are accepted in lenient mode only", s)
+	}
+
+	if epoch.MatchString(s) {
+		seconds, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid epoch timestamp %q", s)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	if m := numeric.FindStringSubmatch(s); m != nil {
+		return parseNumeric(s, m, loc)
+	}
+	for _, layout := range lenientLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, unrecognized format", s)
+}
+
+// parseNumeric parses a dd/mm/yyyy or mm/dd/yyyy date, only when exactly one
+// of the two interpretations is a valid date
+func parseNumeric(s string, m []string, loc *time.Location) (time.Time, error) {
+	first, _ := strconv.Atoi(m[1])
+	second, _ := strconv.Atoi(m[2])
+	year, _ := strconv.Atoi(m[3])
+
+	dayMonth, dayMonthOk := date(year, second, first, loc)
+	monthDay, monthDayOk := date(year, first, second, loc)
+	switch {
+	case dayMonthOk && monthDayOk && !dayMonth.Equal(monthDay):
+		return time.Time{}, fmt.Errorf("ambiguous date %q, it could be read as %s (day/month) or %s (month/day): use the ISO format 2006-01-02",
+			s, dayMonth.Format("2006-01-02"), monthDay.Format("2006-01-02"))
+	case dayMonthOk:
+		return dayMonth, nil
+	case monthDayOk:
+		return monthDay, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, no valid day and month", s)
+}
+
+// date returns the date, ok being false when day or month are out of range
+func date(year, month, day int, loc *time.Location) (time.Time, bool) {
+	if month < 1 || month > 12 || day < 1 {
+		return time.Time{}, false
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+	return t, t.Day() == day
+}
+
+// Raw is a date received in a JSON document, as a string or as a number
+// (epoch seconds), to be parsed with Parse
+type Raw string
+
+// UnmarshalJSON accepts JSON strings and numbers
+func (r *Raw) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*r = Raw(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("invalid date %s, string or number expected", b)
+	}
+	*r = Raw(n.String())
+	return nil
+}
+
+// Parse parses the received date, nil when not received
+func (r *Raw) Parse(mode Mode, loc *time.Location) (*time.Time, error) {
+	if r == nil {
+		return nil, nil
+	}
+	t, err := Parse(string(*r), mode, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
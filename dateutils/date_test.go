@@ -0,0 +1,92 @@
+package dateutils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_strict_ok(t *testing.T) {
+	cases := map[string]time.Time{
+		"2024-06-01T18:00:00Z":      time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC),
+		"2024-06-01T20:00:00+02:00": time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC),
+		"2024-06-01":                time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for s, expected := range cases {
+		got, err := Parse(s, Strict, time.UTC)
+		if err != nil {
+			t.Errorf("no error expected for %s, got %v", s, err)
+		}
+		if !got.Equal(expected) {
+			t.Errorf("expected %v for %s, got %v", expected, s, got)
+		}
+	}
+}
+
+func TestParse_strict_error(t *testing.T) {
+	for _, s := range []string{"1717264800", "2024/06/01", "13/06/2024", "Jun 1, 2024", ""} {
+		if _, err := Parse(s, Strict, time.UTC); err == nil {
+			t.Errorf("expected error in strict mode for %q, got nil", s)
+		}
+	}
+}
+
+func TestParse_lenient_ok(t *testing.T) {
+	rome := time.FixedZone("CEST", 2*60*60)
+	cases := map[string]time.Time{
+		"2024-06-01":          time.Date(2024, 6, 1, 0, 0, 0, 0, rome),
+		"1717264800":          time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC),
+		"2024/06/01":          time.Date(2024, 6, 1, 0, 0, 0, 0, rome),
+		"2024-06-01 20:00":    time.Date(2024, 6, 1, 20, 0, 0, 0, rome),
+		"2024-06-01T20:00:00": time.Date(2024, 6, 1, 20, 0, 0, 0, rome),
+		"1 Jun 2024":          time.Date(2024, 6, 1, 0, 0, 0, 0, rome),
+		"June 1, 2024":        time.Date(2024, 6, 1, 0, 0, 0, 0, rome),
+		"13/06/2024":          time.Date(2024, 6, 13, 0, 0, 0, 0, rome),
+		"06/13/2024":          time.Date(2024, 6, 13, 0, 0, 0, 0, rome),
+		"01/01/2024":          time.Date(2024, 1, 1, 0, 0, 0, 0, rome),
+	}
+	for s, expected := range cases {
+		got, err := Parse(s, Lenient, rome)
+		if err != nil {
+			t.Errorf("no error expected for %s, got %v", s, err)
+		}
+		if !got.Equal(expected) {
+			t.Errorf("expected %v for %s, got %v", expected, s, got)
+		}
+	}
+}
+
+func TestParse_lenientAmbiguous_error(t *testing.T) {
+	_, err := Parse("01/06/2024", Lenient, time.UTC)
+	if err == nil {
+		t.Fatalf("expected ambiguous date error, got nil")
+	}
+	if !strings.Contains(err.Error(), "ambiguous") || !strings.Contains(err.Error(), "2024-06-01") || !strings.Contains(err.Error(), "2024-01-06") {
+		t.Errorf("expected the error to explain both interpretations, got %v", err)
+	}
+}
+
+func TestParse_lenientInvalid_error(t *testing.T) {
+	for _, s := range []string{"31/02/2024", "13/13/2024", "tomorrow", "2024-13-01"} {
+		if _, err := Parse(s, Lenient, time.UTC); err == nil {
+			t.Errorf("expected error for %q, got nil", s)
+		}
+	}
+}
+
+func TestRaw_ok(t *testing.T) {
+	req := struct{ A, B, C *Raw }{}
+	if err := json.Unmarshal([]byte(`{"A": "2024-06-01", "B": 1717264800}`), &req); err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if *req.A != "2024-06-01" || *req.B != "1717264800" || req.C != nil {
+		t.Errorf("expected raw dates 2024-06-01, 1717264800 and nil, got %v, %v, %v", *req.A, *req.B, req.C)
+	}
+	if d, err := req.C.Parse(Strict, time.UTC); d != nil || err != nil {
+		t.Errorf("expected nil date for a missing field, got %v, %v", d, err)
+	}
+	if _, err := req.B.Parse(Strict, time.UTC); err == nil {
+		t.Errorf("expected epoch rejected in strict mode, got nil")
+	}
+}
@@ -0,0 +1,62 @@
+package dateutils
+
+import (
+	"fmt"
+	"time"
+)
+
+// Humanize returns t relative to now in words, e.g. "in 5 minutes", "3 hours
+// ago", "tomorrow" or "in 2 months". Beyond a day the difference is counted
+// in calendar days in the location of now.
+func Humanize(t, now time.Time) string {
+	d := t.Sub(now)
+	if d < 0 {
+		d = -d
+	}
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return relative(int(d/time.Minute), "minute", t.After(now))
+	case d < 24*time.Hour:
+		return relative(int(d/time.Hour), "hour", t.After(now))
+	}
+
+	local := t.In(now.Location())
+	days := int(civilDay(local).Sub(civilDay(now)).Hours() / 24)
+	switch {
+	case days == 1:
+		return "tomorrow"
+	case days == -1:
+		return "yesterday"
+	}
+	n := days
+	if n < 0 {
+		n = -n
+	}
+	switch {
+	case n >= 365:
+		return relative(n/365, "year", days > 0)
+	case n >= 30:
+		return relative(n/30, "month", days > 0)
+	}
+	return relative(n, "day", days > 0)
+}
+
+// relative returns "in n units" for the future, "n units ago" for the past
+func relative(n int, unit string, future bool) string {
+	if n != 1 {
+		unit += "s"
+	}
+	if future {
+		return fmt.Sprintf("in %d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %s ago", n, unit)
+}
+
+// civilDay returns the day of t as a UTC midnight, for counting calendar days
+// regardless of the daylight saving changes
+func civilDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,41 @@
+package dateutils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanize_ok(t *testing.T) {
+	rome, _ := time.LoadLocation("Europe/Rome")
+	now := time.Date(2024, 6, 5, 22, 0, 0, 0, rome)
+	cases := map[time.Time]string{
+		now.Add(20 * time.Second):                     "just now",
+		now.Add(-time.Minute):                         "1 minute ago",
+		now.Add(5 * time.Minute):                      "in 5 minutes",
+		now.Add(-3 * time.Hour):                       "3 hours ago",
+		now.Add(23 * time.Hour):                       "in 23 hours",
+		now.Add(-30 * time.Hour):                      "yesterday",
+		time.Date(2024, 6, 7, 1, 0, 0, 0, rome):       "in 2 days",
+		time.Date(2024, 5, 1, 9, 0, 0, 0, rome):       "1 month ago",
+		time.Date(2026, 6, 6, 9, 0, 0, 0, rome):       "in 2 years",
+		time.Date(2024, 6, 7, 21, 30, 0, 0, time.UTC): "in 2 days",
+	}
+	for at, expected := range cases {
+		if got := Humanize(at, now); got != expected {
+			t.Errorf("expected %q for %s, got %q", expected, at, got)
+		}
+	}
+}
+
+func TestHumanize_timeZone(t *testing.T) {
+	// 00:30 on June 7th in Rome is still June 6th in UTC
+	at := time.Date(2024, 6, 6, 22, 30, 0, 0, time.UTC)
+	now := time.Date(2024, 6, 5, 20, 0, 0, 0, time.UTC)
+	rome, _ := time.LoadLocation("Europe/Rome")
+	if got := Humanize(at, now); got != "tomorrow" {
+		t.Errorf("expected tomorrow in UTC, got %q", got)
+	}
+	if got := Humanize(at, now.In(rome)); got != "in 2 days" {
+		t.Errorf("expected in 2 days in Rome, got %q", got)
+	}
+}
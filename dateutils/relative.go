@@ -0,0 +1,107 @@
+package dateutils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Relative is a date rule relative to the day it is evaluated, e.g.
+// "tomorrow", "in 3 days", "next friday" or "first of next month"
+type Relative struct {
+	rule    string
+	days    int
+	months  int
+	weekday *time.Weekday
+	// monthDay selects the first (1) or last (-1) day of the month, 0 keeps the day
+	monthDay int
+}
+
+var (
+	relativeIn       = regexp.MustCompile(`^in ([0-9]{1,3}) (day|week|month)s?$`)
+	relativeNext     = regexp.MustCompile(`^next ([a-z]+)$`)
+	relativeMonthDay = regexp.MustCompile(`^(first|last) (?:day )?of (this|next) month$`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// ParseRelative parses a relative date rule: today, tomorrow, in N
+// days|weeks|months, next <weekday>, first|last of this|next month
+func ParseRelative(s string) (Relative, error) {
+	rule := strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	r := Relative{rule: rule}
+	switch {
+	case rule == "today":
+		return r, nil
+	case rule == "tomorrow":
+		r.days = 1
+		return r, nil
+	}
+	if m := relativeIn.FindStringSubmatch(rule); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "day":
+			r.days = n
+		case "week":
+			r.days = 7 * n
+		case "month":
+			r.months = n
+		}
+		return r, nil
+	}
+	if m := relativeNext.FindStringSubmatch(rule); m != nil {
+		if weekday, ok := weekdays[m[1]]; ok {
+			r.weekday = &weekday
+			return r, nil
+		}
+	}
+	if m := relativeMonthDay.FindStringSubmatch(rule); m != nil {
+		r.monthDay = 1
+		if m[1] == "last" {
+			r.monthDay = -1
+		}
+		if m[2] == "next" {
+			r.months = 1
+		}
+		return r, nil
+	}
+	return Relative{}, fmt.Errorf("invalid relative date %q, expected today, tomorrow, in N days|weeks|months, next <weekday> or first|last of this|next month", s)
+}
+
+// From returns the start of the day selected by the rule, t giving the
+// reference day and the location
+func (r Relative) From(t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch {
+	case r.weekday != nil:
+		ahead := (int(*r.weekday)-int(t.Weekday())+6)%7 + 1
+		return time.Date(year, month, day+ahead, 0, 0, 0, 0, t.Location())
+	case r.monthDay == 1:
+		return time.Date(year, month+time.Month(r.months), 1, 0, 0, 0, 0, t.Location())
+	case r.monthDay == -1:
+		return time.Date(year, month+time.Month(r.months)+1, 0, 0, 0, 0, 0, t.Location())
+	case r.months > 0:
+		// the day is clamped to the end of shorter months
+		last := time.Date(year, month+time.Month(r.months)+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		if day > last {
+			day = last
+		}
+		return time.Date(year, month+time.Month(r.months), day, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(year, month, day+r.days, 0, 0, 0, 0, t.Location())
+}
+
+// String returns the normalized rule
+func (r Relative) String() string {
+	return r.rule
+}
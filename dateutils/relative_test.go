@@ -0,0 +1,42 @@
+package dateutils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelative_ok(t *testing.T) {
+	rome, _ := time.LoadLocation("Europe/Rome")
+	// a Wednesday, late in the evening
+	from := time.Date(2024, 1, 31, 23, 30, 0, 0, rome)
+	cases := map[string]time.Time{
+		"today":                  time.Date(2024, 1, 31, 0, 0, 0, 0, rome),
+		"Tomorrow":               time.Date(2024, 2, 1, 0, 0, 0, 0, rome),
+		"in 3 days":              time.Date(2024, 2, 3, 0, 0, 0, 0, rome),
+		"in 1 week":              time.Date(2024, 2, 7, 0, 0, 0, 0, rome),
+		"in 1 month":             time.Date(2024, 2, 29, 0, 0, 0, 0, rome),
+		"next friday":            time.Date(2024, 2, 2, 0, 0, 0, 0, rome),
+		"next wednesday":         time.Date(2024, 2, 7, 0, 0, 0, 0, rome),
+		"first of next month":    time.Date(2024, 2, 1, 0, 0, 0, 0, rome),
+		"last day of this month": time.Date(2024, 1, 31, 0, 0, 0, 0, rome),
+		"last of  next month":    time.Date(2024, 2, 29, 0, 0, 0, 0, rome),
+	}
+	for rule, expected := range cases {
+		r, err := ParseRelative(rule)
+		if err != nil {
+			t.Errorf("no error expected for %q, got %v", rule, err)
+			continue
+		}
+		if got := r.From(from); !got.Equal(expected) {
+			t.Errorf("expected %v for %q, got %v", expected, rule, got)
+		}
+	}
+}
+
+func TestRelative_error(t *testing.T) {
+	for _, rule := range []string{"", "yesterday", "next month", "in two days", "first of last month"} {
+		if _, err := ParseRelative(rule); err == nil {
+			t.Errorf("error expected for %q", rule)
+		}
+	}
+}
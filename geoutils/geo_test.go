@@ -0,0 +1,54 @@
+package geoutils
+
+import (
+	"math"
+	"testing"
+)
+
+func assertDistance(t *testing.T, expected, got, tolerance float64) {
+	if math.Abs(expected-got) > tolerance {
+		t.Errorf("expected distance %.1f m, got %.1f m", expected, got)
+	}
+}
+
+func TestValidCoordinates_error(t *testing.T) {
+	for _, c := range [][2]float64{{90.1, 0}, {-90.1, 0}, {0, 180.1}, {0, -180.1}, {math.NaN(), 0}} {
+		if err := ValidCoordinates(c[0], c[1]); err == nil {
+			t.Errorf("expected invalid coordinates error for %v, got nil", c)
+		}
+	}
+}
+
+func TestValidCoordinates_ok(t *testing.T) {
+	for _, c := range [][2]float64{{90, 180}, {-90, -180}, {0, 0}, {41.9, 12.5}} {
+		if err := ValidCoordinates(c[0], c[1]); err != nil {
+			t.Errorf("no error expected for %v, got %v", c, err)
+		}
+	}
+}
+
+func TestDistance_samePoint_ok(t *testing.T) {
+	assertDistance(t, 0, Distance(41.9028, 12.4964, 41.9028, 12.4964), 0.001)
+}
+
+func TestDistance_knownCities_ok(t *testing.T) {
+	// Rome - Milan, about 477 km
+	assertDistance(t, 477000, Distance(41.9028, 12.4964, 45.4642, 9.1900), 2000)
+}
+
+func TestDistance_antimeridian_ok(t *testing.T) {
+	// 0.2 degrees of longitude on the equator, across the antimeridian
+	expected := 0.2 * math.Pi / 180 * EarthRadius
+	assertDistance(t, expected, Distance(0, 179.9, 0, -179.9), 0.1)
+	assertDistance(t, expected, Distance(0, -179.9, 0, 179.9), 0.1)
+}
+
+func TestDistance_poles_ok(t *testing.T) {
+	// all the longitudes meet at the poles
+	assertDistance(t, 0, Distance(90, 0, 90, 180), 0.001)
+	assertDistance(t, 0, Distance(-90, -45, -90, 120), 0.001)
+	// pole to pole is half a great circle
+	assertDistance(t, math.Pi*EarthRadius, Distance(90, 0, -90, 0), 0.1)
+	// antipodal points on the equator
+	assertDistance(t, math.Pi*EarthRadius, Distance(0, 0, 0, 180), 0.1)
+}
@@ -0,0 +1,200 @@
+// Package ipfilter rejects the requests whose client address is not allowed.
+//
+// The client address is the direct peer, or the address reported in the
+// X-Forwarded-For header when the peer is a trusted proxy: the header is read
+// from the right, skipping the trusted proxies, so that a client can not
+// spoof its address by sending the header itself. Deny rules take precedence
+// over allow rules, and an empty allow list allows every address.
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/efreddo/v1/todolist/logutils"
+)
+
+// Rules are the CIDRs (or single addresses) of the filter, IPv4 or IPv6
+type Rules struct {
+	Allow          []string
+	Deny           []string
+	TrustedProxies []string
+}
+
+// LogInterval is the minimum interval between two logs of the requests denied
+// to the same address
+var LogInterval = time.Minute
+
+var (
+	lock    sync.RWMutex
+	rules   Rules
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	trusted []*net.IPNet
+
+	logLock    sync.Mutex
+	lastLogs   = map[string]time.Time{}
+	suppressed = map[string]int{}
+)
+
+// SetRules replaces the rules of the filter, the current ones being kept when
+// a rule is invalid
+func SetRules(r Rules) error {
+	a, err := parseNets(r.Allow)
+	if err != nil {
+		return err
+	}
+	d, err := parseNets(r.Deny)
+	if err != nil {
+		return err
+	}
+	t, err := parseNets(r.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	rules, allow, deny, trusted = r, a, d, t
+	return nil
+}
+
+// CurrentRules returns the rules of the filter
+func CurrentRules() Rules {
+	lock.RLock()
+	defer lock.RUnlock()
+	return Rules{nonNil(rules.Allow), nonNil(rules.Deny), nonNil(rules.TrustedProxies)}
+}
+
+// nonNil returns the rules, an empty slice rather than nil
+func nonNil(rules []string) []string {
+	if rules == nil {
+		return []string{}
+	}
+	return rules
+}
+
+// Middleware rejects with 403 the requests not allowed by the rules, before
+// they reach next
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := ClientIP(r)
+		if !Allowed(ip) {
+			logDenied(ip, r)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIP returns the address of the client of the request, nil when it
+// can not be determined
+func ClientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+
+	lock.RLock()
+	defer lock.RUnlock()
+	if ip == nil || !contains(trusted, ip) {
+		return ip
+	}
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			// not an address, the hops on its left can not be trusted
+			return ip
+		}
+		ip = hop
+		if !contains(trusted, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// Allowed reports whether the rules allow the address
+func Allowed(ip net.IP) bool {
+	lock.RLock()
+	defer lock.RUnlock()
+	if ip == nil {
+		return len(allow) == 0 && len(deny) == 0
+	}
+	if contains(deny, ip) {
+		return false
+	}
+	return len(allow) == 0 || contains(allow, ip)
+}
+
+// logDenied logs the denied request, at most once every LogInterval for the
+// same address, reporting the number of requests not logged in between
+func logDenied(ip net.IP, r *http.Request) {
+	source := r.RemoteAddr
+	if ip != nil {
+		source = ip.String()
+	}
+	logLock.Lock()
+	defer logLock.Unlock()
+	current := time.Now()
+	if last, ok := lastLogs[source]; ok && current.Sub(last) < LogInterval {
+		suppressed[source]++
+		return
+	}
+	if len(lastLogs) > 10000 {
+		// forget the addresses not seen recently rather than growing forever
+		for s, last := range lastLogs {
+			if current.Sub(last) >= LogInterval {
+				delete(lastLogs, s)
+				delete(suppressed, s)
+			}
+		}
+	}
+	logutils.Warning.Println(fmt.Sprintf(
+		"ipfilter:: denied %s %s from %s (peer %s), %d more requests denied since the last log",
+		r.Method, r.URL.Path, source, r.RemoteAddr, suppressed[source]))
+	lastLogs[source] = current
+	suppressed[source] = 0
+}
+
+func parseNets(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %s", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			cidr = fmt.Sprintf("%s/%d", cidr, bits)
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %s", cidr)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package ipfilter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/efreddo/v1/todolist/logutils"
+)
+
+func TestMain(m *testing.M) {
+	logutils.InitLogs(ioutil.Discard, ioutil.Discard, ioutil.Discard, ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+func request(peer string, forwardedFor ...string) *http.Request {
+	r := httptest.NewRequest("GET", "/lists/", nil)
+	r.RemoteAddr = peer
+	for _, f := range forwardedFor {
+		r.Header.Add("X-Forwarded-For", f)
+	}
+	return r
+}
+
+func TestSetRules_invalid_error(t *testing.T) {
+	SetRules(Rules{Allow: []string{"10.0.0.0/8"}})
+	for _, r := range []Rules{{Allow: []string{"10.0.0.0/33"}}, {Deny: []string{"not an address"}}, {TrustedProxies: []string{"fe80::/129"}}} {
+		if err := SetRules(r); err == nil {
+			t.Errorf("expected invalid rule error for %v, got nil", r)
+		}
+	}
+	if current := CurrentRules(); len(current.Allow) != 1 || current.Allow[0] != "10.0.0.0/8" {
+		t.Errorf("expected the current rules kept, got %v", current)
+	}
+}
+
+func TestCurrentRules_emptyArrays(t *testing.T) {
+	SetRules(Rules{Allow: []string{"10.0.0.0/8"}})
+	defer SetRules(Rules{})
+
+	b, _ := json.Marshal(CurrentRules())
+	if string(b) != `{"Allow":["10.0.0.0/8"],"Deny":[],"TrustedProxies":[]}` {
+		t.Errorf("expected empty arrays for the missing rules, got %s", b)
+	}
+}
+
+func TestAllowed_ok(t *testing.T) {
+	SetRules(Rules{
+		Allow: []string{"192.168.1.0/24", "2001:db8::/32"},
+		Deny:  []string{"192.168.1.13", "2001:db8:bad::/48"}})
+	defer SetRules(Rules{})
+
+	expected := map[string]bool{
+		"192.168.1.10:1234":       true,
+		"192.168.1.13:1234":       false,
+		"10.0.0.1:1234":           false,
+		"[2001:db8::1]:1234":      true,
+		"[2001:db8:bad::1]:1234":  false,
+		"[::ffff:192.168.1.10]:1": true,
+	}
+	for peer, allowed := range expected {
+		if got := Allowed(ClientIP(request(peer))); got != allowed {
+			t.Errorf("expected %s allowed=%t, got %t", peer, allowed, got)
+		}
+	}
+}
+
+func TestClientIP_trustedProxy_ok(t *testing.T) {
+	SetRules(Rules{TrustedProxies: []string{"10.0.0.0/8"}})
+	defer SetRules(Rules{})
+
+	expected := []struct {
+		r    *http.Request
+		ip   string
+		desc string
+	}{
+		{request("203.0.113.7:1234", "198.51.100.1"), "203.0.113.7", "untrusted peer, header ignored"},
+		{request("10.0.0.2:1234", "198.51.100.1"), "198.51.100.1", "trusted peer, header used"},
+		{request("10.0.0.2:1234", "198.51.100.1, 10.0.0.3"), "198.51.100.1", "chain of trusted proxies"},
+		{request("10.0.0.2:1234", "192.168.1.10", "198.51.100.1"), "198.51.100.1", "spoofed leftmost hop"},
+		{request("10.0.0.2:1234", "garbage, 198.51.100.1"), "198.51.100.1", "invalid leftmost hop"},
+		{request("10.0.0.2:1234", "198.51.100.1, garbage"), "10.0.0.2", "invalid rightmost hop"},
+	}
+	for _, e := range expected {
+		if got := ClientIP(e.r); got.String() != e.ip {
+			t.Errorf("%s: expected client %s, got %s", e.desc, e.ip, got)
+		}
+	}
+}
+
+func TestMiddleware_spoofedForwardedFor_forbidden(t *testing.T) {
+	SetRules(Rules{Allow: []string{"192.168.1.0/24"}, TrustedProxies: []string{"10.0.0.0/8"}})
+	defer SetRules(Rules{})
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// a client sending the header itself is not trusted
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, request("203.0.113.7:1234", "192.168.1.10"))
+	if res.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for a spoofed header, got %d", res.Code)
+	}
+	// the header forwarded by the proxy ends with the real client
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, request("10.0.0.2:1234", "192.168.1.10, 203.0.113.7"))
+	if res.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for a spoofed hop through the proxy, got %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, request("10.0.0.2:1234", "192.168.1.10"))
+	if res.Code != http.StatusOK {
+		t.Errorf("expected status 200 for an allowed client behind the proxy, got %d", res.Code)
+	}
+}
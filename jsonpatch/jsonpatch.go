@@ -0,0 +1,375 @@
+// Package jsonpatch applies JSON Patch documents (RFC 6902) to JSON
+// documents.
+//
+// A patch is a sequence of add, remove, replace, move, copy and test
+// operations whose locations are JSON Pointers (RFC 6901). The patch is
+// applied as a whole: when an operation fails the original document is left
+// untouched and an error is returned, ErrTestFailed for a failed test.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrTestFailed is returned when the value of a test operation does not
+// match the document
+var ErrTestFailed = errors.New("test operation failed")
+
+// Operation is a single operation of a patch, Value being the raw JSON value
+// of add, replace and test
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is a JSON Patch document, its operations being applied in order
+type Patch []Operation
+
+// Decode reads a patch and checks that its operations are well formed
+func Decode(r io.Reader) (Patch, error) {
+	var members []map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&members); err != nil {
+		return nil, fmt.Errorf("invalid JSON Patch document: %v", err)
+	}
+	patch := make(Patch, len(members))
+	for i, m := range members {
+		op := &patch[i]
+		for name, field := range map[string]*string{"op": &op.Op, "path": &op.Path, "from": &op.From} {
+			if raw, ok := m[name]; ok {
+				if err := json.Unmarshal(raw, field); err != nil {
+					return nil, fmt.Errorf("operation %d: invalid %s: %v", i, name, err)
+				}
+			}
+		}
+		op.Value = m["value"]
+		if _, ok := m["path"]; !ok {
+			return nil, fmt.Errorf("operation %d: missing path", i)
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if len(op.Value) == 0 {
+				return nil, fmt.Errorf("operation %d: missing value", i)
+			}
+		case "move", "copy":
+			if _, ok := m["from"]; !ok {
+				return nil, fmt.Errorf("operation %d: missing from", i)
+			}
+			if _, err := pointer(op.From); err != nil {
+				return nil, fmt.Errorf("operation %d: invalid from: %v", i, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("operation %d: unknown op %q", i, op.Op)
+		}
+		if _, err := pointer(op.Path); err != nil {
+			return nil, fmt.Errorf("operation %d: invalid path: %v", i, err)
+		}
+	}
+	return patch, nil
+}
+
+// Apply applies the patch to the JSON document and returns the patched
+// document
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(doc))
+	d.UseNumber()
+	var root interface{}
+	if err := d.Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %v", err)
+	}
+	for i, op := range p {
+		var err error
+		if root, err = op.apply(root); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// Modifies tells whether an operation of the patch changes the location, a
+// location below it or one of its ancestors
+func (p Patch) Modifies(location string) bool {
+	for _, op := range p {
+		switch op.Op {
+		case "test":
+			continue
+		case "move":
+			if overlaps(op.From, location) {
+				return true
+			}
+		}
+		if overlaps(op.Path, location) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps tells whether one of the locations contains the other
+func overlaps(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/") || a == "" || b == ""
+}
+
+func (op Operation) apply(root interface{}) (interface{}, error) {
+	path, _ := pointer(op.Path)
+	switch op.Op {
+	case "add":
+		value, err := decodeValue(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	case "remove":
+		return remove(root, path)
+	case "replace":
+		value, err := decodeValue(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		if root, err = remove(root, path); err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	case "move":
+		if op.From == op.Path {
+			return root, nil
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("a location can not be moved into one of its children")
+		}
+		from, _ := pointer(op.From)
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if root, err = remove(root, from); err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	case "copy":
+		from, _ := pointer(op.From)
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, deepCopy(value))
+	case "test":
+		value, err := decodeValue(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		current, err := get(root, path)
+		if err != nil || !equal(current, value) {
+			return nil, ErrTestFailed
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// pointer splits a JSON Pointer in its unescaped reference tokens
+func pointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("JSON Pointer %q must start with /", s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+// get returns the value at the location
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch c := doc.(type) {
+		case map[string]interface{}:
+			value, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := index(token, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, fmt.Errorf("member %q not found, not an object or array", token)
+		}
+	}
+	return doc, nil
+}
+
+// add adds the value at the location, inserting it in arrays, and returns
+// the resulting document
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			i := len(c)
+			if token != "-" {
+				var err error
+				if i, err = index(token, len(c)); err != nil {
+					return nil, err
+				}
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, fmt.Errorf("can not add %q, not an object or array", token)
+	})
+}
+
+// remove removes the value at the location, which must exist, and returns
+// the resulting document
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("the whole document can not be removed")
+	}
+	return update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := c[token]; !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			delete(c, token)
+			return c, nil
+		case []interface{}:
+			i, err := index(token, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("can not remove %q, not an object or array", token)
+	})
+}
+
+// update applies leaf to the parent of the location, path having at least
+// one token, and returns the document with the parent replaced by the result
+func update(doc interface{}, path []string, leaf func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return leaf(doc, path[0])
+	}
+	child, err := get(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	if child, err = update(child, path[1:], leaf); err != nil {
+		return nil, err
+	}
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		c[path[0]] = child
+	case []interface{}:
+		i, _ := index(path[0], len(c)-1)
+		c[i] = child
+	}
+	return doc, nil
+}
+
+// index parses an array index, which must not exceed max
+func index(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of bounds", i)
+	}
+	return i, nil
+}
+
+func decodeValue(raw json.RawMessage) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid value: %v", err)
+	}
+	return value, nil
+}
+
+// equal compares JSON values, numbers by their numeric value
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, errx := x.Float64()
+		fy, erry := y.Float64()
+		return errx == nil && erry == nil && fx == fy
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			if w, ok := y[k]; !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(x))
+		for k, v := range x {
+			c[k] = deepCopy(v)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(x))
+		for i, v := range x {
+			c[i] = deepCopy(v)
+		}
+		return c
+	}
+	return v
+}
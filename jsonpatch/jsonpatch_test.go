@@ -0,0 +1,163 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// examples of RFC 6902, Appendix A
+var examples = []struct {
+	name     string
+	doc      string
+	patch    string
+	expected string
+}{
+	{"A.1 adding an object member",
+		`{"foo": "bar"}`,
+		`[{"op": "add", "path": "/baz", "value": "qux"}]`,
+		`{"baz": "qux", "foo": "bar"}`},
+	{"A.2 adding an array element",
+		`{"foo": ["bar", "baz"]}`,
+		`[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+		`{"foo": ["bar", "qux", "baz"]}`},
+	{"A.3 removing an object member",
+		`{"baz": "qux", "foo": "bar"}`,
+		`[{"op": "remove", "path": "/baz"}]`,
+		`{"foo": "bar"}`},
+	{"A.4 removing an array element",
+		`{"foo": ["bar", "qux", "baz"]}`,
+		`[{"op": "remove", "path": "/foo/1"}]`,
+		`{"foo": ["bar", "baz"]}`},
+	{"A.5 replacing a value",
+		`{"baz": "qux", "foo": "bar"}`,
+		`[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+		`{"baz": "boo", "foo": "bar"}`},
+	{"A.6 moving a value",
+		`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+		`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+		`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`},
+	{"A.7 moving an array element",
+		`{"foo": ["all", "grass", "cows", "eat"]}`,
+		`[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+		`{"foo": ["all", "cows", "eat", "grass"]}`},
+	{"A.8 testing a value: success",
+		`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+		`{"baz": "qux", "foo": ["a", 2, "c"]}`},
+	{"A.10 adding a nested member object",
+		`{"foo": "bar"}`,
+		`[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+		`{"foo": "bar", "child": {"grandchild": {}}}`},
+	{"A.11 ignoring unrecognized elements",
+		`{"foo": "bar"}`,
+		`[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+		`{"foo": "bar", "baz": "qux"}`},
+	{"A.14 ~ escape ordering",
+		`{"/": 9, "~1": 10}`,
+		`[{"op": "test", "path": "/~01", "value": 10}]`,
+		`{"/": 9, "~1": 10}`},
+	{"A.16 adding an array value",
+		`{"foo": ["bar"]}`,
+		`[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+		`{"foo": ["bar", ["abc", "def"]]}`},
+	{"copying a value",
+		`{"foo": {"bar": [1]}}`,
+		`[{"op": "copy", "from": "/foo/bar", "path": "/baz"}, {"op": "add", "path": "/baz/-", "value": 2}]`,
+		`{"foo": {"bar": [1]}, "baz": [1, 2]}`},
+	{"replacing the document",
+		`{"foo": "bar"}`,
+		`[{"op": "replace", "path": "", "value": [1]}]`,
+		`[1]`},
+}
+
+func TestApply_examples_ok(t *testing.T) {
+	for _, e := range examples {
+		patch, err := Decode(strings.NewReader(e.patch))
+		if err != nil {
+			t.Errorf("%s: no decoding error expected, got %v", e.name, err)
+			continue
+		}
+		patched, err := patch.Apply([]byte(e.doc))
+		if err != nil {
+			t.Errorf("%s: no error expected, got %v", e.name, err)
+			continue
+		}
+		var got, expected interface{}
+		json.Unmarshal(patched, &got)
+		json.Unmarshal([]byte(e.expected), &expected)
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected %s, got %s", e.name, e.expected, patched)
+		}
+	}
+}
+
+func TestApply_testFailed_error(t *testing.T) {
+	cases := map[string]string{
+		"A.9 testing a value: error":          `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+		"A.15 comparing strings and numbers":  `[{"op": "test", "path": "/~01", "value": "10"}]`,
+		"testing a missing value":             `[{"op": "test", "path": "/missing", "value": null}]`,
+		"failing after successful operations": `[{"op": "add", "path": "/new", "value": 1}, {"op": "test", "path": "/baz", "value": "bar"}]`,
+	}
+	doc := `{"baz": "qux", "~1": 10}`
+	for name, p := range cases {
+		patch, _ := Decode(strings.NewReader(p))
+		if _, err := patch.Apply([]byte(doc)); !errors.Is(err, ErrTestFailed) {
+			t.Errorf("%s: expected ErrTestFailed, got %v", name, err)
+		}
+	}
+}
+
+func TestApply_error(t *testing.T) {
+	cases := map[string]string{
+		"A.12 adding to a nonexistent target": `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+		"removing a missing member":           `[{"op": "remove", "path": "/missing"}]`,
+		"replacing a missing member":          `[{"op": "replace", "path": "/missing", "value": 1}]`,
+		"index out of bounds":                 `[{"op": "add", "path": "/foo/3", "value": 1}]`,
+		"index with leading zero":             `[{"op": "replace", "path": "/foo/01", "value": 1}]`,
+		"moving into a child":                 `[{"op": "move", "from": "/foo", "path": "/foo/0"}]`,
+	}
+	doc := `{"foo": ["bar", "baz"]}`
+	for name, p := range cases {
+		patch, err := Decode(strings.NewReader(p))
+		if err != nil {
+			t.Errorf("%s: no decoding error expected, got %v", name, err)
+			continue
+		}
+		if _, err := patch.Apply([]byte(doc)); err == nil || errors.Is(err, ErrTestFailed) {
+			t.Errorf("%s: expected an error, got %v", name, err)
+		}
+	}
+}
+
+func TestDecode_error(t *testing.T) {
+	cases := map[string]string{
+		"not an array":    `{"op": "add", "path": "/a", "value": 1}`,
+		"unknown op":      `[{"op": "merge", "path": "/a", "value": 1}]`,
+		"missing value":   `[{"op": "add", "path": "/a"}]`,
+		"invalid pointer": `[{"op": "remove", "path": "a"}]`,
+		"missing from":    `[{"op": "move", "path": "/a"}]`,
+		"missing path":    `[{"op": "remove"}]`,
+	}
+	for name, p := range cases {
+		if _, err := Decode(strings.NewReader(p)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestModifies(t *testing.T) {
+	patch, _ := Decode(strings.NewReader(`[{"op": "test", "path": "/Tasks", "value": []}, {"op": "move", "from": "/Color", "path": "/Description"}]`))
+	expected := map[string]bool{"/Tasks": false, "/Color": true, "/Description": true, "/Name": false}
+	for location, modified := range expected {
+		if patch.Modifies(location) != modified {
+			t.Errorf("expected Modifies(%s) to be %t", location, modified)
+		}
+	}
+	patch, _ = Decode(strings.NewReader(`[{"op": "add", "path": "/Tags/0", "value": "home"}, {"op": "replace", "path": "", "value": {}}]`))
+	if !patch.Modifies("/Tags") || !patch.Modifies("/CreatedAt") {
+		t.Errorf("expected the modification of the children and the whole document detected")
+	}
+}
@@ -0,0 +1,82 @@
+// Package limiter bounds the number of requests served concurrently.
+//
+// It is a global cap protecting the store from overload, unrelated to the
+// client addresses: requests beyond the cap are rejected with 503 and a
+// Retry-After header rather than queued.
+package limiter
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// RetryAfter is the delay, in seconds, suggested to the rejected clients
+var RetryAfter = 1
+
+var (
+	lock     sync.RWMutex
+	slots    chan struct{}
+	inFlight int64
+	rejected int64
+)
+
+// SetMaxInFlight sets the maximum number of requests served concurrently, 0
+// meaning no limit. The requests in flight are not affected.
+func SetMaxInFlight(max int) error {
+	if max < 0 {
+		return fmt.Errorf("invalid maximum number of requests in flight %d", max)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	slots = nil
+	if max > 0 {
+		slots = make(chan struct{}, max)
+	}
+	return nil
+}
+
+// MaxInFlight returns the maximum number of requests served concurrently, 0
+// meaning no limit
+func MaxInFlight() int {
+	lock.RLock()
+	defer lock.RUnlock()
+	return cap(slots)
+}
+
+// InFlight returns the number of requests being served
+func InFlight() int64 {
+	return atomic.LoadInt64(&inFlight)
+}
+
+// Rejected returns the number of requests rejected since the start
+func Rejected() int64 {
+	return atomic.LoadInt64(&rejected)
+}
+
+// Middleware serves the requests with next while the maximum number of
+// requests in flight is not reached, rejecting them with 503 otherwise
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.RLock()
+		s := slots
+		lock.RUnlock()
+
+		if s != nil {
+			select {
+			case s <- struct{}{}:
+				defer func() { <-s }()
+			default:
+				atomic.AddInt64(&rejected, 1)
+				w.Header().Set("Retry-After", strconv.Itoa(RetryAfter))
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		next.ServeHTTP(w, r)
+	})
+}
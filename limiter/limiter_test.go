@@ -0,0 +1,58 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetMaxInFlight_invalid_error(t *testing.T) {
+	if err := SetMaxInFlight(-1); err == nil {
+		t.Errorf("expected invalid maximum error, got nil")
+	}
+}
+
+func TestMiddleware_limitReached_unavailable(t *testing.T) {
+	SetMaxInFlight(2)
+	defer SetMaxInFlight(0)
+
+	entered := make(chan bool)
+	release := make(chan bool)
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- true
+		<-release
+	}))
+	done := make(chan bool)
+	for i := 0; i < 2; i++ {
+		go func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/lists/", nil))
+			done <- true
+		}()
+		<-entered
+	}
+	if InFlight() != 2 {
+		t.Errorf("expected 2 requests in flight, got %d", InFlight())
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest("GET", "/lists/", nil))
+	if res.Code != http.StatusServiceUnavailable || res.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected status 503 with Retry-After 1, got %d %q", res.Code, res.Header().Get("Retry-After"))
+	}
+	if Rejected() != 1 {
+		t.Errorf("expected 1 request rejected, got %d", Rejected())
+	}
+
+	close(release)
+	<-done
+	<-done
+	if InFlight() != 0 {
+		t.Errorf("expected no request in flight, got %d", InFlight())
+	}
+	res = httptest.NewRecorder()
+	go func() { <-entered }()
+	handler.ServeHTTP(res, httptest.NewRequest("GET", "/lists/", nil))
+	if res.Code != http.StatusOK {
+		t.Errorf("expected status 200 once the slots are released, got %d", res.Code)
+	}
+}
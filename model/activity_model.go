@@ -0,0 +1,94 @@
+package model
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// activitySummaries are the templates of the readable summaries of the
+// events, see ActivitySummary
+var activitySummaries = map[string]string{
+	EventTaskCreated:    "Task {task} added to {list}",
+	EventTaskCompleted:  "Task {task} completed in {list}",
+	EventTaskReopened:   "Task {task} reopened in {list}",
+	EventTaskDeleted:    "Task {task} deleted from {list}",
+	EventListCreated:    "List {list} created",
+	EventListRenamed:    "List {from} renamed to {to}",
+	EventListDeleted:    "List {list} deleted",
+	EventListArchived:   "List {list} archived",
+	EventListUnarchived: "List {list} unarchived",
+}
+
+// ActivityFilter selects the events of the activity feed, empty fields
+// matching all the events
+type ActivityFilter struct {
+	List  string
+	Types []string
+}
+
+// Activity is an event of the activity feed with its readable summary
+type Activity struct {
+	Event
+	Summary string
+}
+
+// ActivityFeed returns up to limit events matching the filter, the most
+// recent first, among the events recorded before the one numbered before
+// (0 starting from the latest event). The Seq numbers are not reused while
+// the server runs, so the Seq of an event stays a valid cursor, the pages
+// ending early once Compact drops the older events. The returned
+// cursor is the value of before for the next page, 0 when there are no
+// older matching events.
+func ActivityFeed(filter ActivityFilter, before int, limit int) ([]Activity, int) {
+	lock.RLock()
+	defer lock.RUnlock()
+	start := len(history)
+	if before > 0 {
+		start = sort.Search(len(history), func(i int) bool { return history[i].Seq >= before })
+	}
+
+	feed := []Activity{}
+	for i := start - 1; i >= 0; i-- {
+		e := history[i]
+		if !filter.matches(e) {
+			continue
+		}
+		if len(feed) == limit {
+			return feed, feed[len(feed)-1].Seq
+		}
+		feed = append(feed, Activity{Event: *e, Summary: ActivitySummary(e)})
+	}
+	return feed, 0
+}
+
+// ActivitySummary returns the readable summary of the event
+func ActivitySummary(e *Event) string {
+	return strings.NewReplacer(
+		"{task}", strconv.Quote(e.Task),
+		"{list}", strconv.Quote(e.List),
+		"{from}", strconv.Quote(e.From),
+		"{to}", strconv.Quote(e.To),
+	).Replace(activitySummaries[e.Type])
+}
+
+func (f ActivityFilter) matches(e *Event) bool {
+	if f.List != "" && e.List != f.List {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if e.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEventType tells whether the name is one of the recorded event types
+func IsEventType(name string) bool {
+	_, ok := activitySummaries[name]
+	return ok
+}
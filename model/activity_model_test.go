@@ -0,0 +1,56 @@
+package model
+
+import "testing"
+
+/*******************************
+	ACTIVITY feed
+*******************************/
+
+func TestActivityFeed_pages_ok(t *testing.T) {
+	CreateToDoList("ListActivity")
+	AddTask("ListActivity", "Task1")
+	AddTask("ListActivity", "Task2")
+	AddTask("ListActivity", "Task3")
+	UpdateTask("ListActivity", "Task1", "Task1", true)
+	UpdateToDoList("ListActivity", "ListActivityRenamed")
+	filter := ActivityFilter{List: "ListActivityRenamed"}
+
+	feed, next := ActivityFeed(filter, 0, 4)
+	expected := []string{
+		`List "ListActivity" renamed to "ListActivityRenamed"`,
+		`Task "Task1" completed in "ListActivityRenamed"`,
+		`Task "Task3" added to "ListActivityRenamed"`,
+		`Task "Task2" added to "ListActivityRenamed"`,
+	}
+	if len(feed) != len(expected) || next != feed[len(feed)-1].Seq {
+		t.Fatalf("expected %d events and the cursor of the last one, got %v, %d", len(expected), feed, next)
+	}
+	for i, summary := range expected {
+		if feed[i].Summary != summary {
+			t.Errorf("expected %s, got %s", summary, feed[i].Summary)
+		}
+	}
+
+	// events recorded meanwhile do not shift the next page
+	AddTask("ListActivityRenamed", "Task4")
+	feed, next = ActivityFeed(filter, next, 4)
+	if len(feed) != 2 || next != 0 {
+		t.Fatalf("expected the 2 oldest events and no cursor, got %v, %d", feed, next)
+	}
+	if feed[0].Type != EventTaskCreated || feed[1].Type != EventListCreated {
+		t.Errorf("expected the first task and the list creation, got %v", feed)
+	}
+}
+
+func TestActivityFeed_types_ok(t *testing.T) {
+	CreateToDoList("ListActivityTypes")
+	AddTask("ListActivityTypes", "Task1")
+	RemoveTask("ListActivityTypes", "Task1")
+	DeleteToDoList("ListActivityTypes")
+
+	filter := ActivityFilter{List: "ListActivityTypes", Types: []string{EventTaskDeleted, EventListDeleted}}
+	feed, _ := ActivityFeed(filter, 0, 10)
+	if len(feed) != 2 || feed[0].Type != EventListDeleted || feed[1].Type != EventTaskDeleted {
+		t.Errorf("expected the list and task deletions, got %v", feed)
+	}
+}
@@ -0,0 +1,267 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrToDoListConflict is returned when restoring an archive under the name of
+// an existing ToDo list without renaming it
+var ErrToDoListConflict = fmt.Errorf("list already present")
+
+// ToDoListArchive is the self-contained document of a single ToDo list, List
+// having the format of the entries of the export document Lists.
+type ToDoListArchive struct {
+	Version int
+	List    ToDoList
+}
+
+// ArchiveToDoList returns the archive of the ToDo list
+func ArchiveToDoList(name string) (*ToDoListArchive, error) {
+	export, err := ExportLists([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	if len(export.Errors) > 0 {
+		return nil, errors.New(export.Errors[0].Error)
+	}
+	return &ToDoListArchive{Version: export.Version, List: export.Lists[0]}, nil
+}
+
+// RestoreToDoList creates a ToDo list from the archive, under the given name
+// or the archived one when empty. When the name is taken the restore fails
+// with ErrToDoListConflict, unless rename is set: the first free name among
+// "<name> (2)", "<name> (3)"... is used then. The returned warnings report the
+// adjustments made to the task attributes (e.g. truncation).
+func RestoreToDoList(archive *ToDoListArchive, name string, rename bool) (*ToDoList, []string, error) {
+	if archive.Version != ExportVersion {
+		return nil, nil, &ValidationError{fmt.Sprintf("unsupported archive version %d, expected %d", archive.Version, ExportVersion)}
+	}
+	restore, warnings, err := prepareRestore(&archive.List, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if err := checkRestore(restore, rename, nil); err != nil {
+		return nil, nil, err
+	}
+	return applyRestore(restore), warnings, nil
+}
+
+// RestoreToDoLists creates the ToDo lists of the export document, under their
+// exported names, with the conflict policy of RestoreToDoList. The lists are
+// restored as a whole: none is created when any fails. The Errors of the
+// export are ignored.
+func RestoreToDoLists(export *ToDoListExport, rename bool) ([]*ToDoList, []string, error) {
+	if export.Version != ExportVersion {
+		return nil, nil, &ValidationError{fmt.Sprintf("unsupported export version %d, expected %d", export.Version, ExportVersion)}
+	}
+	if len(export.Lists) == 0 {
+		return nil, nil, &ValidationError{"the export has no ToDo list to restore"}
+	}
+	var warnings []string
+	restores := make([]*listRestore, len(export.Lists))
+	for i := range export.Lists {
+		restore, w, err := prepareRestore(&export.Lists[i], "")
+		if err != nil {
+			return nil, nil, err
+		}
+		warnings = append(warnings, w...)
+		restores[i] = restore
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	taken := make(map[string]bool, len(restores))
+	for _, restore := range restores {
+		if err := checkRestore(restore, rename, taken); err != nil {
+			return nil, nil, err
+		}
+		taken[restore.name] = true
+	}
+	lists := make([]*ToDoList, len(restores))
+	for i, restore := range restores {
+		lists[i] = applyRestore(restore)
+	}
+	return lists, warnings, nil
+}
+
+// listRestore is an archived ToDo list being restored under name
+type listRestore struct {
+	archived *ToDoList
+	name     string
+	tasks    []*Task
+}
+
+// prepareRestore validates the archived ToDo list and copies its tasks, the
+// returned warnings reporting the adjustments made to their attributes
+func prepareRestore(archived *ToDoList, name string) (*listRestore, []string, error) {
+	if name == "" {
+		name = archived.Name
+	}
+	if name == "" {
+		return nil, nil, fmt.Errorf("empty ToDo list name")
+	}
+
+	var warnings []string
+	tasks := make([]*Task, 0, len(archived.Tasks))
+	titles := make(map[string]bool, len(archived.Tasks))
+	for _, t := range archived.Tasks {
+		if t == nil || t.Title == "" {
+			return nil, nil, &ValidationError{"archived tasks must have a title"}
+		}
+		if titles[t.Title] {
+			return nil, nil, &ValidationError{fmt.Sprintf("task %s archived more than once", t.Title)}
+		}
+		titles[t.Title] = true
+		if t.Progress < 0 || t.Progress > MaxProgress {
+			return nil, nil, &ValidationError{fmt.Sprintf("task %s archived with an invalid progress %d", t.Title, t.Progress)}
+		}
+		if t.TimeSpent < 0 {
+			return nil, nil, &ValidationError{fmt.Sprintf("task %s archived with a negative time spent %d", t.Title, t.TimeSpent)}
+		}
+		task := cloneTask(t)
+		w, err := validateTaskDetails(&task.TaskDetails)
+		if err != nil {
+			return nil, nil, err
+		}
+		warnings = append(warnings, w...)
+		updateChecklistProgress(task)
+		tasks = append(tasks, task)
+	}
+	return &listRestore{archived: archived, name: name, tasks: tasks}, warnings, nil
+}
+
+// checkRestore settles the name of the restored ToDo list, the names taken by
+// the other lists being restored counting as taken, and runs the before hooks
+// of the creation of its tasks. The store is left untouched.
+func checkRestore(restore *listRestore, rename bool, taken map[string]bool) error {
+	if err := validateListName(restore.name); err != nil {
+		return err
+	}
+	used := func(name string) bool {
+		list, _ := getToDoList(name)
+		return list != nil || taken[name]
+	}
+	if used(restore.name) {
+		if !rename {
+			return ErrToDoListConflict
+		}
+		base := restore.name
+		for i := 2; used(restore.name); i++ {
+			restore.name = fmt.Sprintf("%s (%d)", base, i)
+		}
+	}
+	for _, t := range restore.tasks {
+		t.ToDoList = restore.name
+		if err := vetoEvent(EventTaskCreated, restore.name, nil, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyRestore creates the checked ToDo list and returns its snapshot
+func applyRestore(restore *listRestore) *ToDoList {
+	if data == nil {
+		data = make(map[string]*ToDoList, 100)
+	}
+	name, archived, tasks := restore.name, restore.archived, restore.tasks
+
+	list := &ToDoList{
+		Name:           name,
+		Tasks:          tasks,
+		TaskNumber:     len(tasks),
+		Description:    archived.Description,
+		Color:          archived.Color,
+		LastTaskNumber: archived.LastTaskNumber}
+	if archived.Notifications != nil {
+		if muted, err := mutedEvents(archived.Notifications.Muted); err == nil {
+			list.Notifications = &NotificationPrefs{Muted: sortedEvents(muted)}
+		}
+	}
+	if validatePriority(archived.DefaultPriority) == nil {
+		list.DefaultPriority = archived.DefaultPriority
+	}
+	numberRestoredTasks(list)
+	if validateAutoSort(archived.AutoSort) == nil {
+		setAutoSort(list, archived.AutoSort)
+	}
+	data[name] = list
+	recordListEvent(EventListCreated, name)
+	for _, t := range tasks {
+		t.ToDoList = name
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = now()
+		}
+		recordEvent(EventTaskCreated, t, 1)
+		indexDueDate(t)
+		if !t.Done {
+			t.CompletedAt = nil
+			if t.Progress == MaxProgress {
+				t.Progress = 0
+			}
+			continue
+		}
+		t.Progress = MaxProgress
+		t.Deferred = false
+		if t.CompletedAt == nil {
+			completedAt := now()
+			t.CompletedAt = &completedAt
+		}
+		indexCompletion(t)
+		recordEvent(EventTaskCompleted, t, -1)
+	}
+	return cloneToDoList(list)
+}
+
+// ArchiveSummary reports the outcome of ArchiveLists
+type ArchiveSummary struct {
+	Archived int
+	NotFound int
+	Errors   []ExportError `json:",omitempty"`
+}
+
+// ArchiveLists archives the ToDo lists with the given keys, lists already
+// archived being counted as archived. Missing lists are reported in the
+// summary without failing the others, unless atomic: then no list is
+// archived when any is missing.
+func ArchiveLists(keys []string, atomic bool) (*ArchiveSummary, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("empty list of ToDo lists to archive")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	summary := &ArchiveSummary{}
+	lists := make([]*ToDoList, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		list, err := getToDoList(key)
+		if err != nil {
+			summary.NotFound++
+			summary.Errors = append(summary.Errors, ExportError{Key: key, Error: err.Error()})
+			continue
+		}
+		lists = append(lists, list)
+	}
+	if atomic && summary.NotFound > 0 {
+		return summary, nil
+	}
+
+	for _, list := range lists {
+		if !list.Archived {
+			list.Archived = true
+			recordListEvent(EventListArchived, list.Name)
+		}
+	}
+	summary.Archived = len(lists)
+	return summary, nil
+}
@@ -0,0 +1,105 @@
+package model
+
+import "testing"
+
+/*******************************
+	ARCHIVE ToDo list
+*******************************/
+
+func TestArchiveToDoList_invalidList_error(t *testing.T) {
+	_, err := ArchiveToDoList("invalid")
+	if err == nil {
+		t.Errorf("expected error list not found, got nil")
+	}
+}
+
+func TestArchiveToDoList_restore_ok(t *testing.T) {
+	CreateToDoList("ListArchive")
+	MergePatchToDoList("ListArchive", map[string]interface{}{"Color": "red"})
+	AddTaskWithDetails("ListArchive", "Task1", TaskDetails{Description: "Details"})
+	AddTask("ListArchive", "Task2")
+	UpdateTask("ListArchive", "Task2", "Task2", true)
+
+	archive, err := ArchiveToDoList("ListArchive")
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if archive.Version != ExportVersion || archive.List.Name != "ListArchive" || len(archive.List.Tasks) != 2 {
+		t.Fatalf("expected version %d archive of ListArchive with 2 tasks, got %+v", ExportVersion, archive)
+	}
+
+	list, _, err := RestoreToDoList(archive, "ListArchiveCopy", false)
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if list.Color != "red" || list.TaskNumber != 2 {
+		t.Errorf("expected the settings and the tasks restored, got %+v", list)
+	}
+	task, err := GetTask("ListArchiveCopy", "Task2")
+	if err != nil || !task.Done || task.CompletedAt == nil || task.ToDoList != "ListArchiveCopy" {
+		t.Errorf("expected Task2 restored done in ListArchiveCopy, got %+v, %v", task, err)
+	}
+	if original, _ := GetTask("ListArchive", "Task1"); original.ToDoList != "ListArchive" {
+		t.Errorf("expected the original list untouched, got %+v", original)
+	}
+}
+
+func TestRestoreToDoList_conflict(t *testing.T) {
+	archive, _ := ArchiveToDoList("ListArchive")
+
+	if _, _, err := RestoreToDoList(archive, "", false); err != ErrToDoListConflict {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+	list, _, err := RestoreToDoList(archive, "", true)
+	if err != nil || list.Name != "ListArchive (2)" {
+		t.Errorf("expected the list restored as ListArchive (2), got %v, %v", list, err)
+	}
+	list, _, err = RestoreToDoList(archive, "", true)
+	if err != nil || list.Name != "ListArchive (3)" {
+		t.Errorf("expected the list restored as ListArchive (3), got %v, %v", list, err)
+	}
+}
+
+func TestRestoreToDoList_invalidArchive_error(t *testing.T) {
+	invalid := []*ToDoListArchive{
+		{Version: ExportVersion + 1, List: ToDoList{Name: "ListArchiveInvalid"}},
+		{Version: ExportVersion, List: ToDoList{Name: "ListArchiveInvalid", Tasks: []*Task{{Title: ""}}}},
+		{Version: ExportVersion, List: ToDoList{Name: "ListArchiveInvalid", Tasks: []*Task{{Title: "Task1"}, {Title: "Task1"}}}},
+	}
+	for _, archive := range invalid {
+		if _, _, err := RestoreToDoList(archive, "", false); err == nil {
+			t.Errorf("expected validation error for %+v, got nil", archive)
+		}
+	}
+	if list, _ := GetToDoList("ListArchiveInvalid"); list != nil {
+		t.Errorf("expected no list created by an invalid archive")
+	}
+}
+
+func TestArchiveLists_ok(t *testing.T) {
+	CreateToDoList("ListBulkArchive1")
+	CreateToDoList("ListBulkArchive2")
+
+	summary, err := ArchiveLists([]string{"ListBulkArchive1", "ListBulkArchiveMissing"}, true)
+	if err != nil || summary.Archived != 0 || summary.NotFound != 1 {
+		t.Fatalf("expected nothing archived in atomic mode, got %+v, %v", summary, err)
+	}
+	if list, _ := GetToDoList("ListBulkArchive1"); list.Archived {
+		t.Errorf("expected the list left active")
+	}
+
+	summary, err = ArchiveLists([]string{"ListBulkArchive1", "ListBulkArchive2", "ListBulkArchive1", "ListBulkArchiveMissing"}, false)
+	if err != nil || summary.Archived != 2 || summary.NotFound != 1 || summary.Errors[0].Key != "ListBulkArchiveMissing" {
+		t.Fatalf("expected 2 lists archived and 1 not found, got %+v, %v", summary, err)
+	}
+	if list, _ := GetToDoList("ListBulkArchive2"); !list.Archived {
+		t.Errorf("expected the list archived")
+	}
+
+	if _, err := MergePatchToDoList("ListBulkArchive2", map[string]interface{}{"Archived": false}); err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if list, _ := GetToDoList("ListBulkArchive2"); list.Archived {
+		t.Errorf("expected the list unarchived")
+	}
+}
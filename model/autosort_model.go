@@ -0,0 +1,95 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Automatic sorting of the new tasks of a ToDo list, see ToDoList.AutoSort
+const (
+	AutoSortNone     = ""
+	AutoSortPriority = "priority"
+	AutoSortDueDate  = "dueDate"
+)
+
+// validateAutoSort rejects, with a *ValidationError, the unknown automatic
+// sortings
+func validateAutoSort(autoSort string) error {
+	switch autoSort {
+	case AutoSortNone, AutoSortPriority, AutoSortDueDate:
+		return nil
+	}
+	return &ValidationError{fmt.Sprintf("invalid automatic sorting %s, expected %s or %s", autoSort, AutoSortPriority, AutoSortDueDate)}
+}
+
+// sortsBefore tells whether a is placed strictly before b by the automatic
+// sorting: by priority, tasks without priority last, or by due date, tasks
+// without due date last
+func sortsBefore(autoSort string, a, b *Task) bool {
+	switch autoSort {
+	case AutoSortPriority:
+		return priorityRank(a) < priorityRank(b)
+	case AutoSortDueDate:
+		if a.DueDate == nil || b.DueDate == nil {
+			return a.DueDate != nil && b.DueDate == nil
+		}
+		return a.DueDate.Before(*b.DueDate)
+	}
+	return false
+}
+
+// priorityRank orders the priorities from the most urgent, no priority last
+func priorityRank(t *Task) int {
+	if t.Priority == PriorityNone {
+		return PriorityLow + 1
+	}
+	return t.Priority
+}
+
+// insertTaskSorted inserts the task in the tasks of the list at its position
+// after the automatic sorting of the list, after the tasks it does not sort
+// before. The task is appended when the list is not sorted automatically.
+// The tasks of a list sorted automatically are kept sorted: see setAutoSort
+// and resortTask.
+func insertTaskSorted(list *ToDoList, task *Task) {
+	i := len(list.Tasks)
+	if list.AutoSort != AutoSortNone {
+		i = sort.Search(len(list.Tasks), func(i int) bool {
+			return sortsBefore(list.AutoSort, task, list.Tasks[i])
+		})
+	}
+	list.Tasks = append(list.Tasks, nil)
+	copy(list.Tasks[i+1:], list.Tasks[i:])
+	list.Tasks[i] = task
+}
+
+// resortTask moves the task to its position after the automatic sorting of
+// its list, once its priority or its due date changed
+func resortTask(list *ToDoList, task *Task) {
+	if list.AutoSort == AutoSortNone {
+		return
+	}
+	for i, t := range list.Tasks {
+		if t == task {
+			list.Tasks = append(list.Tasks[:i], list.Tasks[i+1:]...)
+			break
+		}
+	}
+	insertTaskSorted(list, task)
+	invalidateTitles(list)
+}
+
+// setAutoSort sets the automatic sorting of the list, sorting its tasks
+// once, keeping the order of the equal ones, when it changes
+func setAutoSort(list *ToDoList, autoSort string) {
+	if autoSort == list.AutoSort {
+		return
+	}
+	list.AutoSort = autoSort
+	if autoSort != AutoSortNone {
+		sort.SliceStable(list.Tasks, func(i, j int) bool {
+			return sortsBefore(autoSort, list.Tasks[i], list.Tasks[j])
+		})
+		invalidateTitles(list)
+	}
+}
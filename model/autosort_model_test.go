@@ -0,0 +1,107 @@
+package model
+
+import "testing"
+
+/*******************************
+	AUTOMATIC Sorting
+*******************************/
+
+func taskTitles(list *ToDoList) []string {
+	titles := make([]string, len(list.Tasks))
+	for i, t := range list.Tasks {
+		titles[i] = t.Title
+	}
+	return titles
+}
+
+func TestAutoSort_priority(t *testing.T) {
+	CreateToDoListWithTasks("ListAutoSort", []string{"None"})
+	AddTaskWithDetails("ListAutoSort", "Low", TaskDetails{Priority: PriorityLow})
+	AddTaskWithDetails("ListAutoSort", "Urgent", TaskDetails{Priority: PriorityUrgent})
+
+	list, err := MergePatchToDoList("ListAutoSort", map[string]interface{}{"AutoSort": AutoSortPriority})
+	if err != nil || list.AutoSort != AutoSortPriority {
+		t.Fatalf("expected the list sorted by priority, got %v, %v", list, err)
+	}
+	AddTaskWithDetails("ListAutoSort", "High", TaskDetails{Priority: PriorityHigh})
+	AddTaskWithDetails("ListAutoSort", "Urgent too", TaskDetails{Priority: PriorityUrgent})
+	AddTask("ListAutoSort", "None too")
+
+	list, _ = GetToDoListWithTasks("ListAutoSort")
+	expected := []string{"Urgent", "Urgent too", "High", "Low", "None", "None too"}
+	for i, title := range taskTitles(list) {
+		if title != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, taskTitles(list))
+		}
+	}
+	if ref := ResolveTaskTitle("ListAutoSort", "task-4"); ref != "High" {
+		t.Errorf("expected task-4 resolved to High, got %s", ref)
+	}
+
+	MergePatchToDoList("ListAutoSort", map[string]interface{}{"AutoSort": nil})
+	AddTaskWithDetails("ListAutoSort", "Urgent last", TaskDetails{Priority: PriorityUrgent})
+	list, _ = GetToDoListWithTasks("ListAutoSort")
+	if list.AutoSort != AutoSortNone || list.Tasks[len(list.Tasks)-1].Title != "Urgent last" {
+		t.Errorf("expected the new task appended once the sorting is off, got %v", taskTitles(list))
+	}
+}
+
+func TestAutoSort_dueDate(t *testing.T) {
+	CreateToDoList("ListAutoSortDue")
+	MergePatchToDoList("ListAutoSortDue", map[string]interface{}{"AutoSort": AutoSortDueDate})
+	AddTaskWithDetails("ListAutoSortDue", "June 5", dueOn(2024, 6, 5, 9))
+	AddTask("ListAutoSortDue", "No due date")
+	AddTaskWithDetails("ListAutoSortDue", "June 1", dueOn(2024, 6, 1, 9))
+
+	list, _ := GetToDoListWithTasks("ListAutoSortDue")
+	expected := []string{"June 1", "June 5", "No due date"}
+	for i, title := range taskTitles(list) {
+		if title != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, taskTitles(list))
+		}
+	}
+	if _, err := MergePatchToDoList("ListAutoSortDue", map[string]interface{}{"AutoSort": "title"}); err == nil {
+		t.Errorf("expected error with an unknown sorting")
+	} else if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}
+
+func TestAutoSort_changedTasksMoved(t *testing.T) {
+	SeedToDoList("ListAutoSortMoved", []TaskSeed{
+		{Title: "High", TaskDetails: TaskDetails{Priority: PriorityHigh}},
+		{Title: "Medium", TaskDetails: TaskDetails{Priority: PriorityMedium}},
+		{Title: "Low", TaskDetails: TaskDetails{Priority: PriorityLow}}})
+	MergePatchToDoList("ListAutoSortMoved", map[string]interface{}{"AutoSort": AutoSortPriority})
+
+	SetTaskDetails("ListAutoSortMoved", "Low", TaskDetails{Priority: PriorityUrgent})
+	SetTasksPriority("ListAutoSortMoved", []string{"High"}, PriorityLow)
+	AddTaskWithDetails("ListAutoSortMoved", "Medium too", TaskDetails{Priority: PriorityMedium})
+
+	list, _ := GetToDoListWithTasks("ListAutoSortMoved")
+	expected := []string{"Low", "Medium", "Medium too", "High"}
+	for i, title := range taskTitles(list) {
+		if title != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, taskTitles(list))
+		}
+	}
+}
+
+func TestAutoSort_restored(t *testing.T) {
+	high := &Task{Title: "High", TaskDetails: TaskDetails{Priority: PriorityHigh}}
+	urgent := &Task{Title: "Urgent", TaskDetails: TaskDetails{Priority: PriorityUrgent}}
+	archive := &ToDoListArchive{Version: ExportVersion,
+		List: ToDoList{Name: "ListAutoSortRestored", AutoSort: AutoSortPriority, Tasks: []*Task{high, urgent}}}
+	if _, _, err := RestoreToDoList(archive, "", false); err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	AddTaskWithDetails("ListAutoSortRestored", "Medium", TaskDetails{Priority: PriorityMedium})
+
+	list, _ := GetToDoListWithTasks("ListAutoSortRestored")
+	expected := []string{"Urgent", "High", "Medium"}
+	for i, title := range taskTitles(list) {
+		if title != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, taskTitles(list))
+		}
+	}
+}
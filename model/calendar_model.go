@@ -0,0 +1,130 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MaxCalendarDays is the largest number of days of a calendar
+const MaxCalendarDays = 92
+
+// dueIndex buckets the tasks with a due date by UTC day ("2006-01-02"), so
+// that a range query only visits the tasks due around that range.
+var dueIndex map[string][]*Task
+
+// CalendarDay reports the tasks due and completed on a day
+type CalendarDay struct {
+	Day            string
+	Due            []*Task
+	Completed      []*Task
+	DueCount       int
+	CompletedCount int
+}
+
+// Calendar returns, for each day from the day of from to the day of to
+// (included) in the given location, the tasks due and completed that day,
+// ordered by time. Days without tasks are reported with empty lists. An empty
+// listKey covers all the lists.
+func Calendar(listKey string, from, to time.Time, loc *time.Location) ([]CalendarDay, error) {
+	start, _ := DayBounds(from, loc)
+	_, end := DayBounds(to, loc)
+	if !start.Before(end) {
+		return nil, fmt.Errorf("invalid range, %s is after %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	days := calendarDays(start, end)
+	if days > MaxCalendarDays {
+		return nil, fmt.Errorf("invalid range of %d days, expected up to %d", days, MaxCalendarDays)
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	if listKey != "" {
+		if _, err := getToDoList(listKey); err != nil {
+			return nil, err
+		}
+	}
+
+	calendar := make([]CalendarDay, days)
+	index := make(map[string]*CalendarDay, days)
+	for i := range calendar {
+		calendar[i] = CalendarDay{
+			Day:       start.AddDate(0, 0, i).Format("2006-01-02"),
+			Due:       []*Task{},
+			Completed: []*Task{}}
+		index[calendar[i].Day] = &calendar[i]
+	}
+	// all-day tasks are stored at midnight UTC, up to a day away from the
+	// range in the location
+	for _, t := range dueBetween(start.AddDate(0, 0, -1), end.AddDate(0, 0, 1)) {
+		if t.Deferred || listKey != "" && t.ToDoList != listKey {
+			continue
+		}
+		due, _ := dueBounds(t, loc)
+		if day, ok := index[due.In(loc).Format("2006-01-02")]; ok {
+			day.Due = append(day.Due, copyTask(t))
+			day.DueCount++
+		}
+	}
+	for _, t := range completedBetween(start, end) {
+		if listKey == "" || t.ToDoList == listKey {
+			day := index[t.CompletedAt.In(loc).Format("2006-01-02")]
+			day.Completed = append(day.Completed, copyTask(t))
+			day.CompletedCount++
+		}
+	}
+	return calendar, nil
+}
+
+// dueBetween returns the tasks due in [start, end), ordered by due date then
+// creation, visiting only the index buckets of the interval.
+func dueBetween(start, end time.Time) []*Task {
+	tasks := []*Task{}
+	first, _ := DayBounds(start, time.UTC)
+	for d := first; d.Before(end); d = d.AddDate(0, 0, 1) {
+		for _, t := range dueIndex[d.Format("2006-01-02")] {
+			if !t.DueDate.Before(start) && t.DueDate.Before(end) {
+				tasks = append(tasks, t)
+			}
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if a, b := tasks[i].DueDate, tasks[j].DueDate; !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return createdBefore(tasks[i], tasks[j])
+	})
+	return tasks
+}
+
+func dueKey(t *Task) string {
+	return t.DueDate.UTC().Format("2006-01-02")
+}
+
+func indexDueDate(t *Task) {
+	if t.DueDate == nil {
+		return
+	}
+	if dueIndex == nil {
+		dueIndex = make(map[string][]*Task)
+	}
+	key := dueKey(t)
+	dueIndex[key] = append(dueIndex[key], t)
+}
+
+func unindexDueDate(t *Task) {
+	if t.DueDate == nil {
+		return
+	}
+	key := dueKey(t)
+	bucket := dueIndex[key]
+	for i, indexed := range bucket {
+		if indexed == t {
+			dueIndex[key] = append(bucket[:i], bucket[i+1:]...)
+			break
+		}
+	}
+	if len(dueIndex[key]) == 0 {
+		delete(dueIndex, key)
+	}
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	CALENDAR
+*******************************/
+
+func TestCalendar_invalidRange_error(t *testing.T) {
+	from := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	if _, err := Calendar("", from, from.AddDate(0, 0, -1), time.UTC); err == nil {
+		t.Errorf("expected inverted range error, got nil")
+	}
+	if _, err := Calendar("", from, from.AddDate(0, 0, MaxCalendarDays), time.UTC); err == nil {
+		t.Errorf("expected range too long error, got nil")
+	}
+	if _, err := Calendar("invalid", from, from, time.UTC); err == nil {
+		t.Errorf("expected error list not found, got nil")
+	}
+}
+
+func TestCalendar_ok(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2024, 7, 2, 9, 0, 0, 0, time.UTC) }
+	rome := time.FixedZone("CEST", 2*60*60)
+
+	CreateToDoList("ListCalendar")
+	CreateToDoList("ListCalendarOther")
+	// 2024-07-01 23:00 UTC is 2024-07-02 in Rome
+	AddTaskWithDetails("ListCalendar", "Late", dueOn(2024, 7, 1, 23))
+	AddTaskWithDetails("ListCalendar", "Early", dueOn(2024, 7, 2, 6))
+	AddTaskWithDetails("ListCalendar", "Moved", dueOn(2024, 7, 3, 9))
+	AddTaskWithDetails("ListCalendarOther", "Other", dueOn(2024, 7, 2, 10))
+	SetTaskDetails("ListCalendar", "Moved", dueOn(2024, 7, 20, 9))
+	UpdateTask("ListCalendar", "Early", "Early", true)
+
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, rome)
+	calendar, err := Calendar("ListCalendar", from, from.AddDate(0, 0, 2), rome)
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if len(calendar) != 3 || calendar[0].Day != "2024-07-01" || calendar[2].Day != "2024-07-03" {
+		t.Fatalf("expected the days from 2024-07-01 to 2024-07-03, got %v", calendar)
+	}
+	if calendar[0].DueCount != 0 || len(calendar[0].Due) != 0 || calendar[2].DueCount != 0 {
+		t.Errorf("expected no task due on 2024-07-01 and 2024-07-03, got %v, %v", calendar[0].Due, calendar[2].Due)
+	}
+	day := calendar[1]
+	if day.DueCount != 2 || day.Due[0].Title != "Late" || day.Due[1].Title != "Early" {
+		t.Errorf("expected Late and Early due on 2024-07-02, got %v", day.Due)
+	}
+	if day.CompletedCount != 1 || day.Completed[0].Title != "Early" {
+		t.Errorf("expected Early completed on 2024-07-02, got %v", day.Completed)
+	}
+
+	calendar, _ = Calendar("", from, from.AddDate(0, 0, 2), rome)
+	if calendar[1].DueCount < 3 {
+		t.Errorf("expected the tasks of all the lists, got %v", calendar[1].Due)
+	}
+}
+
+func TestCalendar_allDay(t *testing.T) {
+	newYork := time.FixedZone("EDT", -4*60*60)
+	CreateToDoList("ListCalendarAllDay")
+	due := time.Date(2024, 7, 5, 0, 0, 0, 0, time.UTC)
+	AddTaskWithDetails("ListCalendarAllDay", "Holiday", TaskDetails{DueDate: &due, AllDay: true})
+
+	// midnight UTC is still 2024-07-04 in New York
+	from := time.Date(2024, 7, 4, 0, 0, 0, 0, newYork)
+	calendar, _ := Calendar("ListCalendarAllDay", from, from.AddDate(0, 0, 1), newYork)
+	if calendar[0].DueCount != 0 || calendar[1].DueCount != 1 || calendar[1].Day != "2024-07-05" {
+		t.Errorf("expected the all-day task due on 2024-07-05 in New York, got %+v", calendar)
+	}
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/efreddo/v1/todolist/logutils"
+)
+
+// cleanupInterval is the interval between the passes of CleanupEmptyLists
+var cleanupInterval = time.Hour
+
+// cleanupExcluded are the ToDo lists never archived by the cleanup, see
+// SetCleanupExcludedLists
+var cleanupExcluded = map[string]bool{"Inbox": true}
+
+// accessed records when the ToDo lists were last read, the reads holding the
+// store lock for reading only
+var (
+	accessLock sync.Mutex
+	accessed   = map[*ToDoList]time.Time{}
+)
+
+// EmptyListCleanup reports a pass of the empty lists cleanup: the number of
+// empty lists not archived yet and the ones archived by the pass
+type EmptyListCleanup struct {
+	Empty    int
+	Archived []string
+}
+
+// SetCleanupExcludedLists sets the ToDo lists never archived by the cleanup,
+// Inbox by default
+func SetCleanupExcludedLists(names []string) {
+	lock.Lock()
+	defer lock.Unlock()
+	cleanupExcluded = make(map[string]bool, len(names))
+	for _, name := range names {
+		cleanupExcluded[name] = true
+	}
+}
+
+// CleanupEmptyLists archives, every hour until ctx is done, the ToDo lists
+// without tasks for at least maxAge (see ArchiveEmptyLists), logging the
+// outcome of each pass. It returns the error of ctx.
+func CleanupEmptyLists(ctx context.Context, maxAge time.Duration) error {
+	if maxAge <= 0 {
+		return fmt.Errorf("invalid empty list age %s, expected a positive duration", maxAge)
+	}
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		cleanup := ArchiveEmptyLists(maxAge)
+		logutils.Info.Println(fmt.Sprintf(
+			"CleanupEmptyLists:: %d empty lists, %d archived %v", cleanup.Empty, len(cleanup.Archived), cleanup.Archived))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ArchiveEmptyLists archives the ToDo lists without tasks that were neither
+// changed nor read for at least maxAge, the last change of a list being its
+// last event in the history, trimmed or not: its creation or the deletion of
+// its last task for an empty list. The excluded lists are never archived, and the lists
+// are archived, not deleted, so that they can be brought back.
+func ArchiveEmptyLists(maxAge time.Duration) EmptyListCleanup {
+	lock.Lock()
+	defer lock.Unlock()
+	cutoff := now().Add(-maxAge)
+	changed := make(map[string]time.Time, len(data))
+	for list, t := range trimmed {
+		changed[list] = t.last
+	}
+	for _, e := range history {
+		changed[e.List] = e.At
+	}
+
+	accessLock.Lock()
+	defer accessLock.Unlock()
+	for list := range accessed {
+		if data[list.Name] != list {
+			delete(accessed, list)
+		}
+	}
+
+	cleanup := EmptyListCleanup{Archived: []string{}}
+	for name, list := range data {
+		if list.Archived || len(list.Tasks) > 0 || cleanupExcluded[name] {
+			continue
+		}
+		cleanup.Empty++
+		last, ok := changed[name]
+		if !ok || last.After(cutoff) || accessed[list].After(cutoff) {
+			continue
+		}
+		list.Archived = true
+		recordListEvent(EventListArchived, name)
+		cleanup.Archived = append(cleanup.Archived, name)
+	}
+	cleanup.Empty -= len(cleanup.Archived)
+	sort.Strings(cleanup.Archived)
+	return cleanup
+}
+
+// touchList records a read of the ToDo list
+func touchList(list *ToDoList) {
+	accessLock.Lock()
+	defer accessLock.Unlock()
+	accessed[list] = now()
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	ARCHIVE empty lists
+*******************************/
+
+func TestArchiveEmptyLists(t *testing.T) {
+	defer func() { now = time.Now }()
+	defer SetCleanupExcludedLists([]string{"Inbox"})
+	SetCleanupExcludedLists([]string{"ListCleanupInbox"})
+	at := func(day int) {
+		now = func() time.Time { return time.Date(2001, 1, day, 9, 0, 0, 0, time.UTC) }
+	}
+
+	at(1)
+	for _, name := range []string{"ListCleanupAbandoned", "ListCleanupRead", "ListCleanupEmptied", "ListCleanupInbox", "ListCleanupTasks"} {
+		CreateToDoList(name)
+	}
+	AddTask("ListCleanupEmptied", "Task1")
+	AddTask("ListCleanupTasks", "Task1")
+	at(28)
+	GetToDoListWithTasks("ListCleanupRead")
+	RemoveTask("ListCleanupEmptied", "Task1")
+
+	at(31)
+	cleanup := ArchiveEmptyLists(7 * 24 * time.Hour)
+	if len(cleanup.Archived) != 1 || cleanup.Archived[0] != "ListCleanupAbandoned" {
+		t.Fatalf("expected only the abandoned list archived, got %+v", cleanup)
+	}
+	if cleanup.Empty < 2 {
+		t.Errorf("expected at least the read and emptied lists counted as empty, got %d", cleanup.Empty)
+	}
+	for name, archived := range map[string]bool{"ListCleanupAbandoned": true, "ListCleanupRead": false,
+		"ListCleanupEmptied": false, "ListCleanupInbox": false, "ListCleanupTasks": false} {
+		if list, _ := GetToDoList(name); list.Archived != archived {
+			t.Errorf("expected %s archived %t, got %t", name, archived, list.Archived)
+		}
+	}
+	if cleanup := ArchiveEmptyLists(7 * 24 * time.Hour); len(cleanup.Archived) != 0 {
+		t.Errorf("expected the archived lists left alone, got %+v", cleanup)
+	}
+}
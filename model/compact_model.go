@@ -0,0 +1,89 @@
+package model
+
+import (
+	"time"
+	"unsafe"
+)
+
+// Compaction reports the entries dropped by Compact: the expired tombstones,
+// the orphaned index entries, the task changes and access records left by
+// the tasks and ToDo lists no longer stored, and the events older than the
+// history retention
+type Compaction struct {
+	Tombstones    int
+	OrphanedTasks int
+	TaskChanges   int
+	AccessRecords int
+	Events        int
+	// Bytes estimates the memory released: the sizes of the dropped entries
+	// and of their strings, without the overhead of the maps holding them
+	Bytes int64
+}
+
+// Dropped returns the number of entries dropped
+func (c Compaction) Dropped() int {
+	return c.Tombstones + c.OrphanedTasks + c.TaskChanges + c.AccessRecords + c.Events
+}
+
+// Compact drops the data of the deleted ToDo lists and tasks that is no
+// longer needed and the events older than the history retention, rebuilding
+// the queues so that their dropped entries are released. It runs under the
+// store lock, the store being either compacted or left untouched, and keeps
+// the recent history, the tombstones within the retention and the data of
+// the stored lists and tasks. The statistics and the cleanup of the empty
+// lists keep counting the dropped events, folded by ToDo list.
+func Compact() Compaction {
+	lock.Lock()
+	defer lock.Unlock()
+	held := make(map[*Task]bool)
+	for _, list := range data {
+		for _, t := range list.Tasks {
+			held[t] = true
+		}
+	}
+
+	c := Compaction{Tombstones: len(tombstones), Bytes: tombstonesSize()}
+	pruneTombstones()
+	c.Tombstones -= len(tombstones)
+	c.Bytes -= tombstonesSize()
+	tombstoneQueue = append([]tombstone(nil), tombstoneQueue...)
+
+	orphans := findOrphanedTasks(true)
+	c.OrphanedTasks = len(orphans)
+	c.Bytes += int64(len(orphans)) * int64(unsafe.Sizeof(&Task{}))
+	for t, changes := range taskChanges {
+		if !held[t] {
+			delete(taskChanges, t)
+			c.TaskChanges++
+			for _, change := range changes {
+				c.Bytes += int64(unsafe.Sizeof(change)) + int64(len(change.Field)+len(change.Before)+len(change.After))
+			}
+		}
+	}
+
+	for _, e := range trimHistory() {
+		c.Events++
+		c.Bytes += int64(unsafe.Sizeof(*e)+unsafe.Sizeof(e)) + int64(len(e.List)+len(e.Task)+len(e.From)+len(e.To))
+	}
+
+	accessLock.Lock()
+	defer accessLock.Unlock()
+	for list := range accessed {
+		if data[list.Name] != list {
+			delete(accessed, list)
+			c.AccessRecords++
+			c.Bytes += int64(unsafe.Sizeof(list) + unsafe.Sizeof(time.Time{}))
+		}
+	}
+	return c
+}
+
+// tombstonesSize estimates the memory held by the tombstones, in bytes
+func tombstonesSize() int64 {
+	size := int64(0)
+	for key := range tombstones {
+		// the map entry and the queue entry share the key
+		size += int64(len(key)) + int64(unsafe.Sizeof(time.Time{})+unsafe.Sizeof(tombstone{}))
+	}
+	return size
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	COMPACTION
+*******************************/
+
+func TestCompact_ok(t *testing.T) {
+	defer func() { now = time.Now }()
+	CreateToDoListWithTasks("ListCompact", []string{"Kept", "Expired", "Recent"})
+	RemoveTask("ListCompact", "Expired")
+	// the tombstones expire without any later deletion pruning them
+	now = func() time.Time { return time.Now().Add(2 * DefaultTombstoneRetention) }
+	// an orphaned index entry and the changes of a task no longer stored
+	stray := &Task{ToDoList: "ListCompactGone", Title: "Stray", TaskDetails: dueOn(2032, 1, 1, 9)}
+	lock.Lock()
+	indexDueDate(stray)
+	recordTaskChange(stray, TaskChange{Type: EventTaskCreated})
+	lock.Unlock()
+
+	c := Compact()
+	if c.Tombstones < 1 || c.OrphanedTasks < 1 || c.TaskChanges < 1 {
+		t.Errorf("expected a tombstone, an orphan and task changes dropped, got %+v", c)
+	}
+	if _, err := GetTask("ListCompact", "Expired"); err == nil {
+		t.Errorf("expected error task not found")
+	} else if _, gone := err.(*GoneError); gone {
+		t.Errorf("expected the expired tombstone dropped, got %v", err)
+	}
+	if changes, _ := TaskHistory("ListCompact", "Kept"); len(changes) == 0 {
+		t.Errorf("expected the history of Kept kept")
+	}
+	if orphans := FindOrphanedTasks(); len(orphans) != 0 {
+		t.Errorf("expected no orphan left, got %+v", orphans)
+	}
+	RemoveTask("ListCompact", "Recent")
+	if c := Compact(); c.Dropped() != 0 {
+		t.Errorf("expected nothing left to compact, got %+v", c)
+	}
+	if _, err := GetTask("ListCompact", "Recent"); err == nil {
+		t.Errorf("expected error task not found")
+	} else if _, gone := err.(*GoneError); !gone {
+		t.Errorf("expected the recent tombstone kept, got %v", err)
+	}
+}
+
+func TestCompact_history(t *testing.T) {
+	defer func() { now = time.Now }()
+	defer SetHistoryRetention(DefaultHistoryRetention)
+	SetHistoryRetention(time.Hour)
+	CreateToDoListWithTasks("ListCompactHistory", []string{"Open", "Done"})
+	UpdateTask("ListCompactHistory", "Done", "Done", true)
+	day := time.Now().UTC()
+	stats, _ := StatsHistory("ListCompactHistory", 1, day, time.UTC)
+	seq := CurrentSeq()
+
+	now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	c := Compact()
+	if c.Events < 4 || c.Bytes <= 0 {
+		t.Errorf("expected the events dropped and their size estimated, got %+v", c)
+	}
+	if feed, _ := ActivityFeed(ActivityFilter{List: "ListCompactHistory"}, 0, 10); len(feed) != 0 {
+		t.Errorf("expected the events of the list dropped, got %+v", feed)
+	}
+	if after, _ := StatsHistory("ListCompactHistory", 1, day, time.UTC); after[0].Open != stats[0].Open || after[0].Open != 1 {
+		t.Errorf("expected 1 open task still counted, got %+v", after)
+	}
+	if CurrentSeq() != seq {
+		t.Errorf("expected the sequence kept at %d, got %d", seq, CurrentSeq())
+	}
+	AddTask("ListCompactHistory", "New")
+	if feed, _ := ActivityFeed(ActivityFilter{List: "ListCompactHistory"}, 0, 10); len(feed) != 1 || feed[0].Seq != seq+1 {
+		t.Errorf("expected the new event numbered %d, got %+v", seq+1, feed)
+	}
+}
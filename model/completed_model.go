@@ -0,0 +1,101 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// now is the clock used to timestamp tasks, replaceable in tests
+var now = time.Now
+
+// completedIndex buckets the completed tasks by UTC day ("2006-01-02"), so
+// that a day query only visits the tasks completed around that day.
+var completedIndex map[string][]*Task
+
+// CompletedTasks reports the tasks completed on a given day across all lists
+type CompletedTasks struct {
+	Day         string
+	Tasks       []*Task
+	Count       int
+	CountByList map[string]int
+}
+
+// DayBounds returns the [start, end) interval of the calendar day of t in
+// the given location. Every endpoint bucketing data by day must use it, so
+// that day boundaries are the same everywhere.
+func DayBounds(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	t = t.In(loc)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// CompletedTasksOn returns the tasks completed during the calendar day of
+// day in the given location, ordered by completion time.
+func CompletedTasksOn(day time.Time, loc *time.Location) (*CompletedTasks, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	start, end := DayBounds(day, loc)
+	result := &CompletedTasks{
+		Day:         start.Format("2006-01-02"),
+		Tasks:       snapshotTasks(completedBetween(start, end)),
+		CountByList: map[string]int{}}
+
+	for _, t := range result.Tasks {
+		result.CountByList[t.ToDoList]++
+	}
+	result.Count = len(result.Tasks)
+	return result, nil
+}
+
+// completedBetween returns the tasks completed in [start, end), ordered by
+// completion time then creation, visiting only the index buckets of the interval.
+func completedBetween(start, end time.Time) []*Task {
+	tasks := []*Task{}
+	first, _ := DayBounds(start, time.UTC)
+	for d := first; d.Before(end); d = d.AddDate(0, 0, 1) {
+		for _, t := range completedIndex[d.Format("2006-01-02")] {
+			if !t.CompletedAt.Before(start) && t.CompletedAt.Before(end) {
+				tasks = append(tasks, t)
+			}
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if a, b := tasks[i].CompletedAt, tasks[j].CompletedAt; !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return createdBefore(tasks[i], tasks[j])
+	})
+	return tasks
+}
+
+func completionKey(t *Task) string {
+	return t.CompletedAt.UTC().Format("2006-01-02")
+}
+
+func indexCompletion(t *Task) {
+	if t.CompletedAt == nil {
+		return
+	}
+	if completedIndex == nil {
+		completedIndex = make(map[string][]*Task)
+	}
+	key := completionKey(t)
+	completedIndex[key] = append(completedIndex[key], t)
+}
+
+func unindexCompletion(t *Task) {
+	if t.CompletedAt == nil {
+		return
+	}
+	key := completionKey(t)
+	bucket := completedIndex[key]
+	for i, indexed := range bucket {
+		if indexed == t {
+			completedIndex[key] = append(bucket[:i], bucket[i+1:]...)
+			break
+		}
+	}
+	if len(completedIndex[key]) == 0 {
+		delete(completedIndex, key)
+	}
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	COMPLETED Tasks
+*******************************/
+
+func TestCompletedTasksOn_ok(t *testing.T) {
+	rome := time.FixedZone("CEST", 2*60*60)
+	defer func() { now = time.Now }()
+
+	CreateToDoList("ListCompleted")
+	AddTask("ListCompleted", "Late")
+	AddTask("ListCompleted", "Early")
+	AddTask("ListCompleted", "Open")
+
+	// 2024-06-01 23:30 in Rome is 2024-06-01 21:30 UTC
+	now = func() time.Time { return time.Date(2024, 6, 1, 21, 30, 0, 0, time.UTC) }
+	UpdateTask("ListCompleted", "Late", "Late", true)
+	// 2024-06-01 00:30 in Rome is 2024-05-31 22:30 UTC
+	now = func() time.Time { return time.Date(2024, 5, 31, 22, 30, 0, 0, time.UTC) }
+	UpdateTask("ListCompleted", "Early", "Early", true)
+
+	completed, err := CompletedTasksOn(time.Date(2024, 6, 1, 12, 0, 0, 0, rome), rome)
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if completed.Count != 2 || len(completed.Tasks) != 2 {
+		t.Fatalf("expected 2 tasks completed on 2024-06-01, got %d", completed.Count)
+	}
+	if completed.Tasks[0].Title != "Early" || completed.Tasks[1].Title != "Late" {
+		t.Errorf("expected tasks ordered by completion, got %s, %s", completed.Tasks[0].Title, completed.Tasks[1].Title)
+	}
+	if completed.CountByList["ListCompleted"] != 2 {
+		t.Errorf("expected 2 tasks completed in ListCompleted, got %d", completed.CountByList["ListCompleted"])
+	}
+
+	completed, _ = CompletedTasksOn(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), time.UTC)
+	if completed.Count != 1 {
+		t.Errorf("expected 1 task completed on 2024-06-01 UTC, got %d", completed.Count)
+	}
+}
+
+func TestCompletedTasksOn_undone_ok(t *testing.T) {
+	rome := time.FixedZone("CEST", 2*60*60)
+	task, _ := UpdateTask("ListCompleted", "Late", "Late", false)
+	if task.CompletedAt != nil {
+		t.Errorf("expected completion timestamp cleared, got %v", task.CompletedAt)
+	}
+
+	completed, _ := CompletedTasksOn(time.Date(2024, 6, 1, 12, 0, 0, 0, rome), rome)
+	if completed.Count != 1 {
+		t.Errorf("expected 1 task completed on 2024-06-01, got %d", completed.Count)
+	}
+}
+
+func TestCompletedTasksOn_deletedList_ok(t *testing.T) {
+	rome := time.FixedZone("CEST", 2*60*60)
+	DeleteToDoList("ListCompleted")
+
+	completed, _ := CompletedTasksOn(time.Date(2024, 6, 1, 12, 0, 0, 0, rome), rome)
+	if completed.Count != 0 {
+		t.Errorf("expected no task completed on 2024-06-01, got %d", completed.Count)
+	}
+}
@@ -0,0 +1,47 @@
+package model
+
+import "fmt"
+
+// SetTaskDeferred parks the task as someday/maybe, or brings it back to the
+// active views. Deferred tasks are left out of the pending tasks, the overdue
+// ones, the calendar, the digests and the reviews, and are un-deferred when
+// completed. Done tasks can not be deferred.
+func SetTaskDeferred(todoListName string, taskTitle string, deferred bool) (*Task, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	task, err := getTask(todoListName, taskTitle)
+	if err != nil {
+		return nil, err
+	}
+	if deferred && task.Done {
+		return nil, &ValidationError{fmt.Sprintf("the task %s is done and can not be deferred", task.Title)}
+	}
+	if deferred != task.Deferred {
+		recordTaskChange(task, TaskChange{Type: EventTaskUpdated, Field: "Deferred",
+			Before: changeValue(task.Deferred), After: changeValue(deferred)})
+		task.Deferred = deferred
+	}
+	return copyTask(task), nil
+}
+
+// GetDeferredTasks returns the deferred tasks of the ToDo list, in insertion
+// order
+func GetDeferredTasks(listKey string) ([]*Task, error) {
+	tasks, err := GetTasks(listKey)
+	if err != nil {
+		return nil, err
+	}
+	return FilterDeferredTasks(tasks, true), nil
+}
+
+// FilterDeferredTasks returns the deferred tasks, or the other ones when
+// deferred is false
+func FilterDeferredTasks(tasks []*Task, deferred bool) []*Task {
+	filtered := []*Task{}
+	for _, t := range tasks {
+		if t.Deferred == deferred {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
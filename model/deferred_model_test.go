@@ -0,0 +1,73 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	DEFERRED Tasks
+*******************************/
+
+func TestSetTaskDeferred_ok(t *testing.T) {
+	CreateToDoList("ListDeferred")
+	AddTask("ListDeferred", "Learn Japanese")
+	AddTask("ListDeferred", "Pay rent")
+
+	task, err := SetTaskDeferred("ListDeferred", "Learn Japanese", true)
+	if err != nil || !task.Deferred {
+		t.Fatalf("expected the task deferred, got %v, %v", task, err)
+	}
+	tasks, err := GetDeferredTasks("ListDeferred")
+	if err != nil || len(tasks) != 1 || tasks[0].Title != "Learn Japanese" {
+		t.Errorf("expected Learn Japanese deferred, got %v, %v", tasks, err)
+	}
+	all, _ := GetTasks("ListDeferred")
+	if active := FilterDeferredTasks(all, false); len(active) != 1 || active[0].Title != "Pay rent" {
+		t.Errorf("expected Pay rent active, got %v", active)
+	}
+	if next, _ := NextActionableTask("ListDeferred"); next == nil || next.Title != "Pay rent" {
+		t.Errorf("expected Pay rent next, got %v", next)
+	}
+	changes, _ := TaskHistory("ListDeferred", "Learn Japanese")
+	if last := changes[len(changes)-1]; last.Field != "Deferred" || string(last.After) != "true" {
+		t.Errorf("expected the deferral recorded, got %+v", last)
+	}
+
+	UpdateTask("ListDeferred", "Learn Japanese", "Learn Japanese", true)
+	if task, _ := GetTask("ListDeferred", "Learn Japanese"); task.Deferred {
+		t.Errorf("expected the completed task un-deferred")
+	}
+	if _, err := SetTaskDeferred("ListDeferred", "Learn Japanese", true); err == nil {
+		t.Errorf("expected error deferring a done task")
+	}
+	if _, err := SetTaskDeferred("ListDeferred", "invalid", true); err == nil {
+		t.Errorf("expected error task not found")
+	}
+	if _, err := GetDeferredTasks("invalid"); err == nil {
+		t.Errorf("expected error with a missing list")
+	}
+}
+
+func TestSetTaskDeferred_notOverdue(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC) }
+	CreateToDoList("ListDeferredOverdue")
+	AddTaskWithDetails("ListDeferredOverdue", "Someday", dueOn(2024, 6, 3, 9))
+	SetTaskDeferred("ListDeferredOverdue", "Someday", true)
+
+	all, _ := AllOverdueTasks(time.UTC)
+	if overdue := overdueOf(all, "ListDeferredOverdue"); len(overdue) != 0 {
+		t.Errorf("expected the deferred task not overdue, got %v", overdue)
+	}
+	calendar, _ := Calendar("ListDeferredOverdue", time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), time.UTC)
+	if calendar[0].DueCount != 0 {
+		t.Errorf("expected the deferred task out of the calendar, got %v", calendar[0].Due)
+	}
+
+	SetTaskDeferred("ListDeferredOverdue", "Someday", false)
+	all, _ = AllOverdueTasks(time.UTC)
+	if overdue := overdueOf(all, "ListDeferredOverdue"); len(overdue) != 1 {
+		t.Errorf("expected the un-deferred task overdue, got %v", overdue)
+	}
+}
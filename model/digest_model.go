@@ -0,0 +1,123 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// digestWeekStart is the first day of the weeks of the digests, see
+// SetDigestWeekStart
+var digestWeekStart = time.Monday
+
+// WeeklyDigest is a snapshot of the progress of the current week across all
+// the ToDo lists: the tasks completed since the start of the week, the ones
+// overdue, the ones due by the end of the next week and the progress of each
+// list.
+type WeeklyDigest struct {
+	Week        string
+	Start       time.Time
+	End         time.Time
+	GeneratedAt time.Time
+	Completed   []*Task
+	Overdue     []*Task
+	Upcoming    []*Task
+	Lists       []ListProgress
+}
+
+// ListProgress is the progress of a ToDo list in a weekly digest
+type ListProgress struct {
+	List string
+	// Tasks and Done count all the tasks of the list and the done ones
+	Tasks     int
+	Done      int
+	Completed int
+	Overdue   int
+	Upcoming  int
+}
+
+// SetDigestWeekStart sets the first day of the weeks of the digests, Monday
+// (default) or Sunday
+func SetDigestWeekStart(weekStart time.Weekday) error {
+	if weekStart != time.Monday && weekStart != time.Sunday {
+		return fmt.Errorf("invalid week start %s, expected Monday or Sunday", weekStart)
+	}
+	digestWeekStart = weekStart
+	return nil
+}
+
+// DigestWeekStart returns the first day of the weeks of the digests
+func DigestWeekStart() time.Weekday {
+	return digestWeekStart
+}
+
+// GenerateWeeklyDigest returns the digest of the current week in the given
+// location, weeks starting on the configured day
+func GenerateWeeklyDigest(loc *time.Location) *WeeklyDigest {
+	return WeeklyDigestAt(now(), digestWeekStart, loc)
+}
+
+// WeeklyDigestAt returns the digest of the week containing at, as of at: the
+// tasks completed from the start of the week to at, the open tasks due before
+// at (overdue) and until the end of the following week (upcoming). Lists are
+// ordered by name, completed tasks by completion time and the others by due
+// date.
+func WeeklyDigestAt(at time.Time, weekStart time.Weekday, loc *time.Location) *WeeklyDigest {
+	start := WeekStart(at, weekStart, loc)
+	end := start.AddDate(0, 0, 7)
+	upcomingEnd := end.AddDate(0, 0, 7)
+	year, week := start.AddDate(0, 0, 3).ISOWeek()
+	digest := &WeeklyDigest{
+		Week:        fmt.Sprintf("%04d-W%02d", year, week),
+		Start:       start,
+		End:         end,
+		GeneratedAt: at,
+		Completed:   []*Task{},
+		Overdue:     []*Task{},
+		Upcoming:    []*Task{},
+		Lists:       []ListProgress{}}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	byList := make(map[string]*ListProgress, len(data))
+	for name, list := range data {
+		progress := &ListProgress{List: name, Tasks: len(list.Tasks)}
+		byList[name] = progress
+		for _, t := range list.Tasks {
+			if t.Done {
+				progress.Done++
+				continue
+			}
+			if t.Deferred || t.DueDate == nil {
+				continue
+			}
+			due, overdueAt := dueBounds(t, loc)
+			if !due.Before(upcomingEnd) {
+				continue
+			}
+			if !at.Before(overdueAt) {
+				digest.Overdue = append(digest.Overdue, copyTask(t))
+				progress.Overdue++
+			} else {
+				digest.Upcoming = append(digest.Upcoming, copyTask(t))
+				progress.Upcoming++
+			}
+		}
+	}
+	for _, t := range completedBetween(start, at) {
+		if progress, ok := byList[t.ToDoList]; ok {
+			digest.Completed = append(digest.Completed, copyTask(t))
+			progress.Completed++
+		}
+	}
+
+	for _, progress := range byList {
+		digest.Lists = append(digest.Lists, *progress)
+	}
+	sort.Slice(digest.Lists, func(i, j int) bool {
+		return digest.Lists[i].List < digest.Lists[j].List
+	})
+	sortTasksBy(digest.Overdue, func(t *Task) time.Time { return *t.DueDate })
+	sortTasksBy(digest.Upcoming, func(t *Task) time.Time { return *t.DueDate })
+	return digest
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	WEEKLY Digest
+*******************************/
+
+func TestWeeklyDigestAt_ok(t *testing.T) {
+	defer func() { now = time.Now }()
+	// Wednesday 2019-06-05
+	at := time.Date(2019, 6, 5, 12, 0, 0, 0, time.UTC)
+	dueOn := func(d int) TaskDetails {
+		due := time.Date(2019, 6, d, 9, 0, 0, 0, time.UTC)
+		return TaskDetails{DueDate: &due}
+	}
+
+	now = func() time.Time { return time.Date(2019, 5, 20, 9, 0, 0, 0, time.UTC) }
+	CreateToDoList("ListDigest")
+	AddTaskWithDetails("ListDigest", "Overdue", dueOn(4))
+	AddTaskWithDetails("ListDigest", "Tomorrow", dueOn(6))
+	AddTaskWithDetails("ListDigest", "Next week", dueOn(14))
+	AddTaskWithDetails("ListDigest", "Later", dueOn(20))
+	AddTask("ListDigest", "Done last week")
+	AddTask("ListDigest", "Done this week")
+	now = func() time.Time { return time.Date(2019, 5, 31, 9, 0, 0, 0, time.UTC) }
+	UpdateTask("ListDigest", "Done last week", "Done last week", true)
+	now = func() time.Time { return time.Date(2019, 6, 4, 9, 0, 0, 0, time.UTC) }
+	UpdateTask("ListDigest", "Done this week", "Done this week", true)
+
+	digest := WeeklyDigestAt(at, time.Monday, time.UTC)
+	if digest.Week != "2019-W23" || !digest.Start.Equal(time.Date(2019, 6, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected week 2019-W23 starting on Monday 3rd, got %s %v", digest.Week, digest.Start)
+	}
+	titles := func(tasks []*Task) []string {
+		var titles []string
+		for _, t := range tasks {
+			if t.ToDoList == "ListDigest" {
+				titles = append(titles, t.Title)
+			}
+		}
+		return titles
+	}
+	if completed := titles(digest.Completed); len(completed) != 1 || completed[0] != "Done this week" {
+		t.Errorf("expected Done this week completed, got %v", completed)
+	}
+	if overdue := titles(digest.Overdue); len(overdue) != 1 || overdue[0] != "Overdue" {
+		t.Errorf("expected Overdue overdue, got %v", overdue)
+	}
+	if upcoming := titles(digest.Upcoming); len(upcoming) != 2 || upcoming[0] != "Tomorrow" || upcoming[1] != "Next week" {
+		t.Errorf("expected Tomorrow and Next week upcoming, got %v", upcoming)
+	}
+	for _, l := range digest.Lists {
+		if l.List == "ListDigest" && (l.Tasks != 6 || l.Done != 2 || l.Completed != 1 || l.Overdue != 1 || l.Upcoming != 2) {
+			t.Errorf("unexpected progress %+v", l)
+		}
+	}
+
+	// weeks starting on Sunday begin on the 2nd
+	if digest := WeeklyDigestAt(at, time.Sunday, time.UTC); !digest.Start.Equal(time.Date(2019, 6, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the week starting on Sunday 2nd, got %v", digest.Start)
+	}
+}
+
+func TestSetDigestWeekStart_error(t *testing.T) {
+	if err := SetDigestWeekStart(time.Friday); err == nil {
+		t.Errorf("expected error with a week starting on Friday")
+	}
+	if DigestWeekStart() != time.Monday {
+		t.Errorf("expected the default week start kept, got %s", DigestWeekStart())
+	}
+}
@@ -0,0 +1,271 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// DuplicateGroup is a group of tasks of a ToDo list with the same normalized
+// title, and the same tags when grouping by tags. Key identifies the group.
+type DuplicateGroup struct {
+	Key   string
+	Tasks []Task
+}
+
+// DuplicateMerge reports a group of duplicates collapsed into its first task
+type DuplicateMerge struct {
+	Key     string
+	Task    Task
+	Removed []string
+}
+
+// FindDuplicateTasks returns the groups of tasks of the ToDo list whose
+// titles only differ by case and spacing, in the order of their first task,
+// the tasks of a group being in the order of the list
+func FindDuplicateTasks(listKey string) ([]DuplicateGroup, error) {
+	return findDuplicates(listKey, false)
+}
+
+// FindDuplicateTasksByTags returns the groups of duplicate tasks as
+// FindDuplicateTasks, the tasks of a group having the same tags too, ignoring
+// their case and order
+func FindDuplicateTasksByTags(listKey string) ([]DuplicateGroup, error) {
+	return findDuplicates(listKey, true)
+}
+
+func findDuplicates(listKey string, byTags bool) ([]DuplicateGroup, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return nil, err
+	}
+	groups := []DuplicateGroup{}
+	for _, group := range duplicateGroups(list, byTags) {
+		tasks := make([]Task, len(group.tasks))
+		for i, t := range group.tasks {
+			tasks[i] = *copyTask(t)
+		}
+		groups = append(groups, DuplicateGroup{Key: group.key, Tasks: tasks})
+	}
+	return groups, nil
+}
+
+// MergeDuplicateTasks collapses each group of duplicate tasks of the ToDo
+// list into its first task, the others being removed, and reports the merged
+// groups. Only the groups with the given keys are merged, all of them when
+// keys is empty. The kept task gets the tags, checklist items and metadata
+// of the removed ones, missing from it, their description and location when
+// it has none, the earliest due date and the highest priority; it is done
+// when all the duplicates are done.
+func MergeDuplicateTasks(listKey string, keys []string, byTags bool) ([]DuplicateMerge, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		selected[key] = true
+	}
+
+	// the merges are computed and vetoed as a whole before being applied, so
+	// that a veto leaves the list untouched
+	var groups []duplicateTasks
+	var mergedTasks []*Task
+	for _, group := range duplicateGroups(list, byTags) {
+		if len(keys) > 0 && !selected[group.key] {
+			continue
+		}
+		kept := group.tasks[0]
+		merged := copyTask(kept)
+		for _, t := range group.tasks[1:] {
+			mergeTask(merged, t)
+			if err := vetoEvent(EventTaskDeleted, list.Name, t, nil); err != nil {
+				return nil, err
+			}
+		}
+		if err := vetoEvent(EventTaskUpdated, list.Name, kept, merged); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+		mergedTasks = append(mergedTasks, merged)
+	}
+
+	merges := []DuplicateMerge{}
+	removed := map[*Task]bool{}
+	for i, group := range groups {
+		kept, merged := group.tasks[0], mergedTasks[i]
+		merge := DuplicateMerge{Key: group.key}
+		for _, t := range group.tasks[1:] {
+			removed[t] = true
+			merge.Removed = append(merge.Removed, t.Title)
+		}
+		before := copyTask(kept)
+		unindexDueDate(kept)
+		kept.TaskDetails = merged.TaskDetails
+		indexDueDate(kept)
+		rearmReminder(before, kept)
+		resortTask(list, kept)
+		updateChecklistProgress(kept)
+		recordTaskUpdate(before, kept)
+		setTaskDone(kept, merged.Done)
+		dispatchEvent(EventTaskUpdated, list.Name, before, kept)
+		merge.Task = *copyTask(kept)
+		merges = append(merges, merge)
+	}
+
+	if len(removed) > 0 {
+		tasks := list.Tasks[:0]
+		for _, t := range list.Tasks {
+			if !removed[t] {
+				tasks = append(tasks, t)
+				continue
+			}
+			unindexCompletion(t)
+			unindexDueDate(t)
+			recordTaskDeleted(t)
+		}
+		list.Tasks = tasks
+		invalidateTitles(list)
+		list.TaskNumber = len(tasks)
+	}
+	return merges, nil
+}
+
+// duplicateTasks is a group of duplicate stored tasks
+type duplicateTasks struct {
+	key   string
+	tasks []*Task
+}
+
+// duplicateGroups returns the groups of at least two duplicate tasks, the
+// candidates being found with the title index
+func duplicateGroups(list *ToDoList, byTags bool) []duplicateTasks {
+	var groups []duplicateTasks
+	titles := titleIndex(list)
+	seen := map[string]bool{}
+	for _, t := range list.Tasks {
+		title := normalizeTitle(t.Title)
+		if seen[title] || len(titles[title]) < 2 {
+			continue
+		}
+		seen[title] = true
+		if !byTags {
+			groups = append(groups, duplicateTasks{key: title, tasks: titles[title]})
+			continue
+		}
+		var byTag []duplicateTasks
+		index := map[string]int{}
+		for _, candidate := range titles[title] {
+			key := title + " [" + strings.Join(normalizeTags(candidate.Tags), ", ") + "]"
+			i, ok := index[key]
+			if !ok {
+				i = len(byTag)
+				index[key] = i
+				byTag = append(byTag, duplicateTasks{key: key})
+			}
+			byTag[i].tasks = append(byTag[i].tasks, candidate)
+		}
+		for _, group := range byTag {
+			if len(group.tasks) > 1 {
+				groups = append(groups, group)
+			}
+		}
+	}
+	if byTags {
+		// groups of the same title may start after the ones of later titles
+		position := make(map[*Task]int, len(list.Tasks))
+		for i, t := range list.Tasks {
+			position[t] = i
+		}
+		sort.SliceStable(groups, func(i, j int) bool {
+			return position[groups[i].tasks[0]] < position[groups[j].tasks[0]]
+		})
+	}
+	return groups
+}
+
+// normalizeTitle returns the title in lower case, its spaces collapsed
+func normalizeTitle(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// normalizeTags returns the distinct tags in lower case, sorted
+func normalizeTags(tags []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// mergeTask merges the attributes of the duplicate into the copy of the kept
+// task, which is reopened unless the duplicate is done
+func mergeTask(kept, duplicate *Task) {
+	if kept.Description == "" {
+		kept.Description = duplicate.Description
+	}
+	if kept.Location == nil {
+		kept.Location = duplicate.Location
+	}
+	if kept.WaitingOn == "" {
+		kept.WaitingOn = duplicate.WaitingOn
+	}
+	if duplicate.Priority != PriorityNone && (kept.Priority == PriorityNone || duplicate.Priority < kept.Priority) {
+		kept.Priority = duplicate.Priority
+	}
+	if duplicate.DueDate != nil && (kept.DueDate == nil || duplicate.DueDate.Before(*kept.DueDate)) {
+		kept.DueDate = duplicate.DueDate
+		kept.AllDay = duplicate.AllDay
+	}
+	if kept.ReminderLead == "" && kept.DueDate != nil {
+		kept.ReminderLead = duplicate.ReminderLead
+	}
+
+	tags := map[string]bool{}
+	for _, tag := range kept.Tags {
+		tags[strings.ToLower(tag)] = true
+	}
+	for _, tag := range duplicate.Tags {
+		if !tags[strings.ToLower(tag)] {
+			tags[strings.ToLower(tag)] = true
+			kept.Tags = append(kept.Tags, tag)
+		}
+	}
+
+	items := map[string]bool{}
+	for _, item := range kept.Checklist {
+		items[item.Text] = true
+	}
+	for _, item := range duplicate.Checklist {
+		if !items[item.Text] {
+			items[item.Text] = true
+			kept.Checklist = append(kept.Checklist, item)
+		}
+	}
+	updateChecklistProgress(kept)
+
+	if len(duplicate.Meta) > 0 {
+		meta := make(map[string]string, len(kept.Meta)+len(duplicate.Meta))
+		for key, value := range duplicate.Meta {
+			meta[key] = value
+		}
+		for key, value := range kept.Meta {
+			meta[key] = value
+		}
+		kept.Meta = meta
+	}
+
+	if !duplicate.Done {
+		kept.Done = false
+		kept.CompletedAt = nil
+	}
+}
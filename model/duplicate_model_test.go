@@ -0,0 +1,87 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	FIND duplicate tasks
+*******************************/
+
+func TestFindDuplicateTasks_ok(t *testing.T) {
+	CreateToDoList("ListDuplicates")
+	AddTaskWithDetails("ListDuplicates", "Buy milk", TaskDetails{Tags: []string{"shop"}})
+	AddTask("ListDuplicates", "Call Bob")
+	AddTaskWithDetails("ListDuplicates", " buy  MILK", TaskDetails{Tags: []string{"Shop"}})
+	AddTask("ListDuplicates", "buy milk")
+
+	groups, err := FindDuplicateTasks("ListDuplicates")
+	if err != nil || len(groups) != 1 {
+		t.Fatalf("expected one group, got %v, %v", groups, err)
+	}
+	if groups[0].Key != "buy milk" || len(groups[0].Tasks) != 3 || groups[0].Tasks[1].Title != " buy  MILK" {
+		t.Errorf("expected the three milk tasks in insertion order, got %+v", groups[0])
+	}
+
+	groups, _ = FindDuplicateTasksByTags("ListDuplicates")
+	if len(groups) != 1 || groups[0].Key != "buy milk [shop]" || len(groups[0].Tasks) != 2 {
+		t.Errorf("expected the two milk tasks tagged shop, got %+v", groups)
+	}
+}
+
+func TestFindDuplicateTasks_error(t *testing.T) {
+	if _, err := FindDuplicateTasks("invalid"); err == nil {
+		t.Errorf("expected error with a missing list")
+	}
+}
+
+/*******************************
+	MERGE duplicate tasks
+*******************************/
+
+func TestMergeDuplicateTasks_ok(t *testing.T) {
+	early := time.Date(2031, 6, 1, 0, 0, 0, 0, time.UTC)
+	late := early.AddDate(0, 0, 7)
+	CreateToDoList("ListDuplicatesMerged")
+	AddTaskWithDetails("ListDuplicatesMerged", "Buy milk", TaskDetails{DueDate: &late, Priority: PriorityLow,
+		Tags: []string{"shop"}, Meta: map[string]string{"store": "corner"}})
+	UpdateTask("ListDuplicatesMerged", "Buy milk", "Buy milk", true)
+	AddTaskWithDetails("ListDuplicatesMerged", "buy milk", TaskDetails{DueDate: &early, Priority: PriorityHigh,
+		Description: "2 liters", Tags: []string{"SHOP", "dairy"}, Meta: map[string]string{"store": "mall", "brand": "any"},
+		Checklist: []ChecklistItem{{Text: "check the fridge"}}})
+	AddTask("ListDuplicatesMerged", "Call Bob")
+	AddTask("ListDuplicatesMerged", "call bob")
+
+	merges, err := MergeDuplicateTasks("ListDuplicatesMerged", []string{"buy milk"}, false)
+	if err != nil || len(merges) != 1 || len(merges[0].Removed) != 1 || merges[0].Removed[0] != "buy milk" {
+		t.Fatalf("expected the milk tasks merged, got %+v, %v", merges, err)
+	}
+	task, _ := GetTask("ListDuplicatesMerged", "Buy milk")
+	if task.Priority != PriorityHigh || !task.DueDate.Equal(early) || task.Description != "2 liters" {
+		t.Errorf("expected the highest priority, earliest due date and the description, got %+v", task)
+	}
+	if len(task.Tags) != 2 || task.Meta["store"] != "corner" || task.Meta["brand"] != "any" || len(task.Checklist) != 1 {
+		t.Errorf("expected the tags, metadata and checklist merged, got %+v", task)
+	}
+	if task.Done {
+		t.Errorf("expected the task reopened, a duplicate not being done")
+	}
+	if list, _ := GetToDoList("ListDuplicatesMerged"); list.TaskNumber != 3 {
+		t.Errorf("expected 3 tasks left, got %d", list.TaskNumber)
+	}
+
+	merges, _ = MergeDuplicateTasks("ListDuplicatesMerged", nil, false)
+	if len(merges) != 1 || merges[0].Task.Title != "Call Bob" {
+		t.Errorf("expected the remaining group merged, got %+v", merges)
+	}
+	if groups, _ := FindDuplicateTasks("ListDuplicatesMerged"); len(groups) != 0 {
+		t.Errorf("expected no duplicates left, got %+v", groups)
+	}
+}
+
+func TestMergeDuplicateTasks_error(t *testing.T) {
+	if _, err := MergeDuplicateTasks("invalid", nil, false); err == nil {
+		t.Errorf("expected error with a missing list")
+	}
+}
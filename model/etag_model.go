@@ -0,0 +1,23 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// TaskETag returns the entity tag of the task, a strong validator changing
+// whenever any of its attributes, timestamps included, changes
+func TaskETag(t *Task) string {
+	content, _ := json.Marshal(t)
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ToDoListETag returns the entity tag of the ToDo list, changing whenever the
+// list or any of its tasks changes
+func ToDoListETag(l *ToDoList) string {
+	content, _ := json.Marshal(l)
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+/*******************************
+	ETAG
+*******************************/
+
+func TestTaskETag_ok(t *testing.T) {
+	CreateToDoList("ListETag")
+	AddTask("ListETag", "Task1")
+	AddTask("ListETag", "Task2")
+
+	task, _ := GetTask("ListETag", "Task1")
+	etag := TaskETag(task)
+	if again, _ := GetTask("ListETag", "Task1"); TaskETag(again) != etag {
+		t.Errorf("expected the same ETag for an unchanged task, got %s and %s", etag, TaskETag(again))
+	}
+	if other, _ := GetTask("ListETag", "Task2"); TaskETag(other) == etag {
+		t.Errorf("expected different ETags for different tasks, got %s", etag)
+	}
+
+	task, _ = UpdateTask("ListETag", "Task1", "Task1", true)
+	if TaskETag(task) == etag {
+		t.Errorf("expected the ETag to change with the task, got %s", etag)
+	}
+}
+
+func TestTaskJSON_stableKeyOrder(t *testing.T) {
+	meta := map[string]string{}
+	for _, key := range []string{"zeta", "alpha", "mid", "beta", "omega", "gamma", "delta", "epsilon"} {
+		meta[key] = key
+	}
+	CreateToDoList("ListStableJSON")
+	AddTaskWithDetails("ListStableJSON", "Task1", TaskDetails{Meta: meta})
+	task, _ := GetTask("ListStableJSON", "Task1")
+
+	first, _ := json.Marshal(task)
+	for i := 0; i < 20; i++ {
+		if again, _ := json.Marshal(cloneTask(task)); !bytes.Equal(first, again) {
+			t.Fatalf("expected byte-identical encodings, got %s and %s", first, again)
+		}
+	}
+	if !strings.Contains(string(first), `"Meta":{"alpha":"alpha","beta":"beta","delta":"delta","epsilon":"epsilon",`) {
+		t.Errorf("expected the metadata keys sorted, got %s", first)
+	}
+	if etag := TaskETag(task); etag != TaskETag(cloneTask(task)) {
+		t.Errorf("expected a stable ETag, got %s", etag)
+	}
+}
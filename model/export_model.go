@@ -0,0 +1,145 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExportVersion is the version of the export document format
+const ExportVersion = 1
+
+// ToDoListExport bundles ToDo lists with their tasks in a single document,
+// the keys that could not be exported are reported in Errors.
+type ToDoListExport struct {
+	Version int
+	Lists   []ToDoList
+	Errors  []ExportError `json:",omitempty"`
+}
+
+// ExportError reports a ToDo list that could not be exported
+type ExportError struct {
+	Key   string
+	Error string
+}
+
+// ExportLists returns a snapshot of the requested ToDo lists and their tasks.
+// Missing lists do not fail the export, they are reported in the Errors section.
+func ExportLists(keys []string) (*ToDoListExport, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("empty list of ToDo lists to export")
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	export := &ToDoListExport{Version: ExportVersion, Lists: []ToDoList{}}
+	exported := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if exported[key] {
+			continue
+		}
+		exported[key] = true
+
+		list, err := getToDoList(key)
+		if err != nil {
+			export.Errors = append(export.Errors, ExportError{Key: key, Error: err.Error()})
+			continue
+		}
+		export.Lists = append(export.Lists, *cloneToDoList(list))
+	}
+	return export, nil
+}
+
+// ExportAll returns a snapshot of all the ToDo lists and their tasks, ordered
+// by name, taken at once
+func ExportAll() *ToDoListExport {
+	lock.RLock()
+	defer lock.RUnlock()
+	export := &ToDoListExport{Version: ExportVersion, Lists: make([]ToDoList, 0, len(data))}
+	for _, list := range data {
+		export.Lists = append(export.Lists, *cloneToDoList(list))
+	}
+	sort.Slice(export.Lists, func(i, j int) bool {
+		return export.Lists[i].Name < export.Lists[j].Name
+	})
+	return export
+}
+
+// StreamTasks calls fn with a snapshot of each task of the ToDo list, in the
+// order of the list, stopping at the first error of fn, which is returned. The
+// tasks are those of the list when the call starts, each one being copied
+// when its turn comes, so that fn runs without holding the store lock and
+// the memory used does not grow with the size of the tasks.
+func StreamTasks(listKey string, fn func(Task) error) error {
+	lock.RLock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		lock.RUnlock()
+		return err
+	}
+	tasks := append([]*Task(nil), list.Tasks...)
+	lock.RUnlock()
+
+	for _, t := range tasks {
+		lock.RLock()
+		task := *copyTask(t)
+		lock.RUnlock()
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markdownPriorities are the names of the priorities in the markdown exports
+var markdownPriorities = map[int]string{
+	PriorityUrgent: "urgent",
+	PriorityHigh:   "high",
+	PriorityMedium: "medium",
+	PriorityLow:    "low",
+}
+
+// ExportMarkdown returns the ToDo list as a markdown checklist: the list name
+// as a heading and a "- [x]" or "- [ ]" line for each task, in insertion
+// order. With annotated the priority and the tags of the tasks follow their
+// titles, e.g. "- [ ] Book the hotel (priority: high) #travel".
+func ExportMarkdown(listKey string, annotated bool) (string, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return "", err
+	}
+	var md strings.Builder
+	fmt.Fprintf(&md, "# %s\n\n", list.Name)
+	for _, t := range list.Tasks {
+		box := " "
+		if t.Done {
+			box = "x"
+		}
+		fmt.Fprintf(&md, "- [%s] %s", box, t.Title)
+		if annotated {
+			if name, ok := markdownPriorities[t.Priority]; ok {
+				fmt.Fprintf(&md, " (priority: %s)", name)
+			}
+			for _, tag := range t.Tags {
+				fmt.Fprintf(&md, " #%s", strings.Join(strings.Fields(tag), "-"))
+			}
+		}
+		md.WriteString("\n")
+	}
+	return md.String(), nil
+}
+
+// cloneToDoList creates and returns a deep copy of the given ToDo list.
+func cloneToDoList(l *ToDoList) *ToDoList {
+	c := *l
+	c.titles = nil
+	c.Tasks = make([]*Task, len(l.Tasks))
+	for i, t := range l.Tasks {
+		c.Tasks[i] = copyTask(t)
+	}
+	percent := percentComplete(l.Tasks)
+	c.percentComplete = &percent
+	return &c
+}
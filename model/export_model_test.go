@@ -0,0 +1,94 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+)
+
+/*******************************
+	EXPORT ToDo lists
+*******************************/
+
+func TestExportLists_noKeys_error(t *testing.T) {
+	_, err := ExportLists([]string{})
+	if err == nil {
+		t.Errorf("expected empty keys error, got nil")
+	}
+}
+
+func TestExportLists_ok(t *testing.T) {
+	CreateToDoList("ListExport1")
+	CreateToDoList("ListExport2")
+	AddTask("ListExport1", "Task1")
+	AddTask("ListExport1", "Task2")
+
+	export, err := ExportLists([]string{"ListExport2", "invalid", "ListExport1", "ListExport2"})
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if export.Version != ExportVersion {
+		t.Errorf("expected export version %d, got %d", ExportVersion, export.Version)
+	}
+	if len(export.Lists) != 2 {
+		t.Fatalf("expected 2 lists exported, got %d", len(export.Lists))
+	}
+	if export.Lists[0].Name != "ListExport2" || export.Lists[1].Name != "ListExport1" {
+		t.Errorf("expected lists exported in the requested order, got %s, %s", export.Lists[0].Name, export.Lists[1].Name)
+	}
+	if len(export.Lists[1].Tasks) != 2 {
+		t.Errorf("expected 2 tasks exported for ListExport1, got %d", len(export.Lists[1].Tasks))
+	}
+	if len(export.Errors) != 1 || export.Errors[0].Key != "invalid" {
+		t.Errorf("expected invalid reported as not exported, got %v", export.Errors)
+	}
+}
+
+func TestExportLists_snapshot_ok(t *testing.T) {
+	export, _ := ExportLists([]string{"ListExport1"})
+	UpdateTask("ListExport1", "Task1", "Task1", true)
+
+	if export.Lists[0].Tasks[0].Done {
+		t.Errorf("expected exported task not to be affected by later updates")
+	}
+}
+
+/*******************************
+	STREAM Tasks
+*******************************/
+
+func TestStreamTasks_ok(t *testing.T) {
+	SeedToDoList("ListStream", []TaskSeed{{Title: "Task1"}, {Title: "Task2"}, {Title: "Task3"}})
+
+	var titles []string
+	err := StreamTasks("ListStream", func(task Task) error {
+		titles = append(titles, task.Title)
+		if len(titles) == 2 {
+			return fmt.Errorf("stop")
+		}
+		return nil
+	})
+	if err == nil || len(titles) != 2 || titles[1] != "Task2" {
+		t.Errorf("expected Task1 and Task2 streamed before the error, got %v, %v", titles, err)
+	}
+	if err := StreamTasks("invalid", func(Task) error { return nil }); err == nil {
+		t.Errorf("expected error list not found, got nil")
+	}
+}
+
+func TestExportMarkdown_ok(t *testing.T) {
+	SeedToDoList("ListMarkdown", []TaskSeed{{Title: "Book the flight"},
+		{Title: "Book the hotel", TaskDetails: TaskDetails{Priority: PriorityHigh, Tags: []string{"travel", "last minute"}}}})
+	UpdateTask("ListMarkdown", "Book the flight", "Book the flight", true)
+
+	md, err := ExportMarkdown("ListMarkdown", false)
+	if expected := "# ListMarkdown\n\n- [x] Book the flight\n- [ ] Book the hotel\n"; err != nil || md != expected {
+		t.Errorf("expected %q, got %q, %v", expected, md, err)
+	}
+	md, _ = ExportMarkdown("ListMarkdown", true)
+	if expected := "# ListMarkdown\n\n- [x] Book the flight\n- [ ] Book the hotel (priority: high) #travel #last-minute\n"; md != expected {
+		t.Errorf("expected %q, got %q", expected, md)
+	}
+	if _, err := ExportMarkdown("invalid", false); err == nil {
+		t.Errorf("expected error list not found, got nil")
+	}
+}
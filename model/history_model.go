@@ -0,0 +1,262 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxHistoryDays is the largest range served by StatsHistory, longer
+// periods are retrieved one window at a time
+const MaxHistoryDays = 366
+
+// DefaultHistoryRetention is how long the events are kept by default in the
+// history, see Compact
+const DefaultHistoryRetention = MaxHistoryDays * 24 * time.Hour
+
+// Event types recorded in the history
+const (
+	EventTaskCreated    = "task.created"
+	EventTaskCompleted  = "task.completed"
+	EventTaskReopened   = "task.reopened"
+	EventTaskDeleted    = "task.deleted"
+	EventListCreated    = "list.created"
+	EventListRenamed    = "list.renamed"
+	EventListDeleted    = "list.deleted"
+	EventListArchived   = "list.archived"
+	EventListUnarchived = "list.unarchived"
+)
+
+var (
+	// history records the events of all the ToDo lists in chronological
+	// order, the ones older than historyRetention being dropped by Compact
+	history          []*Event
+	historyRetention = DefaultHistoryRetention
+	// lastSeq is the Seq of the last recorded event, kept across the trims
+	lastSeq int
+	// trimmed folds, by ToDo list, the events dropped from the history
+	trimmed = map[string]trimmedEvents{}
+)
+
+// trimmedEvents summarizes the events of a ToDo list dropped from the
+// history: their change of the number of open tasks and the time of the last
+// one
+type trimmedEvents struct {
+	openDelta int
+	last      time.Time
+}
+
+// Event is a change recorded in the history. Seq numbers the events from 1 in
+// the order they are recorded.
+type Event struct {
+	Seq  int
+	Type string
+	List string
+	Task string `json:",omitempty"`
+	// From and To are the names of a renamed ToDo list, before and after
+	// the change, List following the later renames
+	From string `json:",omitempty"`
+	To   string `json:",omitempty"`
+	At   time.Time
+	// openDelta is the change of the number of open tasks
+	openDelta int
+}
+
+// DayStats summarizes the activity of a day
+type DayStats struct {
+	Day       string
+	Created   int
+	Completed int
+	Open      int
+}
+
+// StatsHistory returns, for each of the days ending with until (included),
+// the number of tasks created and completed that day and the number of tasks
+// still open at the end of the day. An empty listKey aggregates all the lists.
+// Days are bucketed in the given location and days without activity are
+// reported with zero counts. The events dropped by Compact are only counted
+// in the open tasks, as if recorded before the first day.
+func StatsHistory(listKey string, days int, until time.Time, loc *time.Location) ([]DayStats, error) {
+	if days < 1 || days > MaxHistoryDays {
+		return nil, fmt.Errorf("invalid number of days, expected 1 to %d", MaxHistoryDays)
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+	if listKey != "" {
+		if _, err := getToDoList(listKey); err != nil {
+			return nil, err
+		}
+	}
+
+	_, end := DayBounds(until, loc)
+	start := end.AddDate(0, 0, -days)
+	series := make([]DayStats, days)
+	index := make(map[string]*DayStats, days)
+	for i := range series {
+		series[i].Day = start.AddDate(0, 0, i).Format("2006-01-02")
+		index[series[i].Day] = &series[i]
+	}
+
+	open := 0
+	for list, t := range trimmed {
+		if listKey == "" || list == listKey {
+			open += t.openDelta
+		}
+	}
+	deltas := make(map[string]int, days)
+	for _, e := range history {
+		if listKey != "" && e.List != listKey {
+			continue
+		}
+		if e.At.Before(start) {
+			open += e.openDelta
+			continue
+		}
+		if !e.At.Before(end) {
+			continue
+		}
+		day := e.At.In(loc).Format("2006-01-02")
+		switch e.Type {
+		case EventTaskCreated:
+			index[day].Created++
+		case EventTaskCompleted:
+			index[day].Completed++
+		}
+		deltas[day] += e.openDelta
+	}
+
+	for i := range series {
+		open += deltas[series[i].Day]
+		series[i].Open = open
+	}
+	return series, nil
+}
+
+func recordEvent(eventType string, t *Task, openDelta int) {
+	lastSeq++
+	history = append(history, &Event{
+		Seq:       lastSeq,
+		Type:      eventType,
+		List:      t.ToDoList,
+		Task:      t.Title,
+		At:        now(),
+		openDelta: openDelta})
+	if list := data[t.ToDoList]; list != nil {
+		list.openTasks += openDelta
+	}
+	recordTaskEvent(eventType, t)
+	if eventType == EventTaskDeleted {
+		dispatchEvent(eventType, t.ToDoList, t, nil)
+	} else {
+		dispatchEvent(eventType, t.ToDoList, nil, t)
+	}
+}
+
+// recordListEvent records a change of the ToDo list itself
+func recordListEvent(eventType string, list string) {
+	lastSeq++
+	history = append(history, &Event{
+		Seq:  lastSeq,
+		Type: eventType,
+		List: list,
+		At:   now()})
+	if l := data[list]; l != nil {
+		l.UpdatedAt = history[len(history)-1].At
+	}
+	switch eventType {
+	case EventListDeleted:
+		recordTombstone(list, "")
+	case EventListCreated, EventListRenamed:
+		clearTombstone(list, "")
+	}
+	dispatchEvent(eventType, list, nil, nil)
+}
+
+// CurrentSeq returns the Seq of the last recorded event, 0 before the first
+// one. The history is kept in memory: unlike the task numbers, saved with
+// their lists, the sequence restarts with the server. It is never reused
+// while the server runs, the old events being dropped or not.
+func CurrentSeq() int {
+	lock.RLock()
+	defer lock.RUnlock()
+	return lastSeq
+}
+
+// SetHistoryRetention sets how long the events are kept in the history, the
+// older ones being dropped by Compact. 0 keeps the whole history.
+func SetHistoryRetention(retention time.Duration) error {
+	if retention < 0 {
+		return fmt.Errorf("invalid history retention %s, expected a non negative duration", retention)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	historyRetention = retention
+	return nil
+}
+
+// trimHistory drops the events recorded more than the history retention ago,
+// folding them into trimmed, and returns them. The summaries of the deleted
+// ToDo lists are dropped once they have no open tasks left.
+func trimHistory() []*Event {
+	if historyRetention == 0 {
+		return nil
+	}
+	expired := now().Add(-historyRetention)
+	var kept, dropped []*Event
+	// the whole history is scanned, the clock possibly going back
+	for _, e := range history {
+		if !e.At.Before(expired) {
+			kept = append(kept, e)
+			continue
+		}
+		t := trimmed[e.List]
+		t.openDelta += e.openDelta
+		if e.At.After(t.last) {
+			t.last = e.At
+		}
+		trimmed[e.List] = t
+		dropped = append(dropped, e)
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+	history = kept
+	for list, t := range trimmed {
+		if data[list] == nil && t.openDelta == 0 {
+			delete(trimmed, list)
+		}
+	}
+	return dropped
+}
+
+// recordListRenamed records the renaming of a ToDo list, after renameHistory
+func recordListRenamed(name, newName string) {
+	recordListEvent(EventListRenamed, newName)
+	e := history[len(history)-1]
+	e.From, e.To = name, newName
+}
+
+func recordTaskDeleted(t *Task) {
+	openDelta := 0
+	if !t.Done {
+		openDelta = -1
+	}
+	recordEvent(EventTaskDeleted, t, openDelta)
+}
+
+// renameHistory moves the recorded events of a ToDo list to its new name
+func renameHistory(name, newName string) {
+	for _, e := range history {
+		if e.List == name {
+			e.List = newName
+		}
+	}
+	if t, ok := trimmed[name]; ok {
+		moved := trimmed[newName]
+		moved.openDelta += t.openDelta
+		if t.last.After(moved.last) {
+			moved.last = t.last
+		}
+		trimmed[newName] = moved
+		delete(trimmed, name)
+	}
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	STATS History
+*******************************/
+
+func TestStatsHistory_invalidDays_error(t *testing.T) {
+	_, err := StatsHistory("", 0, time.Now(), time.UTC)
+	if err == nil {
+		t.Errorf("expected invalid number of days error, got nil")
+	}
+	_, err = StatsHistory("", MaxHistoryDays+1, time.Now(), time.UTC)
+	if err == nil {
+		t.Errorf("expected invalid number of days error, got nil")
+	}
+}
+
+func TestStatsHistory_invalidList_error(t *testing.T) {
+	_, err := StatsHistory("invalid", 30, time.Now(), time.UTC)
+	if err == nil {
+		t.Errorf("expected error list not found, got nil")
+	}
+}
+
+func TestStatsHistory_ok(t *testing.T) {
+	defer func() { now = time.Now }()
+	day := func(d, h int) func() time.Time {
+		return func() time.Time { return time.Date(2023, 3, d, h, 0, 0, 0, time.UTC) }
+	}
+
+	CreateToDoList("ListHistory")
+	now = day(1, 10)
+	AddTask("ListHistory", "Task1")
+	AddTask("ListHistory", "Task2")
+	now = day(2, 10)
+	AddTask("ListHistory", "Task3")
+	now = day(4, 10)
+	UpdateTask("ListHistory", "Task1", "Task1", true)
+	now = day(5, 10)
+	RemoveTask("ListHistory", "Task2")
+
+	series, err := StatsHistory("ListHistory", 4, time.Date(2023, 3, 5, 12, 0, 0, 0, time.UTC), time.UTC)
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	expected := []DayStats{
+		{Day: "2023-03-02", Created: 1, Completed: 0, Open: 3},
+		{Day: "2023-03-03", Created: 0, Completed: 0, Open: 3},
+		{Day: "2023-03-04", Created: 0, Completed: 1, Open: 2},
+		{Day: "2023-03-05", Created: 0, Completed: 0, Open: 1},
+	}
+	if len(series) != len(expected) {
+		t.Fatalf("expected %d days, got %d", len(expected), len(series))
+	}
+	for i := range expected {
+		if series[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], series[i])
+		}
+	}
+}
+
+func TestStatsHistory_timeZone_ok(t *testing.T) {
+	// 2023-03-04 10:00 UTC is still 2023-03-03 in a UTC-12 time zone
+	loc := time.FixedZone("UTC-12", -12*60*60)
+	series, _ := StatsHistory("ListHistory", 2, time.Date(2023, 3, 4, 12, 0, 0, 0, loc), loc)
+	if series[0].Completed != 1 || series[1].Completed != 0 {
+		t.Errorf("expected the completion bucketed on 2023-03-03, got %+v", series)
+	}
+}
+
+func TestStatsHistory_renamedList_ok(t *testing.T) {
+	UpdateToDoList("ListHistory", "ListHistoryNew")
+	series, err := StatsHistory("ListHistoryNew", 1, time.Date(2023, 3, 5, 12, 0, 0, 0, time.UTC), time.UTC)
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if series[0].Open != 1 {
+		t.Errorf("expected history kept after rename, got %+v", series[0])
+	}
+}
+
+func TestStatsHistory_allLists_ok(t *testing.T) {
+	series, err := StatsHistory("", 1, time.Date(2023, 3, 5, 12, 0, 0, 0, time.UTC), time.UTC)
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if series[0].Open < 1 {
+		t.Errorf("expected at least the open task of ListHistoryNew, got %+v", series[0])
+	}
+}
@@ -0,0 +1,240 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// EventTaskUpdated is the hook event of the changes of the title and details
+// of a task, it is not recorded in the history
+const EventTaskUpdated = "task.updated"
+
+// HookEvent is a change submitted to the hooks. Before and After are deep
+// copies of the task before and after the change, nil when it does not exist
+// (e.g. Before for a creation), so that the hooks can not alter the store.
+type HookEvent struct {
+	Type   string
+	List   string
+	Before *Task
+	After  *Task
+}
+
+// Hook reacts to a type of event. Before runs before the change, the store
+// being locked: it must not call the other functions of the model, an error
+// vetoing the change, which then fails with a *ValidationError carrying its
+// message. Before hooks run for the creation of the tasks (including the
+// initial tasks of a list, the imports and the restored archives), their
+// deletion (including the deletion of their list, the replacement of its
+// tasks and the merge of duplicates), and the changes of their title,
+// details, checklist, priority and done state (including the progress
+// completing or reopening them and the merge of duplicates), as
+// EventTaskUpdated. The changes of the deferred state and of the time spent,
+// and the touches, are not vetoed. The tasks created done by a checklist
+// import are vetoed as created open. After runs
+// asynchronously once the change is stored, for all the events of the
+// history, EventTaskUpdated and EventTaskReminder, one event at a time in the order of the
+// changes.
+type Hook struct {
+	Name   string
+	Before func(e HookEvent) error
+	After  func(e HookEvent)
+}
+
+var (
+	hookLock sync.RWMutex
+	hooks    = map[string][]*Hook{}
+	// afterQueue holds the events waiting for the after hooks, in order
+	afterQueue   []func()
+	afterCond    = sync.NewCond(&sync.Mutex{})
+	afterStarted bool
+)
+
+// RegisterHook registers the hook for the given event type (one of the Event*
+// types), the returned function unregisters it
+func RegisterHook(event string, hook Hook) (unregister func()) {
+	h := &hook
+	hookLock.Lock()
+	defer hookLock.Unlock()
+	hooks[event] = append(hooks[event], h)
+	if hook.After != nil {
+		startAfterHooks()
+	}
+	return func() {
+		hookLock.Lock()
+		defer hookLock.Unlock()
+		registered := hooks[event]
+		for i, other := range registered {
+			if other == h {
+				hooks[event] = append(registered[:i:i], registered[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// vetoEvent runs the before hooks of the event, the copies of the tasks being
+// made only when there are hooks to run
+func vetoEvent(event, list string, before, after *Task) error {
+	hookLock.RLock()
+	registered := hooks[event]
+	hookLock.RUnlock()
+	for _, h := range registered {
+		if h.Before == nil {
+			continue
+		}
+		if err := h.Before(HookEvent{event, list, copyTask(before), copyTask(after)}); err != nil {
+			return &ValidationError{fmt.Sprintf("rejected by hook %s: %v", h.Name, err)}
+		}
+	}
+	return nil
+}
+
+// vetoTasksDeleted runs the before hooks of the deletion of all the tasks of
+// the list
+func vetoTasksDeleted(list *ToDoList) error {
+	for _, t := range list.Tasks {
+		if err := vetoEvent(EventTaskDeleted, list.Name, t, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// vetoNewTask runs the before hooks of the creation of the task, before it is
+// numbered and stored
+func vetoNewTask(list, title string, details TaskDetails) error {
+	return vetoEvent(EventTaskCreated, list, nil, &Task{ToDoList: list, Title: title, TaskDetails: details, CreatedAt: now()})
+}
+
+// dispatchEvent queues the event for the after hooks, unless muted in the
+// ToDo list
+func dispatchEvent(event, list string, before, after *Task) {
+	hookLock.RLock()
+	var registered []*Hook
+	for _, h := range hooks[event] {
+		if h.After != nil {
+			registered = append(registered, h)
+		}
+	}
+	hookLock.RUnlock()
+	if len(registered) == 0 || !notified(event, list) {
+		return
+	}
+	e := HookEvent{event, list, copyTask(before), copyTask(after)}
+	afterCond.L.Lock()
+	afterQueue = append(afterQueue, func() {
+		for _, h := range registered {
+			// each hook gets its own copies
+			h.After(HookEvent{e.Type, e.List, copyTask(e.Before), copyTask(e.After)})
+		}
+	})
+	afterCond.L.Unlock()
+	afterCond.Signal()
+}
+
+// startAfterHooks starts the goroutine running the after hooks, once
+func startAfterHooks() {
+	if afterStarted {
+		return
+	}
+	afterStarted = true
+	go func() {
+		for {
+			afterCond.L.Lock()
+			for len(afterQueue) == 0 {
+				afterCond.Wait()
+			}
+			run := afterQueue[0]
+			afterQueue = afterQueue[1:]
+			afterCond.L.Unlock()
+			run()
+		}
+	}()
+}
+
+// copyTask returns a deep copy of the task, nil for nil
+func copyTask(t *Task) *Task {
+	if t == nil {
+		return nil
+	}
+	c := cloneTask(t)
+	if t.DueDate != nil {
+		dueDate := *t.DueDate
+		c.DueDate = &dueDate
+	}
+	if t.CompletedAt != nil {
+		completedAt := *t.CompletedAt
+		c.CompletedAt = &completedAt
+	}
+	if t.ChecklistProgress != nil {
+		progress := *t.ChecklistProgress
+		c.ChecklistProgress = &progress
+	}
+	if t.Location != nil {
+		location := *t.Location
+		if t.Location.Lat != nil {
+			lat := *t.Location.Lat
+			location.Lat = &lat
+		}
+		if t.Location.Lon != nil {
+			lon := *t.Location.Lon
+			location.Lon = &lon
+		}
+		c.Location = &location
+	}
+	if t.Meta != nil {
+		c.Meta = make(map[string]string, len(t.Meta))
+		for k, v := range t.Meta {
+			c.Meta[k] = v
+		}
+	}
+	c.Checklist = append([]ChecklistItem(nil), t.Checklist...)
+	c.Tags = append([]string(nil), t.Tags...)
+	return c
+}
+
+// RequiredTagRule returns a before hook, for EventTaskCreated and
+// EventTaskUpdated, requiring the tasks of the ToDo list to have a tag
+// starting with prefix (e.g. "ticket:" for "ticket:OPS-12")
+func RequiredTagRule(list, prefix string) Hook {
+	return Hook{
+		Name: fmt.Sprintf("required tag %s* in %s", prefix, list),
+		Before: func(e HookEvent) error {
+			if e.List != list || e.After == nil {
+				return nil
+			}
+			for _, tag := range e.After.Tags {
+				if strings.HasPrefix(tag, prefix) {
+					return nil
+				}
+			}
+			return fmt.Errorf("the tasks of %s must have a tag %s*", list, prefix)
+		}}
+}
+
+// MaxOpenTasksRule returns a before hook, for EventTaskCreated and
+// EventTaskUpdated, limiting the number of open tasks of the ToDo list, the
+// reopened tasks included. The limit is checked on the list as stored, the
+// hook reading it under the store lock held by the change.
+func MaxOpenTasksRule(list string, max int) Hook {
+	return Hook{
+		Name: fmt.Sprintf("at most %d open tasks in %s", max, list),
+		Before: func(e HookEvent) error {
+			if e.List != list || e.After == nil || e.After.Done || e.Before != nil && !e.Before.Done {
+				return nil
+			}
+			open := 0
+			if l, err := getToDoList(list); err == nil {
+				for _, t := range l.Tasks {
+					if !t.Done {
+						open++
+					}
+				}
+			}
+			if open >= max {
+				return fmt.Errorf("%s already has %d open tasks", list, open)
+			}
+			return nil
+		}}
+}
@@ -0,0 +1,243 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+/*******************************
+	BEFORE hooks
+*******************************/
+
+func TestRegisterHook_veto(t *testing.T) {
+	CreateToDoList("ListHooksVeto")
+	AddTask("ListHooksVeto", "Protected")
+	veto := func(e HookEvent) error {
+		if e.List != "ListHooksVeto" {
+			return nil
+		}
+		return fmt.Errorf("vetoed %s", e.Type)
+	}
+	for _, event := range []string{EventTaskCreated, EventTaskUpdated, EventTaskDeleted} {
+		defer RegisterHook(event, Hook{Name: "veto", Before: veto})()
+	}
+
+	if _, err := AddTask("ListHooksVeto", "Task1"); err == nil {
+		t.Errorf("expected the creation vetoed")
+	} else if _, invalid := err.(*ValidationError); !invalid || err.Error() != "rejected by hook veto: vetoed task.created" {
+		t.Errorf("expected a *ValidationError with the hook message, got %v", err)
+	}
+	if _, _, err := SetTaskDetails("ListHooksVeto", "Protected", TaskDetails{Description: "changed"}); err == nil {
+		t.Errorf("expected the update vetoed")
+	}
+	if _, err := UpdateTask("ListHooksVeto", "Protected", "Renamed", false); err == nil {
+		t.Errorf("expected the renaming vetoed")
+	}
+	if _, err := RemoveTask("ListHooksVeto", "Protected"); err == nil {
+		t.Errorf("expected the deletion vetoed")
+	}
+	list, _ := GetToDoListWithTasks("ListHooksVeto")
+	if len(list.Tasks) != 1 || list.Tasks[0].Title != "Protected" || list.Tasks[0].Description != "" || list.LastTaskNumber != 1 {
+		t.Errorf("expected the list untouched, got %+v", list)
+	}
+}
+
+func TestRegisterHook_vetoSeedsAtomically(t *testing.T) {
+	defer RegisterHook(EventTaskCreated, Hook{Name: "no drafts", Before: func(e HookEvent) error {
+		if e.After.Title == "Draft" {
+			return fmt.Errorf("drafts not allowed")
+		}
+		return nil
+	}})()
+
+	_, _, err := SeedToDoList("ListHooksSeeds", []TaskSeed{{Title: "Task1"}, {Title: "Draft"}})
+	if seedErr, ok := err.(*TaskSeedError); !ok || len(seedErr.Failures) != 1 || seedErr.Failures[0].Index != 1 {
+		t.Fatalf("expected the second task reported, got %v", err)
+	}
+	if list, _ := GetToDoList("ListHooksSeeds"); list != nil {
+		t.Errorf("expected no list created, got %+v", list)
+	}
+}
+
+func TestRegisterHook_deepCopies(t *testing.T) {
+	CreateToDoList("ListHooksCopies")
+	defer RegisterHook(EventTaskCreated, Hook{Name: "corrupt", Before: func(e HookEvent) error {
+		e.After.Title = "Corrupted"
+		e.After.Tags[0] = "corrupted"
+		return nil
+	}})()
+
+	AddTaskWithDetails("ListHooksCopies", "Task1", TaskDetails{Tags: []string{"home"}})
+	if task, _ := GetTask("ListHooksCopies", "Task1"); task == nil || task.Tags[0] != "home" {
+		t.Errorf("expected the stored task untouched, got %+v", task)
+	}
+}
+
+func TestRegisterHook_unregister(t *testing.T) {
+	CreateToDoList("ListHooksUnregistered")
+	unregister := RegisterHook(EventTaskCreated, Hook{Name: "veto", Before: func(e HookEvent) error {
+		return fmt.Errorf("vetoed")
+	}})
+	unregister()
+	if _, err := AddTask("ListHooksUnregistered", "Task1"); err != nil {
+		t.Errorf("expected the hook unregistered, got %v", err)
+	}
+}
+
+/*******************************
+	AFTER hooks
+*******************************/
+
+func TestRegisterHook_afterInOrder(t *testing.T) {
+	events := make(chan HookEvent, 10)
+	after := Hook{Name: "collect", After: func(e HookEvent) {
+		if e.List == "ListHooksAfter" {
+			time.Sleep(time.Millisecond)
+			events <- e
+		}
+	}}
+	for _, event := range []string{EventListCreated, EventTaskCreated, EventTaskUpdated, EventTaskCompleted, EventTaskDeleted} {
+		defer RegisterHook(event, after)()
+	}
+
+	CreateToDoList("ListHooksAfter")
+	AddTask("ListHooksAfter", "Task1")
+	SetTaskDetails("ListHooksAfter", "Task1", TaskDetails{Description: "updated"})
+	UpdateTask("ListHooksAfter", "Task1", "Task1", true)
+	RemoveTask("ListHooksAfter", "Task1")
+
+	expected := []string{EventListCreated, EventTaskCreated, EventTaskUpdated, EventTaskCompleted, EventTaskDeleted}
+	for i, eventType := range expected {
+		select {
+		case e := <-events:
+			if e.Type != eventType {
+				t.Fatalf("expected event %d to be %s, got %s", i, eventType, e.Type)
+			}
+			if eventType == EventTaskUpdated && (e.Before.Description != "" || e.After.Description != "updated") {
+				t.Errorf("expected the task before and after the update, got %+v and %+v", e.Before, e.After)
+			}
+			if eventType == EventTaskDeleted && (e.Before == nil || e.After != nil) {
+				t.Errorf("expected the deleted task before the change only, got %+v", e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected event %s, got none", eventType)
+		}
+	}
+}
+
+/*******************************
+	BUILT-IN rules
+*******************************/
+
+func TestRequiredTagRule(t *testing.T) {
+	CreateToDoList("ListHooksCompliance")
+	rule := RequiredTagRule("ListHooksCompliance", "ticket:")
+	defer RegisterHook(EventTaskCreated, rule)()
+	defer RegisterHook(EventTaskUpdated, rule)()
+
+	if _, err := AddTask("ListHooksCompliance", "Untracked"); err == nil {
+		t.Errorf("expected a task without ticket tag rejected")
+	}
+	if _, _, err := AddTaskWithDetails("ListHooksCompliance", "Tracked", TaskDetails{Tags: []string{"ticket:OPS-12"}}); err != nil {
+		t.Errorf("expected a task with a ticket tag accepted, got %v", err)
+	}
+	if _, _, err := SetTaskDetails("ListHooksCompliance", "Tracked", TaskDetails{}); err == nil {
+		t.Errorf("expected the removal of the ticket tag rejected")
+	}
+	CreateToDoList("ListHooksUnregulated")
+	if _, err := AddTask("ListHooksUnregulated", "Untracked"); err != nil {
+		t.Errorf("expected the other lists unaffected, got %v", err)
+	}
+}
+
+func TestMaxOpenTasksRule(t *testing.T) {
+	CreateToDoList("ListHooksMaxOpen")
+	defer RegisterHook(EventTaskCreated, MaxOpenTasksRule("ListHooksMaxOpen", 2))()
+
+	AddTask("ListHooksMaxOpen", "Task1")
+	AddTask("ListHooksMaxOpen", "Task2")
+	if _, err := AddTask("ListHooksMaxOpen", "Task3"); err == nil {
+		t.Errorf("expected a third open task rejected")
+	}
+	UpdateTask("ListHooksMaxOpen", "Task1", "Task1", true)
+	if _, err := AddTask("ListHooksMaxOpen", "Task3"); err != nil {
+		t.Errorf("expected a task accepted once another is done, got %v", err)
+	}
+}
+
+func TestMaxOpenTasksRule_reopen(t *testing.T) {
+	CreateToDoListWithTasks("ListHooksReopen", []string{"Task1", "Task2", "Task3"})
+	UpdateTask("ListHooksReopen", "Task1", "Task1", true)
+	hook := MaxOpenTasksRule("ListHooksReopen", 2)
+	defer RegisterHook(EventTaskCreated, hook)()
+	defer RegisterHook(EventTaskUpdated, hook)()
+
+	if _, err := UpdateTask("ListHooksReopen", "Task1", "Task1", false); err == nil {
+		t.Errorf("expected the reopening rejected")
+	}
+	if _, err := CompareAndSetTaskDone("ListHooksReopen", "Task1", true, false); err == nil {
+		t.Errorf("expected the reopening rejected")
+	}
+	if _, err := SetTaskProgress("ListHooksReopen", "Task1", 50); err == nil {
+		t.Errorf("expected the reopening by progress rejected")
+	}
+	if task, _ := GetTask("ListHooksReopen", "Task1"); !task.Done {
+		t.Errorf("expected Task1 still done")
+	}
+	if _, err := UpdateTask("ListHooksReopen", "Task2", "Task2", true); err != nil {
+		t.Errorf("expected a completion accepted, got %v", err)
+	}
+	if _, err := UpdateTask("ListHooksReopen", "Task1", "Task1", false); err != nil {
+		t.Errorf("expected the reopening accepted once another task is done, got %v", err)
+	}
+}
+
+func TestRegisterHook_vetoMerge(t *testing.T) {
+	CreateToDoListWithTasks("ListHooksMerge", []string{"Buy milk", "buy  milk"})
+	SetTaskDetails("ListHooksMerge", "buy  milk", TaskDetails{Description: "2 liters"})
+	unregister := RegisterHook(EventTaskDeleted, Hook{Name: "keep", Before: func(e HookEvent) error {
+		return fmt.Errorf("tasks can not be deleted")
+	}})
+
+	if _, err := MergeDuplicateTasks("ListHooksMerge", nil, false); err == nil {
+		t.Errorf("expected the merge vetoed")
+	}
+	if tasks, _ := GetTasks("ListHooksMerge"); len(tasks) != 2 || tasks[0].Description != "" {
+		t.Errorf("expected the list untouched by the vetoed merge, got %+v", tasks)
+	}
+	unregister()
+
+	updates := 0
+	defer RegisterHook(EventTaskUpdated, Hook{Name: "count", Before: func(e HookEvent) error {
+		if e.After.Description == "2 liters" {
+			updates++
+		}
+		return nil
+	}})()
+	if merges, err := MergeDuplicateTasks("ListHooksMerge", nil, false); err != nil || len(merges) != 1 {
+		t.Fatalf("expected the duplicates merged, got %v, %v", merges, err)
+	}
+	if updates != 1 {
+		t.Errorf("expected the update of the kept task submitted to the hooks, got %d", updates)
+	}
+}
+
+func TestRegisterHook_vetoChecklistAndListDeletion(t *testing.T) {
+	SeedToDoList("ListHooksChecklist", []TaskSeed{{Title: "Task1", TaskDetails: TaskDetails{Checklist: []ChecklistItem{{Text: "Step"}}}}})
+	for _, event := range []string{EventTaskUpdated, EventTaskDeleted} {
+		defer RegisterHook(event, Hook{Name: "veto", Before: func(e HookEvent) error {
+			return fmt.Errorf("vetoed %s", e.Type)
+		}})()
+	}
+
+	if _, err := SetChecklistItemDone("ListHooksChecklist", "Task1", 0, nil); err == nil {
+		t.Errorf("expected the checklist change vetoed")
+	}
+	if _, err := DeleteToDoList("ListHooksChecklist"); err == nil {
+		t.Errorf("expected the deletion of the list with its tasks vetoed")
+	}
+	if task, _ := GetTask("ListHooksChecklist", "Task1"); task == nil || task.Checklist[0].Done {
+		t.Errorf("expected the task untouched, got %+v", task)
+	}
+}
@@ -0,0 +1,294 @@
+package model
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/efreddo/v1/todolist/dateutils"
+)
+
+// ImportSummary reports the outcome of an import
+type ImportSummary struct {
+	Created int
+	Failed  int
+	Errors  []ImportRowError `json:",omitempty"`
+}
+
+// ImportRowError reports a row not imported, Line being its line in the file
+type ImportRowError struct {
+	Line  int
+	Error string
+}
+
+// csvColumns maps the accepted CSV column names, normalized by csvColumnName,
+// to the task field they set
+var csvColumns = map[string]string{
+	"title":       "title",
+	"done":        "done",
+	"status":      "done",
+	"description": "description",
+	"notes":       "description",
+	"duedate":     "duedate",
+	"due":         "duedate",
+	"priority":    "priority",
+	"tags":        "tags",
+}
+
+// csvDone maps the accepted values of the done (status) column, lowercase
+var csvDone = map[string]bool{
+	"true": true, "yes": true, "x": true, "done": true, "completed": true, "closed": true,
+	"false": false, "no": false, "open": false, "todo": false, "pending": false,
+}
+
+// importedTask is a task read from an import file, not yet validated
+type importedTask struct {
+	line    int
+	title   string
+	done    bool
+	details TaskDetails
+}
+
+// ImportTasksCSV creates in the ToDo list the tasks read from a CSV file, in
+// file order. The header row names the columns, case insensitive, among Title
+// (required), Done or Status, Description or Notes, DueDate, Priority and Tags
+// (comma separated). Fields are separated by commas or semicolons, detected
+// from the header, and a leading byte order mark is ignored. Rows that can not
+// be imported are reported in the summary without failing the others, unless
+// atomic is set: no task is created then when any row is invalid.
+func ImportTasksCSV(listKey string, r io.Reader, atomic bool) (*ImportSummary, error) {
+	buffered := bufio.NewReader(r)
+	headerLine, err := buffered.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+	headerLine = strings.TrimPrefix(headerLine, "\ufeff")
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(headerLine), buffered))
+	reader.Comma = csvDelimiter(headerLine)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		field, ok := csvColumns[csvColumnName(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %s", header[i])
+		}
+		if _, ok := columns[field]; ok {
+			return nil, fmt.Errorf("duplicated CSV column %s", header[i])
+		}
+		columns[field] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("missing CSV column Title")
+	}
+
+	summary := &ImportSummary{}
+	var tasks []importedTask
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// FieldPos is only valid for the records read, the parse
+			// errors carrying the line of the row themselves
+			perr, ok := err.(*csv.ParseError)
+			if !ok || !errors.Is(err, csv.ErrFieldCount) {
+				return nil, fmt.Errorf("invalid CSV: %v", err)
+			}
+			summary.Errors = append(summary.Errors, ImportRowError{perr.StartLine, "wrong number of fields"})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		task, err := parseCSVTask(record, columns)
+		if err != nil {
+			summary.Errors = append(summary.Errors, ImportRowError{line, err.Error()})
+			continue
+		}
+		task.line = line
+		tasks = append(tasks, task)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if _, err := getToDoList(listKey); err != nil {
+		return nil, err
+	}
+
+	valid := tasks[:0]
+	titles := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		if existing, _ := getTask(listKey, task.title); existing != nil || titles[task.title] {
+			summary.Errors = append(summary.Errors, ImportRowError{task.line, fmt.Sprintf("task %s already present", task.title)})
+			continue
+		}
+		if _, err := validateTaskDetails(&task.details); err != nil {
+			summary.Errors = append(summary.Errors, ImportRowError{task.line, err.Error()})
+			continue
+		}
+		if err := vetoNewTask(listKey, task.title, task.details); err != nil {
+			summary.Errors = append(summary.Errors, ImportRowError{task.line, err.Error()})
+			continue
+		}
+		titles[task.title] = true
+		valid = append(valid, task)
+	}
+	sort.SliceStable(summary.Errors, func(i, j int) bool {
+		return summary.Errors[i].Line < summary.Errors[j].Line
+	})
+	summary.Failed = len(summary.Errors)
+	if atomic && summary.Failed > 0 {
+		return summary, nil
+	}
+
+	for _, task := range valid {
+		if _, _, err := addTask(listKey, task.title, task.details, false); err != nil {
+			summary.Errors = append(summary.Errors, ImportRowError{task.line, err.Error()})
+			summary.Failed++
+			continue
+		}
+		if task.done {
+			stored, _ := getTask(listKey, task.title)
+			setTaskDone(stored, true)
+		}
+		summary.Created++
+	}
+	return summary, nil
+}
+
+// checklistBox matches the markdown bullet and checkbox of a checklist line,
+// "- [x] ", "* [ ] " or a plain bullet
+var checklistBox = regexp.MustCompile(`^[-*+]\s+(?:\[([ xX])\]\s*)?|^\[([ xX])\]\s*`)
+
+// ImportChecklistText creates the ToDo list along with a task for each
+// non-empty line of a plain text or markdown checklist, in text order. The
+// markdown bullets are removed and the checked boxes ("- [x]") set the tasks
+// done. As with SeedToDoList nothing is created when any task is invalid,
+// a *TaskSeedError reporting them all.
+func ImportChecklistText(name string, r io.Reader) (*ToDoList, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty ToDo list name")
+	}
+	var seeds []TaskSeed
+	done := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" {
+			continue
+		}
+		checked := false
+		if m := checklistBox.FindStringSubmatch(line); m != nil {
+			checked = strings.EqualFold(m[1], "x") || strings.EqualFold(m[2], "x")
+			line = strings.TrimSpace(line[len(m[0]):])
+		}
+		seeds = append(seeds, TaskSeed{Title: line})
+		if checked {
+			done[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid checklist: %v", err)
+	}
+	if err := validateTaskSeeds(seeds); err != nil {
+		return nil, err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	list, _, err := seedToDoList(name, PriorityNone, seeds)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range list.Tasks {
+		if done[t.Title] {
+			setTaskDone(t, true)
+		}
+	}
+	return cloneToDoList(list), nil
+}
+
+// parseCSVTask reads the task of a CSV record, the columns mapping the
+// lowercase column names to their index
+func parseCSVTask(record []string, columns map[string]int) (importedTask, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	task := importedTask{title: field("title")}
+	if task.title == "" {
+		return task, fmt.Errorf("empty title")
+	}
+	var err error
+	if done := field("done"); done != "" {
+		var ok bool
+		if task.done, ok = csvDone[strings.ToLower(done)]; !ok {
+			return task, fmt.Errorf("invalid status %s, expected done or open", done)
+		}
+	}
+	for _, tag := range strings.Split(field("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			task.details.Tags = append(task.details.Tags, tag)
+		}
+	}
+	task.details.Description = field("description")
+	if due := field("duedate"); due != "" {
+		dueDate, err := dateutils.Parse(due, dateutils.DefaultMode(), time.UTC)
+		if err != nil {
+			return task, fmt.Errorf("invalid due date: %v", err)
+		}
+		task.details.DueDate = &dueDate
+	}
+	if priority := field("priority"); priority != "" {
+		if task.details.Priority, err = strconv.Atoi(priority); err != nil {
+			return task, fmt.Errorf("invalid priority %s, expected %d to %d", priority, PriorityNone, PriorityLow)
+		}
+	}
+	return task, nil
+}
+
+// csvDelimiter returns the delimiter of the CSV header line, a semicolon when
+// it separates more fields than a comma, ignoring quoted names
+func csvDelimiter(header string) rune {
+	commas, semicolons, quoted := 0, 0, false
+	for _, c := range header {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == ',':
+			commas++
+		case c == ';':
+			semicolons++
+		}
+	}
+	if semicolons > commas {
+		return ';'
+	}
+	return ','
+}
+
+// csvColumnName normalizes a column name: lowercase, without spaces, dashes
+// and underscores ("Due Date" and "due_date" being both "duedate")
+func csvColumnName(name string) string {
+	return strings.Map(func(c rune) rune {
+		if c == ' ' || c == '-' || c == '_' {
+			return -1
+		}
+		return unicode.ToLower(c)
+	}, strings.TrimSpace(name))
+}
@@ -0,0 +1,199 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*******************************
+	IMPORT Tasks
+*******************************/
+
+func TestImportTasksCSV_invalidHeader_error(t *testing.T) {
+	CreateToDoList("ListImport")
+	headers := []string{"", "Description", "Title,Owner", "Title,title"}
+	for _, header := range headers {
+		if _, err := ImportTasksCSV("ListImport", strings.NewReader(header+"\n"), false); err == nil {
+			t.Errorf("expected invalid header error for %q, got nil", header)
+		}
+	}
+	if _, err := ImportTasksCSV("invalid", strings.NewReader("Title\nTask1\n"), false); err == nil {
+		t.Errorf("expected error list not found, got nil")
+	}
+}
+
+func TestImportTasksCSV_malformedQuotes_error(t *testing.T) {
+	CreateToDoList("ListImportQuotes")
+	bodies := []string{"Title\na\"b\n", "Title\n\"unterminated\n", "Title,Done\nok,yes\nx\"y,no\n"}
+	for _, body := range bodies {
+		if _, err := ImportTasksCSV("ListImportQuotes", strings.NewReader(body), false); err == nil {
+			t.Errorf("expected invalid CSV error for %q, got nil", body)
+		}
+	}
+	if tasks, _ := GetTasks("ListImportQuotes"); len(tasks) != 0 {
+		t.Errorf("expected no task imported, got %d", len(tasks))
+	}
+}
+
+func TestImportTasksCSV_rowErrors_ok(t *testing.T) {
+	csv := "title,Done,DueDate,Priority,Description\n" +
+		"Task1,false,2024-06-01,1,\"First, with a comma\"\n" +
+		"Task2,true,,,\n" +
+		"Task3,,2024-13-01,,\n" +
+		"Task4,,,9,\n" +
+		"Task1,,,,\n" +
+		",,,,\n" +
+		"Task5,,\n"
+
+	summary, err := ImportTasksCSV("ListImport", strings.NewReader(csv), false)
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if summary.Created != 2 || summary.Failed != 5 {
+		t.Errorf("expected 2 tasks created and 5 failed, got %+v", summary)
+	}
+	lines := []int{4, 5, 6, 7, 8}
+	for i, line := range lines {
+		if i >= len(summary.Errors) || summary.Errors[i].Line != line {
+			t.Errorf("expected an error on line %d, got %+v", line, summary.Errors)
+		}
+	}
+
+	task, _ := GetTask("ListImport", "Task1")
+	if task.Priority != PriorityUrgent || task.DueDate == nil || task.Description != "First, with a comma" {
+		t.Errorf("expected Task1 imported with its fields, got %+v", task)
+	}
+	if task, _ := GetTask("ListImport", "Task2"); !task.Done || task.CompletedAt == nil {
+		t.Errorf("expected Task2 imported done, got %+v", task)
+	}
+}
+
+func TestImportTasksCSV_atomic_noTaskCreated(t *testing.T) {
+	csv := "Title,Priority\nAtomic1,2\nAtomic2,high\n"
+	summary, err := ImportTasksCSV("ListImport", strings.NewReader(csv), true)
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if summary.Created != 0 || summary.Failed != 1 || summary.Errors[0].Line != 3 {
+		t.Errorf("expected no task created and an error on line 3, got %+v", summary)
+	}
+	if task, _ := GetTask("ListImport", "Atomic1"); task != nil {
+		t.Errorf("expected Atomic1 not created, got %+v", task)
+	}
+}
+
+func importFixture(t *testing.T, listKey, name string, atomic bool) *ImportSummary {
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("no error expected opening %s, got %v", name, err)
+	}
+	defer f.Close()
+	CreateToDoList(listKey)
+	summary, err := ImportTasksCSV(listKey, f, atomic)
+	if err != nil {
+		t.Fatalf("no error expected importing %s, got %v", name, err)
+	}
+	return summary
+}
+
+func TestImportTasksCSV_commaFixture_ok(t *testing.T) {
+	summary := importFixture(t, "ListImportComma", "tasks_comma.csv", false)
+	if summary.Created != 2 || summary.Failed != 0 {
+		t.Fatalf("expected 2 tasks created, got %+v", summary)
+	}
+	tasks, _ := GetTasks("ListImportComma")
+	if tasks[0].Title != "Buy milk" || tasks[1].Title != "Pay rent" {
+		t.Errorf("expected tasks created in file order, got %s, %s", tasks[0].Title, tasks[1].Title)
+	}
+	if len(tasks[0].Tags) != 2 || tasks[0].Tags[1] != "errands" || tasks[0].Done {
+		t.Errorf("expected Buy milk open with tags home and errands, got %+v", tasks[0])
+	}
+	if !tasks[1].Done || tasks[1].Priority != PriorityUrgent || tasks[1].Description != "Bank transfer, not cash" {
+		t.Errorf("expected Pay rent done, urgent, with its notes, got %+v", tasks[1])
+	}
+}
+
+func TestImportTasksCSV_semicolonBOMFixture_ok(t *testing.T) {
+	summary := importFixture(t, "ListImportSemicolon", "tasks_semicolon_bom.csv", false)
+	if summary.Created != 2 || summary.Failed != 0 {
+		t.Fatalf("expected 2 tasks created, got %+v", summary)
+	}
+	task, _ := GetTask("ListImportSemicolon", "Buy bread")
+	if task == nil || task.Description != "Wholemeal; sliced" || task.DueDate == nil || task.Tags[0] != "home" {
+		t.Errorf("expected Buy bread with its notes, due date and tag, got %+v", task)
+	}
+	if task, _ := GetTask("ListImportSemicolon", "Call mum"); task == nil || !task.Done {
+		t.Errorf("expected Call mum completed, got %+v", task)
+	}
+}
+
+func TestImportTasksCSV_quotedFixture_ok(t *testing.T) {
+	summary := importFixture(t, "ListImportQuoted", "tasks_quoted.csv", false)
+	if summary.Created != 2 {
+		t.Fatalf("expected 2 tasks created, got %+v", summary)
+	}
+	task, _ := GetTask("ListImportQuoted", `Plan "summer" trip`)
+	if task == nil || task.Description != "Line one\nLine two, with a comma" {
+		t.Errorf("expected the quoted title and multiline notes, got %+v", task)
+	}
+}
+
+func TestImportTasksCSV_errorsFixture_lines(t *testing.T) {
+	summary := importFixture(t, "ListImportErrors", "tasks_errors.csv", false)
+	if summary.Created != 1 || summary.Failed != 4 {
+		t.Fatalf("expected 1 task created and 4 failed, got %+v", summary)
+	}
+	for i, line := range []int{3, 4, 5, 6} {
+		if summary.Errors[i].Line != line {
+			t.Errorf("expected an error on line %d, got %+v", line, summary.Errors[i])
+		}
+	}
+
+	summary = importFixture(t, "ListImportStrict", "tasks_errors.csv", true)
+	if summary.Created != 0 || summary.Failed != 4 {
+		t.Errorf("expected no task created in strict mode, got %+v", summary)
+	}
+	if list, _ := GetToDoList("ListImportStrict"); list.TaskNumber != 0 {
+		t.Errorf("expected ListImportStrict left empty, got %d tasks", list.TaskNumber)
+	}
+}
+
+func TestImportChecklistText_ok(t *testing.T) {
+	text := "\ufeff- [x] Passport\n\n  - [ ] Tickets  \n* [X] Visa\n+ Hotel\n[ ] Insurance\nTaxi to the airport\n"
+	list, err := ImportChecklistText("ListChecklist", strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	expected := []struct {
+		title string
+		done  bool
+	}{{"Passport", true}, {"Tickets", false}, {"Visa", true}, {"Hotel", false}, {"Insurance", false}, {"Taxi to the airport", false}}
+	if len(list.Tasks) != len(expected) {
+		t.Fatalf("expected %d tasks, got %d", len(expected), len(list.Tasks))
+	}
+	for i, e := range expected {
+		if task := list.Tasks[i]; task.Title != e.title || task.Done != e.done || (task.CompletedAt != nil) != e.done {
+			t.Errorf("expected task %d %s done=%t, got %s done=%t", i, e.title, e.done, task.Title, task.Done)
+		}
+	}
+}
+
+func TestImportChecklistText_invalid_noListCreated(t *testing.T) {
+	_, err := ImportChecklistText("ListChecklistDuplicated", strings.NewReader("- [ ] Passport\n- [x] Passport\n- [ ]\n"))
+	seedErr, ok := err.(*TaskSeedError)
+	if !ok || len(seedErr.Failures) != 2 || seedErr.Failures[0].Index != 1 || seedErr.Failures[1].Index != 2 {
+		t.Fatalf("expected the duplicated and empty tasks reported, got %v", err)
+	}
+	if _, err := GetToDoList("ListChecklistDuplicated"); err == nil {
+		t.Errorf("expected no list created")
+	}
+	if _, err := ImportChecklistText("", strings.NewReader("Passport")); err == nil {
+		t.Errorf("expected error with an empty name")
+	}
+	ImportChecklistText("ListChecklistTwice", strings.NewReader("Passport"))
+	if _, err := ImportChecklistText("ListChecklistTwice", strings.NewReader("Passport")); err == nil {
+		t.Errorf("expected error with an existing list")
+	}
+}
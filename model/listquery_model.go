@@ -0,0 +1,163 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sort keys of the ToDo list queries
+const (
+	ListSortName       = "name"
+	ListSortTaskNumber = "taskNumber"
+)
+
+// ListQuery selects, orders and paginates the ToDo lists, the zero value
+// selecting all the lists ordered by name
+type ListQuery struct {
+	// Archived selects the archived lists, or the others, when set
+	Archived *bool
+	// Name selects the lists whose name contains it, ignoring case
+	Name string
+	Color string
+	// Tag selects the lists with at least a task tagged with it
+	Tag string
+	// Sort is the sort key, ListSortName by default, ties ordered by name
+	Sort string
+	Desc bool
+	Offset int
+	// Limit is the maximum number of lists returned, 0 meaning no limit
+	Limit int
+}
+
+// ListQueryResult is a page of the lists selected by a query, Total being
+// the number of lists selected
+type ListQueryResult struct {
+	Total int
+	Lists []*ToDoList
+}
+
+// ListCounts are the counts of the lists matching a query for its filters
+// that can be changed: TotalArchived counts the archived lists, whatever the
+// Archived filter, and Tags the lists with a task tagged with each tag,
+// whatever the Tag filter
+type ListCounts struct {
+	TotalArchived int
+	Tags          map[string]int
+}
+
+// Validate checks the query and reports all its errors
+func (q ListQuery) Validate() []string {
+	var problems []string
+	if q.Sort != "" && q.Sort != ListSortName && q.Sort != ListSortTaskNumber {
+		problems = append(problems, fmt.Sprintf("unknown sort %s, expected %s or %s", q.Sort, ListSortName, ListSortTaskNumber))
+	}
+	if q.Offset < 0 {
+		problems = append(problems, fmt.Sprintf("invalid offset %d, expected a non negative integer", q.Offset))
+	}
+	if q.Limit < 0 {
+		problems = append(problems, fmt.Sprintf("invalid limit %d, expected a non negative integer", q.Limit))
+	}
+	return problems
+}
+
+// QueryLists returns snapshots of the ToDo lists selected by the query, with
+// their tasks
+func QueryLists(q ListQuery) (*ListQueryResult, error) {
+	if problems := q.Validate(); len(problems) > 0 {
+		return nil, &ValidationError{strings.Join(problems, "; ")}
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+	return queryLists(q), nil
+}
+
+// ListsWithCounts returns the lists selected by the query as QueryLists,
+// along with their counts, taken from the same snapshot of the store
+func ListsWithCounts(q ListQuery) (*ListQueryResult, *ListCounts, error) {
+	if problems := q.Validate(); len(problems) > 0 {
+		return nil, nil, &ValidationError{strings.Join(problems, "; ")}
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+	counts := &ListCounts{Tags: map[string]int{}}
+	anyArchived, anyTag := q, q
+	anyArchived.Archived = nil
+	anyTag.Tag = ""
+	for _, list := range data {
+		if list.Archived && anyArchived.matches(list) {
+			counts.TotalArchived++
+		}
+		if anyTag.matches(list) {
+			for tag := range listTags(list) {
+				counts.Tags[tag]++
+			}
+		}
+	}
+	return queryLists(q), counts, nil
+}
+
+// listTags returns the distinct tags of the tasks of the list
+func listTags(list *ToDoList) map[string]bool {
+	tags := map[string]bool{}
+	for _, t := range list.Tasks {
+		for _, tag := range t.Tags {
+			tags[tag] = true
+		}
+	}
+	return tags
+}
+
+func queryLists(q ListQuery) *ListQueryResult {
+	selected := []*ToDoList{}
+	for _, list := range data {
+		if q.matches(list) {
+			selected = append(selected, list)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		a, b := selected[i], selected[j]
+		if q.Sort == ListSortTaskNumber && a.TaskNumber != b.TaskNumber {
+			return (a.TaskNumber < b.TaskNumber) != q.Desc
+		}
+		return (a.Name < b.Name) != q.Desc
+	})
+
+	result := &ListQueryResult{Total: len(selected), Lists: []*ToDoList{}}
+	start := q.Offset
+	if start > len(selected) {
+		start = len(selected)
+	}
+	end := len(selected)
+	// compared to the remaining lists, start+q.Limit overflowing for huge limits
+	if q.Limit > 0 && q.Limit < end-start {
+		end = start + q.Limit
+	}
+	for _, list := range selected[start:end] {
+		result.Lists = append(result.Lists, cloneToDoList(list))
+	}
+	return result
+}
+
+func (q ListQuery) matches(list *ToDoList) bool {
+	if q.Archived != nil && list.Archived != *q.Archived {
+		return false
+	}
+	if q.Name != "" && !strings.Contains(strings.ToLower(list.Name), strings.ToLower(q.Name)) {
+		return false
+	}
+	if q.Color != "" && list.Color != q.Color {
+		return false
+	}
+	if q.Tag == "" {
+		return true
+	}
+	for _, t := range list.Tasks {
+		for _, tag := range t.Tags {
+			if tag == q.Tag {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+/*******************************
+	QUERY ToDo lists
+*******************************/
+
+func TestQueryLists_ok(t *testing.T) {
+	SeedToDoList("ListQuery A", []TaskSeed{{Title: "Task1", TaskDetails: TaskDetails{Tags: []string{"querytag"}}}})
+	SeedToDoList("ListQuery B", []TaskSeed{{Title: "Task1", TaskDetails: TaskDetails{Tags: []string{"querytag"}}}, {Title: "Task2"}})
+	SeedToDoList("ListQuery C", []TaskSeed{{Title: "Task1"}})
+	MergePatchToDoList("ListQuery B", map[string]interface{}{"Archived": true})
+
+	result, err := QueryLists(ListQuery{Tag: "querytag", Sort: ListSortTaskNumber, Desc: true})
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if result.Total != 2 || result.Lists[0].Name != "ListQuery B" || result.Lists[1].Name != "ListQuery A" {
+		t.Errorf("expected ListQuery B then A, got %+v", result)
+	}
+
+	active := false
+	result, _ = QueryLists(ListQuery{Name: "listquery", Archived: &active, Offset: 1, Limit: 1})
+	if result.Total != 2 || len(result.Lists) != 1 || result.Lists[0].Name != "ListQuery C" {
+		t.Errorf("expected the second page with ListQuery C, got %+v", result)
+	}
+	result, _ = QueryLists(ListQuery{Name: "listquery", Archived: &active, Offset: 1, Limit: math.MaxInt})
+	if result.Total != 2 || len(result.Lists) != 1 {
+		t.Errorf("expected the lists after the first with a huge limit, got %+v", result)
+	}
+}
+
+func TestListsWithCounts_ok(t *testing.T) {
+	SeedToDoList("ListCounts A", []TaskSeed{{Title: "Task1", TaskDetails: TaskDetails{Tags: []string{"home", "urgent"}}},
+		{Title: "Task2", TaskDetails: TaskDetails{Tags: []string{"home"}}}})
+	SeedToDoList("ListCounts B", []TaskSeed{{Title: "Task1", TaskDetails: TaskDetails{Tags: []string{"home"}}}})
+	SeedToDoList("ListCounts C", []TaskSeed{{Title: "Task1", TaskDetails: TaskDetails{Tags: []string{"work"}}}})
+	MergePatchToDoList("ListCounts B", map[string]interface{}{"Archived": true})
+	MergePatchToDoList("ListCounts C", map[string]interface{}{"Archived": true})
+
+	active := false
+	result, counts, err := ListsWithCounts(ListQuery{Name: "listcounts", Archived: &active, Tag: "home"})
+	if err != nil || result.Total != 1 || result.Lists[0].Name != "ListCounts A" {
+		t.Fatalf("expected ListCounts A selected, got %+v, %v", result, err)
+	}
+	if counts.TotalArchived != 1 {
+		t.Errorf("expected the archived list tagged home counted, got %d", counts.TotalArchived)
+	}
+	expected := map[string]int{"home": 1, "urgent": 1}
+	if len(counts.Tags) != len(expected) || counts.Tags["home"] != 1 || counts.Tags["urgent"] != 1 {
+		t.Errorf("expected the tags of the active lists %v, got %v", expected, counts.Tags)
+	}
+}
+
+func TestQueryLists_invalid_error(t *testing.T) {
+	q := ListQuery{Sort: "color", Offset: -1}
+	if problems := q.Validate(); len(problems) != 2 {
+		t.Errorf("expected 2 problems, got %v", problems)
+	}
+	if _, err := QueryLists(q); err == nil {
+		t.Errorf("expected error with an invalid query")
+	}
+}
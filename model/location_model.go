@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/efreddo/v1/todolist/geoutils"
+)
+
+// MaxNearbyRadius is the largest search radius of NearbyTasks, in meters
+const MaxNearbyRadius = 100000
+
+// NearbyTask is a task found close to a point, with its distance in meters
+type NearbyTask struct {
+	*Task
+	Distance float64
+}
+
+// NearbyTasks returns the pending tasks of all the lists located within
+// radius meters from the given point, the closest first. Tasks without
+// coordinates are excluded.
+func NearbyTasks(lat, lon, radius float64) ([]NearbyTask, error) {
+	if err := geoutils.ValidCoordinates(lat, lon); err != nil {
+		return nil, err
+	}
+	if !(radius > 0 && radius <= MaxNearbyRadius) {
+		return nil, fmt.Errorf("invalid radius %v, expected more than 0 and up to %d meters", radius, MaxNearbyRadius)
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	nearby := []NearbyTask{}
+	for _, list := range data {
+		for _, t := range list.Tasks {
+			if t.Done || t.Location == nil || t.Location.Lat == nil {
+				continue
+			}
+			distance := geoutils.Distance(lat, lon, *t.Location.Lat, *t.Location.Lon)
+			if distance <= radius {
+				nearby = append(nearby, NearbyTask{Task: copyTask(t), Distance: distance})
+			}
+		}
+	}
+	sort.Slice(nearby, func(i, j int) bool {
+		if nearby[i].Distance == nearby[j].Distance {
+			return nearby[i].ToDoList+"/"+nearby[i].Title < nearby[j].ToDoList+"/"+nearby[j].Title
+		}
+		return nearby[i].Distance < nearby[j].Distance
+	})
+	return nearby, nil
+}
@@ -0,0 +1,62 @@
+package model
+
+import "testing"
+
+/*******************************
+	NEARBY Tasks
+*******************************/
+
+func location(label string, lat, lon float64) *Location {
+	return &Location{Label: label, Lat: &lat, Lon: &lon}
+}
+
+func TestAddTaskWithDetails_invalidLocation_error(t *testing.T) {
+	CreateToDoList("ListNearby")
+	lat := 45.0
+	invalid := []*Location{
+		location("North of the pole", 90.5, 0),
+		location("Beyond the antimeridian", 0, 181),
+		{Label: "Latitude only", Lat: &lat},
+	}
+	for _, l := range invalid {
+		_, _, err := AddTaskWithDetails("ListNearby", "Invalid", TaskDetails{Location: l})
+		if _, ok := err.(*ValidationError); !ok {
+			t.Errorf("expected validation error for %s, got %v", l.Label, err)
+		}
+	}
+}
+
+func TestNearbyTasks_invalidParameters_error(t *testing.T) {
+	if _, err := NearbyTasks(91, 0, 500); err == nil {
+		t.Errorf("expected invalid latitude error, got nil")
+	}
+	if _, err := NearbyTasks(0, 0, 0); err == nil {
+		t.Errorf("expected invalid radius error, got nil")
+	}
+	if _, err := NearbyTasks(0, 0, MaxNearbyRadius+1); err == nil {
+		t.Errorf("expected invalid radius error, got nil")
+	}
+}
+
+func TestNearbyTasks_ok(t *testing.T) {
+	AddTaskWithDetails("ListNearby", "Bakery", TaskDetails{Location: location("Bakery", 41.9030, 12.4964)})
+	AddTaskWithDetails("ListNearby", "Pharmacy", TaskDetails{Location: location("Pharmacy", 41.9010, 12.4964)})
+	AddTaskWithDetails("ListNearby", "Airport", TaskDetails{Location: location("Airport", 41.8003, 12.2389)})
+	AddTaskWithDetails("ListNearby", "Label only", TaskDetails{Location: &Location{Label: "Somewhere"}})
+	AddTaskWithDetails("ListNearby", "Done", TaskDetails{Location: location("Done", 41.9028, 12.4964)})
+	UpdateTask("ListNearby", "Done", "Done", true)
+
+	nearby, err := NearbyTasks(41.9028, 12.4964, 500)
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if len(nearby) != 2 {
+		t.Fatalf("expected 2 pending tasks nearby, got %d", len(nearby))
+	}
+	if nearby[0].Title != "Bakery" || nearby[1].Title != "Pharmacy" {
+		t.Errorf("expected Bakery then Pharmacy, got %s, %s", nearby[0].Title, nearby[1].Title)
+	}
+	if nearby[0].Distance > nearby[1].Distance {
+		t.Errorf("expected the closest task first")
+	}
+}
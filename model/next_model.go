@@ -0,0 +1,44 @@
+package model
+
+// NextActionableTask returns the pending task of the ToDo list to work on
+// next: the one with the highest priority, tasks without priority coming
+// last, the earliest due date breaking ties, tasks without due date coming
+// last, then the order of the list. Deferred tasks are skipped, nil is returned
+// when all tasks are done or deferred.
+func NextActionableTask(listKey string) (*Task, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var next *Task
+	for _, t := range list.Tasks {
+		if !t.Done && !t.Deferred && (next == nil || actionableBefore(t, next)) {
+			next = t
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	return copyTask(next), nil
+}
+
+// actionableBefore tells whether a is to be worked on strictly before b
+func actionableBefore(a, b *Task) bool {
+	pa, pb := a.Priority, b.Priority
+	if pa == PriorityNone {
+		pa = PriorityLow + 1
+	}
+	if pb == PriorityNone {
+		pb = PriorityLow + 1
+	}
+	if pa != pb {
+		return pa < pb
+	}
+	if a.DueDate == nil || b.DueDate == nil {
+		return a.DueDate != nil && b.DueDate == nil
+	}
+	return a.DueDate.Before(*b.DueDate)
+}
@@ -0,0 +1,40 @@
+package model
+
+import "testing"
+
+/*******************************
+	NEXT actionable Task
+*******************************/
+
+func TestNextActionableTask_invalidList_error(t *testing.T) {
+	if _, err := NextActionableTask("invalid"); err == nil {
+		t.Errorf("expected error list not found, got nil")
+	}
+}
+
+func TestNextActionableTask_ok(t *testing.T) {
+	CreateToDoList("ListNext")
+	if task, err := NextActionableTask("ListNext"); task != nil || err != nil {
+		t.Errorf("expected no actionable task in an empty list, got %v, %v", task, err)
+	}
+
+	AddTask("ListNext", "NoPriority")
+	later, sooner := dueOn(2024, 6, 10, 9), dueOn(2024, 6, 1, 9)
+	later.Priority, sooner.Priority = PriorityHigh, PriorityHigh
+	AddTaskWithDetails("ListNext", "HighLater", later)
+	AddTaskWithDetails("ListNext", "HighNoDue", TaskDetails{Priority: PriorityHigh})
+	AddTaskWithDetails("ListNext", "HighSooner", sooner)
+	AddTaskWithDetails("ListNext", "Low", TaskDetails{Priority: PriorityLow})
+
+	expected := []string{"HighSooner", "HighLater", "HighNoDue", "Low", "NoPriority"}
+	for _, title := range expected {
+		task, err := NextActionableTask("ListNext")
+		if err != nil || task == nil || task.Title != title {
+			t.Fatalf("expected %s as next task, got %v, %v", title, task, err)
+		}
+		UpdateTask("ListNext", title, title, true)
+	}
+	if task, _ := NextActionableTask("ListNext"); task != nil {
+		t.Errorf("expected no actionable task once all are done, got %v", task)
+	}
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NotificationPrefs select the events of a ToDo list notified by the after
+// hooks, the integrations delivering the notifications: the events of the
+// Muted types are not dispatched to them. The before hooks, which enforce
+// the rules of the lists, are not affected.
+type NotificationPrefs struct {
+	Muted []string
+}
+
+// defaultMuted are the event types muted in the lists without notification
+// preferences, see SetDefaultMutedEvents
+var defaultMuted = map[string]bool{}
+
+// SetDefaultMutedEvents sets the event types muted in the lists without
+// notification preferences, none by default
+func SetDefaultMutedEvents(types []string) error {
+	muted, err := mutedEvents(types)
+	if err != nil {
+		return err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	defaultMuted = muted
+	return nil
+}
+
+// SetListNotificationPrefs sets the notification preferences of the ToDo
+// list, nil restoring the default ones
+func SetListNotificationPrefs(listKey string, prefs *NotificationPrefs) (NotificationPrefs, error) {
+	if prefs != nil {
+		muted, err := mutedEvents(prefs.Muted)
+		if err != nil {
+			return NotificationPrefs{}, err
+		}
+		prefs = &NotificationPrefs{Muted: sortedEvents(muted)}
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return NotificationPrefs{}, err
+	}
+	list.Notifications = prefs
+	list.UpdatedAt = now()
+	return listNotificationPrefs(list), nil
+}
+
+// ListNotificationPrefs returns the notification preferences applying to the
+// ToDo list: its own or the default ones
+func ListNotificationPrefs(listKey string) (NotificationPrefs, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return NotificationPrefs{}, err
+	}
+	return listNotificationPrefs(list), nil
+}
+
+func listNotificationPrefs(list *ToDoList) NotificationPrefs {
+	if list.Notifications != nil {
+		return NotificationPrefs{Muted: append([]string{}, list.Notifications.Muted...)}
+	}
+	return NotificationPrefs{Muted: sortedEvents(defaultMuted)}
+}
+
+// notified tells whether the event of the ToDo list is dispatched to the
+// after hooks, the lists deleted meanwhile following the default preferences
+func notified(event, listName string) bool {
+	list := data[listName]
+	if list == nil || list.Notifications == nil {
+		return !defaultMuted[event]
+	}
+	for _, muted := range list.Notifications.Muted {
+		if muted == event {
+			return false
+		}
+	}
+	return true
+}
+
+// mutedEvents validates the event types to mute
+func mutedEvents(types []string) (map[string]bool, error) {
+	muted := make(map[string]bool, len(types))
+	for _, t := range types {
+		if !IsEventType(t) && t != EventTaskUpdated && t != EventTaskReminder {
+			return nil, &ValidationError{fmt.Sprintf("unknown event type %s", t)}
+		}
+		muted[t] = true
+	}
+	return muted, nil
+}
+
+func sortedEvents(events map[string]bool) []string {
+	sorted := make([]string, 0, len(events))
+	for event := range events {
+		sorted = append(sorted, event)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	NOTIFICATION preferences
+*******************************/
+
+func TestSetListNotificationPrefs(t *testing.T) {
+	events := make(chan HookEvent, 10)
+	after := Hook{Name: "notify", After: func(e HookEvent) {
+		if e.List == "ListNotifications" {
+			events <- e
+		}
+	}}
+	for _, event := range []string{EventTaskCreated, EventTaskCompleted} {
+		defer RegisterHook(event, after)()
+	}
+	CreateToDoList("ListNotifications")
+
+	prefs, err := SetListNotificationPrefs("ListNotifications", &NotificationPrefs{Muted: []string{EventTaskCreated, EventTaskCreated}})
+	if err != nil || len(prefs.Muted) != 1 || prefs.Muted[0] != EventTaskCreated {
+		t.Fatalf("expected task.created muted, got %+v (%v)", prefs, err)
+	}
+	AddTask("ListNotifications", "Task1")
+	UpdateTask("ListNotifications", "Task1", "Task1", true)
+	select {
+	case e := <-events:
+		if e.Type != EventTaskCompleted {
+			t.Errorf("expected only the completion notified, got %s", e.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the completion notified")
+	}
+
+	if _, err := SetListNotificationPrefs("ListNotifications", &NotificationPrefs{Muted: []string{"task.moved"}}); err == nil {
+		t.Errorf("expected an unknown event type rejected")
+	}
+	defer SetDefaultMutedEvents(nil)
+	SetDefaultMutedEvents([]string{EventTaskCompleted})
+	if prefs, _ := SetListNotificationPrefs("ListNotifications", nil); len(prefs.Muted) != 1 || prefs.Muted[0] != EventTaskCompleted {
+		t.Errorf("expected the default preferences restored, got %+v", prefs)
+	}
+}
@@ -0,0 +1,79 @@
+package model
+
+import "sort"
+
+// Indexes checked for orphaned tasks
+const (
+	IndexCompleted = "completed"
+	IndexDue       = "due"
+)
+
+// OrphanedTask is an index entry of a task that its ToDo list no longer
+// holds, e.g. left behind by a partial delete
+type OrphanedTask struct {
+	List  string
+	Title string
+	Index string
+	Day   string
+}
+
+// FindOrphanedTasks returns the index entries of the tasks that no ToDo
+// list holds, ordered by index, day, list and title
+func FindOrphanedTasks() []OrphanedTask {
+	lock.RLock()
+	defer lock.RUnlock()
+	return findOrphanedTasks(false)
+}
+
+// CleanupOrphanedTasks removes the index entries of the tasks that no ToDo
+// list holds and returns them
+func CleanupOrphanedTasks() []OrphanedTask {
+	lock.Lock()
+	defer lock.Unlock()
+	return findOrphanedTasks(true)
+}
+
+func findOrphanedTasks(remove bool) []OrphanedTask {
+	held := make(map[*Task]bool)
+	for _, list := range data {
+		for _, t := range list.Tasks {
+			held[t] = true
+		}
+	}
+
+	orphans := []OrphanedTask{}
+	for name, index := range map[string]map[string][]*Task{IndexCompleted: completedIndex, IndexDue: dueIndex} {
+		for day, bucket := range index {
+			var kept []*Task
+			for _, t := range bucket {
+				if held[t] {
+					kept = append(kept, t)
+					continue
+				}
+				orphans = append(orphans, OrphanedTask{List: t.ToDoList, Title: t.Title, Index: name, Day: day})
+			}
+			switch {
+			case !remove || len(kept) == len(bucket):
+			case len(kept) == 0:
+				delete(index, day)
+			default:
+				index[day] = kept
+			}
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		a, b := orphans[i], orphans[j]
+		if a.Index != b.Index {
+			return a.Index < b.Index
+		}
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.List != b.List {
+			return a.List < b.List
+		}
+		return a.Title < b.Title
+	})
+	return orphans
+}
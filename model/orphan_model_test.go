@@ -0,0 +1,31 @@
+package model
+
+import "testing"
+
+/*******************************
+	ORPHANED Tasks
+*******************************/
+
+func TestCleanupOrphanedTasks_ok(t *testing.T) {
+	CreateToDoList("ListOrphans")
+	AddTaskWithDetails("ListOrphans", "Held", dueOn(2031, 1, 1, 9))
+	// a task indexed by a partial delete which left it out of its list
+	orphan := &Task{ToDoList: "ListOrphansGone", Title: "Orphan", TaskDetails: dueOn(2031, 1, 1, 10)}
+	lock.Lock()
+	indexDueDate(orphan)
+	lock.Unlock()
+
+	expected := OrphanedTask{List: "ListOrphansGone", Title: "Orphan", Index: IndexDue, Day: "2031-01-01"}
+	if orphans := FindOrphanedTasks(); len(orphans) != 1 || orphans[0] != expected {
+		t.Fatalf("expected %+v, got %+v", expected, orphans)
+	}
+	if orphans := CleanupOrphanedTasks(); len(orphans) != 1 {
+		t.Errorf("expected the orphan removed, got %+v", orphans)
+	}
+	if orphans := FindOrphanedTasks(); len(orphans) != 0 {
+		t.Errorf("expected no orphan left, got %+v", orphans)
+	}
+	if due := dueBetween(dueOn(2031, 1, 1, 0).DueDate.UTC(), dueOn(2031, 1, 2, 0).DueDate.UTC()); len(due) != 1 || due[0].Title != "Held" {
+		t.Errorf("expected the held task still indexed, got %v", due)
+	}
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// OverdueTask is an incomplete task whose due date has passed, with the
+// number of calendar days elapsed since the due day
+type OverdueTask struct {
+	*Task
+	DaysOverdue int
+}
+
+// OverdueList groups the overdue tasks of a ToDo list
+type OverdueList struct {
+	List  string
+	Tasks []OverdueTask
+}
+
+// AllOverdueTasks returns the incomplete tasks of all the lists whose due date
+// has passed, the most overdue first. Days overdue are counted in the given
+// location, a task due earlier today being 0 days overdue.
+func AllOverdueTasks(loc *time.Location) ([]OverdueTask, error) {
+	if loc == nil {
+		return nil, fmt.Errorf("missing time zone")
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+
+	current := now()
+	today, _ := DayBounds(current, loc)
+	overdue := []OverdueTask{}
+	for _, list := range data {
+		for _, t := range list.Tasks {
+			if t.Done || t.Deferred || t.DueDate == nil {
+				continue
+			}
+			due, overdueAt := dueBounds(t, loc)
+			if current.Before(overdueAt) {
+				continue
+			}
+			dueDay, _ := DayBounds(due, loc)
+			overdue = append(overdue, OverdueTask{Task: copyTask(t), DaysOverdue: calendarDays(dueDay, today)})
+		}
+	}
+	sort.Slice(overdue, func(i, j int) bool {
+		a, b := overdue[i], overdue[j]
+		if !a.DueDate.Equal(*b.DueDate) {
+			return a.DueDate.Before(*b.DueDate)
+		}
+		return a.ToDoList+"/"+a.Title < b.ToDoList+"/"+b.Title
+	})
+	return overdue, nil
+}
+
+// dueBounds returns when the task, with a due date, falls due and from when it
+// is overdue in the location: right after its due date, or the bounds of its
+// day in the location for an all-day task
+func dueBounds(t *Task, loc *time.Location) (due, overdue time.Time) {
+	if !t.AllDay {
+		return *t.DueDate, t.DueDate.Add(time.Nanosecond)
+	}
+	year, month, day := t.DueDate.Date()
+	due = time.Date(year, month, day, 0, 0, 0, 0, loc)
+	return due, due.AddDate(0, 0, 1)
+}
+
+// GroupOverdueTasks groups the tasks by list, keeping their order: lists
+// appear in the order of their first task.
+func GroupOverdueTasks(tasks []OverdueTask) []OverdueList {
+	groups := []OverdueList{}
+	index := map[string]int{}
+	for _, t := range tasks {
+		i, ok := index[t.ToDoList]
+		if !ok {
+			i = len(groups)
+			index[t.ToDoList] = i
+			groups = append(groups, OverdueList{List: t.ToDoList})
+		}
+		groups[i].Tasks = append(groups[i].Tasks, t)
+	}
+	return groups
+}
+
+// calendarDays returns the number of calendar days from the start of the day
+// from to the start of the day to, DST changes included
+func calendarDays(from, to time.Time) int {
+	days := 0
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	return days
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	OVERDUE Tasks
+*******************************/
+
+func dueOn(year int, month time.Month, day, hour int) TaskDetails {
+	due := time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	return TaskDetails{DueDate: &due}
+}
+
+// overdueOf keeps the overdue tasks of the given lists, the data being shared
+// with the other tests
+func overdueOf(tasks []OverdueTask, lists ...string) []OverdueTask {
+	kept := []OverdueTask{}
+	for _, t := range tasks {
+		for _, l := range lists {
+			if t.ToDoList == l {
+				kept = append(kept, t)
+			}
+		}
+	}
+	return kept
+}
+
+func TestAllOverdueTasks_missingLocation_error(t *testing.T) {
+	if _, err := AllOverdueTasks(nil); err == nil {
+		t.Errorf("expected missing time zone error, got nil")
+	}
+}
+
+func TestAllOverdueTasks_ok(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC) }
+
+	CreateToDoList("ListOverdue1")
+	CreateToDoList("ListOverdue2")
+	AddTaskWithDetails("ListOverdue1", "Week", dueOn(2024, 6, 3, 9))
+	AddTaskWithDetails("ListOverdue1", "Today", dueOn(2024, 6, 10, 8))
+	AddTaskWithDetails("ListOverdue1", "Future", dueOn(2024, 6, 10, 18))
+	AddTaskWithDetails("ListOverdue1", "Done", dueOn(2024, 6, 1, 9))
+	AddTaskWithDetails("ListOverdue2", "Yesterday", dueOn(2024, 6, 9, 23))
+	AddTask("ListOverdue2", "NoDueDate")
+	UpdateTask("ListOverdue1", "Done", "Done", true)
+
+	all, err := AllOverdueTasks(time.UTC)
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	overdue := overdueOf(all, "ListOverdue1", "ListOverdue2")
+	expected := []struct {
+		title string
+		days  int
+	}{{"Week", 7}, {"Yesterday", 1}, {"Today", 0}}
+	if len(overdue) != len(expected) {
+		t.Fatalf("expected %d overdue tasks, got %d", len(expected), len(overdue))
+	}
+	for i, e := range expected {
+		if overdue[i].Title != e.title || overdue[i].DaysOverdue != e.days {
+			t.Errorf("expected %s overdue by %d days, got %s by %d", e.title, e.days, overdue[i].Title, overdue[i].DaysOverdue)
+		}
+	}
+
+	// 2024-06-09 23:00 UTC is already 2024-06-10 in Rome
+	rome := time.FixedZone("CEST", 2*60*60)
+	overdue, _ = AllOverdueTasks(rome)
+	overdue = overdueOf(overdue, "ListOverdue2")
+	if len(overdue) != 1 || overdue[0].DaysOverdue != 0 {
+		t.Errorf("expected Yesterday overdue by 0 days in Rome, got %v", overdue)
+	}
+
+	groups := GroupOverdueTasks(overdueOf(all, "ListOverdue1", "ListOverdue2"))
+	if len(groups) != 2 || groups[0].List != "ListOverdue1" || len(groups[0].Tasks) != 2 || groups[1].List != "ListOverdue2" {
+		t.Errorf("expected the tasks grouped by list, most overdue list first, got %v", groups)
+	}
+}
+
+func TestAllOverdueTasks_allDay(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2024, 6, 10, 2, 0, 0, 0, time.UTC) }
+	newYork := time.FixedZone("EDT", -4*60*60)
+
+	CreateToDoList("ListOverdueAllDay")
+	lateEvening := time.Date(2024, 6, 9, 23, 30, 0, 0, newYork)
+	monday := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	task, _, err := AddTaskWithDetails("ListOverdueAllDay", "Sunday", TaskDetails{DueDate: &lateEvening, AllDay: true})
+	if err != nil || !task.DueDate.Equal(time.Date(2024, 6, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the due date stored as the day at midnight UTC, got %v, %v", task, err)
+	}
+	AddTaskWithDetails("ListOverdueAllDay", "Monday", TaskDetails{DueDate: &monday, AllDay: true})
+
+	overdue, _ := AllOverdueTasks(time.UTC)
+	overdue = overdueOf(overdue, "ListOverdueAllDay")
+	if len(overdue) != 1 || overdue[0].Title != "Sunday" || overdue[0].DaysOverdue != 1 {
+		t.Errorf("expected Sunday overdue by 1 day once the day ended in UTC, got %v", overdue)
+	}
+	// still 2024-06-09 in New York
+	if overdue, _ := AllOverdueTasks(newYork); len(overdueOf(overdue, "ListOverdueAllDay")) != 0 {
+		t.Errorf("expected no all-day task overdue before the day ends in New York, got %v", overdue)
+	}
+
+	if _, _, err := AddTaskWithDetails("ListOverdueAllDay", "NoDate", TaskDetails{AllDay: true}); err == nil {
+		t.Errorf("expected an all-day task without due date rejected")
+	}
+}
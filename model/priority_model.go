@@ -0,0 +1,62 @@
+package model
+
+import "fmt"
+
+// PrioritySummary reports the outcome of SetTasksPriority: the number of
+// tasks updated, the ids not found and the tasks whose update was vetoed by
+// a hook
+type PrioritySummary struct {
+	Updated  int
+	NotFound []string      `json:",omitempty"`
+	Rejected []ExportError `json:",omitempty"`
+}
+
+// SetTasksPriority sets the priority of the tasks of the ToDo list with the
+// given ids, their titles or short references (task-7). Tasks already with
+// the priority are counted as updated. Missing tasks and vetoed updates are
+// reported in the summary without failing the others.
+func SetTasksPriority(listKey string, ids []string, priority int) (*PrioritySummary, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("empty list of tasks to prioritize")
+	}
+	if err := validatePriority(priority); err != nil {
+		return nil, err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &PrioritySummary{}
+	seen := make(map[*Task]bool, len(ids))
+	for _, id := range ids {
+		task, err := getTask(list.Name, resolveTaskTitle(list, id))
+		if err != nil {
+			summary.NotFound = append(summary.NotFound, id)
+			continue
+		}
+		if seen[task] {
+			continue
+		}
+		seen[task] = true
+		if task.Priority == priority {
+			summary.Updated++
+			continue
+		}
+		updated := cloneTask(task)
+		updated.Priority = priority
+		if err := vetoEvent(EventTaskUpdated, list.Name, task, updated); err != nil {
+			summary.Rejected = append(summary.Rejected, ExportError{Key: id, Error: err.Error()})
+			continue
+		}
+		before := copyTask(task)
+		task.Priority = priority
+		resortTask(list, task)
+		recordTaskUpdate(before, task)
+		dispatchEvent(EventTaskUpdated, list.Name, before, task)
+		summary.Updated++
+	}
+	return summary, nil
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+)
+
+/*******************************
+	BULK priority
+*******************************/
+
+func TestSetTasksPriority(t *testing.T) {
+	CreateToDoList("ListBulkPriority")
+	AddTask("ListBulkPriority", "Task1")
+	task2, _ := AddTask("ListBulkPriority", "Task2")
+	AddTaskWithDetails("ListBulkPriority", "Task3", TaskDetails{Priority: PriorityHigh})
+	AddTask("ListBulkPriority", "Vetoed")
+	defer RegisterHook(EventTaskUpdated, Hook{Name: "veto", Before: func(e HookEvent) error {
+		if e.After.Title == "Vetoed" {
+			return fmt.Errorf("frozen")
+		}
+		return nil
+	}})()
+
+	summary, err := SetTasksPriority("ListBulkPriority", []string{"Task1", TaskRef(task2), "Task3", "Missing", "Vetoed"}, PriorityHigh)
+	if err != nil {
+		t.Fatalf("expected the priorities set, got %v", err)
+	}
+	if summary.Updated != 3 || len(summary.NotFound) != 1 || summary.NotFound[0] != "Missing" ||
+		len(summary.Rejected) != 1 || summary.Rejected[0].Key != "Vetoed" {
+		t.Errorf("expected 3 tasks updated, Missing not found and Vetoed rejected, got %+v", summary)
+	}
+	for _, title := range []string{"Task1", "Task2", "Task3"} {
+		if task, _ := GetTask("ListBulkPriority", title); task.Priority != PriorityHigh {
+			t.Errorf("expected %s with a high priority, got %d", title, task.Priority)
+		}
+	}
+	if task, _ := GetTask("ListBulkPriority", "Vetoed"); task.Priority != PriorityNone {
+		t.Errorf("expected the vetoed task untouched, got %d", task.Priority)
+	}
+
+	if _, err := SetTasksPriority("ListBulkPriority", []string{"Task1"}, 5); err == nil {
+		t.Errorf("expected an invalid priority rejected")
+	}
+	if _, err := SetTasksPriority("ListBulkPriorityMissing", []string{"Task1"}, PriorityLow); err == nil {
+		t.Errorf("expected an error for a missing list")
+	}
+}
@@ -0,0 +1,43 @@
+package model
+
+import "fmt"
+
+// MaxProgress is the progress of a done task
+const MaxProgress = 100
+
+// SetTaskProgress sets the completion percentage of the task, from 0 to
+// MaxProgress: MaxProgress completes the task, a lower progress reopens it
+func SetTaskProgress(todoListName string, taskTitle string, progress int) (*Task, error) {
+	if progress < 0 || progress > MaxProgress {
+		return nil, &ValidationError{fmt.Sprintf("invalid progress %d, expected 0 to %d", progress, MaxProgress)}
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	task, err := getTask(todoListName, taskTitle)
+	if err != nil {
+		return nil, err
+	}
+	if err := vetoTaskDone(todoListName, task, progress == MaxProgress); err != nil {
+		return nil, err
+	}
+	setTaskDone(task, progress == MaxProgress)
+	if progress != task.Progress {
+		recordTaskChange(task, TaskChange{Type: EventTaskUpdated, Field: "Progress",
+			Before: changeValue(task.Progress), After: changeValue(progress)})
+		task.Progress = progress
+	}
+	return copyTask(task), nil
+}
+
+// percentComplete returns the average progress of the tasks, rounded, 0
+// without tasks
+func percentComplete(tasks []*Task) int {
+	if len(tasks) == 0 {
+		return 0
+	}
+	total := 0
+	for _, t := range tasks {
+		total += t.Progress
+	}
+	return (total + len(tasks)/2) / len(tasks)
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+/*******************************
+	TASK progress
+*******************************/
+
+func TestSetTaskProgress(t *testing.T) {
+	CreateToDoList("ListProgress")
+	AddTask("ListProgress", "Task1")
+	AddTask("ListProgress", "Task2")
+
+	task, err := SetTaskProgress("ListProgress", "Task1", 40)
+	if err != nil || task.Progress != 40 || task.Done {
+		t.Fatalf("expected the task 40%% done, got %+v (%v)", task, err)
+	}
+	if task, _ = SetTaskProgress("ListProgress", "Task2", MaxProgress); !task.Done || task.CompletedAt == nil {
+		t.Errorf("expected the task completed with a full progress, got %+v", task)
+	}
+	list, _ := GetToDoListWithTasks("ListProgress")
+	list.Tasks = list.Tasks[:1]
+	if b, _ := json.Marshal(list); !strings.Contains(string(b), `"PercentComplete":70`) {
+		t.Errorf("expected the list 70%% complete across all its tasks, got %s", b)
+	}
+
+	if task, _ = SetTaskProgress("ListProgress", "Task2", 90); task.Done || task.Progress != 90 {
+		t.Errorf("expected the task reopened at 90%%, got %+v", task)
+	}
+	UpdateTask("ListProgress", "Task2", "Task2", true)
+	UpdateTask("ListProgress", "Task2", "Task2", false)
+	if task, _ = GetTask("ListProgress", "Task2"); task.Progress != 0 {
+		t.Errorf("expected the progress reset when reopened, got %d", task.Progress)
+	}
+
+	for _, progress := range []int{-1, 101} {
+		if _, err := SetTaskProgress("ListProgress", "Task1", progress); err == nil {
+			t.Errorf("expected the progress %d rejected", progress)
+		}
+	}
+}
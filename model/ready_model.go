@@ -0,0 +1,21 @@
+package model
+
+import "sync/atomic"
+
+// ready is set once the store finished loading at startup, see SetReady
+var ready int32
+
+// Ready reports whether the store finished loading at startup and serves
+// complete data
+func Ready() bool {
+	return atomic.LoadInt32(&ready) == 1
+}
+
+// SetReady marks the store as loaded, or as loading again
+func SetReady(loaded bool) {
+	var value int32
+	if loaded {
+		value = 1
+	}
+	atomic.StoreInt32(&ready, value)
+}
@@ -0,0 +1,19 @@
+package model
+
+import "testing"
+
+/*******************************
+	READINESS
+*******************************/
+
+func TestSetReady(t *testing.T) {
+	defer SetReady(Ready())
+	SetReady(false)
+	if Ready() {
+		t.Errorf("expected the store not ready while loading")
+	}
+	SetReady(true)
+	if !Ready() {
+		t.Errorf("expected the store ready once loaded")
+	}
+}
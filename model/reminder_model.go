@@ -0,0 +1,100 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/efreddo/v1/todolist/logutils"
+)
+
+// EventTaskReminder is the hook event of a reminder due, fired once the
+// ReminderLead of the task before its due date is reached. It is not
+// recorded in the history.
+const EventTaskReminder = "task.reminder"
+
+// MaxReminderLead is the longest lead time of the reminders
+const MaxReminderLead = 30 * 24 * time.Hour
+
+// reminderInterval is the interval between the passes of RemindTasks
+var reminderInterval = time.Minute
+
+// validateReminderLead checks the lead time of the reminder of the task, a
+// duration (e.g. 30m or 2h) up to MaxReminderLead needing a due date
+func validateReminderLead(details *TaskDetails) error {
+	if details.ReminderLead == "" {
+		return nil
+	}
+	lead, err := time.ParseDuration(details.ReminderLead)
+	if err != nil || lead <= 0 || lead > MaxReminderLead {
+		return &ValidationError{fmt.Sprintf("invalid reminder lead %s, expected a positive duration up to %s, e.g. 30m",
+			details.ReminderLead, MaxReminderLead)}
+	}
+	if details.DueDate == nil {
+		return &ValidationError{"tasks with a reminder lead must have a due date"}
+	}
+	return nil
+}
+
+// rearmReminder clears the ReminderFired flag of the task when its due date
+// or its reminder lead changed, so that the new reminder fires
+func rearmReminder(before, t *Task) {
+	if before.ReminderLead != t.ReminderLead || !sameTime(before.DueDate, t.DueDate) {
+		t.ReminderFired = false
+	}
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// RemindTasks fires, every minute until ctx is done, the reminders due (see
+// FireReminders), logging them. It returns the error of ctx.
+func RemindTasks(ctx context.Context) error {
+	ticker := time.NewTicker(reminderInterval)
+	defer ticker.Stop()
+	for {
+		for _, t := range FireReminders() {
+			logutils.Info.Println(fmt.Sprintf(
+				"RemindTasks:: task %s of %s due at %s", t.Title, t.ToDoList, t.DueDate.Format(time.RFC3339)))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// FireReminders fires the reminders of the open tasks whose ReminderLead
+// before their due date is reached, dispatching EventTaskReminder to the
+// after hooks, and flags them with ReminderFired so that each reminder fires
+// once. The due time of the all-day tasks is the start of their day, in UTC.
+// The copies of the reminded tasks are returned.
+func FireReminders() []*Task {
+	lock.Lock()
+	defer lock.Unlock()
+	at := now()
+	reminded := []*Task{}
+	for _, list := range data {
+		if list.Archived {
+			continue
+		}
+		for _, t := range list.Tasks {
+			if t.Done || t.Deferred || t.ReminderFired || t.ReminderLead == "" || t.DueDate == nil {
+				continue
+			}
+			lead, err := time.ParseDuration(t.ReminderLead)
+			if err != nil || at.Before(t.DueDate.Add(-lead)) {
+				continue
+			}
+			t.ReminderFired = true
+			dispatchEvent(EventTaskReminder, list.Name, nil, t)
+			reminded = append(reminded, copyTask(t))
+		}
+	}
+	return reminded
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	REMINDERS
+*******************************/
+
+// remindedIn keeps the titles of the reminded tasks of the ToDo list
+func remindedIn(list string, tasks []*Task) []string {
+	titles := []string{}
+	for _, t := range tasks {
+		if t.ToDoList == list {
+			titles = append(titles, t.Title)
+		}
+	}
+	return titles
+}
+
+func TestFireReminders_ok(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC) }
+	CreateToDoListWithTasks("ListReminders", []string{"Soon", "Later", "No reminder", "Done"})
+	for _, title := range []string{"Soon", "Later", "Done"} {
+		details := dueOn(2024, 6, 3, 9)
+		details.ReminderLead = "30m"
+		if title == "Soon" {
+			details.ReminderLead = "2h"
+		}
+		if _, _, err := SetTaskDetails("ListReminders", title, details); err != nil {
+			t.Fatalf("no error expected, got %v", err)
+		}
+	}
+	SetTaskDetails("ListReminders", "No reminder", dueOn(2024, 6, 3, 9))
+	UpdateTask("ListReminders", "Done", "Done", true)
+
+	if reminded := remindedIn("ListReminders", FireReminders()); len(reminded) != 1 || reminded[0] != "Soon" {
+		t.Errorf("expected only Soon reminded 2h ahead, got %v", reminded)
+	}
+	if reminded := remindedIn("ListReminders", FireReminders()); len(reminded) != 0 {
+		t.Errorf("expected no duplicate reminder, got %v", reminded)
+	}
+	if task, _ := GetTask("ListReminders", "Soon"); !task.ReminderFired {
+		t.Errorf("expected Soon flagged ReminderFired")
+	}
+
+	now = func() time.Time { return time.Date(2024, 6, 3, 8, 30, 0, 0, time.UTC) }
+	if reminded := remindedIn("ListReminders", FireReminders()); len(reminded) != 1 || reminded[0] != "Later" {
+		t.Errorf("expected Later reminded 30m ahead, got %v", reminded)
+	}
+
+	// postponing the due date rearms the reminder
+	details := dueOn(2024, 6, 4, 9)
+	details.ReminderLead = "2h"
+	task, _, _ := SetTaskDetails("ListReminders", "Soon", details)
+	if task.ReminderFired {
+		t.Errorf("expected the reminder of Soon rearmed")
+	}
+	if reminded := remindedIn("ListReminders", FireReminders()); len(reminded) != 0 {
+		t.Errorf("expected no reminder before the new lead time, got %v", reminded)
+	}
+}
+
+func TestFireReminders_invalidLead_error(t *testing.T) {
+	CreateToDoListWithTasks("ListRemindersInvalid", []string{"Task1"})
+	for _, lead := range []string{"soon", "0s", "-30m", "721h"} {
+		details := dueOn(2024, 6, 3, 9)
+		details.ReminderLead = lead
+		if _, _, err := SetTaskDetails("ListRemindersInvalid", "Task1", details); err == nil {
+			t.Errorf("expected error with the reminder lead %s", lead)
+		}
+	}
+	if _, _, err := SetTaskDetails("ListRemindersInvalid", "Task1", TaskDetails{ReminderLead: "30m"}); err == nil {
+		t.Errorf("expected error with a reminder lead without due date")
+	}
+}
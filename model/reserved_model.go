@@ -0,0 +1,38 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultReservedListNames are the list names reserved by default: the
+// static segments of the /lists/ routes, which would shadow the lists
+var DefaultReservedListNames = []string{"query", "export", "archive", "bulk-archive", "import"}
+
+// reservedListNames are the reserved list names in lower case, see
+// SetReservedListNames
+var reservedListNames = lowerNames(DefaultReservedListNames)
+
+// SetReservedListNames sets the names, compared ignoring case, that the new
+// or renamed ToDo lists can not take. The existing lists keep their names.
+func SetReservedListNames(names []string) {
+	lock.Lock()
+	defer lock.Unlock()
+	reservedListNames = lowerNames(names)
+}
+
+// validateListName rejects, with a *ValidationError, the reserved names
+func validateListName(name string) error {
+	if reservedListNames[strings.ToLower(name)] {
+		return &ValidationError{fmt.Sprintf("the ToDo list name %s is reserved", name)}
+	}
+	return nil
+}
+
+func lowerNames(names []string) map[string]bool {
+	lower := make(map[string]bool, len(names))
+	for _, name := range names {
+		lower[strings.ToLower(name)] = true
+	}
+	return lower
+}
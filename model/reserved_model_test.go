@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+)
+
+/*******************************
+	RESERVED list names
+*******************************/
+
+func TestReservedListNames(t *testing.T) {
+	defer SetReservedListNames(DefaultReservedListNames)
+	SetReservedListNames([]string{"Reserved Inbox"})
+	CreateToDoList("ListReservedExisting")
+
+	if _, err := CreateToDoList("RESERVED inbox"); err == nil {
+		t.Errorf("expected the reserved name rejected ignoring case")
+	} else if _, invalid := err.(*ValidationError); !invalid {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+	if _, _, err := SeedToDoList("reserved inbox", nil); err == nil {
+		t.Errorf("expected the reserved name rejected on seeding")
+	}
+	if _, err := MergePatchToDoList("ListReservedExisting", map[string]interface{}{"Name": "Reserved Inbox"}); err == nil {
+		t.Errorf("expected the rename to a reserved name rejected")
+	}
+	if _, err := UpdateToDoList("ListReservedExisting", "Reserved Inbox"); err == nil {
+		t.Errorf("expected the rename to a reserved name rejected")
+	}
+	if list, _ := GetToDoList("ListReservedExisting"); list == nil {
+		t.Errorf("expected the list left untouched by the rejected renames")
+	}
+	if _, err := CreateToDoList("Export"); err != nil {
+		t.Errorf("expected the default reserved names replaced, got %v", err)
+	}
+
+	SetReservedListNames([]string{"ListReservedExisting"})
+	if _, err := MergePatchToDoList("ListReservedExisting", map[string]interface{}{"Description": "kept"}); err != nil {
+		t.Errorf("expected an existing list with a newly reserved name still updatable, got %v", err)
+	}
+}
+
+func TestReservedListNames_defaults(t *testing.T) {
+	// /lists/import/text is served in place of the list named import
+	for _, name := range []string{"query", "Export", "archive", "bulk-archive", "Import"} {
+		if _, err := CreateToDoList(name); err == nil {
+			t.Errorf("expected the list %s rejected", name)
+		}
+	}
+}
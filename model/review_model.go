@@ -0,0 +1,141 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// WeeklyReview summarizes, per ToDo list, the activity of a week
+type WeeklyReview struct {
+	Week  string
+	Start time.Time
+	End   time.Time
+	Lists []ListReview
+}
+
+// ListReview is the weekly review of a ToDo list: tasks completed and added
+// during the week, open tasks left unchanged for longer than the staleness
+// threshold and tasks overdue at the end of the week.
+type ListReview struct {
+	List      string
+	Completed []*Task
+	Added     []*Task
+	Stale     []*Task
+	Overdue   []*Task
+}
+
+// WeekStart returns the start of the week containing t, weeks starting on
+// the given weekday in the given location.
+func WeekStart(t time.Time, weekStart time.Weekday, loc *time.Location) time.Time {
+	day, _ := DayBounds(t, loc)
+	offset := (int(day.Weekday()) - int(weekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// ParseISOWeek parses an ISO 8601 week ("2024-W23") and returns the start of
+// that week. Weeks starting on Sunday begin the day before the ISO Monday.
+func ParseISOWeek(s string, weekStart time.Weekday, loc *time.Location) (time.Time, error) {
+	if len(s) != 8 || s[4:6] != "-W" {
+		return time.Time{}, fmt.Errorf("invalid ISO week %s, expected format 2024-W23", s)
+	}
+	year, errYear := strconv.Atoi(s[:4])
+	week, errWeek := strconv.Atoi(s[6:])
+	if errYear != nil || errWeek != nil {
+		return time.Time{}, fmt.Errorf("invalid ISO week %s, expected format 2024-W23", s)
+	}
+	jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, loc)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(week-1)*7)
+	if y, w := monday.ISOWeek(); week < 1 || y != year || w != week {
+		return time.Time{}, fmt.Errorf("invalid ISO week %s, week out of range", s)
+	}
+	return WeekStart(monday, weekStart, loc), nil
+}
+
+// WeeklyReviewOf returns the review of the week beginning at start for all the
+// ToDo lists, ordered by name. Tasks are stale when still open staleWeeks weeks
+// after their last change or touch (UpdatedAt), at the end of the week. The
+// review only depends on the data and the week, tasks of each section being
+// ordered by the relevant date.
+func WeeklyReviewOf(start time.Time, staleWeeks int) (*WeeklyReview, error) {
+	if staleWeeks < 1 {
+		return nil, fmt.Errorf("invalid staleness threshold, at least one week expected")
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+	end := start.AddDate(0, 0, 7)
+	staleBefore := end.AddDate(0, 0, -7*staleWeeks)
+	year, week := start.AddDate(0, 0, 3).ISOWeek()
+	review := &WeeklyReview{
+		Week:  fmt.Sprintf("%04d-W%02d", year, week),
+		Start: start,
+		End:   end,
+		Lists: []ListReview{}}
+
+	byList := make(map[string]*ListReview, len(data))
+	for name := range data {
+		byList[name] = &ListReview{
+			List:      name,
+			Completed: []*Task{},
+			Added:     []*Task{},
+			Stale:     []*Task{},
+			Overdue:   []*Task{}}
+	}
+
+	for _, t := range completedBetween(start, end) {
+		if l, ok := byList[t.ToDoList]; ok {
+			l.Completed = append(l.Completed, copyTask(t))
+		}
+	}
+
+	for name, list := range data {
+		l := byList[name]
+		for _, t := range list.Tasks {
+			if !t.CreatedAt.Before(start) && t.CreatedAt.Before(end) {
+				l.Added = append(l.Added, copyTask(t))
+			}
+			if t.Done || t.Deferred {
+				continue
+			}
+			if lastChange(t).Before(staleBefore) {
+				l.Stale = append(l.Stale, copyTask(t))
+			}
+			if t.DueDate == nil {
+				continue
+			}
+			if due, _ := dueBounds(t, end.Location()); due.Before(end) {
+				l.Overdue = append(l.Overdue, copyTask(t))
+			}
+		}
+		sortTasksBy(l.Added, func(t *Task) time.Time { return t.CreatedAt })
+		sortTasksBy(l.Stale, lastChange)
+		sortTasksBy(l.Overdue, func(t *Task) time.Time { return *t.DueDate })
+		review.Lists = append(review.Lists, *l)
+	}
+
+	sort.Slice(review.Lists, func(i, j int) bool {
+		return review.Lists[i].List < review.Lists[j].List
+	})
+	return review, nil
+}
+
+// lastChange returns the time of the last change or touch of the task, its
+// creation for the tasks never updated
+func lastChange(t *Task) time.Time {
+	if t.UpdatedAt.IsZero() {
+		return t.CreatedAt
+	}
+	return t.UpdatedAt
+}
+
+// sortTasksBy sorts the tasks by the given date, ties ordered by title
+func sortTasksBy(tasks []*Task, date func(*Task) time.Time) {
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := date(tasks[i]), date(tasks[j])
+		if a.Equal(b) {
+			return tasks[i].Title < tasks[j].Title
+		}
+		return a.Before(b)
+	})
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	WEEKLY Review
+*******************************/
+
+func TestParseISOWeek_invalid_error(t *testing.T) {
+	for _, week := range []string{"", "2024-23", "2024-W00", "2024-W54", "2021-W53", "2024-W1x"} {
+		if _, err := ParseISOWeek(week, time.Monday, time.UTC); err == nil {
+			t.Errorf("expected invalid week error for %q, got nil", week)
+		}
+	}
+}
+
+func TestParseISOWeek_ok(t *testing.T) {
+	start, err := ParseISOWeek("2024-W23", time.Monday, time.UTC)
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if !start.Equal(time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 2024-W23 starting on 2024-06-03, got %v", start)
+	}
+
+	start, _ = ParseISOWeek("2024-W23", time.Sunday, time.UTC)
+	if !start.Equal(time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 2024-W23 starting on Sunday 2024-06-02, got %v", start)
+	}
+
+	start, _ = ParseISOWeek("2020-W53", time.Monday, time.UTC)
+	if !start.Equal(time.Date(2020, 12, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 2020-W53 starting on 2020-12-28, got %v", start)
+	}
+}
+
+func TestWeekStart_ok(t *testing.T) {
+	wednesday := time.Date(2024, 6, 5, 15, 0, 0, 0, time.UTC)
+	if start := WeekStart(wednesday, time.Monday, time.UTC); start.Day() != 3 {
+		t.Errorf("expected week starting on Monday 3rd, got %v", start)
+	}
+	if start := WeekStart(wednesday, time.Sunday, time.UTC); start.Day() != 2 {
+		t.Errorf("expected week starting on Sunday 2nd, got %v", start)
+	}
+}
+
+func TestWeeklyReviewOf_invalidStaleness_error(t *testing.T) {
+	_, err := WeeklyReviewOf(time.Date(2022, 6, 6, 0, 0, 0, 0, time.UTC), 0)
+	if err == nil {
+		t.Errorf("expected invalid staleness error, got nil")
+	}
+}
+
+func TestWeeklyReviewOf_ok(t *testing.T) {
+	defer func() { now = time.Now }()
+	at := func(month time.Month, d int) func() time.Time {
+		return func() time.Time { return time.Date(2022, month, d, 10, 0, 0, 0, time.UTC) }
+	}
+
+	CreateToDoList("ListReview")
+	now = at(4, 1)
+	AddTask("ListReview", "Old")
+	AddTask("ListReview", "Touched")
+	now = at(5, 20)
+	TouchTask("ListReview", "Touched")
+	now = at(6, 1)
+	AddTask("ListReview", "Recent")
+	now = at(6, 7)
+	AddTask("ListReview", "Added")
+	due := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+	SetTaskDetails("ListReview", "Added", TaskDetails{DueDate: &due})
+	now = at(6, 8)
+	UpdateTask("ListReview", "Recent", "Recent", true)
+
+	start, _ := ParseISOWeek("2022-W23", time.Monday, time.UTC)
+	review, err := WeeklyReviewOf(start, 4)
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if review.Week != "2022-W23" {
+		t.Errorf("expected week 2022-W23, got %s", review.Week)
+	}
+
+	var l *ListReview
+	for i := range review.Lists {
+		if review.Lists[i].List == "ListReview" {
+			l = &review.Lists[i]
+		}
+	}
+	if l == nil {
+		t.Fatalf("expected ListReview in the review")
+	}
+	if len(l.Completed) != 1 || l.Completed[0].Title != "Recent" {
+		t.Errorf("expected Recent completed, got %v", l.Completed)
+	}
+	if len(l.Added) != 1 || l.Added[0].Title != "Added" {
+		t.Errorf("expected Added added, got %v", l.Added)
+	}
+	if len(l.Stale) != 1 || l.Stale[0].Title != "Old" {
+		t.Errorf("expected Old stale, Touched being touched since, got %v", l.Stale)
+	}
+	if len(l.Overdue) != 1 || l.Overdue[0].Title != "Added" {
+		t.Errorf("expected Added overdue, got %v", l.Overdue)
+	}
+}
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TaskSeed is an initial task of a ToDo list, created along with it
+type TaskSeed struct {
+	Title string
+	TaskDetails
+}
+
+// TaskSeedFailure is an initial task that can not be created, Index being
+// its position among the initial tasks
+type TaskSeedFailure struct {
+	Index int
+	Title string
+	Error string
+}
+
+// TaskSeedError is returned when some initial tasks can not be created, the
+// ToDo list being left untouched
+type TaskSeedError struct {
+	Failures []TaskSeedFailure
+}
+
+func (e *TaskSeedError) Error() string {
+	reasons := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		reasons[i] = fmt.Sprintf("task %d: %s", f.Index, f.Error)
+	}
+	return "invalid initial tasks: " + strings.Join(reasons, "; ")
+}
+
+// SeedToDoList creates the ToDo list along with its initial tasks, atomically:
+// when any task is invalid nothing is created and a *TaskSeedError reports
+// them all. The returned warnings report the adjustments made to the task
+// attributes (e.g. truncation).
+func SeedToDoList(name string, seeds []TaskSeed) (*ToDoList, []string, error) {
+	return SeedToDoListWithPriority(name, PriorityNone, seeds)
+}
+
+// CreateToDoListWithTasks creates the ToDo list along with tasks with the
+// given titles, atomically as SeedToDoList
+func CreateToDoListWithTasks(name string, taskTitles []string) (*ToDoList, error) {
+	seeds := make([]TaskSeed, len(taskTitles))
+	for i, title := range taskTitles {
+		seeds[i].Title = title
+	}
+	list, _, err := SeedToDoList(name, seeds)
+	return list, err
+}
+
+// SeedToDoListWithPriority creates the ToDo list as SeedToDoList, with the
+// default priority of its new tasks, the initial ones included
+func SeedToDoListWithPriority(name string, defaultPriority int, seeds []TaskSeed) (*ToDoList, []string, error) {
+	if name == "" {
+		return nil, nil, fmt.Errorf("empty ToDo list name")
+	}
+	if err := validatePriority(defaultPriority); err != nil {
+		return nil, nil, err
+	}
+	if err := validateTaskSeeds(seeds); err != nil {
+		return nil, nil, err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	list, warnings, err := seedToDoList(name, defaultPriority, seeds)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cloneToDoList(list), warnings, nil
+}
+
+// seedToDoList creates the ToDo list along with the validated initial tasks,
+// running their before hooks
+func seedToDoList(name string, defaultPriority int, seeds []TaskSeed) (*ToDoList, []string, error) {
+	if err := validateListName(name); err != nil {
+		return nil, nil, err
+	}
+	if list, _ := getToDoList(name); list != nil {
+		return nil, nil, fmt.Errorf("list already present")
+	}
+	if err := vetoTaskSeeds(name, seeds); err != nil {
+		return nil, nil, err
+	}
+	if data == nil {
+		data = make(map[string]*ToDoList, 100)
+	}
+	list := &ToDoList{Name: name, DefaultPriority: defaultPriority}
+	data[name] = list
+	recordListEvent(EventListCreated, name)
+	return list, addTaskSeeds(list, seeds), nil
+}
+
+// validateTaskSeeds checks the initial tasks, without adjusting them, and
+// reports all the invalid ones
+func validateTaskSeeds(seeds []TaskSeed) error {
+	var failures []TaskSeedFailure
+	titles := make(map[string]bool, len(seeds))
+	for i, seed := range seeds {
+		fail := func(reason string) {
+			failures = append(failures, TaskSeedFailure{Index: i, Title: seed.Title, Error: reason})
+		}
+		if seed.Title == "" {
+			fail("the task title can not be empty")
+			continue
+		}
+		if titles[seed.Title] {
+			fail(fmt.Sprintf("task %s given more than once", seed.Title))
+			continue
+		}
+		titles[seed.Title] = true
+		details := seed.TaskDetails
+		if _, err := validateTaskDetails(&details); err != nil {
+			fail(err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return &TaskSeedError{failures}
+	}
+	return nil
+}
+
+// addTaskSeeds adds the validated initial tasks to the list and returns the
+// warnings raised while storing them
+func addTaskSeeds(list *ToDoList, seeds []TaskSeed) []string {
+	var warnings []string
+	for _, seed := range seeds {
+		_, w, _ := addTask(list.Name, seed.Title, seed.TaskDetails, false)
+		warnings = append(warnings, w...)
+	}
+	return warnings
+}
+
+// vetoTaskSeeds runs the before hooks of the creation of the initial tasks of
+// the list, reporting all the vetoed ones
+func vetoTaskSeeds(list string, seeds []TaskSeed) error {
+	var failures []TaskSeedFailure
+	for i, seed := range seeds {
+		if err := vetoNewTask(list, seed.Title, seed.TaskDetails); err != nil {
+			failures = append(failures, TaskSeedFailure{Index: i, Title: seed.Title, Error: err.Error()})
+		}
+	}
+	if len(failures) > 0 {
+		return &TaskSeedError{Failures: failures}
+	}
+	return nil
+}
+
+// removeAllTasks removes the tasks of the list, their numbers not being reused
+func removeAllTasks(list *ToDoList) {
+	for _, t := range list.Tasks {
+		unindexCompletion(t)
+		unindexDueDate(t)
+		recordTaskDeleted(t)
+	}
+	list.Tasks = nil
+	invalidateTitles(list)
+	list.TaskNumber = 0
+}
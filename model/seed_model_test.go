@@ -0,0 +1,63 @@
+package model
+
+import "testing"
+
+/*******************************
+	SEED ToDo list
+*******************************/
+
+func TestSeedToDoList_ok(t *testing.T) {
+	seeds := []TaskSeed{{Title: "Passport"}, {Title: "Tickets", TaskDetails: TaskDetails{Priority: PriorityHigh, Tags: []string{"travel"}}}}
+	list, _, err := SeedToDoList("ListSeeded", seeds)
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if list.TaskNumber != 2 || list.Tasks[1].Priority != PriorityHigh || list.Tasks[1].Number != 2 {
+		t.Errorf("expected Passport and Tickets created, got %+v", list.Tasks)
+	}
+}
+
+func TestSeedToDoList_invalidTasks_error(t *testing.T) {
+	seeds := []TaskSeed{{Title: "Passport"}, {Title: ""}, {Title: "Passport"}, {Title: "Hotel", TaskDetails: TaskDetails{Tags: []string{" "}}}}
+	_, _, err := SeedToDoList("ListSeedInvalid", seeds)
+	seedErr, ok := err.(*TaskSeedError)
+	if !ok || len(seedErr.Failures) != 3 {
+		t.Fatalf("expected 3 failures, got %v", err)
+	}
+	for i, index := range []int{1, 2, 3} {
+		if seedErr.Failures[i].Index != index {
+			t.Errorf("expected failure %d on task %d, got %+v", i, index, seedErr.Failures[i])
+		}
+	}
+	if list, _ := GetToDoList("ListSeedInvalid"); list != nil {
+		t.Errorf("expected no list created, got %+v", list)
+	}
+}
+
+func TestCreateToDoListWithTasks(t *testing.T) {
+	list, err := CreateToDoListWithTasks("ListWithTasks", []string{"Passport", "Tickets", "Hotel"})
+	if err != nil || list.TaskNumber != 3 || list.Tasks[2].Title != "Hotel" || list.Tasks[2].Number != 3 {
+		t.Fatalf("expected the list with its 3 tasks, got %+v, %v", list, err)
+	}
+
+	if _, err := CreateToDoListWithTasks("ListWithTasksRolledBack", []string{"Passport", "", "Passport"}); err == nil {
+		t.Errorf("expected error with an empty and a duplicate title")
+	}
+	if list, _ := GetToDoList("ListWithTasksRolledBack"); list != nil {
+		t.Errorf("expected no list created, got %+v", list)
+	}
+}
+
+func TestUpsertToDoList_replaceTasks(t *testing.T) {
+	SeedToDoList("ListSeedReplaced", []TaskSeed{{Title: "Old"}})
+	seeds := []TaskSeed{{Title: "New"}}
+
+	list, _, _, _ := UpsertToDoList("ListSeedReplaced", map[string]interface{}{}, seeds, false, nil)
+	if len(list.Tasks) != 1 || list.Tasks[0].Title != "Old" {
+		t.Errorf("expected the tasks kept without replaceTasks, got %+v", list.Tasks)
+	}
+	list, _, _, _ = UpsertToDoList("ListSeedReplaced", map[string]interface{}{}, seeds, true, nil)
+	if len(list.Tasks) != 1 || list.Tasks[0].Title != "New" || list.Tasks[0].Number != 2 {
+		t.Errorf("expected the tasks replaced by New, numbered 2, got %+v", list.Tasks)
+	}
+}
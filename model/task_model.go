@@ -1,43 +1,199 @@
 package model
 
-import "fmt"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/efreddo/v1/todolist/geoutils"
+)
+
+// ValidationError reports task attributes rejected by the validation
+type ValidationError struct {
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+// ErrDescriptionTooLong is returned when a description exceeds the maximum
+// length and the policy rejects it
+var ErrDescriptionTooLong = &ValidationError{"description too long"}
+
+// ErrTaskDoneConflict is returned by CompareAndSetTaskDone when the done
+// state of the task is not the expected one
+var ErrTaskDoneConflict = fmt.Errorf("task done state does not match the expected one")
+
+// description length policy, see SetDescriptionPolicy
+var (
+	descriptionMaxLength = 10000
+	descriptionTruncate  = false
+)
+
+// defaultPriority is given to the new tasks of the lists without a default
+// priority, see SetDefaultPriority
+var defaultPriority = PriorityNone
 
 type Task struct {
 	ToDoList string
 	Title string 
+	// Number identifies the task within its ToDo list, as task-<Number>
+	Number int `json:",omitempty"`
 	Done  bool   
+	// Progress is the completion percentage of the task, 100 when done, see
+	// SetTaskProgress
+	Progress int `json:",omitempty"`
+	// Deferred parks the task as someday/maybe, out of the active views until
+	// un-deferred, see SetTaskDeferred
+	Deferred bool
+	// TimeSpent is the time logged on the task, in minutes, see LogTaskTime
+	TimeSpent int `json:",omitempty"`
+	// ReminderFired is set once the reminder of the task fired, and cleared
+	// when its due date or reminder lead change
+	ReminderFired bool `json:",omitempty"`
+	TaskDetails
+	CreatedAt time.Time
+	// UpdatedAt is the time of the last change of the task, or of its last
+	// touch, see TouchTask
+	UpdatedAt time.Time
+	CompletedAt *time.Time `json:",omitempty"`
+	ChecklistProgress *ChecklistProgress `json:",omitempty"`
+}
+
+// TaskDetails are the optional attributes of a task
+type TaskDetails struct {
+	Description string `json:",omitempty"`
+	DueDate *time.Time `json:",omitempty"`
+	Location *Location `json:",omitempty"`
+	Meta map[string]string `json:",omitempty"`
+	Checklist []ChecklistItem `json:",omitempty"`
+	Priority int `json:",omitempty" validate:"min=0,max=4"`
+	Tags []string `json:",omitempty"`
+	// WaitingOn is the external blocker of the task, e.g. "Bob's reply"
+	WaitingOn string `json:",omitempty" validate:"max=500"`
+	// AllDay tasks are due on the calendar day of DueDate, stored at midnight
+	// UTC, rather than at a time: they are overdue only once that day ends in
+	// the time zone of the user
+	AllDay bool `json:",omitempty"`
+	// ReminderLead is how long before DueDate the reminder of the task fires,
+	// a duration such as 30m or 2h, see FireReminders
+	ReminderLead string `json:",omitempty"`
+}
+
+// Task priorities, from the highest to the lowest, the zero value meaning
+// no priority
+const (
+	PriorityNone = iota
+	PriorityUrgent
+	PriorityHigh
+	PriorityMedium
+	PriorityLow
+)
+
+// ChecklistItem is an inline step of a task
+type ChecklistItem struct {
+	Text string `validate:"required,max=500"`
+	Done bool
+}
+
+// ChecklistProgress counts the checklist items done, it is computed from
+// the checklist of the task
+type ChecklistProgress struct {
+	Done  int
+	Total int
+}
+
+// Location is the place attached to a task: a label and optionally its
+// coordinates, in degrees
+type Location struct {
+	Label string   `validate:"max=200"`
+	Lat   *float64 `json:",omitempty" validate:"min=-90,max=90"`
+	Lon   *float64 `json:",omitempty" validate:"min=-180,max=180"`
 }
 
 func AddTask(todoListName string, taskTitle string) (*Task, error) {
+	task, _, err := AddTaskWithDetails(todoListName, taskTitle, TaskDetails{})
+	return task, err
+}
+
+// AddTaskWithDetails adds a task with the given optional attributes, the
+// returned warnings report the adjustments made to them (e.g. truncation).
+func AddTaskWithDetails(todoListName string, taskTitle string, details TaskDetails) (*Task, []string, error) {
 	if taskTitle == "" || todoListName == "" {
-		return nil, fmt.Errorf("empty mandatory parameters")
+		return nil, nil, fmt.Errorf("empty mandatory parameters")
 	}
-	
-	if task, _ := GetTask(todoListName, taskTitle); task != nil {
-		return nil, fmt.Errorf("task already present")
+	lock.Lock()
+	defer lock.Unlock()
+	return addTaskWithDetails(todoListName, taskTitle, details)
+}
+
+func addTaskWithDetails(todoListName string, taskTitle string, details TaskDetails) (*Task, []string, error) {
+	return addTask(todoListName, taskTitle, details, true)
+}
+
+// addTask adds the task, running the before hooks when veto is set: the
+// callers adding a batch of tasks run them upfront, with vetoNewTask
+func addTask(todoListName string, taskTitle string, details TaskDetails, veto bool) (*Task, []string, error) {
+	if task, _ := getTask(todoListName, taskTitle); task != nil {
+		return nil, nil, fmt.Errorf("task already present")
 	}
 
-	list, err := GetToDoList(todoListName)
+	list, err := getToDoList(todoListName)
 
 	if err != nil{
-		return nil, err
+		return nil, nil, err
+	}
+
+	warnings, err := validateTaskDetails(&details)
+	if err != nil {
+		return nil, nil, err
+	}
+	if details.Priority == PriorityNone {
+		details.Priority = list.effectiveDefaultPriority()
 	}
 
 	task := &Task {	ToDoList: todoListName,
 					Title: 	taskTitle,
-					Done:	false} 
+					Done:	false,
+					TaskDetails: details,
+					CreatedAt: now()} 
+	updateChecklistProgress(task)
+	if veto {
+		if err := vetoEvent(EventTaskCreated, todoListName, nil, task); err != nil {
+			return nil, nil, err
+		}
+	}
+	task.Number = nextTaskNumber(list)
 
-	list.Tasks = append(list.Tasks, cloneTask(task))
+	stored := cloneTask(task)
+	insertTaskSorted(list, stored)
+	invalidateTitles(list)
 	list.TaskNumber = list.TaskNumber + 1 
-	return task, nil
+	indexDueDate(stored)
+	recordEvent(EventTaskCreated, stored, 1)
+	return copyTask(stored), warnings, nil
 }
 
 func GetTask(todoListName string, taskTitle string) (*Task, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	task, err := getTask(todoListName, taskTitle)
+	if err != nil {
+		return nil, err
+	}
+	return copyTask(task), nil
+}
+
+func getTask(todoListName string, taskTitle string) (*Task, error) {
 	if taskTitle == "" || todoListName == "" {
 		return nil, fmt.Errorf("empty mandatory parameters")
 	}
 	
-	list, err := GetToDoList(todoListName)
+	list, err := getToDoList(todoListName)
 
 	if err != nil{
 		return nil, err
@@ -48,15 +204,17 @@ func GetTask(todoListName string, taskTitle string) (*Task, error) {
 			return t, nil
 		}
 	}
-	return nil, fmt.Errorf("Task not found")
+	return nil, notFound(todoListName, taskTitle, fmt.Errorf("Task not found"))
 }
 
 func UpdateTask(todoListName string, taskTitle string, newTitle string, done bool) (*Task, error) {
 	if taskTitle == "" || todoListName == "" {
 		return nil, fmt.Errorf("empty mandatory parameters")
 	}
+	lock.Lock()
+	defer lock.Unlock()
 	
-	list, err := GetToDoList(todoListName)
+	list, err := getToDoList(todoListName)
 
 	if err != nil{
 		return nil, err
@@ -64,20 +222,59 @@ func UpdateTask(todoListName string, taskTitle string, newTitle string, done boo
 
 	for _, t := range list.Tasks {
 		if t.Title == taskTitle {
-			t.Done = done
+			if newTitle == t.Title {
+				if err := vetoTaskDone(todoListName, t, done); err != nil {
+					return nil, err
+				}
+				setTaskDone(t, done)
+				return copyTask(t), nil
+			}
+			updated := cloneTask(t)
+			updated.Title = newTitle
+			updated.Done = done
+			if err := vetoEvent(EventTaskUpdated, todoListName, t, updated); err != nil {
+				return nil, err
+			}
+			before := copyTask(t)
 			t.Title = newTitle
-			return t, nil
+			invalidateTitles(list)
+			recordTaskUpdate(before, t)
+			setTaskDone(t, done)
+			dispatchEvent(EventTaskUpdated, todoListName, before, t)
+			return copyTask(t), nil
 		}
 	}
-	return nil, fmt.Errorf("Task not found")
+	return nil, notFound(todoListName, taskTitle, fmt.Errorf("Task not found"))
+}
+
+// CompareAndSetTaskDone sets the done state of the task to desired only if
+// it is currently expected, atomically with respect to the other updates.
+// ErrTaskDoneConflict is returned, and the task left untouched, otherwise.
+func CompareAndSetTaskDone(todoListName string, taskTitle string, expected bool, desired bool) (*Task, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	task, err := getTask(todoListName, taskTitle)
+	if err != nil {
+		return nil, err
+	}
+	if task.Done != expected {
+		return nil, ErrTaskDoneConflict
+	}
+	if err := vetoTaskDone(todoListName, task, desired); err != nil {
+		return nil, err
+	}
+	setTaskDone(task, desired)
+	return copyTask(task), nil
 }
 
 func RemoveTask(todoListName string, taskTitle string) (*Task, error) {
 	if taskTitle == "" || todoListName == "" {
 		return nil, fmt.Errorf("empty mandatory parameters")
 	}
+	lock.Lock()
+	defer lock.Unlock()
 	
-	list, err := GetToDoList(todoListName)
+	list, err := getToDoList(todoListName)
 
 	if err != nil{
 		return nil, err
@@ -85,12 +282,469 @@ func RemoveTask(todoListName string, taskTitle string) (*Task, error) {
 
 	for i, t := range list.Tasks {
 		if t.Title == taskTitle {
+			if err := vetoEvent(EventTaskDeleted, todoListName, t, nil); err != nil {
+				return nil, err
+			}
 			list.Tasks = append(list.Tasks[:i], list.Tasks[i+1:]...)
+			invalidateTitles(list)
 			list.TaskNumber = list.TaskNumber - 1 
+			unindexCompletion(t)
+			unindexDueDate(t)
+			recordTaskDeleted(t)
 			return t, nil
 		}
 	}
-	return nil, fmt.Errorf("Task not found")
+	return nil, notFound(todoListName, taskTitle, fmt.Errorf("Task not found"))
+}
+
+// GetTasks returns the tasks of the ToDo list, in the order of the list:
+// insertion order, or the automatic sorting of the list (see AutoSort)
+func GetTasks(todoListName string) ([]*Task, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	list, err := getToDoList(todoListName)
+	if err != nil {
+		return nil, err
+	}
+	touchList(list)
+	return snapshotTasks(list.Tasks), nil
+}
+
+// GetTasksByMeta returns the tasks of the ToDo list whose metadata contains
+// the given key/value pair
+func GetTasksByMeta(todoListName string, key string, value string) ([]*Task, error) {
+	tasks, err := GetTasks(todoListName)
+	if err != nil {
+		return nil, err
+	}
+	return FilterTasksByMeta(tasks, key, value), nil
+}
+
+// FilterTasksByMeta returns the tasks whose metadata contains the given
+// key/value pair, filters being combined in AND when applied in sequence
+func FilterTasksByMeta(tasks []*Task, key string, value string) []*Task {
+	filtered := []*Task{}
+	for _, t := range tasks {
+		if v, ok := t.Meta[key]; ok && v == value {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// GetWaitingTasks returns the tasks of the ToDo list waiting on an external
+// blocker, the ones with a WaitingOn, in the order of the list
+func GetWaitingTasks(listKey string) ([]*Task, error) {
+	tasks, err := GetTasks(listKey)
+	if err != nil {
+		return nil, err
+	}
+	return FilterWaitingTasks(tasks, true), nil
+}
+
+// FilterWaitingTasks returns the tasks waiting on an external blocker, or the
+// other ones when waiting is false
+func FilterWaitingTasks(tasks []*Task, waiting bool) []*Task {
+	filtered := []*Task{}
+	for _, t := range tasks {
+		if (t.WaitingOn != "") == waiting {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// GetTasksByCreatedRange returns the tasks of the ToDo list created at or
+// after after and before before, in the order of the list. A zero bound is not
+// applied.
+func GetTasksByCreatedRange(listKey string, after, before time.Time) ([]*Task, error) {
+	tasks, err := GetTasks(listKey)
+	if err != nil {
+		return nil, err
+	}
+	return FilterTasksByCreatedRange(tasks, after, before), nil
+}
+
+// FilterTasksByCreatedRange returns the tasks created at or after after and
+// before before, a zero bound not being applied
+func FilterTasksByCreatedRange(tasks []*Task, after, before time.Time) []*Task {
+	filtered := []*Task{}
+	for _, t := range tasks {
+		if (after.IsZero() || !t.CreatedAt.Before(after)) && (before.IsZero() || t.CreatedAt.Before(before)) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// SetTaskDetails replaces the optional attributes of the task, the returned
+// warnings report the adjustments made to them (e.g. truncation).
+func SetTaskDetails(todoListName string, taskTitle string, details TaskDetails) (*Task, []string, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	task, err := getTask(todoListName, taskTitle)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings, err := validateTaskDetails(&details)
+	if err != nil {
+		return nil, nil, err
+	}
+	updated := cloneTask(task)
+	updated.TaskDetails = details
+	updateChecklistProgress(updated)
+	if err := vetoEvent(EventTaskUpdated, todoListName, task, updated); err != nil {
+		return nil, nil, err
+	}
+	before := copyTask(task)
+	unindexDueDate(task)
+	task.TaskDetails = details
+	indexDueDate(task)
+	rearmReminder(before, task)
+	list, _ := getToDoList(todoListName)
+	resortTask(list, task)
+	updateChecklistProgress(task)
+	recordTaskUpdate(before, task)
+	dispatchEvent(EventTaskUpdated, todoListName, before, task)
+	return copyTask(task), warnings, nil
+}
+
+// ApplyTaskPatch replaces the task with the result of apply, given its JSON
+// representation, atomically with respect to the other updates. The editable
+// fields (Title, Done and the details) are taken from the result, which must
+// not have unknown fields. The errors of apply are returned as is.
+func ApplyTaskPatch(todoListName string, taskTitle string, apply func(doc []byte) ([]byte, error)) (*Task, []string, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	task, err := getTask(todoListName, taskTitle)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := json.Marshal(task)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc, err = apply(doc); err != nil {
+		return nil, nil, err
+	}
+
+	patched := Task{}
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return nil, nil, &ValidationError{fmt.Sprintf("invalid patched task: %v", err)}
+	}
+	if patched.Title == "" {
+		return nil, nil, &ValidationError{"the task title can not be empty"}
+	}
+	if other, _ := getTask(todoListName, patched.Title); other != nil && other != task {
+		return nil, nil, fmt.Errorf("task already present")
+	}
+	warnings, err := validateTaskDetails(&patched.TaskDetails)
+	if err != nil {
+		return nil, nil, err
+	}
+	updated := cloneTask(task)
+	updated.Title = patched.Title
+	updated.TaskDetails = patched.TaskDetails
+	updated.Done = patched.Done
+	updateChecklistProgress(updated)
+	if err := vetoEvent(EventTaskUpdated, todoListName, task, updated); err != nil {
+		return nil, nil, err
+	}
+
+	replaceTask(todoListName, task, updated)
+	return copyTask(task), warnings, nil
+}
+
+// UpdateTaskWithDetails renames the task to newTitle, sets its done state and
+// replaces its optional attributes, all or none of the changes being applied.
+// The returned warnings report the adjustments made to the details.
+func UpdateTaskWithDetails(todoListName string, taskTitle string, newTitle string, done bool, details TaskDetails) (*Task, []string, error) {
+	if taskTitle == "" || todoListName == "" || newTitle == "" {
+		return nil, nil, fmt.Errorf("empty mandatory parameters")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	task, err := getTask(todoListName, taskTitle)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings, err := validateTaskDetails(&details)
+	if err != nil {
+		return nil, nil, err
+	}
+	updated := cloneTask(task)
+	updated.Title = newTitle
+	updated.TaskDetails = details
+	updated.Done = done
+	updateChecklistProgress(updated)
+	if err := vetoEvent(EventTaskUpdated, todoListName, task, updated); err != nil {
+		return nil, nil, err
+	}
+	replaceTask(todoListName, task, updated)
+	return copyTask(task), warnings, nil
+}
+
+// replaceTask applies the title, the details and the done state of updated to
+// the task, once validated and vetted
+func replaceTask(todoListName string, task *Task, updated *Task) {
+	before := copyTask(task)
+	list, _ := getToDoList(todoListName)
+	unindexDueDate(task)
+	if task.Title != updated.Title {
+		task.Title = updated.Title
+		invalidateTitles(list)
+	}
+	task.TaskDetails = updated.TaskDetails
+	indexDueDate(task)
+	rearmReminder(before, task)
+	resortTask(list, task)
+	updateChecklistProgress(task)
+	recordTaskUpdate(before, task)
+	setTaskDone(task, updated.Done)
+	dispatchEvent(EventTaskUpdated, todoListName, before, task)
+}
+
+// SetChecklistItemDone sets the done state of the checklist item at index,
+// toggling it when done is nil
+func SetChecklistItemDone(todoListName string, taskTitle string, index int, done *bool) (*Task, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	task, err := getTask(todoListName, taskTitle)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(task.Checklist) {
+		return nil, fmt.Errorf("checklist item %d not found", index)
+	}
+	// items are copied on write, snapshots sharing the checklist
+	checklist := make([]ChecklistItem, len(task.Checklist))
+	copy(checklist, task.Checklist)
+	if done == nil {
+		checklist[index].Done = !checklist[index].Done
+	} else {
+		checklist[index].Done = *done
+	}
+	updated := cloneTask(task)
+	updated.Checklist = checklist
+	updateChecklistProgress(updated)
+	if err := vetoEvent(EventTaskUpdated, todoListName, task, updated); err != nil {
+		return nil, err
+	}
+	before := copyTask(task)
+	task.Checklist = checklist
+	updateChecklistProgress(task)
+	recordTaskUpdate(before, task)
+	dispatchEvent(EventTaskUpdated, todoListName, before, task)
+	return copyTask(task), nil
+}
+
+// updateChecklistProgress computes the checklist progress of the task
+func updateChecklistProgress(t *Task) {
+	t.ChecklistProgress = nil
+	if len(t.Checklist) == 0 {
+		return
+	}
+	t.ChecklistProgress = &ChecklistProgress{Total: len(t.Checklist)}
+	for _, item := range t.Checklist {
+		if item.Done {
+			t.ChecklistProgress.Done++
+		}
+	}
+}
+
+// validateTaskDetails checks the task attributes, adjusting them when the
+// configured policies allow it.
+func validateTaskDetails(details *TaskDetails) ([]string, error) {
+	var warnings []string
+	description, truncated, err := ApplyDescriptionPolicy(details.Description)
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		details.Description = description
+		warnings = append(warnings, fmt.Sprintf("Description truncated to %d characters", descriptionMaxLength))
+	}
+	if err := validatePriority(details.Priority); err != nil {
+		return nil, err
+	}
+	if err := validateReminderLead(details); err != nil {
+		return nil, err
+	}
+	if details.AllDay {
+		if details.DueDate == nil {
+			return nil, &ValidationError{"all-day tasks must have a due date"}
+		}
+		year, month, day := details.DueDate.Date()
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		details.DueDate = &date
+	}
+	for _, tag := range details.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return nil, &ValidationError{"tags can not be empty"}
+		}
+	}
+	for _, item := range details.Checklist {
+		if item.Text == "" {
+			return nil, &ValidationError{"checklist items must have a text"}
+		}
+	}
+	for key := range details.Meta {
+		if key == "" {
+			return nil, &ValidationError{"metadata keys can not be empty"}
+		}
+	}
+	if l := details.Location; l != nil {
+		if (l.Lat == nil) != (l.Lon == nil) {
+			return nil, &ValidationError{"location latitude and longitude must be set together"}
+		}
+		if l.Lat != nil {
+			if err := geoutils.ValidCoordinates(*l.Lat, *l.Lon); err != nil {
+				return nil, &ValidationError{err.Error()}
+			}
+		}
+	}
+	return warnings, nil
+}
+
+func validatePriority(priority int) error {
+	if priority < PriorityNone || priority > PriorityLow {
+		return &ValidationError{fmt.Sprintf("invalid priority %d, expected %d (none) to %d", priority, PriorityNone, PriorityLow)}
+	}
+	return nil
+}
+
+// SetDefaultPriority sets the priority given to the new tasks created without
+// a priority, in the lists without their own default priority
+func SetDefaultPriority(priority int) error {
+	if err := validatePriority(priority); err != nil {
+		return err
+	}
+	defaultPriority = priority
+	return nil
+}
+
+// SetDescriptionPolicy sets the maximum length, in characters, of task
+// descriptions. Longer descriptions are truncated when truncate is set,
+// rejected otherwise.
+func SetDescriptionPolicy(maxLength int, truncate bool) error {
+	if maxLength < 1 {
+		return fmt.Errorf("invalid maximum description length %d", maxLength)
+	}
+	descriptionMaxLength = maxLength
+	descriptionTruncate = truncate
+	return nil
+}
+
+// ApplyDescriptionPolicy checks the description against the length policy and
+// returns the value to store, truncated tells whether it has been shortened.
+// ErrDescriptionTooLong is returned when the policy rejects the description.
+func ApplyDescriptionPolicy(description string) (string, bool, error) {
+	runes := []rune(description)
+	if len(runes) <= descriptionMaxLength {
+		return description, false, nil
+	}
+	if !descriptionTruncate {
+		return "", false, ErrDescriptionTooLong
+	}
+	return string(runes[:descriptionMaxLength]), true, nil
+}
+
+// SortTasksByDueDate sorts the tasks by due date, ascending or descending.
+// Tasks without a due date are placed last in both directions, tasks with
+// the same due date in creation order, whatever their order in tasks.
+func SortTasksByDueDate(tasks []*Task, desc bool) {
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := tasks[i].DueDate, tasks[j].DueDate
+		switch {
+		case a == nil && b == nil:
+		case a == nil || b == nil:
+			return a != nil
+		case !a.Equal(*b):
+			return a.Before(*b) != desc
+		}
+		return createdBefore(tasks[i], tasks[j])
+	})
+}
+
+// createdBefore orders the tasks by creation: creation time, then ToDo list
+// and task number for the tasks created at the same time, a total order for
+// the sorts to be deterministic
+func createdBefore(a, b *Task) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	if a.ToDoList != b.ToDoList {
+		return a.ToDoList < b.ToDoList
+	}
+	if a.Number != b.Number {
+		return a.Number < b.Number
+	}
+	return a.Title < b.Title
+}
+
+// NumberedTask is a task with its position in a listing, starting from 1.
+// The number depends on the sorting and filtering of the listing, it does
+// not identify the task.
+type NumberedTask struct {
+	*Task
+	DisplayNumber int
+}
+
+// NumberTasks numbers the tasks in the given order
+func NumberTasks(tasks []*Task) []NumberedTask {
+	numbered := make([]NumberedTask, len(tasks))
+	for i, t := range tasks {
+		numbered[i] = NumberedTask{Task: t, DisplayNumber: i + 1}
+	}
+	return numbered
+}
+
+// vetoTaskDone runs the before hooks of the change of the done state of the
+// task, an EventTaskUpdated, none running when the state is unchanged
+func vetoTaskDone(list string, t *Task, done bool) error {
+	if t.Done == done {
+		return nil
+	}
+	updated := cloneTask(t)
+	updated.Done = done
+	return vetoEvent(EventTaskUpdated, list, t, updated)
+}
+
+// setTaskDone updates the done state of the task, recording the completion
+// timestamp and keeping the completion index aligned.
+func setTaskDone(t *Task, done bool) {
+	if t.Done == done {
+		return
+	}
+	unindexCompletion(t)
+	t.Done = done
+	t.CompletedAt = nil
+	if done {
+		t.Progress = MaxProgress
+		t.Deferred = false
+	} else if t.Progress == MaxProgress {
+		t.Progress = 0
+	}
+	if done {
+		completedAt := now()
+		t.CompletedAt = &completedAt
+		indexCompletion(t)
+		recordEvent(EventTaskCompleted, t, -1)
+	} else {
+		recordEvent(EventTaskReopened, t, 1)
+	}
+}
+
+// snapshotTasks returns deep copies of the tasks, see copyTask, for the
+// callers reading them once the store lock is released
+func snapshotTasks(tasks []*Task) []*Task {
+	snapshot := make([]*Task, len(tasks))
+	for i, t := range tasks {
+		snapshot[i] = copyTask(t)
+	}
+	return snapshot
 }
 
 // cloneTask creates and returns a deep copy of the given Task.
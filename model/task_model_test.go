@@ -1,6 +1,12 @@
 package model
 
-import "testing"
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
 
 /*******************************
 	CREATE Task
@@ -169,6 +175,38 @@ func TestGetTask_ok(t *testing.T) {
 }
 
 
+// run with -race: the tasks returned are snapshots, read once the store lock
+// is released while the stored ones change
+func TestGetTask_snapshot_concurrentUpdates(t *testing.T) {
+	CreateToDoListWithTasks("ListSnapshots", []string{"Task1"})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			UpdateTask("ListSnapshots", "Task1", "Task1", i%2 == 0)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if task, err := GetTask("ListSnapshots", "Task1"); err == nil {
+				_ = task.Done && task.CompletedAt != nil
+			}
+			if list, err := GetToDoList("ListSnapshots"); err == nil {
+				_ = list.Tasks[0].Done
+			}
+		}
+	}()
+	wg.Wait()
+
+	task, _ := GetTask("ListSnapshots", "Task1")
+	task.Title = "Changed"
+	if stored, _ := GetTask("ListSnapshots", "Task1"); stored == nil || stored.Title != "Task1" {
+		t.Errorf("expected the stored task left untouched by a change of its snapshot, got %+v", stored)
+	}
+}
+
 /*******************************
 	UPDATE Task
 *******************************/
@@ -242,6 +280,34 @@ func TestUpdateTask_newNameAndStatus_ok(t *testing.T) {
 	}
 }
 
+func TestUpdateTaskWithDetails_allOrNone(t *testing.T) {
+	CreateToDoList("ListTaskReplace")
+	AddTask("ListTaskReplace", "Task1")
+	SetTaskDetails("ListTaskReplace", "Task1", TaskDetails{Description: "kept", Tags: []string{"home"}})
+	defer RegisterHook(EventTaskUpdated, Hook{Name: "no done", Before: func(e HookEvent) error {
+		if e.List == "ListTaskReplace" && e.After.Done {
+			return fmt.Errorf("done vetoed")
+		}
+		return nil
+	}})()
+
+	if _, _, err := UpdateTaskWithDetails("ListTaskReplace", "Task1", "Renamed", true, TaskDetails{Description: "changed"}); err == nil {
+		t.Errorf("expected the update vetoed")
+	}
+	task, _ := GetTask("ListTaskReplace", "Task1")
+	if task == nil || task.Done || task.Description != "kept" || len(task.Tags) != 1 {
+		t.Errorf("expected the task untouched, got %+v", task)
+	}
+
+	task, _, err := UpdateTaskWithDetails("ListTaskReplace", "Task1", "Renamed", false, TaskDetails{Priority: PriorityHigh})
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if task.Title != "Renamed" || task.Priority != PriorityHigh || task.Description != "" || task.Tags != nil {
+		t.Errorf("expected the task renamed and its details replaced, got %+v", task)
+	}
+}
+
 /*******************************
 	DELETE Task
 *******************************/
@@ -306,3 +372,309 @@ func TestRemoveTask_newName_ok(t *testing.T) {
 		t.Errorf("expected 1 tasks in ToDoList ListTask1, got %d", len(list.Tasks))
 	}
 }
+
+/*******************************
+	SORT Tasks by due date
+*******************************/
+func TestSortTasksByDueDate_nullsLast_ok(t *testing.T) {
+	day := func(d int) *time.Time {
+		due := time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC)
+		return &due
+	}
+	tasks := []*Task{
+		{Title: "NoDue1"},
+		{Title: "Due3", TaskDetails: TaskDetails{DueDate: day(3)}},
+		{Title: "NoDue2"},
+		{Title: "Due1", TaskDetails: TaskDetails{DueDate: day(1)}},
+		{Title: "Due2", TaskDetails: TaskDetails{DueDate: day(2)}},
+	}
+
+	SortTasksByDueDate(tasks, false)
+	expected := []string{"Due1", "Due2", "Due3", "NoDue1", "NoDue2"}
+	for i, title := range expected {
+		if tasks[i].Title != title {
+			t.Errorf("expected %s at position %d, got %s", title, i, tasks[i].Title)
+		}
+	}
+
+	SortTasksByDueDate(tasks, true)
+	expected = []string{"Due3", "Due2", "Due1", "NoDue1", "NoDue2"}
+	for i, title := range expected {
+		if tasks[i].Title != title {
+			t.Errorf("expected %s at position %d, got %s", title, i, tasks[i].Title)
+		}
+	}
+}
+
+func TestSortTasksByDueDate_allNull_ok(t *testing.T) {
+	tasks := []*Task{{Title: "NoDue1"}, {Title: "NoDue2"}, {Title: "NoDue3"}}
+	SortTasksByDueDate(tasks, true)
+	for i, title := range []string{"NoDue1", "NoDue2", "NoDue3"} {
+		if tasks[i].Title != title {
+			t.Errorf("expected %s at position %d, got %s", title, i, tasks[i].Title)
+		}
+	}
+}
+
+func TestSortTasksByDueDate_equalDueDates_creationOrder(t *testing.T) {
+	due := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tasks := make([]*Task, 60)
+	for i := range tasks {
+		tasks[i] = &Task{ToDoList: "ListEqual", Title: fmt.Sprintf("Task%d", i+1), Number: i + 1,
+			CreatedAt: created.Add(time.Duration(i/20) * time.Hour), TaskDetails: TaskDetails{DueDate: &due}}
+	}
+	tasks = append(tasks, &Task{ToDoList: "AnotherList", Title: "Other", Number: 7, CreatedAt: created, TaskDetails: TaskDetails{DueDate: &due}})
+
+	for run := int64(0); run < 10; run++ {
+		shuffled := append([]*Task{}, tasks...)
+		rand.New(rand.NewSource(run)).Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		SortTasksByDueDate(shuffled, run%2 == 0)
+		if shuffled[0].Title != "Other" {
+			t.Fatalf("expected Other first, the same creation time ordered by list, got %s", shuffled[0].Title)
+		}
+		for i, task := range shuffled[1:] {
+			if task.Number != i+1 {
+				t.Fatalf("expected Task%d at position %d in run %d, got %s", i+1, i+1, run, task.Title)
+			}
+		}
+	}
+}
+
+func TestGetTasks_sortDoesNotReorderList_ok(t *testing.T) {
+	CreateToDoList("ListSort")
+	AddTask("ListSort", "Later")
+	AddTask("ListSort", "Sooner")
+	due := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	SetTaskDetails("ListSort", "Sooner", TaskDetails{DueDate: &due})
+
+	tasks, err := GetTasks("ListSort")
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	SortTasksByDueDate(tasks, false)
+	if tasks[0].Title != "Sooner" {
+		t.Errorf("expected Sooner first, got %s", tasks[0].Title)
+	}
+	list, _ := GetToDoList("ListSort")
+	if list.Tasks[0].Title != "Later" {
+		t.Errorf("expected ListSort order unchanged, got %s first", list.Tasks[0].Title)
+	}
+}
+
+/*******************************
+	DESCRIPTION policy
+*******************************/
+func TestSetDescriptionPolicy_invalid_error(t *testing.T) {
+	if err := SetDescriptionPolicy(0, false); err == nil {
+		t.Errorf("expected invalid maximum length error, got nil")
+	}
+}
+
+func TestAddTaskWithDetails_descriptionRejected_error(t *testing.T) {
+	defer SetDescriptionPolicy(10000, false)
+	SetDescriptionPolicy(5, false)
+	CreateToDoList("ListDescription")
+
+	_, _, err := AddTaskWithDetails("ListDescription", "Task1", TaskDetails{Description: "too long"})
+	if err != ErrDescriptionTooLong {
+		t.Errorf("expected description too long error, got %v", err)
+	}
+	if _, err = GetTask("ListDescription", "Task1"); err == nil {
+		t.Errorf("expected task with a rejected description not to be added")
+	}
+
+	task, warnings, err := AddTaskWithDetails("ListDescription", "Task1", TaskDetails{Description: "short"})
+	if err != nil || len(warnings) != 0 {
+		t.Errorf("no error and no warning expected, got %v, %v", err, warnings)
+	}
+	if task.Description != "short" {
+		t.Errorf("expected description short, got %s", task.Description)
+	}
+}
+
+func TestSetTaskDetails_descriptionTruncated_ok(t *testing.T) {
+	defer SetDescriptionPolicy(10000, false)
+	SetDescriptionPolicy(5, true)
+
+	task, warnings, err := SetTaskDetails("ListDescription", "Task1", TaskDetails{Description: "più lungo"})
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("expected a truncation warning, got %v", warnings)
+	}
+	if task.Description != "più l" {
+		t.Errorf("expected description truncated to 5 characters, got %s", task.Description)
+	}
+}
+
+/*******************************
+	FILTER Tasks by metadata
+*******************************/
+func TestAddTaskWithDetails_emptyMetaKey_error(t *testing.T) {
+	CreateToDoList("ListMeta")
+	_, _, err := AddTaskWithDetails("ListMeta", "Task1", TaskDetails{Meta: map[string]string{"": "jira"}})
+	if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestGetTasksByMeta_invalidList_error(t *testing.T) {
+	_, err := GetTasksByMeta("invalid", "source", "jira")
+	if err == nil {
+		t.Errorf("Expected error list not found, got nil")
+	}
+}
+
+func TestGetTasksByMeta_ok(t *testing.T) {
+	AddTaskWithDetails("ListMeta", "Jira1", TaskDetails{Meta: map[string]string{"source": "jira", "id": "PRJ-1"}})
+	AddTaskWithDetails("ListMeta", "Jira2", TaskDetails{Meta: map[string]string{"source": "jira", "id": "PRJ-2"}})
+	AddTaskWithDetails("ListMeta", "Github1", TaskDetails{Meta: map[string]string{"source": "github", "id": "PRJ-1"}})
+	AddTask("ListMeta", "NoMeta")
+
+	tasks, err := GetTasksByMeta("ListMeta", "source", "jira")
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Title != "Jira1" || tasks[1].Title != "Jira2" {
+		t.Errorf("expected Jira1 and Jira2, got %v", tasks)
+	}
+
+	tasks = FilterTasksByMeta(tasks, "id", "PRJ-1")
+	if len(tasks) != 1 || tasks[0].Title != "Jira1" {
+		t.Errorf("expected filters combined in AND to return Jira1, got %v", tasks)
+	}
+}
+
+func TestGetWaitingTasks_ok(t *testing.T) {
+	CreateToDoList("ListWaiting")
+	AddTaskWithDetails("ListWaiting", "Contract", TaskDetails{WaitingOn: "Bob's reply"})
+	AddTask("ListWaiting", "Draft")
+	AddTask("ListWaiting", "Quote")
+	SetTaskDetails("ListWaiting", "Quote", TaskDetails{WaitingOn: "supplier"})
+
+	tasks, err := GetWaitingTasks("ListWaiting")
+	if err != nil || len(tasks) != 2 || tasks[0].Title != "Contract" || tasks[1].WaitingOn != "supplier" {
+		t.Errorf("expected Contract and Quote waiting, got %v, %v", tasks, err)
+	}
+	if _, err := GetWaitingTasks("invalid"); err == nil {
+		t.Errorf("expected error with a missing list")
+	}
+}
+
+func TestGetTasksByCreatedRange_ok(t *testing.T) {
+	defer func() { now = time.Now }()
+	CreateToDoList("ListCreatedRange")
+	for i, title := range []string{"Monday", "Wednesday", "Friday"} {
+		now = func() time.Time { return time.Date(2024, 6, 3+2*i, 9, 0, 0, 0, time.UTC) }
+		AddTask("ListCreatedRange", title)
+	}
+
+	tasks, err := GetTasksByCreatedRange("ListCreatedRange", time.Date(2024, 6, 5, 9, 0, 0, 0, time.UTC), time.Time{})
+	if err != nil || len(tasks) != 2 || tasks[0].Title != "Wednesday" || tasks[1].Title != "Friday" {
+		t.Errorf("expected Wednesday and Friday created at or after the bound, got %v, %v", tasks, err)
+	}
+	tasks, _ = GetTasksByCreatedRange("ListCreatedRange", time.Time{}, time.Date(2024, 6, 5, 9, 0, 0, 0, time.UTC))
+	if len(tasks) != 1 || tasks[0].Title != "Monday" {
+		t.Errorf("expected Monday only created before the bound, got %v", tasks)
+	}
+	tasks, _ = GetTasksByCreatedRange("ListCreatedRange", time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 6, 0, 0, 0, 0, time.UTC))
+	if len(tasks) != 1 || tasks[0].Title != "Wednesday" {
+		t.Errorf("expected Wednesday only within both bounds, got %v", tasks)
+	}
+	if _, err := GetTasksByCreatedRange("invalid", time.Time{}, time.Time{}); err == nil {
+		t.Errorf("expected error with a missing list")
+	}
+}
+
+/*******************************
+	COMPARE AND SET done state
+*******************************/
+func TestCompareAndSetTaskDone_invalidTask_error(t *testing.T) {
+	CreateToDoList("ListCAS")
+	_, err := CompareAndSetTaskDone("ListCAS", "invalid", false, true)
+	if err == nil || err == ErrTaskDoneConflict {
+		t.Errorf("expected error task not found, got %v", err)
+	}
+}
+
+func TestCompareAndSetTaskDone_ok(t *testing.T) {
+	AddTask("ListCAS", "Task1")
+	task, err := CompareAndSetTaskDone("ListCAS", "Task1", false, true)
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if task == nil || !task.Done || task.CompletedAt == nil {
+		t.Errorf("expected the task completed, got %v", task)
+	}
+
+	_, err = CompareAndSetTaskDone("ListCAS", "Task1", false, true)
+	if err != ErrTaskDoneConflict {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
+func TestCompareAndSetTaskDone_concurrent_singleWinner(t *testing.T) {
+	AddTask("ListCAS", "Task2")
+	results := make(chan error, 20)
+	for i := 0; i < cap(results); i++ {
+		go func() {
+			_, err := CompareAndSetTaskDone("ListCAS", "Task2", false, true)
+			results <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < cap(results); i++ {
+		if err := <-results; err == nil {
+			succeeded++
+		} else if err != ErrTaskDoneConflict {
+			t.Errorf("expected conflict error, got %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("expected a single completion, got %d", succeeded)
+	}
+}
+
+/*******************************
+	CHECKLIST
+*******************************/
+func TestAddTaskWithDetails_emptyChecklistItem_error(t *testing.T) {
+	CreateToDoList("ListChecklist")
+	_, _, err := AddTaskWithDetails("ListChecklist", "Invalid", TaskDetails{Checklist: []ChecklistItem{{Text: ""}}})
+	if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestSetChecklistItemDone_ok(t *testing.T) {
+	task, _, err := AddTaskWithDetails("ListChecklist", "Task1", TaskDetails{Checklist: []ChecklistItem{{Text: "Step1"}, {Text: "Step2"}}})
+	if err != nil || task.ChecklistProgress == nil || *task.ChecklistProgress != (ChecklistProgress{Done: 0, Total: 2}) {
+		t.Fatalf("expected progress 0/2, got %v, %v", task, err)
+	}
+
+	done := true
+	task, err = SetChecklistItemDone("ListChecklist", "Task1", 1, &done)
+	if err != nil || !task.Checklist[1].Done || *task.ChecklistProgress != (ChecklistProgress{Done: 1, Total: 2}) {
+		t.Errorf("expected Step2 done and progress 1/2, got %v, %v", task, err)
+	}
+	task, err = SetChecklistItemDone("ListChecklist", "Task1", 1, nil)
+	if err != nil || task.Checklist[1].Done || task.ChecklistProgress.Done != 0 {
+		t.Errorf("expected Step2 toggled back, got %v, %v", task, err)
+	}
+
+	if _, err = SetChecklistItemDone("ListChecklist", "Task1", 2, nil); err == nil {
+		t.Errorf("expected item not found error, got nil")
+	}
+}
+
+func TestSetTaskDetails_checklistRemoved_ok(t *testing.T) {
+	task, _, err := SetTaskDetails("ListChecklist", "Task1", TaskDetails{})
+	if err != nil || task.Checklist != nil || task.ChecklistProgress != nil {
+		t.Errorf("expected checklist and progress removed, got %v, %v", task, err)
+	}
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// MaxTaskHistory is the number of changes retained per task, the oldest ones
+// being dropped first
+const MaxTaskHistory = 100
+
+// TaskChange is a change of a task: its creation (task.created), a change of
+// its done state (task.completed, task.reopened) or of its title and details
+// (task.updated). Field is the changed field, Before and After its JSON
+// values around the change, null when unset.
+type TaskChange struct {
+	Type   string
+	Field  string          `json:",omitempty"`
+	Before json.RawMessage `json:",omitempty"`
+	After  json.RawMessage `json:",omitempty"`
+	At     time.Time
+}
+
+// taskChanges holds the changes of the stored tasks, the oldest first. They
+// follow the tasks across renames and are dropped with them.
+var taskChanges = map[*Task][]TaskChange{}
+
+// TaskHistory returns the changes of the task, in chronological order, up to
+// the latest MaxTaskHistory ones
+func TaskHistory(listKey, taskTitle string) ([]TaskChange, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	task, err := getTask(listKey, taskTitle)
+	if err != nil {
+		return nil, err
+	}
+	return append([]TaskChange{}, taskChanges[task]...), nil
+}
+
+// recordTaskChange records the change of the task, dropping the oldest ones
+// beyond MaxTaskHistory
+func recordTaskChange(t *Task, change TaskChange) {
+	change.At = now()
+	t.UpdatedAt = change.At
+	if list := data[t.ToDoList]; list != nil {
+		list.UpdatedAt = change.At
+	}
+	changes := append(taskChanges[t], change)
+	if len(changes) > MaxTaskHistory {
+		changes = append([]TaskChange(nil), changes[len(changes)-MaxTaskHistory:]...)
+	}
+	taskChanges[t] = changes
+}
+
+// recordTaskEvent records the history event of the task in its changes
+func recordTaskEvent(eventType string, t *Task) {
+	switch eventType {
+	case EventTaskCreated:
+		recordTaskChange(t, TaskChange{Type: eventType})
+		clearTombstone(t.ToDoList, t.Title)
+	case EventTaskCompleted, EventTaskReopened:
+		recordTaskChange(t, TaskChange{Type: eventType, Field: "Done",
+			Before: json.RawMessage(strconv.FormatBool(!t.Done)), After: json.RawMessage(strconv.FormatBool(t.Done))})
+	case EventTaskDeleted:
+		delete(taskChanges, t)
+		recordTombstone(t.ToDoList, t.Title)
+	}
+}
+
+// recordTaskUpdate records a change for each field of the title and details
+// of the task differing from the copy taken before the update
+func recordTaskUpdate(before, t *Task) {
+	if before.Title != t.Title {
+		recordTaskChange(t, TaskChange{Type: EventTaskUpdated, Field: "Title",
+			Before: changeValue(before.Title), After: changeValue(t.Title)})
+	}
+	old, updated := reflect.ValueOf(before.TaskDetails), reflect.ValueOf(t.TaskDetails)
+	for i := 0; i < old.NumField(); i++ {
+		from, to := changeValue(old.Field(i).Interface()), changeValue(updated.Field(i).Interface())
+		if !bytes.Equal(from, to) {
+			recordTaskChange(t, TaskChange{Type: EventTaskUpdated, Field: old.Type().Field(i).Name,
+				Before: from, After: to})
+		}
+	}
+}
+
+// changeValue returns the JSON value of a detail, null for the empty values
+// omitted from the tasks
+func changeValue(v interface{}) json.RawMessage {
+	value := reflect.ValueOf(v)
+	if value.IsZero() || (value.Kind() == reflect.Slice || value.Kind() == reflect.Map) && value.Len() == 0 {
+		return json.RawMessage("null")
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return b
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"testing"
+)
+
+/*******************************
+	TASK history
+*******************************/
+
+func TestTaskHistory(t *testing.T) {
+	CreateToDoList("ListTaskHistory")
+	AddTask("ListTaskHistory", "Task1")
+	SetTaskDetails("ListTaskHistory", "Task1", TaskDetails{Priority: PriorityHigh, Tags: []string{"home"}})
+	UpdateTask("ListTaskHistory", "Task1", "Task1 renamed", true)
+
+	changes, err := TaskHistory("ListTaskHistory", "Task1 renamed")
+	if err != nil {
+		t.Fatalf("expected the history of the task, got %v", err)
+	}
+	expected := []struct {
+		typ, field, before, after string
+	}{
+		{EventTaskCreated, "", "", ""},
+		{EventTaskUpdated, "Priority", "null", "2"},
+		{EventTaskUpdated, "Tags", "null", `["home"]`},
+		{EventTaskUpdated, "Title", `"Task1"`, `"Task1 renamed"`},
+		{EventTaskCompleted, "Done", "false", "true"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), changes)
+	}
+	for i, e := range expected {
+		c := changes[i]
+		if c.Type != e.typ || c.Field != e.field || string(c.Before) != e.before || string(c.After) != e.after {
+			t.Errorf("expected change %d %+v, got %s %s %s %s", i, e, c.Type, c.Field, c.Before, c.After)
+		}
+	}
+
+	if _, err := TaskHistory("ListTaskHistory", "Task1"); err == nil {
+		t.Errorf("expected an error for a missing task")
+	}
+	RemoveTask("ListTaskHistory", "Task1 renamed")
+	AddTask("ListTaskHistory", "Task1 renamed")
+	if changes, _ := TaskHistory("ListTaskHistory", "Task1 renamed"); len(changes) != 1 {
+		t.Errorf("expected the history dropped with the task, got %+v", changes)
+	}
+}
+
+func TestTaskHistory_bounded(t *testing.T) {
+	CreateToDoList("ListTaskHistoryBounded")
+	AddTask("ListTaskHistoryBounded", "Task1")
+	for i := 0; i < MaxTaskHistory; i++ {
+		UpdateTask("ListTaskHistoryBounded", "Task1", "Task1", i%2 == 0)
+	}
+
+	changes, _ := TaskHistory("ListTaskHistoryBounded", "Task1")
+	if len(changes) != MaxTaskHistory || changes[0].Type == EventTaskCreated {
+		t.Errorf("expected the latest %d changes only, got %d starting with %s", MaxTaskHistory, len(changes), changes[0].Type)
+	}
+}
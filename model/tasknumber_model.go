@@ -0,0 +1,90 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TaskRefPrefix prefixes the number of a task in its short reference within
+// the ToDo list, e.g. task-7
+const TaskRefPrefix = "task-"
+
+// TaskRef returns the short reference of the task within its ToDo list
+func TaskRef(t *Task) string {
+	return TaskRefPrefix + strconv.Itoa(t.Number)
+}
+
+// NextTaskNumber reserves and returns the next task number of the ToDo list.
+// Numbers are never reused, not even once their task is deleted.
+func NextTaskNumber(listKey string) (int, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return 0, err
+	}
+	return nextTaskNumber(list), nil
+}
+
+func nextTaskNumber(list *ToDoList) int {
+	list.LastTaskNumber++
+	return list.LastTaskNumber
+}
+
+// ResolveTaskTitle returns the title of the task referenced by ref in the
+// ToDo list: a task titled ref or, failing that, the task numbered by the
+// short reference (task-7). ref is returned as is when no task matches.
+func ResolveTaskTitle(listKey string, ref string) string {
+	lock.RLock()
+	defer lock.RUnlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return ref
+	}
+	return resolveTaskTitle(list, ref)
+}
+
+func resolveTaskTitle(list *ToDoList, ref string) string {
+	if !strings.HasPrefix(ref, TaskRefPrefix) {
+		return ref
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(ref, TaskRefPrefix))
+	if err != nil || number <= 0 {
+		return ref
+	}
+	var numbered *Task
+	for _, t := range list.Tasks {
+		if t.Title == ref {
+			return ref
+		}
+		if t.Number == number {
+			numbered = t
+		}
+	}
+	if numbered == nil {
+		return ref
+	}
+	return numbered.Title
+}
+
+// numberRestoredTasks keeps the archived task numbers, dropping the duplicated
+// ones, and numbers the tasks left after them
+func numberRestoredTasks(list *ToDoList) {
+	used := make(map[int]bool, len(list.Tasks))
+	for _, t := range list.Tasks {
+		if t.Number <= 0 || used[t.Number] {
+			t.Number = 0
+			continue
+		}
+		used[t.Number] = true
+		if t.Number > list.LastTaskNumber {
+			list.LastTaskNumber = t.Number
+		}
+	}
+	for _, t := range list.Tasks {
+		if t.Number == 0 {
+			t.Number = nextTaskNumber(list)
+		}
+	}
+}
+
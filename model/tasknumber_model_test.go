@@ -0,0 +1,105 @@
+package model
+
+import (
+	"sync"
+	"testing"
+)
+
+/*******************************
+	TASK Numbers
+*******************************/
+
+func TestNextTaskNumber_notReused(t *testing.T) {
+	CreateToDoList("ListNumbers")
+	AddTask("ListNumbers", "Task1")
+	AddTask("ListNumbers", "Task2")
+	RemoveTask("ListNumbers", "Task2")
+
+	task, _ := AddTask("ListNumbers", "Task3")
+	if task.Number != 3 {
+		t.Errorf("expected Task3 numbered 3 after the deletion of Task2, got %d", task.Number)
+	}
+	if _, err := NextTaskNumber("invalid"); err == nil {
+		t.Errorf("expected error list not found, got nil")
+	}
+}
+
+func TestNextTaskNumber_concurrent(t *testing.T) {
+	CreateToDoList("ListNumbersConcurrent")
+	var wg sync.WaitGroup
+	numbers := make([]int, 50)
+	for i := range numbers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			numbers[i], _ = NextTaskNumber("ListNumbersConcurrent")
+		}(i)
+	}
+	wg.Wait()
+	seen := make(map[int]bool)
+	for _, n := range numbers {
+		if n < 1 || n > len(numbers) || seen[n] {
+			t.Fatalf("expected distinct numbers from 1 to %d, got %v", len(numbers), numbers)
+		}
+		seen[n] = true
+	}
+}
+
+func TestResolveTaskTitle_ok(t *testing.T) {
+	CreateToDoList("ListRefs")
+	AddTask("ListRefs", "Task1")
+	AddTask("ListRefs", "task-1")
+
+	cases := map[string]string{
+		"task-2":  "task-1",
+		"Task1":   "Task1",
+		"task-1":  "task-1",
+		"task-9":  "task-9",
+		"task-x":  "task-x",
+		"Missing": "Missing",
+	}
+	for ref, expected := range cases {
+		if title := ResolveTaskTitle("ListRefs", ref); title != expected {
+			t.Errorf("expected %s resolved to %s, got %s", ref, expected, title)
+		}
+	}
+}
+
+func TestRestoreToDoList_numbers(t *testing.T) {
+	archive := &ToDoListArchive{Version: ExportVersion, List: ToDoList{
+		Name:           "ListNumbersRestored",
+		LastTaskNumber: 5,
+		Tasks: []*Task{
+			{Title: "Task1", Number: 4},
+			{Title: "Task2", Number: 4},
+			{Title: "Task3"}}}}
+	list, _, err := RestoreToDoList(archive, "", false)
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if list.Tasks[0].Number != 4 || list.Tasks[1].Number != 6 || list.Tasks[2].Number != 7 || list.LastTaskNumber != 7 {
+		t.Errorf("expected numbers 4, 6 and 7, got %d, %d, %d", list.Tasks[0].Number, list.Tasks[1].Number, list.Tasks[2].Number)
+	}
+}
+
+func TestNextTaskNumber_reloaded_notReused(t *testing.T) {
+	CreateToDoListWithTasks("ListNumbersReloaded", []string{"Task1", "Task2", "Task3"})
+	RemoveTask("ListNumbersReloaded", "Task3")
+	archive, err := ArchiveToDoList("ListNumbersReloaded")
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	seq := CurrentSeq()
+	DeleteToDoList("ListNumbersReloaded")
+	if _, _, err := RestoreToDoList(archive, "", false); err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+
+	task, _ := AddTask("ListNumbersReloaded", "Task4")
+	if task.Number != 4 {
+		t.Errorf("expected Task4 numbered 4 after the reload, got %d", task.Number)
+	}
+	if current := CurrentSeq(); current <= seq {
+		t.Errorf("expected the event sequence beyond %d, got %d", seq, current)
+	}
+}
@@ -0,0 +1,199 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/efreddo/v1/todolist/dateutils"
+)
+
+// templates holds the task templates by name
+var templates = map[string]*TaskTemplate{}
+
+// ErrTaskTemplateConflict is returned when creating or renaming a task
+// template under the name of an existing one
+var ErrTaskTemplateConflict = fmt.Errorf("task template already present")
+
+// TaskTemplate is a task shape instantiated repeatedly: a title pattern, the
+// default attributes of the task and an optional relative due rule (e.g.
+// "first of next month"). The title pattern may contain the {date}, {month}
+// and {year} placeholders, replaced at instantiation.
+type TaskTemplate struct {
+	Name  string
+	Title string
+	TaskDetails
+	DueRule string `json:",omitempty"`
+}
+
+// CreateTaskTemplate stores a new task template
+func CreateTaskTemplate(tmpl TaskTemplate) (*TaskTemplate, error) {
+	if err := validateTaskTemplate(&tmpl); err != nil {
+		return nil, err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := templates[tmpl.Name]; ok {
+		return nil, ErrTaskTemplateConflict
+	}
+	templates[tmpl.Name] = &tmpl
+	c := tmpl
+	return &c, nil
+}
+
+// GetTaskTemplate returns the task template with the given name
+func GetTaskTemplate(name string) (*TaskTemplate, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	tmpl, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("task template not found")
+	}
+	c := *tmpl
+	return &c, nil
+}
+
+// GetAllTaskTemplates returns the task templates sorted by name
+func GetAllTaskTemplates() []*TaskTemplate {
+	lock.RLock()
+	defer lock.RUnlock()
+	all := make([]*TaskTemplate, 0, len(templates))
+	for _, tmpl := range templates {
+		c := *tmpl
+		all = append(all, &c)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// UpdateTaskTemplate replaces the task template with the given name, the
+// template is renamed when tmpl has a different name
+func UpdateTaskTemplate(name string, tmpl TaskTemplate) (*TaskTemplate, error) {
+	if err := validateTaskTemplate(&tmpl); err != nil {
+		return nil, err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := templates[name]; !ok {
+		return nil, fmt.Errorf("task template not found")
+	}
+	if _, ok := templates[tmpl.Name]; ok && tmpl.Name != name {
+		return nil, ErrTaskTemplateConflict
+	}
+	delete(templates, name)
+	templates[tmpl.Name] = &tmpl
+	c := tmpl
+	return &c, nil
+}
+
+// DeleteTaskTemplate removes the task template with the given name
+func DeleteTaskTemplate(name string) (*TaskTemplate, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	tmpl, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("task template not found")
+	}
+	delete(templates, name)
+	return tmpl, nil
+}
+
+// InstantiateTaskTemplate adds to the ToDo list a task built from the
+// template: the title pattern is expanded and the due rule evaluated on the
+// day of at, in its location. The non-empty attributes of overrides replace
+// the template ones, as does a non-empty title. The task goes through the
+// validation and the duplicate detection of AddTaskWithDetails.
+func InstantiateTaskTemplate(todoListName string, name string, title string, overrides TaskDetails, at time.Time) (*Task, []string, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	tmpl, ok := templates[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("task template not found")
+	}
+
+	if title == "" {
+		title = expandTitle(tmpl.Title, at)
+	}
+	details := mergeTaskDetails(tmpl.TaskDetails, overrides)
+	if details.DueDate == nil && tmpl.DueRule != "" {
+		rule, err := dateutils.ParseRelative(tmpl.DueRule)
+		if err != nil {
+			return nil, nil, &ValidationError{err.Error()}
+		}
+		due := rule.From(at)
+		details.DueDate = &due
+	}
+	return addTaskWithDetails(todoListName, title, details)
+}
+
+func validateTaskTemplate(tmpl *TaskTemplate) error {
+	if tmpl.Name == "" || tmpl.Title == "" {
+		return &ValidationError{"task templates must have a name and a title"}
+	}
+	if tmpl.DueDate != nil {
+		return &ValidationError{"task templates can not have a due date, use a due rule"}
+	}
+	if tmpl.DueRule != "" {
+		rule, err := dateutils.ParseRelative(tmpl.DueRule)
+		if err != nil {
+			return &ValidationError{err.Error()}
+		}
+		tmpl.DueRule = rule.String()
+	}
+	_, err := validateTaskDetails(&tmpl.TaskDetails)
+	return err
+}
+
+// expandTitle replaces the placeholders of the title pattern with the date at
+func expandTitle(pattern string, at time.Time) string {
+	return strings.NewReplacer(
+		"{date}", at.Format("2006-01-02"),
+		"{month}", at.Format("January"),
+		"{year}", at.Format("2006"),
+	).Replace(pattern)
+}
+
+// mergeTaskDetails returns a copy of base with the non-empty attributes of
+// overrides, the copy sharing nothing with the template
+func mergeTaskDetails(base, overrides TaskDetails) TaskDetails {
+	merged := base
+	if overrides.Description != "" {
+		merged.Description = overrides.Description
+	}
+	if overrides.DueDate != nil {
+		merged.DueDate = overrides.DueDate
+		merged.AllDay = overrides.AllDay
+	}
+	if overrides.Location != nil {
+		merged.Location = overrides.Location
+	}
+	if overrides.WaitingOn != "" {
+		merged.WaitingOn = overrides.WaitingOn
+	}
+	if overrides.ReminderLead != "" {
+		merged.ReminderLead = overrides.ReminderLead
+	}
+	if overrides.Priority != PriorityNone {
+		merged.Priority = overrides.Priority
+	}
+	if overrides.Meta != nil {
+		merged.Meta = overrides.Meta
+	} else if base.Meta != nil {
+		merged.Meta = make(map[string]string, len(base.Meta))
+		for k, v := range base.Meta {
+			merged.Meta[k] = v
+		}
+	}
+	if overrides.Checklist != nil {
+		merged.Checklist = overrides.Checklist
+	} else {
+		merged.Checklist = append([]ChecklistItem(nil), base.Checklist...)
+	}
+	if overrides.Tags != nil {
+		merged.Tags = overrides.Tags
+	} else {
+		merged.Tags = append([]string(nil), base.Tags...)
+	}
+	return merged
+}
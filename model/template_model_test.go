@@ -0,0 +1,92 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	Task TEMPLATES
+*******************************/
+
+func TestCreateTaskTemplate_invalid_error(t *testing.T) {
+	invalid := []TaskTemplate{
+		{Title: "No name"},
+		{Name: "NoTitle"},
+		{Name: "BadRule", Title: "Bad rule", DueRule: "someday"},
+		{Name: "BadPriority", Title: "Bad priority", TaskDetails: TaskDetails{Priority: 9}},
+		{Name: "DueDate", Title: "Due date", TaskDetails: dueOn(2024, 6, 1, 9)},
+	}
+	for _, tmpl := range invalid {
+		if _, err := CreateTaskTemplate(tmpl); err == nil {
+			t.Errorf("expected a validation error for %+v", tmpl)
+		}
+	}
+}
+
+func TestTaskTemplate_crud_ok(t *testing.T) {
+	if _, err := CreateTaskTemplate(TaskTemplate{Name: "Crud", Title: "Crud", DueRule: "Tomorrow"}); err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if _, err := CreateTaskTemplate(TaskTemplate{Name: "Crud", Title: "Again"}); err == nil {
+		t.Errorf("expected error template already present")
+	}
+	if tmpl, err := GetTaskTemplate("Crud"); err != nil || tmpl.DueRule != "tomorrow" {
+		t.Errorf("expected the normalized due rule, got %v, %v", tmpl, err)
+	}
+	if _, err := UpdateTaskTemplate("Crud", TaskTemplate{Name: "CrudRenamed", Title: "Renamed"}); err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if _, err := GetTaskTemplate("Crud"); err == nil {
+		t.Errorf("expected the template renamed")
+	}
+	if _, err := DeleteTaskTemplate("CrudRenamed"); err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if _, err := DeleteTaskTemplate("CrudRenamed"); err == nil {
+		t.Errorf("expected error template not found")
+	}
+}
+
+func TestInstantiateTaskTemplate_ok(t *testing.T) {
+	CreateToDoList("ListTemplate")
+	CreateTaskTemplate(TaskTemplate{
+		Name:        "Rent",
+		Title:       "Pay rent {month} {year}",
+		TaskDetails: TaskDetails{Priority: PriorityHigh, Tags: []string{"finance"}},
+		DueRule:     "first of next month"})
+	rome, _ := time.LoadLocation("Europe/Rome")
+	// still May in Rome, already June in UTC
+	at := time.Date(2024, 5, 31, 23, 30, 0, 0, rome)
+
+	task, _, err := InstantiateTaskTemplate("ListTemplate", "Rent", "", TaskDetails{}, at)
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if task.Title != "Pay rent May 2024" || task.Priority != PriorityHigh || len(task.Tags) != 1 {
+		t.Errorf("expected the template applied, got %+v", task)
+	}
+	if expected := time.Date(2024, 6, 1, 0, 0, 0, 0, rome); task.DueDate == nil || !task.DueDate.Equal(expected) {
+		t.Errorf("expected due date %v, got %v", expected, task.DueDate)
+	}
+
+	if _, _, err := InstantiateTaskTemplate("ListTemplate", "Rent", "", TaskDetails{}, at); err == nil {
+		t.Errorf("expected error task already present")
+	}
+
+	due := time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC)
+	task, _, err = InstantiateTaskTemplate("ListTemplate", "Rent", "Pay rent late", TaskDetails{DueDate: &due, Tags: []string{"late"}}, at)
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if !task.DueDate.Equal(due) || task.Tags[0] != "late" || task.Priority != PriorityHigh {
+		t.Errorf("expected the overrides applied, got %+v", task)
+	}
+
+	if _, _, err := InstantiateTaskTemplate("ListTemplate", "Rent", "Invalid", TaskDetails{Priority: 7}, at); err == nil {
+		t.Errorf("expected a validation error")
+	}
+	if _, _, err := InstantiateTaskTemplate("ListTemplate", "Missing", "", TaskDetails{}, at); err == nil {
+		t.Errorf("expected error template not found")
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import "fmt"
+
+// MaxTimeLog is the maximum number of minutes logged at once, a day
+const MaxTimeLog = 24 * 60
+
+// LogTaskTime adds the minutes spent on the task to its TimeSpent, from 1 to
+// MaxTimeLog at once
+func LogTaskTime(listKey, taskTitle string, minutes int) (*Task, error) {
+	if minutes < 1 || minutes > MaxTimeLog {
+		return nil, &ValidationError{fmt.Sprintf("invalid minutes %d, expected 1 to %d", minutes, MaxTimeLog)}
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	task, err := getTask(listKey, taskTitle)
+	if err != nil {
+		return nil, err
+	}
+	recordTaskChange(task, TaskChange{Type: EventTaskUpdated, Field: "TimeSpent",
+		Before: changeValue(task.TimeSpent), After: changeValue(task.TimeSpent + minutes)})
+	task.TimeSpent += minutes
+	return copyTask(task), nil
+}
+
+// ListTimeSpent returns the minutes spent on the tasks of the ToDo list
+func ListTimeSpent(listKey string) (int, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, t := range list.Tasks {
+		total += t.TimeSpent
+	}
+	return total, nil
+}
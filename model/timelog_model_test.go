@@ -0,0 +1,43 @@
+package model
+
+import "testing"
+
+/*******************************
+	TIME LOGS
+*******************************/
+
+func TestLogTaskTime_ok(t *testing.T) {
+	CreateToDoListWithTasks("ListTimeLog", []string{"Write report", "Review report"})
+
+	LogTaskTime("ListTimeLog", "Write report", 30)
+	task, err := LogTaskTime("ListTimeLog", "Write report", 60)
+	if err != nil || task.TimeSpent != 90 {
+		t.Fatalf("expected 90 minutes spent, got %v, %v", task, err)
+	}
+	LogTaskTime("ListTimeLog", "Review report", 15)
+	if total, err := ListTimeSpent("ListTimeLog"); err != nil || total != 105 {
+		t.Errorf("expected 105 minutes spent on the list, got %d, %v", total, err)
+	}
+	changes, _ := TaskHistory("ListTimeLog", "Write report")
+	if last := changes[len(changes)-1]; last.Field != "TimeSpent" || string(last.Before) != "30" || string(last.After) != "90" {
+		t.Errorf("expected the time log recorded, got %+v", last)
+	}
+}
+
+func TestLogTaskTime_error(t *testing.T) {
+	CreateToDoListWithTasks("ListTimeLogError", []string{"Write report"})
+
+	for _, minutes := range []int{0, -5, MaxTimeLog + 1} {
+		if _, err := LogTaskTime("ListTimeLogError", "Write report", minutes); err == nil {
+			t.Errorf("expected error logging %d minutes", minutes)
+		} else if _, ok := err.(*ValidationError); !ok {
+			t.Errorf("expected a validation error logging %d minutes, got %v", minutes, err)
+		}
+	}
+	if _, err := LogTaskTime("ListTimeLogError", "invalid", 30); err == nil {
+		t.Errorf("expected error task not found")
+	}
+	if _, err := ListTimeSpent("invalid"); err == nil {
+		t.Errorf("expected error with a missing list")
+	}
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+)
+
+// titlesLock guards the building of the title indexes, which the lookups do
+// holding the store lock for reading only
+var titlesLock sync.Mutex
+
+// titleIndex returns the tasks of the list by normalized title (see
+// normalizeTitle), in the order of the list. Titles are stored as entered, the
+// index serving the lookups ignoring case and spacing. It is built on first
+// use and dropped, with invalidateTitles, whenever the tasks or their titles
+// change. The index is never changed once built, the concurrent readers
+// sharing it.
+func titleIndex(list *ToDoList) map[string][]*Task {
+	titlesLock.Lock()
+	defer titlesLock.Unlock()
+	if list.titles == nil {
+		titles := make(map[string][]*Task, len(list.Tasks))
+		for _, t := range list.Tasks {
+			key := normalizeTitle(t.Title)
+			titles[key] = append(titles[key], t)
+		}
+		list.titles = titles
+	}
+	return list.titles
+}
+
+// invalidateTitles drops the title index of the list, the store lock being
+// held for writing
+func invalidateTitles(list *ToDoList) {
+	titlesLock.Lock()
+	defer titlesLock.Unlock()
+	list.titles = nil
+}
+
+// FindTasksByTitle returns the tasks of the ToDo list whose title matches the
+// given one ignoring case and spacing, in the order of the list, with their
+// original titles
+func FindTasksByTitle(listKey, title string) ([]Task, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []Task{}
+	for _, t := range titleIndex(list)[normalizeTitle(title)] {
+		tasks = append(tasks, *copyTask(t))
+	}
+	return tasks, nil
+}
+
+// AddTaskIfNotExists adds the task as AddTaskWithDetails unless the ToDo list
+// already holds a task with the same title, ignoring case and spacing: the
+// first such task is returned then, untouched, created being false.
+func AddTaskIfNotExists(todoListName string, taskTitle string, details TaskDetails) (task *Task, created bool, warnings []string, err error) {
+	if taskTitle == "" || todoListName == "" {
+		return nil, false, nil, fmt.Errorf("empty mandatory parameters")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	list, err := getToDoList(todoListName)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	if existing := titleIndex(list)[normalizeTitle(taskTitle)]; len(existing) > 0 {
+		return copyTask(existing[0]), false, nil, nil
+	}
+	task, warnings, err = addTaskWithDetails(todoListName, taskTitle, details)
+	return task, err == nil, warnings, err
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"sync"
+	"testing"
+)
+
+/*******************************
+	CASE insensitive lookups
+*******************************/
+
+func TestFindTasksByTitle_ok(t *testing.T) {
+	CreateToDoList("ListTitles")
+	AddTask("ListTitles", "Buy Milk")
+	AddTask("ListTitles", "buy milk")
+	AddTask("ListTitles", "Call Bob")
+
+	tasks, err := FindTasksByTitle("ListTitles", "BUY  MILK")
+	if err != nil || len(tasks) != 2 || tasks[0].Title != "Buy Milk" || tasks[1].Title != "buy milk" {
+		t.Fatalf("expected both milk tasks with their original titles, got %+v, %v", tasks, err)
+	}
+	if groups, _ := FindDuplicateTasks("ListTitles"); len(groups) != 1 || groups[0].Tasks[0].Title != "Buy Milk" {
+		t.Errorf("expected the milk tasks detected as duplicates, got %+v", groups)
+	}
+
+	UpdateTask("ListTitles", "buy milk", "Buy Bread", false)
+	RemoveTask("ListTitles", "Call Bob")
+	if tasks, _ := FindTasksByTitle("ListTitles", "buy milk"); len(tasks) != 1 {
+		t.Errorf("expected the renamed task out of the index, got %+v", tasks)
+	}
+	if tasks, _ := FindTasksByTitle("ListTitles", "call bob"); len(tasks) != 0 {
+		t.Errorf("expected the removed task out of the index, got %+v", tasks)
+	}
+}
+
+// run with -race: the readers build the index holding the store lock for
+// reading only
+func TestFindTasksByTitle_concurrentReaders(t *testing.T) {
+	CreateToDoListWithTasks("ListTitlesConcurrent", []string{"Buy Milk", "Call Bob"})
+	for round := 0; round < 10; round++ {
+		AddTask("ListTitlesConcurrent", "Task"+string(rune('A'+round)))
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if tasks, _ := FindTasksByTitle("ListTitlesConcurrent", "buy milk"); len(tasks) != 1 {
+					t.Errorf("expected the milk task found, got %+v", tasks)
+				}
+				FindDuplicateTasks("ListTitlesConcurrent")
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestAddTaskIfNotExists(t *testing.T) {
+	CreateToDoList("ListTitlesIfNotExists")
+	AddTask("ListTitlesIfNotExists", "Buy Milk")
+
+	task, created, _, err := AddTaskIfNotExists("ListTitlesIfNotExists", "buy milk", TaskDetails{})
+	if err != nil || created || task.Title != "Buy Milk" {
+		t.Errorf("expected the existing task, got %+v, %t, %v", task, created, err)
+	}
+	task, created, _, err = AddTaskIfNotExists("ListTitlesIfNotExists", "Buy Bread", TaskDetails{})
+	if err != nil || !created || task.Title != "Buy Bread" {
+		t.Errorf("expected the task created, got %+v, %t, %v", task, created, err)
+	}
+	if list, _ := GetToDoList("ListTitlesIfNotExists"); list.TaskNumber != 2 {
+		t.Errorf("expected 2 tasks, got %d", list.TaskNumber)
+	}
+}
@@ -1,14 +1,113 @@
 package model
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
 
 var data map[string]*ToDoList
 
+// ErrToDoListNotEmpty is returned when deleting, only if empty, a ToDo list
+// with tasks
+var ErrToDoListNotEmpty = fmt.Errorf("ToDo list not empty, list not deleted")
+
+// ErrToDoListPrecondition is returned when the precondition of an update does
+// not hold on the current ToDo list
+var ErrToDoListPrecondition = fmt.Errorf("ToDo list precondition failed")
+
+// lock guards data and the indexes built on it: exported functions acquire
+// it, unexported helpers expect the caller to hold it
+var lock sync.RWMutex
+
 // ToDoList manages a list of tasks in memory
 type ToDoList struct {
 	Name string			
 	Tasks  []*Task	
 	TaskNumber int	
+	Description string `json:",omitempty"`
+	Color       string `json:",omitempty"`
+	// Archived lists are hidden from the listing of the ToDo lists by default
+	Archived bool `json:",omitempty"`
+	// LastTaskNumber is the number of the last task added, numbers not being reused
+	LastTaskNumber int `json:",omitempty"`
+	// DefaultPriority is given to the new tasks created without a priority, the
+	// global default priority applying when it is PriorityNone
+	DefaultPriority int `json:",omitempty"`
+	// AutoSort keeps the tasks sorted by priority or due date: the new tasks
+	// are inserted at their position rather than appended, and the changed
+	// ones moved, see AutoSortPriority and AutoSortDueDate
+	AutoSort string `json:",omitempty"`
+	// Notifications are the notification preferences of the list, the default
+	// ones applying when nil, see SetListNotificationPrefs
+	Notifications *NotificationPrefs `json:",omitempty"`
+	// UpdatedAt is the time of the last change of the list or of its tasks, or
+	// of its last touch, see Touch
+	UpdatedAt time.Time
+	// titles indexes the tasks by normalized title, see titleIndex
+	titles map[string][]*Task
+	// percentComplete is the PercentComplete of a snapshot, taken with all
+	// its tasks before they are paginated
+	percentComplete *int
+	// openTasks counts the incomplete tasks, maintained by recordEvent
+	openTasks int
+}
+
+// OpenTasks returns the number of incomplete tasks of the list, kept up to
+// date as the tasks are added, completed, reopened and removed
+func (l *ToDoList) OpenTasks() int {
+	return l.openTasks
+}
+
+// MarshalJSON adds the effective default priority of the new tasks and the
+// completion percentage of the list to its JSON representation, the lists
+// without tasks having an empty array of Tasks
+func (l ToDoList) MarshalJSON() ([]byte, error) {
+	type toDoList ToDoList
+	if l.Tasks == nil {
+		l.Tasks = []*Task{}
+	}
+	percent := l.percentComplete
+	if percent == nil {
+		p := percentComplete(l.Tasks)
+		percent = &p
+	}
+	return json.Marshal(struct {
+		toDoList
+		EffectiveDefaultPriority int
+		PercentComplete          int
+	}{toDoList(l), l.effectiveDefaultPriority(), *percent})
+}
+
+// effectiveDefaultPriority returns the priority given to the new tasks created
+// without a priority
+func (l *ToDoList) effectiveDefaultPriority() int {
+	if l.DefaultPriority != PriorityNone {
+		return l.DefaultPriority
+	}
+	return defaultPriority
+}
+
+// SetListDefaultPriority sets the default priority of the new tasks of the
+// ToDo list, PriorityNone to fall back to the global default priority
+func SetListDefaultPriority(name string, priority int) (*ToDoList, error) {
+	if err := validatePriority(priority); err != nil {
+		return nil, err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	list, err := getToDoList(name)
+	if err != nil {
+		return nil, err
+	}
+	if list.DefaultPriority != priority {
+		list.DefaultPriority = priority
+		list.UpdatedAt = now()
+	}
+	return cloneToDoList(list), nil
 }
 
 
@@ -16,8 +115,13 @@ func CreateToDoList(name string) (*ToDoList, error) {
 	if name == "" {
 		return nil, fmt.Errorf("empty ToDo list name")
 	}
+	lock.Lock()
+	defer lock.Unlock()
 	
-	if list, _ := GetToDoList(name); list != nil {
+	if err := validateListName(name); err != nil {
+		return nil, err
+	}
+	if list, _ := getToDoList(name); list != nil {
 		return nil, fmt.Errorf("list already present")
 	}
 	if data == nil {
@@ -28,42 +132,384 @@ func CreateToDoList(name string) (*ToDoList, error) {
 	newToDoList.TaskNumber = 0
 
 	data[name] = newToDoList
-	return data[name], nil
+	recordListEvent(EventListCreated, name)
+	return cloneToDoList(newToDoList), nil
 }
 
 func  GetToDoList(name string) (*ToDoList, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	list, err := getToDoList(name)
+	if err != nil {
+		return nil, err
+	}
+	touchList(list)
+	return cloneToDoList(list), nil
+}
+
+func getToDoList(name string) (*ToDoList, error) {
 	if name == "" || data == nil || data[name] == nil {
-		return nil, fmt.Errorf("ToDo list not found")
+		return nil, notFound(name, "", fmt.Errorf("ToDo list not found"))
 	}
 	return data[name], nil
 }
 
+// GetToDoListWithTasks returns a snapshot of the ToDo list with its tasks
+func GetToDoListWithTasks(name string) (*ToDoList, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	list, err := getToDoList(name)
+	if err != nil {
+		return nil, err
+	}
+	touchList(list)
+	return cloneToDoList(list), nil
+}
+
+// GetToDoLists returns snapshots of the named ToDo lists with their tasks,
+// taken together, in the order of the names: nil for the lists not found
+func GetToDoLists(names []string) []*ToDoList {
+	lock.RLock()
+	defer lock.RUnlock()
+	lists := make([]*ToDoList, len(names))
+	for i, name := range names {
+		if list, err := getToDoList(name); err == nil {
+			lists[i] = cloneToDoList(list)
+		}
+	}
+	return lists
+}
+
+// GetAllToDoList returns all the ToDo lists, ordered by name
 func GetAllToDoList() ([]ToDoList, error) {
+	lock.RLock()
+	defer lock.RUnlock()
 	allToDoList := []ToDoList{}
 	if data != nil {
 		for _, value := range data {
-			allToDoList = append(allToDoList, *value)
+			allToDoList = append(allToDoList, *cloneToDoList(value))
 		}
 	}
+	sort.Slice(allToDoList, func(i, j int) bool {
+		return allToDoList[i].Name < allToDoList[j].Name
+	})
 	return allToDoList, nil
 }
 
 func DeleteToDoList(name string) (*ToDoList, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	return deleteToDoList(name)
+}
+
+// DeleteEmptyToDoList deletes the ToDo list only when it has no task,
+// failing with ErrToDoListNotEmpty otherwise, the check and the deletion
+// being atomic
+func DeleteEmptyToDoList(name string) (*ToDoList, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	list, err := getToDoList(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Tasks) > 0 {
+		return nil, ErrToDoListNotEmpty
+	}
+	return deleteToDoList(name)
+}
+
+// DeleteToDoListWithTasks deletes the ToDo list and returns a snapshot of it
+// with all its tasks, e.g. to restore it later
+func DeleteToDoListWithTasks(name string) (*ToDoList, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	list, err := deleteToDoList(name)
+	if err != nil {
+		return nil, err
+	}
+	return cloneToDoList(list), nil
+}
+
+func deleteToDoList(name string) (*ToDoList, error) {
 	if name == "" || data == nil || data[name] == nil {
-		return  nil, fmt.Errorf("ToDo list not found, list not deleted")
+		return  nil, notFound(name, "", fmt.Errorf("ToDo list not found, list not deleted"))
 	}
 	list := data[name]
+	if err := vetoTasksDeleted(list); err != nil {
+		return nil, err
+	}
 	delete(data, name)
+	for _, t := range list.Tasks {
+		unindexCompletion(t)
+		unindexDueDate(t)
+		recordTaskDeleted(t)
+	}
+	recordListEvent(EventListDeleted, name)
 	return list, nil
 }
 
 func UpdateToDoList(name string, newName string)(*ToDoList, error) {
+	lock.Lock()
+	defer lock.Unlock()
 	if name == "" || newName == "" || data == nil || data[name] == nil {
-		return  nil, fmt.Errorf("ToDo list not found, list not deleted")
+		return  nil, notFound(name, "", fmt.Errorf("ToDo list not found, list not deleted"))
+	}
+	if err := validateListName(newName); err != nil {
+		return nil, err
 	}
 	list := data[name]
-	list.Name = newName
 	delete(data, name)
-	data[newName] = list
+	renameToDoList(list, newName)
+	return cloneToDoList(list), nil
+}
+
+// UpsertToDoList applies the merge patch to the ToDo list, creating it when
+// absent: the list is then named after name, the patch must not rename it,
+// and it gets the initial tasks. The tasks of an existing list are replaced
+// by the initial ones only with replaceTasks. When any initial task is
+// invalid a *TaskSeedError reports them all and nothing is changed.
+// precondition is given the current list, nil when absent, and the list is
+// left untouched with ErrToDoListPrecondition when it returns false. created
+// tells whether the list was created, the warnings report the adjustments
+// made to the task attributes (e.g. truncation).
+func UpsertToDoList(name string, patch map[string]interface{}, seeds []TaskSeed, replaceTasks bool,
+	precondition func(current *ToDoList) bool) (list *ToDoList, created bool, warnings []string, err error) {
+	if name == "" {
+		return nil, false, nil, fmt.Errorf("empty ToDo list name")
+	}
+	if err := validateTaskSeeds(seeds); err != nil {
+		return nil, false, nil, err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	current, _ := getToDoList(name)
+	if precondition != nil && !precondition(current) {
+		return nil, false, nil, ErrToDoListPrecondition
+	}
+	if current == nil || replaceTasks {
+		if err := vetoTaskSeeds(name, seeds); err != nil {
+			return nil, false, nil, err
+		}
+	}
+	if current != nil && replaceTasks {
+		if err := vetoTasksDeleted(current); err != nil {
+			return nil, false, nil, err
+		}
+	}
+	if current != nil {
+		if list, err = mergePatchToDoList(current, patch); err != nil {
+			return nil, false, nil, err
+		}
+		if replaceTasks {
+			removeAllTasks(list)
+			warnings = addTaskSeeds(list, seeds)
+		}
+		return cloneToDoList(list), false, warnings, nil
+	}
+
+	list = &ToDoList{Name: name}
+	fields, err := parseToDoListPatch(list, patch)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	if fields.name != name {
+		return nil, false, nil, fmt.Errorf("ToDo list not found, a missing list can not be renamed")
+	}
+	if err := validateListName(name); err != nil {
+		return nil, false, nil, err
+	}
+	if data == nil {
+		data = make(map[string]*ToDoList, 100)
+	}
+	data[name] = list
+	recordListEvent(EventListCreated, name)
+	setToDoListFields(list, fields)
+	warnings = addTaskSeeds(list, seeds)
+	return cloneToDoList(list), true, warnings, nil
+}
+
+// MergePatchToDoList applies a JSON Merge Patch (RFC 7386) to the ToDo list.
+// Fields absent from the patch are left untouched, a null value clears the
+// nullable fields (Description, Color, Archived, DefaultPriority, AutoSort). The patch is
+// validated as a whole before being applied, so an invalid patch leaves the list unchanged.
+func MergePatchToDoList(name string, patch map[string]interface{}) (*ToDoList, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	list, err := getToDoList(name)
+	if err != nil {
+		return nil, err
+	}
+	if list, err = mergePatchToDoList(list, patch); err != nil {
+		return nil, err
+	}
+	return cloneToDoList(list), nil
+}
+
+// ApplyToDoListPatch replaces the ToDo list with the result of apply, given
+// its JSON representation, atomically with respect to the other updates. The
+// editable fields (Name, Description, Color, Archived, DefaultPriority, AutoSort) are taken from the
+// result, the others being ignored. The errors of apply are returned as is.
+func ApplyToDoListPatch(name string, apply func(doc []byte) ([]byte, error)) (*ToDoList, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	list, err := getToDoList(name)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	if doc, err = apply(doc); err != nil {
+		return nil, err
+	}
+
+	patched := map[string]interface{}{}
+	if err := json.Unmarshal(doc, &patched); err != nil {
+		return nil, fmt.Errorf("the patched ToDo list must be an object")
+	}
+	patch := map[string]interface{}{}
+	for _, key := range []string{"Name", "Description", "Color", "Archived", "DefaultPriority", "AutoSort"} {
+		patch[key] = patched[key]
+	}
+	if list, err = mergePatchToDoList(list, patch); err != nil {
+		return nil, err
+	}
+	return cloneToDoList(list), nil
+}
+
+func mergePatchToDoList(list *ToDoList, patch map[string]interface{}) (*ToDoList, error) {
+	fields, err := parseToDoListPatch(list, patch)
+	if err != nil {
+		return nil, err
+	}
+	if fields.name != list.Name {
+		if err := validateListName(fields.name); err != nil {
+			return nil, err
+		}
+		if other, _ := getToDoList(fields.name); other != nil {
+			return nil, fmt.Errorf("list already present")
+		}
+		delete(data, list.Name)
+		renameToDoList(list, fields.name)
+	}
+	setToDoListFields(list, fields)
 	return list, nil
-}
\ No newline at end of file
+}
+
+// toDoListFields are the editable fields of a ToDo list
+type toDoListFields struct {
+	name        string
+	description string
+	color       string
+	archived    bool
+	priority    int
+	autoSort    string
+}
+
+// parseToDoListPatch returns the fields of the list once the merge patch is
+// applied, leaving the list untouched
+func parseToDoListPatch(list *ToDoList, patch map[string]interface{}) (toDoListFields, error) {
+	var err error
+	fields := toDoListFields{list.Name, list.Description, list.Color, list.Archived, list.DefaultPriority, list.AutoSort}
+	for key, value := range patch {
+		switch strings.ToLower(key) {
+		case "name":
+			s, ok := value.(string)
+			if !ok || s == "" {
+				return fields, fmt.Errorf("invalid ToDo list name, it can not be removed or empty")
+			}
+			fields.name = s
+		case "description":
+			if fields.description, err = nullableString(key, value); err != nil {
+				return fields, err
+			}
+		case "color":
+			if fields.color, err = nullableString(key, value); err != nil {
+				return fields, err
+			}
+		case "archived":
+			if value == nil {
+				fields.archived = false
+				break
+			}
+			b, ok := value.(bool)
+			if !ok {
+				return fields, fmt.Errorf("field %s must be a boolean or null", key)
+			}
+			fields.archived = b
+		case "defaultpriority":
+			if value == nil {
+				fields.priority = PriorityNone
+				break
+			}
+			n, ok := value.(float64)
+			if !ok || n != float64(int(n)) {
+				return fields, fmt.Errorf("field %s must be an integer or null", key)
+			}
+			if err := validatePriority(int(n)); err != nil {
+				return fields, err
+			}
+			fields.priority = int(n)
+		case "autosort":
+			if fields.autoSort, err = nullableString(key, value); err != nil {
+				return fields, err
+			}
+			if err := validateAutoSort(fields.autoSort); err != nil {
+				return fields, err
+			}
+		case "tasks", "tasknumber", "lasttasknumber", "effectivedefaultpriority", "updatedat":
+			return fields, fmt.Errorf("field %s is read only", key)
+		default:
+			return fields, fmt.Errorf("unknown field %s", key)
+		}
+	}
+	return fields, nil
+}
+
+// setToDoListFields sets the fields of the list but its name, recording
+// archiving and unarchiving
+func setToDoListFields(list *ToDoList, fields toDoListFields) {
+	if fields.description != list.Description || fields.color != list.Color || fields.priority != list.DefaultPriority ||
+		fields.autoSort != list.AutoSort {
+		list.UpdatedAt = now()
+	}
+	list.Description = fields.description
+	list.Color = fields.color
+	list.DefaultPriority = fields.priority
+	setAutoSort(list, fields.autoSort)
+	if fields.archived != list.Archived {
+		list.Archived = fields.archived
+		if fields.archived {
+			recordListEvent(EventListArchived, list.Name)
+		} else {
+			recordListEvent(EventListUnarchived, list.Name)
+		}
+	}
+}
+
+// renameToDoList stores the list under its new name, keeping the list
+// reference of its tasks aligned.
+func renameToDoList(list *ToDoList, newName string) {
+	renameHistory(list.Name, newName)
+	recordListRenamed(list.Name, newName)
+	list.Name = newName
+	for _, t := range list.Tasks {
+		t.ToDoList = newName
+	}
+	data[newName] = list
+	list.UpdatedAt = now()
+}
+
+// nullableString converts a merge patch value to a string, null being
+// mapped to the empty (cleared) value.
+func nullableString(key string, value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field %s must be a string or null", key)
+	}
+	return s, nil
+}
@@ -1,6 +1,10 @@
 package model
 
-import "testing"
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
 
 /*******************************
 	CREATE ToDo list
@@ -156,3 +160,259 @@ func TestDeleteToDoList_ok(t *testing.T) {
 		t.Errorf("expected error ToDo list not found, got nil")
 	}
 }
+
+func TestDeleteToDoListWithTasks_ok(t *testing.T) {
+	SeedToDoList("ListDeletedWithTasks", []TaskSeed{{Title: "Task1"}, {Title: "Task2"}})
+
+	list, err := DeleteToDoListWithTasks("ListDeletedWithTasks")
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if len(list.Tasks) != 2 || list.Tasks[1].Title != "Task2" || list.Tasks[1].Number != 2 {
+		t.Errorf("expected the deleted list with Task1 and Task2, got %+v", list.Tasks)
+	}
+	if _, err := GetToDoList("ListDeletedWithTasks"); err == nil {
+		t.Errorf("expected error ToDo list not found, got nil")
+	}
+	if _, err := DeleteToDoListWithTasks("ListDeletedWithTasks"); err == nil {
+		t.Errorf("expected error ToDo list not found, got nil")
+	}
+}
+
+/*******************************
+	MERGE PATCH ToDo list
+*******************************/
+
+func TestMergePatchToDoList_invalidName_error(t *testing.T) {
+	_, err := MergePatchToDoList("invalid", map[string]interface{}{"Color": "red"})
+	if err == nil {
+		t.Errorf("Expected error list not found, got nil")
+	}
+}
+
+func TestMergePatchToDoList_set_ok(t *testing.T) {
+	CreateToDoList("ListPatch")
+	list, err := MergePatchToDoList("ListPatch", map[string]interface{}{"Description": "Groceries", "color": "green"})
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if list.Description != "Groceries" || list.Color != "green" {
+		t.Errorf("expected description Groceries and color green, got %s and %s", list.Description, list.Color)
+	}
+}
+
+func TestMergePatchToDoList_noOp_ok(t *testing.T) {
+	list, err := MergePatchToDoList("ListPatch", map[string]interface{}{})
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if list.Name != "ListPatch" || list.Description != "Groceries" || list.Color != "green" {
+		t.Errorf("expected ListPatch unchanged, got %+v", list)
+	}
+}
+
+func TestMergePatchToDoList_clearViaNull_ok(t *testing.T) {
+	list, err := MergePatchToDoList("ListPatch", map[string]interface{}{"Color": nil})
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if list.Color != "" {
+		t.Errorf("expected color cleared, got %s", list.Color)
+	}
+	if list.Description != "Groceries" {
+		t.Errorf("expected description untouched, got %s", list.Description)
+	}
+}
+
+func TestMergePatchToDoList_nullName_error(t *testing.T) {
+	_, err := MergePatchToDoList("ListPatch", map[string]interface{}{"Name": nil})
+	if err == nil {
+		t.Errorf("Expected error name can not be removed, got nil")
+	}
+}
+
+func TestMergePatchToDoList_invalidPatch_unchanged(t *testing.T) {
+	_, err := MergePatchToDoList("ListPatch", map[string]interface{}{"Description": nil, "Color": 42.0})
+	if err == nil {
+		t.Errorf("Expected error invalid color, got nil")
+	}
+	list, _ := GetToDoList("ListPatch")
+	if list.Description != "Groceries" {
+		t.Errorf("expected description untouched by an invalid patch, got %s", list.Description)
+	}
+}
+
+func TestMergePatchToDoList_rename_ok(t *testing.T) {
+	list, err := MergePatchToDoList("ListPatch", map[string]interface{}{"Name": "ListPatchNew"})
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if list.Name != "ListPatchNew" {
+		t.Errorf("expected ToDoList renamed ListPatchNew, got %s", list.Name)
+	}
+	if _, err = GetToDoList("ListPatch"); err == nil {
+		t.Errorf("expected error ToDo list not found, got nil")
+	}
+}
+
+/*******************************
+	GET ToDo list with tasks
+*******************************/
+
+func TestGetToDoListWithTasks_invalidName_error(t *testing.T) {
+	_, err := GetToDoListWithTasks("invalid")
+	if err == nil {
+		t.Errorf("Expected error list not found, got nil")
+	}
+}
+
+func TestGetToDoListWithTasks_ok(t *testing.T) {
+	CreateToDoList("ListEmbed")
+	AddTask("ListEmbed", "Task1")
+	AddTask("ListEmbed", "Task2")
+
+	list, err := GetToDoListWithTasks("ListEmbed")
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+	if len(list.Tasks) != 2 || list.Tasks[0].Title != "Task1" {
+		t.Errorf("expected Task1 and Task2 embedded, got %v", list.Tasks)
+	}
+
+	list.Tasks = list.Tasks[:1]
+	stored, _ := GetToDoList("ListEmbed")
+	if len(stored.Tasks) != 2 {
+		t.Errorf("expected stored list not affected by the snapshot, got %d tasks", len(stored.Tasks))
+	}
+}
+
+/******************************
+	GET ToDo lists by name
+*******************************/
+
+func TestGetToDoLists_ok(t *testing.T) {
+	CreateToDoList("ListByName")
+	AddTask("ListByName", "Task1")
+
+	lists := GetToDoLists([]string{"ListByName", "invalid", "ListByName"})
+	if len(lists) != 3 || lists[1] != nil {
+		t.Fatalf("expected 3 results with the invalid list nil, got %v", lists)
+	}
+	if lists[0].Name != "ListByName" || len(lists[0].Tasks) != 1 || lists[0] == lists[2] {
+		t.Errorf("expected distinct snapshots of ListByName, got %v and %v", lists[0], lists[2])
+	}
+}
+
+/******************************
+	UPSERT ToDo list
+*******************************/
+
+func TestUpsertToDoList_ok(t *testing.T) {
+	list, created, _, err := UpsertToDoList("ListUpsert", map[string]interface{}{"Name": "ListUpsert", "Archived": true}, nil, false, nil)
+	if err != nil || !created || !list.Archived {
+		t.Fatalf("expected an archived list created, got %v, %t, %v", list, created, err)
+	}
+	list, created, _, err = UpsertToDoList("ListUpsert", map[string]interface{}{"Color": "red"}, nil, false, nil)
+	if err != nil || created || list.Color != "red" || !list.Archived {
+		t.Errorf("expected the list updated, got %v, %t, %v", list, created, err)
+	}
+}
+
+func TestUpsertToDoList_error(t *testing.T) {
+	if _, _, _, err := UpsertToDoList("ListUpsertRenamed", map[string]interface{}{"Name": "Other"}, nil, false, nil); err == nil {
+		t.Errorf("expected error renaming a missing list")
+	}
+	if _, _, _, err := UpsertToDoList("ListUpsertInvalid", map[string]interface{}{"Color": 1}, nil, false, nil); err == nil {
+		t.Errorf("expected error with an invalid color")
+	}
+	if list, _ := GetToDoList("ListUpsertInvalid"); list != nil {
+		t.Errorf("expected no list created by a failed upsert")
+	}
+	absent := func(current *ToDoList) bool { return current == nil }
+	CreateToDoList("ListUpsertPresent")
+	if _, _, _, err := UpsertToDoList("ListUpsertPresent", map[string]interface{}{}, nil, false, absent); err != ErrToDoListPrecondition {
+		t.Errorf("expected ErrToDoListPrecondition, got %v", err)
+	}
+}
+
+/******************************
+	DEFAULT priority
+*******************************/
+
+func TestSetListDefaultPriority_ok(t *testing.T) {
+	CreateToDoList("ListDefaultPriority")
+	AddTask("ListDefaultPriority", "Task1")
+	if _, err := SetListDefaultPriority("ListDefaultPriority", PriorityHigh); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	task, _, _ := AddTaskWithDetails("ListDefaultPriority", "Task2", TaskDetails{})
+	if task.Priority != PriorityHigh {
+		t.Errorf("expected the list default priority, got %d", task.Priority)
+	}
+	task, _, _ = AddTaskWithDetails("ListDefaultPriority", "Task3", TaskDetails{Priority: PriorityLow})
+	if task.Priority != PriorityLow {
+		t.Errorf("expected the explicit priority, got %d", task.Priority)
+	}
+	if task, _ := GetTask("ListDefaultPriority", "Task1"); task.Priority != PriorityNone {
+		t.Errorf("expected the existing tasks untouched, got %d", task.Priority)
+	}
+
+	SetDefaultPriority(PriorityMedium)
+	defer SetDefaultPriority(PriorityNone)
+	list, _ := MergePatchToDoList("ListDefaultPriority", map[string]interface{}{"DefaultPriority": nil})
+	b, _ := json.Marshal(list)
+	if list.DefaultPriority != PriorityNone || !strings.Contains(string(b), `"EffectiveDefaultPriority":3`) {
+		t.Errorf("expected the global default priority effective, got %s", b)
+	}
+	if task, _ := AddTask("ListDefaultPriority", "Task4"); task.Priority != PriorityMedium {
+		t.Errorf("expected the global default priority, got %d", task.Priority)
+	}
+}
+
+func TestSetListDefaultPriority_error(t *testing.T) {
+	CreateToDoList("ListDefaultPriorityInvalid")
+	if _, err := SetListDefaultPriority("ListDefaultPriorityInvalid", 5); err == nil {
+		t.Errorf("expected error with an invalid priority")
+	}
+	if _, err := MergePatchToDoList("ListDefaultPriorityInvalid", map[string]interface{}{"DefaultPriority": 1.5}); err == nil {
+		t.Errorf("expected error with a non integer priority")
+	}
+	if _, err := SetListDefaultPriority("invalid", PriorityHigh); err == nil {
+		t.Errorf("expected error with a missing list")
+	}
+}
+
+/*******************************
+	OPEN Tasks
+*******************************/
+func TestOpenTasks_maintained(t *testing.T) {
+	CreateToDoListWithTasks("ListOpenTasks", []string{"Task1", "Task2", "Task3"})
+	UpdateTask("ListOpenTasks", "Task1", "Task1", true)
+	UpdateTask("ListOpenTasks", "Task2", "Task2", true)
+	UpdateTask("ListOpenTasks", "Task2", "Task2", false)
+	RemoveTask("ListOpenTasks", "Task3")
+	AddTask("ListOpenTasks", "Task4")
+
+	list, _ := GetToDoList("ListOpenTasks")
+	if list.OpenTasks() != 2 {
+		t.Errorf("expected 2 open tasks, got %d", list.OpenTasks())
+	}
+
+	archive, _ := ArchiveToDoList("ListOpenTasks")
+	restored, _, err := RestoreToDoList(archive, "ListOpenTasksRestored", false)
+	if err != nil || restored.OpenTasks() != 2 {
+		t.Errorf("expected 2 open tasks once restored, got %d (%v)", restored.OpenTasks(), err)
+	}
+	UpdateToDoList("ListOpenTasks", "ListOpenTasksRenamed")
+	RemoveTask("ListOpenTasksRenamed", "Task1")
+	UpdateTask("ListOpenTasksRenamed", "Task4", "Task4", true)
+	if list, _ := GetToDoList("ListOpenTasksRenamed"); list.OpenTasks() != 1 {
+		t.Errorf("expected 1 open task after the rename, got %d", list.OpenTasks())
+	}
+	all, _ := GetAllToDoList()
+	for _, l := range all {
+		if l.Name == "ListOpenTasksRenamed" && l.OpenTasks() != 1 {
+			t.Errorf("expected 1 open task in the listing, got %d", l.OpenTasks())
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultTombstoneRetention is how long the deleted ToDo lists and tasks are
+// remembered by default
+const DefaultTombstoneRetention = 7 * 24 * time.Hour
+
+// GoneError is returned when looking up a ToDo list or a task deleted less
+// than the tombstone retention ago, rather than a not found error
+type GoneError struct {
+	Reason    string
+	DeletedAt time.Time
+}
+
+func (e *GoneError) Error() string {
+	return e.Reason
+}
+
+// tombstone is the deletion of a ToDo list or a task, see tombstoneKey
+type tombstone struct {
+	key string
+	at  time.Time
+}
+
+var (
+	tombstoneRetention = DefaultTombstoneRetention
+	// tombstones maps the keys of the deleted lists and tasks to their
+	// deletion time, tombstoneQueue holding them in deletion order for pruning
+	tombstones     = map[string]time.Time{}
+	tombstoneQueue []tombstone
+)
+
+// SetTombstoneRetention sets how long the deleted ToDo lists and tasks are
+// remembered, their lookups failing with a *GoneError rather than a not found
+// error meanwhile. 0 disables the tombstones.
+func SetTombstoneRetention(retention time.Duration) error {
+	if retention < 0 {
+		return fmt.Errorf("invalid tombstone retention %s, expected a non negative duration", retention)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	tombstoneRetention = retention
+	pruneTombstones()
+	return nil
+}
+
+// tombstoneKey returns the key of the tombstone of a ToDo list, or of one of
+// its tasks with a title
+func tombstoneKey(list, title string) string {
+	if title == "" {
+		return list
+	}
+	return list + "\x00" + title
+}
+
+// recordTombstone remembers the deletion of a ToDo list or of a task
+func recordTombstone(list, title string) {
+	if tombstoneRetention == 0 {
+		return
+	}
+	at := now()
+	key := tombstoneKey(list, title)
+	tombstones[key] = at
+	tombstoneQueue = append(tombstoneQueue, tombstone{key, at})
+	pruneTombstones()
+}
+
+// clearTombstone forgets the deletion of a ToDo list or of a task created
+// again with the same name
+func clearTombstone(list, title string) {
+	delete(tombstones, tombstoneKey(list, title))
+}
+
+// pruneTombstones drops the tombstones older than the retention
+func pruneTombstones() {
+	expired := now().Add(-tombstoneRetention)
+	for len(tombstoneQueue) > 0 && (tombstoneRetention == 0 || tombstoneQueue[0].at.Before(expired)) {
+		t := tombstoneQueue[0]
+		if at, ok := tombstones[t.key]; ok && at.Equal(t.at) {
+			delete(tombstones, t.key)
+		}
+		tombstoneQueue = tombstoneQueue[1:]
+	}
+}
+
+// notFound returns the error of a ToDo list, or of one of its tasks with a
+// title, not found: a *GoneError when deleted within the retention
+func notFound(list, title string, err error) error {
+	at, ok := tombstones[tombstoneKey(list, title)]
+	if !ok || tombstoneRetention == 0 || now().Sub(at) > tombstoneRetention {
+		return err
+	}
+	if title == "" {
+		return &GoneError{fmt.Sprintf("ToDo list %s deleted", list), at}
+	}
+	return &GoneError{fmt.Sprintf("Task %s deleted", title), at}
+}
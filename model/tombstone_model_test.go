@@ -0,0 +1,87 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	TOMBSTONES
+*******************************/
+
+func TestTombstones_goneWithinRetention(t *testing.T) {
+	defer func() { now = time.Now }()
+	at := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return at }
+
+	CreateToDoListWithTasks("ListTombstone", []string{"Kept", "Deleted"})
+	RemoveTask("ListTombstone", "Deleted")
+	if _, err := GetTask("ListTombstone", "Deleted"); err == nil {
+		t.Fatalf("expected error with a deleted task")
+	} else if gone, ok := err.(*GoneError); !ok || !gone.DeletedAt.Equal(at) {
+		t.Errorf("expected a *GoneError for the deleted task, got %v", err)
+	}
+	if _, err := UpdateTask("ListTombstone", "Deleted", "Deleted", true); err == nil {
+		t.Errorf("expected error updating a deleted task")
+	} else if _, ok := err.(*GoneError); !ok {
+		t.Errorf("expected a *GoneError updating the deleted task, got %v", err)
+	}
+	if _, err := GetTask("ListTombstone", "Never"); err == nil {
+		t.Errorf("expected error with a missing task")
+	} else if _, ok := err.(*GoneError); ok {
+		t.Errorf("expected a not found error for a task never created, got %v", err)
+	}
+
+	DeleteToDoList("ListTombstone")
+	if _, err := GetToDoList("ListTombstone"); err == nil {
+		t.Fatalf("expected error with a deleted list")
+	} else if _, ok := err.(*GoneError); !ok {
+		t.Errorf("expected a *GoneError for the deleted list, got %v", err)
+	}
+	if _, err := DeleteToDoList("ListTombstone"); err == nil {
+		t.Errorf("expected error deleting the list again")
+	} else if _, ok := err.(*GoneError); !ok {
+		t.Errorf("expected a *GoneError deleting the list again, got %v", err)
+	}
+	if _, err := GetToDoList("ListTombstoneNever"); err == nil {
+		t.Errorf("expected error with a missing list")
+	} else if _, ok := err.(*GoneError); ok {
+		t.Errorf("expected a not found error for a list never created, got %v", err)
+	}
+
+	at = at.Add(DefaultTombstoneRetention + time.Second)
+	if _, err := GetToDoList("ListTombstone"); err == nil {
+		t.Errorf("expected error with a deleted list")
+	} else if _, ok := err.(*GoneError); ok {
+		t.Errorf("expected a not found error after the retention, got %v", err)
+	}
+}
+
+func TestTombstones_clearedOnCreation(t *testing.T) {
+	CreateToDoListWithTasks("ListTombstoneAgain", []string{"Task1"})
+	RemoveTask("ListTombstoneAgain", "Task1")
+	AddTask("ListTombstoneAgain", "Task1")
+	if _, err := GetTask("ListTombstoneAgain", "Task1"); err != nil {
+		t.Errorf("expected the task created again found, got %v", err)
+	}
+	DeleteToDoList("ListTombstoneAgain")
+	CreateToDoList("ListTombstoneAgain")
+	if _, err := GetToDoList("ListTombstoneAgain"); err != nil {
+		t.Errorf("expected the list created again found, got %v", err)
+	}
+}
+
+func TestSetTombstoneRetention_disabled(t *testing.T) {
+	defer SetTombstoneRetention(DefaultTombstoneRetention)
+	if err := SetTombstoneRetention(-time.Hour); err == nil {
+		t.Errorf("expected error with a negative retention")
+	}
+	SetTombstoneRetention(0)
+	CreateToDoList("ListTombstoneDisabled")
+	DeleteToDoList("ListTombstoneDisabled")
+	if _, err := GetToDoList("ListTombstoneDisabled"); err == nil {
+		t.Errorf("expected error with a deleted list")
+	} else if _, ok := err.(*GoneError); ok {
+		t.Errorf("expected a not found error without tombstones, got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package model
+
+// Touch bumps the UpdatedAt of the ToDo list, leaving it unchanged otherwise,
+// e.g. to keep it fresh in the views sorted by recency
+func Touch(listKey string) (*ToDoList, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return nil, err
+	}
+	list.UpdatedAt = now()
+	return cloneToDoList(list), nil
+}
+
+// TouchTask bumps the UpdatedAt of the task, leaving it and its history
+// unchanged otherwise
+func TouchTask(listKey, taskTitle string) (*Task, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	task, err := getTask(listKey, taskTitle)
+	if err != nil {
+		return nil, err
+	}
+	task.UpdatedAt = now()
+	return copyTask(task), nil
+}
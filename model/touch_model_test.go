@@ -0,0 +1,52 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	TOUCH Lists and Tasks
+*******************************/
+
+func TestTouch_ok(t *testing.T) {
+	defer func() { now = time.Now }()
+	created := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	now = func() time.Time { return created }
+	CreateToDoListWithTasks("ListTouch", []string{"Read book"})
+	if task, _ := GetTask("ListTouch", "Read book"); !task.UpdatedAt.Equal(created) {
+		t.Fatalf("expected the task updated on creation, got %v", task.UpdatedAt)
+	}
+
+	touched := created.Add(time.Hour)
+	now = func() time.Time { return touched }
+	task, err := TouchTask("ListTouch", "Read book")
+	if err != nil || !task.UpdatedAt.Equal(touched) || !task.CreatedAt.Equal(created) {
+		t.Errorf("expected only the task UpdatedAt bumped, got %v, %v", task, err)
+	}
+	if changes, _ := TaskHistory("ListTouch", "Read book"); len(changes) != 1 {
+		t.Errorf("expected no change recorded by the touch, got %+v", changes)
+	}
+	if list, _ := GetToDoList("ListTouch"); !list.UpdatedAt.Equal(created) {
+		t.Errorf("expected the list left untouched, got %v", list.UpdatedAt)
+	}
+
+	list, err := Touch("ListTouch")
+	if err != nil || !list.UpdatedAt.Equal(touched) || len(list.Tasks) != 1 {
+		t.Errorf("expected the list UpdatedAt bumped, got %v, %v", list, err)
+	}
+
+	updated := touched.Add(time.Hour)
+	now = func() time.Time { return updated }
+	UpdateTask("ListTouch", "Read book", "Read book", true)
+	if list, _ := GetToDoList("ListTouch"); !list.UpdatedAt.Equal(updated) || !list.Tasks[0].UpdatedAt.Equal(updated) {
+		t.Errorf("expected the change of the task to update the list and the task, got %v", list.UpdatedAt)
+	}
+
+	if _, err := Touch("invalid"); err == nil {
+		t.Errorf("expected error with a missing list")
+	}
+	if _, err := TouchTask("ListTouch", "invalid"); err == nil {
+		t.Errorf("expected error task not found")
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MaxUpcomingDays is the maximum number of days looked ahead for the
+// upcoming tasks
+const MaxUpcomingDays = 366
+
+// UpcomingTasks returns the open tasks of the ToDo list due within the next
+// days days in the location, today included, that are not overdue yet, sorted
+// by due date. Deferred tasks are left out.
+func UpcomingTasks(listKey string, days int, loc *time.Location) ([]*Task, error) {
+	if days < 1 || days > MaxUpcomingDays {
+		return nil, fmt.Errorf("invalid number of days, expected 1 to %d", MaxUpcomingDays)
+	}
+	if loc == nil {
+		return nil, fmt.Errorf("missing time zone")
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+	list, err := getToDoList(listKey)
+	if err != nil {
+		return nil, err
+	}
+
+	current := now()
+	today, _ := DayBounds(current, loc)
+	end := today.AddDate(0, 0, days)
+	upcoming := []*Task{}
+	for _, t := range list.Tasks {
+		if t.Done || t.Deferred || t.DueDate == nil {
+			continue
+		}
+		due, overdueAt := dueBounds(t, loc)
+		if !current.Before(overdueAt) || !due.Before(end) {
+			continue
+		}
+		upcoming = append(upcoming, copyTask(t))
+	}
+	sort.Slice(upcoming, func(i, j int) bool {
+		if a, b := upcoming[i].DueDate, upcoming[j].DueDate; !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return createdBefore(upcoming[i], upcoming[j])
+	})
+	return upcoming, nil
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+/*******************************
+	UPCOMING Tasks
+*******************************/
+
+func TestUpcomingTasks_ok(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC) }
+	CreateToDoListWithTasks("ListUpcoming", []string{"Overdue", "Tonight", "Friday", "Next month", "Done", "Someday", "No due date"})
+	SetTaskDetails("ListUpcoming", "Overdue", dueOn(2024, 6, 3, 9))
+	SetTaskDetails("ListUpcoming", "Tonight", dueOn(2024, 6, 3, 20))
+	SetTaskDetails("ListUpcoming", "Friday", dueOn(2024, 6, 7, 9))
+	SetTaskDetails("ListUpcoming", "Next month", dueOn(2024, 7, 1, 9))
+	SetTaskDetails("ListUpcoming", "Done", dueOn(2024, 6, 4, 9))
+	SetTaskDetails("ListUpcoming", "Someday", dueOn(2024, 6, 4, 9))
+	UpdateTask("ListUpcoming", "Done", "Done", true)
+	SetTaskDeferred("ListUpcoming", "Someday", true)
+
+	tasks, err := UpcomingTasks("ListUpcoming", 7, time.UTC)
+	if err != nil || len(tasks) != 2 || tasks[0].Title != "Tonight" || tasks[1].Title != "Friday" {
+		t.Fatalf("expected Tonight and Friday upcoming, got %v, %v", tasks, err)
+	}
+	if tasks, _ := UpcomingTasks("ListUpcoming", 1, time.UTC); len(tasks) != 1 || tasks[0].Title != "Tonight" {
+		t.Errorf("expected only Tonight upcoming today, got %v", tasks)
+	}
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	if tasks, _ := UpcomingTasks("ListUpcoming", 1, tokyo); len(tasks) != 0 {
+		t.Errorf("expected nothing upcoming today in Tokyo, got %v", tasks)
+	}
+}
+
+func TestUpcomingTasks_error(t *testing.T) {
+	for _, days := range []int{0, -1, MaxUpcomingDays + 1} {
+		if _, err := UpcomingTasks("ListUpcoming", days, time.UTC); err == nil {
+			t.Errorf("expected error with %d days", days)
+		}
+	}
+	if _, err := UpcomingTasks("invalid", 7, time.UTC); err == nil {
+		t.Errorf("expected error with a missing list")
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/efreddo/v1/todolist/backup"
+	"github.com/efreddo/v1/todolist/controller"
+	"github.com/efreddo/v1/todolist/dateutils"
+	"github.com/efreddo/v1/todolist/ipfilter"
+	"github.com/efreddo/v1/todolist/limiter"
+	"github.com/efreddo/v1/todolist/model"
+)
+
+// emptyListMaxAge is the time after which the empty lists are archived, 0
+// when the cleanup is disabled
+var emptyListMaxAge time.Duration
+
+// configure applies the settings read from the TODOLIST_* environment variables
+func configure() error {
+	maxLength, err := envInt("TODOLIST_MAX_DESCRIPTION_LENGTH", 10000)
+	if err != nil {
+		return err
+	}
+	policy := os.Getenv("TODOLIST_DESCRIPTION_POLICY")
+	if policy != "" && policy != "reject" && policy != "truncate" {
+		return fmt.Errorf("invalid TODOLIST_DESCRIPTION_POLICY %s, expected reject or truncate", policy)
+	}
+	if err := model.SetDescriptionPolicy(maxLength, policy == "truncate"); err != nil {
+		return err
+	}
+
+	priority, err := envInt("TODOLIST_DEFAULT_PRIORITY", model.PriorityNone)
+	if err != nil {
+		return err
+	}
+	if err := model.SetDefaultPriority(priority); err != nil {
+		return err
+	}
+
+	switch weekStart := os.Getenv("TODOLIST_DIGEST_WEEK_START"); weekStart {
+	case "", "monday":
+	case "sunday":
+		model.SetDigestWeekStart(time.Sunday)
+	default:
+		return fmt.Errorf("invalid TODOLIST_DIGEST_WEEK_START %s, expected monday or sunday", weekStart)
+	}
+
+	switch mode := os.Getenv("TODOLIST_STRICT"); mode {
+	case "", "false":
+	case "true":
+		controller.SetStrict(true)
+	default:
+		return fmt.Errorf("invalid TODOLIST_STRICT %s, expected true or false", mode)
+	}
+
+	switch debug := os.Getenv("TODOLIST_DEBUG"); debug {
+	case "", "false":
+	case "true":
+		controller.SetDebug(true)
+	default:
+		return fmt.Errorf("invalid TODOLIST_DEBUG %s, expected true or false", debug)
+	}
+
+	if emptyListMaxAge, err = envDuration("TODOLIST_EMPTY_LIST_MAX_AGE"); err != nil {
+		return err
+	}
+	if _, ok := os.LookupEnv("TODOLIST_OPERATION_TTL"); ok {
+		ttl, err := envDuration("TODOLIST_OPERATION_TTL")
+		if err != nil {
+			return err
+		}
+		if err := controller.SetOperationTTL(ttl); err != nil {
+			return err
+		}
+	}
+	if _, ok := os.LookupEnv("TODOLIST_TOMBSTONE_RETENTION"); ok {
+		retention, err := envDuration("TODOLIST_TOMBSTONE_RETENTION")
+		if err != nil {
+			return err
+		}
+		if err := model.SetTombstoneRetention(retention); err != nil {
+			return err
+		}
+	}
+	if _, ok := os.LookupEnv("TODOLIST_HISTORY_RETENTION"); ok {
+		retention, err := envDuration("TODOLIST_HISTORY_RETENTION")
+		if err != nil {
+			return err
+		}
+		if err := model.SetHistoryRetention(retention); err != nil {
+			return err
+		}
+	}
+	if _, ok := os.LookupEnv("TODOLIST_EMPTY_LIST_EXCLUDE"); ok {
+		model.SetCleanupExcludedLists(envList("TODOLIST_EMPTY_LIST_EXCLUDE"))
+	}
+	if err := model.SetDefaultMutedEvents(envList("TODOLIST_MUTED_EVENTS")); err != nil {
+		return fmt.Errorf("invalid TODOLIST_MUTED_EVENTS: %v", err)
+	}
+	if _, ok := os.LookupEnv("TODOLIST_RESERVED_LIST_NAMES"); ok {
+		model.SetReservedListNames(envList("TODOLIST_RESERVED_LIST_NAMES"))
+	}
+
+	maxImportSize, err := envInt("TODOLIST_MAX_IMPORT_SIZE", 32<<20)
+	if err != nil {
+		return err
+	}
+	if err := controller.SetMaxImportSize(int64(maxImportSize)); err != nil {
+		return err
+	}
+
+	maxInFlight, err := envInt("TODOLIST_MAX_INFLIGHT", 0)
+	if err != nil {
+		return err
+	}
+	if err := limiter.SetMaxInFlight(maxInFlight); err != nil {
+		return err
+	}
+
+	var sizes controller.PageSizes
+	if sizes.Lists, err = envInt("TODOLIST_PAGE_SIZE_LISTS", 0); err != nil {
+		return err
+	}
+	if sizes.Tasks, err = envInt("TODOLIST_PAGE_SIZE_TASKS", 0); err != nil {
+		return err
+	}
+	if sizes.Search, err = envInt("TODOLIST_PAGE_SIZE_SEARCH", 0); err != nil {
+		return err
+	}
+	if err := controller.SetPageSizes(sizes); err != nil {
+		return err
+	}
+	maxUnpaginatedTasks, err := envInt("TODOLIST_MAX_UNPAGINATED_TASKS", controller.DefaultMaxUnpaginatedTasks)
+	if err != nil {
+		return err
+	}
+	if err := controller.SetMaxUnpaginatedTasks(maxUnpaginatedTasks); err != nil {
+		return err
+	}
+
+	if name := os.Getenv("TODOLIST_DATE_PARSING"); name != "" {
+		mode, err := dateutils.ParseMode(name)
+		if err != nil {
+			return err
+		}
+		dateutils.SetDefaultMode(mode)
+	}
+	if mode := os.Getenv("TODOLIST_JSON_NULLS"); mode != "" {
+		if err := controller.SetNullHandling(mode); err != nil {
+			return err
+		}
+	}
+
+	var backups backup.Policy
+	backups.Dir = os.Getenv("TODOLIST_BACKUP_DIR")
+	if backups.Interval, err = envDuration("TODOLIST_BACKUP_INTERVAL"); err != nil {
+		return err
+	}
+	if backups.Keep, err = envInt("TODOLIST_BACKUP_KEEP", 0); err != nil {
+		return err
+	}
+	if backups.MaxAge, err = envDuration("TODOLIST_BACKUP_MAX_AGE"); err != nil {
+		return err
+	}
+	if err := backup.SetPolicy(backups); err != nil {
+		return err
+	}
+
+	for _, rule := range envList("TODOLIST_REQUIRED_TAGS") {
+		list, prefix, ok := strings.Cut(rule, "=")
+		if !ok || list == "" || prefix == "" {
+			return fmt.Errorf("invalid TODOLIST_REQUIRED_TAGS rule %s, expected <list>=<tag prefix>", rule)
+		}
+		hook := model.RequiredTagRule(list, prefix)
+		model.RegisterHook(model.EventTaskCreated, hook)
+		model.RegisterHook(model.EventTaskUpdated, hook)
+	}
+	for _, rule := range envList("TODOLIST_MAX_OPEN_TASKS") {
+		list, value, _ := strings.Cut(rule, "=")
+		max, err := strconv.Atoi(value)
+		if list == "" || err != nil || max < 1 {
+			return fmt.Errorf("invalid TODOLIST_MAX_OPEN_TASKS rule %s, expected <list>=<maximum>", rule)
+		}
+		hook := model.MaxOpenTasksRule(list, max)
+		model.RegisterHook(model.EventTaskCreated, hook)
+		model.RegisterHook(model.EventTaskUpdated, hook)
+	}
+	aliases := map[string]string{}
+	for _, rule := range envList("TODOLIST_FIELD_ALIASES") {
+		field, alias, _ := strings.Cut(rule, "=")
+		field = strings.TrimSpace(field)
+		if _, ok := aliases[field]; ok {
+			return fmt.Errorf("invalid TODOLIST_FIELD_ALIASES: field %s aliased twice", field)
+		}
+		aliases[field] = strings.TrimSpace(alias)
+	}
+	if err := controller.SetFieldAliases(aliases); err != nil {
+		return fmt.Errorf("invalid TODOLIST_FIELD_ALIASES: %v", err)
+	}
+	for _, rule := range envList("TODOLIST_CACHE_CONTROL") {
+		category, policy, _ := strings.Cut(rule, "=")
+		if err := controller.SetCachePolicy(category, policy); err != nil {
+			return fmt.Errorf("invalid TODOLIST_CACHE_CONTROL rule %s: %v", rule, err)
+		}
+	}
+
+	return ipfilter.SetRules(ipfilter.Rules{
+		Allow:          envList("TODOLIST_IP_ALLOW"),
+		Deny:           envList("TODOLIST_IP_DENY"),
+		TrustedProxies: envList("TODOLIST_TRUSTED_PROXIES")})
+}
+
+// envList returns the comma separated values of the environment variable
+func envList(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// envInt returns the integer value of the environment variable, def when unset
+func envInt(name string, def int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %s, integer expected", name, value)
+	}
+	return n, nil
+}
+
+// envDuration returns the duration value of the environment variable, e.g.
+// 1h30m, 0 when unset
+func envDuration(name string) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %s, duration expected (e.g. 1h30m)", name, value)
+	}
+	return d, nil
+}
@@ -1,18 +1,35 @@
 package main
 
 import (
+		"context"
 		"net/http"
 		"io/ioutil"
 		"os"
+		"strings"
 		"fmt"
+		"github.com/efreddo/v1/todolist/backup"
 		"github.com/efreddo/v1/todolist/controller"
+		"github.com/efreddo/v1/todolist/ipfilter"
+		"github.com/efreddo/v1/todolist/limiter"
 		"github.com/efreddo/v1/todolist/logutils"
+		"github.com/efreddo/v1/todolist/model"
 		"github.com/julienschmidt/httprouter"
 )
 
 
 func main(){
 	logutils.InitLogs(ioutil.Discard, os.Stdout, os.Stdout, os.Stderr)
+	if err := configure(); err != nil {
+		logutils.Error.Fatalln(err)
+	}
+	go backup.Start(nil)
+	go model.RemindTasks(context.Background())
+	if emptyListMaxAge > 0 {
+		go model.CleanupEmptyLists(context.Background(), emptyListMaxAge)
+	}
+	// nothing is loaded from disk at startup yet, the store is ready once
+	// configured
+	model.SetReady(true)
 	RegisterHandlers()
 }
 
@@ -26,19 +43,133 @@ func RegisterHandlers() {
 	r.POST("/lists/", controller.CreateToDoList)	
 	r.DELETE("/lists/:list", controller.DeleteToDoList)
 	r.PUT("/lists/:list",  controller.UpdateToDoList)	
+	r.PATCH("/lists/:list/", controller.PatchToDoList)
 	r.GET("/lists/", controller.GetAllToDoList)
 	r.GET("/lists/:list/", controller.GetToDoList)
+	r.GET("/lists/:list", staticRoutes("list", map[string]httprouter.Handle{
+		"query": controller.QueryLists,
+		"export": controller.ExportAllToDoListsXLSX,
+	}))
+	r.POST("/lists/:list", staticRoutes("list", map[string]httprouter.Handle{
+		"export": controller.ExportToDoLists,
+		"archive": controller.UploadToDoListArchive,
+		"bulk-archive": controller.Idempotent(controller.ArchiveToDoLists),
+	}))
+	r.GET("/lists/:list/archive", controller.DownloadToDoListArchive)
+	r.GET("/lists/:list/export", controller.StreamToDoListExport)
+	r.GET("/lists/:list/export.md", controller.ExportToDoListMarkdown)
+	r.GET("/lists/:list/notifications", controller.GetListNotifications)
+	r.PUT("/lists/:list/notifications", controller.SetListNotifications)
+
+	r.GET("/lists/:list/stats/history", controller.GetStatsHistory)
+	r.GET("/stats/history", controller.GetStatsHistory)
+	r.GET("/review", controller.GetWeeklyReview)
+	r.GET("/digest/weekly", controller.GetWeeklyDigest)
+	r.GET("/calendar", controller.GetCalendar)
+	r.GET("/activity", controller.GetActivity)
 
 	// Tasks
 	r.POST("/lists/:list/tasks",  controller.CreateTask)	
-	r.DELETE("/lists/:list/tasks/:task",  controller.DeleteTask)	
-	r.PUT("/lists/:list/tasks/:task",  controller.UpdateTask)	
-	r.GET("/lists/:list/tasks/:task",  controller.GetTask)
+	r.DELETE("/lists/:list/tasks/:task",  controller.TaskRef(controller.DeleteTask))	
+	r.PUT("/lists/:list/tasks/:task",  controller.TaskRef(controller.UpdateTask))	
+	r.GET("/lists/:list/tasks/:task",  staticRoutesOr("task", map[string]httprouter.Handle{
+		"duplicates": controller.GetDuplicateTasks,
+		"upcoming":   controller.GetUpcomingTasks,
+	}, controller.TaskRef(controller.GetTask)))
+	r.GET("/lists/:list/tasks/:task/:sub", staticRoutes("sub", map[string]httprouter.Handle{
+		"history": controller.TaskRef(controller.GetTaskHistory),
+	}))
+	r.PATCH("/lists/:list/tasks/:task", staticRoutesOr("task", map[string]httprouter.Handle{
+		"priority": controller.Idempotent(controller.SetTasksPriority),
+	}, controller.TaskRef(controller.PatchTask)))
+	r.POST("/lists/:list/tasks/:task", staticRoutes("task", map[string]httprouter.Handle{
+		"import.csv": controller.Idempotent(controller.ImportTasksCSV),
+	}))
+	r.POST("/lists/:list/tasks/:task/:sub", staticRoutes("sub", map[string]httprouter.Handle{
+		"done":    controller.TaskRef(controller.CompareAndSetTaskDone),
+		"timelog": controller.TaskRef(controller.LogTaskTime),
+		"touch":   controller.TaskRef(controller.TouchTask),
+		"merge": staticRoutes("task", map[string]httprouter.Handle{
+			"duplicates": controller.MergeDuplicateTasks,
+		}),
+	}))
+	r.POST("/lists/:list/tasks/:task/:sub/", staticRoutes("task", map[string]httprouter.Handle{
+		"from-template": aliasParam("sub", "template", controller.CreateTaskFromTemplate),
+	}))
+	r.POST("/lists/:list/import", controller.Idempotent(controller.ImportTasks))
+	r.POST("/lists/:list/touch", controller.TouchToDoList)
+	r.POST("/lists/:list/text", staticRoutes("list", map[string]httprouter.Handle{
+		"import": controller.ImportChecklistText,
+	}))
+	r.PATCH("/lists/:list/tasks/:task/checklist/:index", controller.TaskRef(controller.PatchChecklistItem))
+	r.PATCH("/lists/:list/tasks/:task/progress", controller.TaskRef(controller.PatchTaskProgress))
+	r.PUT("/lists/:list/tasks/:task/deferred", controller.TaskRef(controller.SetTaskDeferred))
+	r.GET("/lists/:list/tasks/", controller.GetTasks)
+	r.GET("/lists/:list/next", controller.GetNextTask)
+	r.GET("/lists/:list/timespent", controller.GetListTimeSpent)
+	r.GET("/tasks/completed", controller.GetCompletedTasks)
+	r.GET("/tasks/nearby", controller.GetNearbyTasks)
+	r.GET("/overdue", controller.GetOverdueTasks)
+
+	// Task templates
+	r.POST("/tasktemplates/", controller.CreateTaskTemplate)
+	r.GET("/tasktemplates/", controller.GetAllTaskTemplates)
+	r.GET("/tasktemplates/:template", controller.GetTaskTemplate)
+	r.PUT("/tasktemplates/:template", controller.UpdateTaskTemplate)
+	r.DELETE("/tasktemplates/:template", controller.DeleteTaskTemplate)
+
+	r.GET("/config", controller.GetConfig)
+	r.GET("/metrics", controller.GetMetrics)
+	r.GET("/readyz", controller.GetReadiness)
 
-	http.ListenAndServe(":8080" , r)	
+	// Admin
+	r.GET("/admin/ipfilter", controller.GetIPFilter)
+	r.PUT("/admin/ipfilter", controller.SetIPFilter)
+	r.GET("/admin/orphans", controller.GetOrphanedTasks)
+	r.POST("/admin/orphans/cleanup", controller.CleanupOrphanedTasks)
+	r.POST("/admin/compact", controller.CompactStore)
+	r.GET("/admin/backup/", controller.GetBackupStatus)
+	r.POST("/admin/backup/", controller.CreateBackup)
+
+	http.ListenAndServe(":8080" , controller.Versioning(ipfilter.Middleware(limiter.Middleware(controller.Readiness(controller.ContentEncoding(controller.CacheControl(controller.Timing(r)), importRequest))))))	
 	
 }
 
+// staticRoutes dispatches the static paths sharing a path segment with the
+// given wildcard, httprouter not allowing both on the same segment.
+func staticRoutes(name string, routes map[string]httprouter.Handle) httprouter.Handle {
+	return staticRoutesOr(name, routes, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		http.NotFound(w, r)
+	})
+}
+
+// staticRoutesOr dispatches the static paths as staticRoutes, the other
+// values of the wildcard to fallback.
+func staticRoutesOr(name string, routes map[string]httprouter.Handle, fallback httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+		if handle, ok := routes[param.ByName(name)]; ok {
+			handle(w, r, param)
+			return
+		}
+		fallback(w, r, param)
+	}
+}
+
+// aliasParam exposes the from path parameter as to, the routes sharing a
+// wildcard segment having to use the same parameter name.
+func aliasParam(from, to string, handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+		handle(w, r, append(param, httprouter.Param{Key: to, Value: param.ByName(from)}))
+	}
+}
+
+// importRequest selects the import requests, accepting compressed bodies
+func importRequest(r *http.Request) bool {
+	return r.Method == http.MethodPost &&
+		(r.URL.Path == "/lists/archive" || r.URL.Path == "/lists/import/text" || strings.HasSuffix(r.URL.Path, "/tasks/import.csv") ||
+			strings.HasSuffix(r.URL.Path, "/import"))
+}
+
 func testWorking(w http.ResponseWriter, r *http.Request, param httprouter.Params){
 	fmt.Fprintf(w, "WORKING!!!")
 }
\ No newline at end of file
@@ -0,0 +1,149 @@
+// Package validation checks request bodies against the rules declared in the
+// validate struct tags, e.g. `validate:"required,max=200"`.
+//
+// Supported rules:
+//   required   the value must not be empty (zero value, nil pointer, empty map or slice)
+//   min=N      minimum length of strings, maps and slices, minimum value of numbers
+//   max=N      maximum length of strings, maps and slices, maximum value of numbers
+//
+// Nested and embedded structs, and the structs in slices, are validated too,
+// nil pointers are skipped unless required.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation is a rule not satisfied by a field. Field is the JSON path of
+// the field, Value the offending value.
+type Violation struct {
+	Field string
+	Rule  string
+	Value interface{}
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s violates %s", v.Field, v.Rule)
+}
+
+// Validate checks v, a struct or a pointer to a struct, and returns all the
+// violations found, in field declaration order.
+func Validate(v interface{}) []Violation {
+	var violations []Violation
+	validateStruct(reflect.Indirect(reflect.ValueOf(v)), "", &violations)
+	return violations
+}
+
+func validateStruct(v reflect.Value, path string, violations *[]Violation) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		value := v.Field(i)
+		if field.Anonymous {
+			validateStruct(reflect.Indirect(value), path, violations)
+			continue
+		}
+
+		fieldPath := jsonName(field)
+		if path != "" {
+			fieldPath = path + "." + fieldPath
+		}
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule != "" && !check(rule, value) {
+				*violations = append(*violations, Violation{Field: fieldPath, Rule: rule, Value: offending(value)})
+			}
+		}
+
+		switch value.Kind() {
+		case reflect.Struct:
+			validateStruct(value, fieldPath, violations)
+		case reflect.Ptr:
+			if !value.IsNil() {
+				validateStruct(value.Elem(), fieldPath, violations)
+			}
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < value.Len(); j++ {
+				validateStruct(reflect.Indirect(value.Index(j)), fmt.Sprintf("%s[%d]", fieldPath, j), violations)
+			}
+		}
+	}
+}
+
+// check tells whether the value satisfies the rule
+func check(rule string, value reflect.Value) bool {
+	name, arg := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+
+	if name == "required" {
+		if value.Kind() == reflect.Map || value.Kind() == reflect.Slice {
+			return value.Len() > 0
+		}
+		return !value.IsZero()
+	}
+
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return true
+		}
+		value = value.Elem()
+	}
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: invalid rule %s", rule))
+	}
+
+	var measure float64
+	switch value.Kind() {
+	case reflect.String:
+		measure = float64(utf8.RuneCountInString(value.String()))
+	case reflect.Map, reflect.Slice:
+		measure = float64(value.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		measure = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		measure = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		measure = value.Float()
+	default:
+		return true
+	}
+
+	switch name {
+	case "min":
+		return measure >= limit
+	case "max":
+		return measure <= limit
+	}
+	panic(fmt.Sprintf("validation: unknown rule %s", rule))
+}
+
+// offending returns the value to report in a violation
+func offending(value reflect.Value) interface{} {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	return value.Interface()
+}
+
+// jsonName returns the name of the field in the JSON documents
+func jsonName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
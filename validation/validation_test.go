@@ -0,0 +1,82 @@
+package validation
+
+import "testing"
+
+type point struct {
+	Lat *float64 `validate:"min=-90,max=90"`
+	Lon *float64 `validate:"min=-180,max=180"`
+}
+
+type details struct {
+	Tags []string `validate:"max=2"`
+}
+
+type request struct {
+	Name  string `json:"name" validate:"required,max=5"`
+	Count int    `validate:"min=1"`
+	Point *point
+	details
+}
+
+func TestValidate_valid_ok(t *testing.T) {
+	lat, lon := 45.0, 9.0
+	violations := Validate(&request{Name: "list", Count: 1, Point: &point{Lat: &lat, Lon: &lon}})
+	if len(violations) != 0 {
+		t.Errorf("no violation expected, got %v", violations)
+	}
+}
+
+func TestValidate_nilPointer_ok(t *testing.T) {
+	violations := Validate(request{Name: "list", Count: 1})
+	if len(violations) != 0 {
+		t.Errorf("no violation expected, got %v", violations)
+	}
+}
+
+func TestValidate_multipleViolations_ok(t *testing.T) {
+	lat, lon := 91.0, -181.0
+	violations := Validate(&request{
+		Name:    "",
+		Count:   0,
+		Point:   &point{Lat: &lat, Lon: &lon},
+		details: details{Tags: []string{"a", "b", "c"}}})
+
+	expected := []Violation{
+		{Field: "name", Rule: "required", Value: ""},
+		{Field: "Count", Rule: "min=1", Value: 0},
+		{Field: "Point.Lat", Rule: "max=90", Value: 91.0},
+		{Field: "Point.Lon", Rule: "min=-180", Value: -181.0},
+		{Field: "Tags", Rule: "max=2"},
+	}
+	if len(violations) != len(expected) {
+		t.Fatalf("expected %d violations, got %v", len(expected), violations)
+	}
+	for i, e := range expected {
+		if violations[i].Field != e.Field || violations[i].Rule != e.Rule {
+			t.Errorf("expected %v, got %v", e, violations[i])
+		}
+		if e.Value != nil && violations[i].Value != e.Value {
+			t.Errorf("expected offending value %v, got %v", e.Value, violations[i].Value)
+		}
+	}
+}
+
+func TestValidate_maxLength_ok(t *testing.T) {
+	violations := Validate(&request{Name: "toolong", Count: 1})
+	if len(violations) != 1 || violations[0].Rule != "max=5" || violations[0].Value != "toolong" {
+		t.Errorf("expected name max=5 violation, got %v", violations)
+	}
+	// length is measured in characters
+	violations = Validate(&request{Name: "città", Count: 1})
+	if len(violations) != 0 {
+		t.Errorf("no violation expected, got %v", violations)
+	}
+}
+
+func TestValidate_sliceOfStructs_ok(t *testing.T) {
+	lat, lon := 95.0, 9.0
+	violations := Validate(&struct{ Points []point }{[]point{{}, {Lat: &lat, Lon: &lon}}})
+	if len(violations) != 1 || violations[0].Field != "Points[1].Lat" {
+		t.Errorf("expected Points[1].Lat violation, got %v", violations)
+	}
+}
@@ -0,0 +1,380 @@
+// Package xlsx writes Office Open XML workbooks (.xlsx) readable by Excel and
+// LibreOffice.
+//
+// The workbook is written as it goes: each row is encoded and compressed as
+// soon as it is written, so that the memory used does not grow with the
+// number of rows. Sheets are written one after the other, the first row of
+// each being a styled header with an auto-filter over the written rows.
+// Strings are stored inline, time.Time values as date-typed cells.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxRows is the maximum number of rows of a sheet, header included
+	MaxRows = 1048576
+	// MaxCellLength is the maximum length of a cell text, in characters,
+	// longer texts are truncated
+	MaxCellLength = 32767
+	// maxSheetName is the maximum length of a sheet name, in characters
+	maxSheetName = 31
+)
+
+// cell styles, indexes in the cellXfs of the stylesheet
+const (
+	styleDefault = iota
+	styleHeader
+	styleDate
+)
+
+// ErrTooManyRows is returned when a row is written to a full sheet
+var ErrTooManyRows = errors.New("too many rows, a sheet holds at most 1048576 rows")
+
+// ErrNoSheet is returned when a row is written before any sheet is added
+var ErrNoSheet = errors.New("no sheet to write the row to")
+
+// ContentType is the media type of the workbooks
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// excelEpoch is the day 0 of the date serial numbers
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// Writer writes a workbook to an io.Writer, Close completing it
+type Writer struct {
+	zip     *zip.Writer
+	sheets  []sheet
+	current io.Writer
+	names   map[string]bool
+	buf     bytes.Buffer
+	closed  bool
+}
+
+type sheet struct {
+	name    string
+	columns int
+	rows    int
+}
+
+// NewWriter returns a writer of a workbook to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zip: zip.NewWriter(w), names: map[string]bool{}}
+}
+
+// AddSheet starts a new sheet, the rows written afterwards being added to it,
+// and writes its header row. The name is adjusted to the rules of the sheet
+// names (at most 31 characters, none of []:*?/\, unique), the name used is
+// returned.
+func (w *Writer) AddSheet(name string, header []string) (string, error) {
+	if w.closed {
+		return "", errors.New("workbook closed")
+	}
+	if err := w.endSheet(); err != nil {
+		return "", err
+	}
+	name = w.sheetName(name)
+	part, err := w.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)+1))
+	if err != nil {
+		return "", err
+	}
+	w.current = part
+	w.sheets = append(w.sheets, sheet{name: name, columns: len(header)})
+
+	w.buf.Reset()
+	w.buf.WriteString(xml.Header)
+	w.buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	w.buf.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	if len(header) > 0 {
+		w.buf.WriteString(`<cols>`)
+		for i, title := range header {
+			width := utf8.RuneCountInString(title) + 4
+			if width < 12 {
+				width = 12
+			}
+			fmt.Fprintf(&w.buf, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+		}
+		w.buf.WriteString(`</cols>`)
+	}
+	w.buf.WriteString(`<sheetData>`)
+	if _, err := w.current.Write(w.buf.Bytes()); err != nil {
+		return "", err
+	}
+	cells := make([]interface{}, len(header))
+	for i, title := range header {
+		cells[i] = title
+	}
+	return name, w.writeRow(cells, styleHeader)
+}
+
+// WriteRow adds a row to the current sheet. The cells are strings, numbers
+// (int, int64, float64), booleans, time.Time or *time.Time values written as
+// dates, nil leaving the cell empty; other values are written with fmt.
+func (w *Writer) WriteRow(cells ...interface{}) error {
+	if w.current == nil {
+		return ErrNoSheet
+	}
+	return w.writeRow(cells, styleDefault)
+}
+
+func (w *Writer) writeRow(cells []interface{}, style int) error {
+	s := &w.sheets[len(w.sheets)-1]
+	if s.rows == MaxRows {
+		return ErrTooManyRows
+	}
+	s.rows++
+	if len(cells) > s.columns {
+		s.columns = len(cells)
+	}
+
+	w.buf.Reset()
+	fmt.Fprintf(&w.buf, `<row r="%d">`, s.rows)
+	for i, value := range cells {
+		ref := CellRef(i, s.rows-1)
+		if t, ok := value.(*time.Time); ok {
+			if t == nil {
+				continue
+			}
+			value = *t
+		}
+		switch v := value.(type) {
+		case nil:
+		case string:
+			writeString(&w.buf, ref, v, style)
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(&w.buf, `<c r="%s" t="b"%s><v>%d</v></c>`, ref, styleAttr(style), b)
+		case int:
+			fmt.Fprintf(&w.buf, `<c r="%s"%s><v>%d</v></c>`, ref, styleAttr(style), v)
+		case int64:
+			fmt.Fprintf(&w.buf, `<c r="%s"%s><v>%d</v></c>`, ref, styleAttr(style), v)
+		case float64:
+			fmt.Fprintf(&w.buf, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr(style), strconv.FormatFloat(v, 'g', -1, 64))
+		case time.Time:
+			fmt.Fprintf(&w.buf, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDate, strconv.FormatFloat(serial(v), 'f', -1, 64))
+		default:
+			writeString(&w.buf, ref, fmt.Sprint(v), style)
+		}
+	}
+	w.buf.WriteString(`</row>`)
+	_, err := w.current.Write(w.buf.Bytes())
+	return err
+}
+
+// Flush flushes the compressed rows to the underlying writer
+func (w *Writer) Flush() error {
+	return w.zip.Flush()
+}
+
+// Close completes the current sheet and the workbook, it does not close the
+// underlying writer. A workbook without sheets gets an empty one.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if len(w.sheets) == 0 {
+		if _, err := w.AddSheet("Sheet1", nil); err != nil {
+			return err
+		}
+	}
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", w.contentTypes()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", w.workbook()},
+		{"xl/_rels/workbook.xml.rels", w.workbookRels()},
+		{"xl/styles.xml", styles},
+	}
+	for _, p := range parts {
+		part, err := w.zip.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(part, p.content); err != nil {
+			return err
+		}
+	}
+	return w.zip.Close()
+}
+
+// endSheet completes the current sheet, if any
+func (w *Writer) endSheet() error {
+	if w.current == nil {
+		return nil
+	}
+	s := w.sheets[len(w.sheets)-1]
+	w.buf.Reset()
+	w.buf.WriteString(`</sheetData>`)
+	if s.columns > 0 {
+		fmt.Fprintf(&w.buf, `<autoFilter ref="%s"/>`, s.filterRange())
+	}
+	w.buf.WriteString(`</worksheet>`)
+	_, err := w.current.Write(w.buf.Bytes())
+	w.current = nil
+	return err
+}
+
+// filterRange is the range of the auto-filter, from the header to the last
+// written row
+func (s sheet) filterRange() string {
+	return CellRef(0, 0) + ":" + CellRef(s.columns-1, s.rows-1)
+}
+
+// sheetName adjusts the name to the rules of the sheet names
+func (w *Writer) sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.Trim(name, "'"))
+	if name == "" {
+		name = "Sheet"
+	}
+	base := truncate(name, maxSheetName)
+	name = base
+	for i := 2; w.names[strings.ToLower(name)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		name = truncate(base, maxSheetName-len(suffix)) + suffix
+	}
+	w.names[strings.ToLower(name)] = true
+	return name
+}
+
+// CellRef returns the A1 reference of the cell, column and row starting at 0
+func CellRef(column, row int) string {
+	name := ""
+	for column++; column > 0; column = (column - 1) / 26 {
+		name = string(rune('A'+(column-1)%26)) + name
+	}
+	return name + strconv.Itoa(row+1)
+}
+
+// absoluteRef returns the $A$1 reference of the cell
+func absoluteRef(column, row int) string {
+	ref := CellRef(column, row)
+	i := strings.IndexAny(ref, "0123456789")
+	return "$" + ref[:i] + "$" + ref[i:]
+}
+
+// serial returns the date serial number of t, the number of days since the
+// epoch of the workbooks, the time of day being its fraction. The time is
+// written as is, in its own location.
+func serial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	days := wall.Sub(excelEpoch) / time.Millisecond
+	return float64(days) / float64(24*time.Hour/time.Millisecond)
+}
+
+func writeString(buf *bytes.Buffer, ref, value string, style int) {
+	fmt.Fprintf(buf, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">`, ref, styleAttr(style))
+	xml.EscapeText(buf, []byte(truncate(value, MaxCellLength)))
+	buf.WriteString(`</t></is></c>`)
+}
+
+func styleAttr(style int) string {
+	if style == styleDefault {
+		return ""
+	}
+	return fmt.Sprintf(` s="%d"`, style)
+}
+
+// truncate returns the first max characters of s
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
+
+func (w *Writer) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func (w *Writer) workbook() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range w.sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(s.name), i+1, i+1)
+	}
+	b.WriteString(`</sheets><definedNames>`)
+	for i, s := range w.sheets {
+		if s.columns == 0 {
+			continue
+		}
+		// the auto-filter ranges, as Excel defines them
+		ref := absoluteRef(0, 0) + ":" + absoluteRef(s.columns-1, s.rows-1)
+		fmt.Fprintf(&b, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">'%s'!%s</definedName>`,
+			i, escape(strings.Replace(s.name, "'", "''", -1)), ref)
+	}
+	b.WriteString(`</definedNames></workbook>`)
+	return strings.Replace(b.String(), `<definedNames></definedNames>`, ``, 1)
+}
+
+func (w *Writer) workbookRels() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// styles defines the default, header (bold on a grey fill) and date cell
+// styles, in this order
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd\ hh:mm"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFD9D9D9"/><bgColor indexed="64"/></patternFill></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="2" borderId="0" xfId="0" applyFont="1" applyFill="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,147 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+// worksheet is the part of a sheet read back by the tests
+type worksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Style  int    `xml:"s,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+	AutoFilter struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"autoFilter"`
+}
+
+func readWorkbook(t *testing.T, b []byte) map[string][]byte {
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("expected a zip archive, got %v", err)
+	}
+	parts := map[string][]byte{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("unexpected error opening %s: %v", f.Name, err)
+		}
+		parts[f.Name], _ = ioutil.ReadAll(rc)
+		rc.Close()
+	}
+	return parts
+}
+
+func readSheet(t *testing.T, parts map[string][]byte, name string) worksheet {
+	var sheet worksheet
+	if err := xml.Unmarshal(parts[name], &sheet); err != nil {
+		t.Fatalf("expected the sheet %s, got %v", name, err)
+	}
+	return sheet
+}
+
+func TestWriter_ok(t *testing.T) {
+	var b bytes.Buffer
+	w := NewWriter(&b)
+	due := time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC)
+	if _, err := w.AddSheet("Trip", []string{"Title", "Done", "Priority", "Due date"}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	w.WriteRow("Passport <&>", true, 2, &due)
+	w.WriteRow("Tickets", false, 0, (*time.Time)(nil))
+	if _, err := w.AddSheet("Chores", []string{"Title"}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	parts := readWorkbook(t, b.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if parts[name] == nil {
+			t.Errorf("expected the part %s", name)
+		}
+	}
+	if workbook := string(parts["xl/workbook.xml"]); !strings.Contains(workbook, `<sheet name="Trip" sheetId="1"`) ||
+		!strings.Contains(workbook, `<sheet name="Chores" sheetId="2"`) {
+		t.Errorf("expected the two sheets in the workbook, got %s", workbook)
+	}
+
+	sheet := readSheet(t, parts, "xl/worksheets/sheet1.xml")
+	if len(sheet.Rows) != 3 || sheet.AutoFilter.Ref != "A1:D3" {
+		t.Fatalf("expected 3 rows filtered on A1:D3, got %+v", sheet)
+	}
+	header := sheet.Rows[0].Cells
+	if header[0].Inline != "Title" || header[0].Style != styleHeader || header[3].Inline != "Due date" {
+		t.Errorf("expected the styled header, got %+v", header)
+	}
+	row := sheet.Rows[1].Cells
+	if row[0].Ref != "A2" || row[0].Type != "inlineStr" || row[0].Inline != "Passport <&>" {
+		t.Errorf("expected an inline string, got %+v", row[0])
+	}
+	if row[1].Type != "b" || row[1].Value != "1" {
+		t.Errorf("expected a boolean, got %+v", row[1])
+	}
+	if row[2].Type != "" || row[2].Value != "2" {
+		t.Errorf("expected a number, got %+v", row[2])
+	}
+	// 2024-06-01 is the day 45444 of the workbooks, 18:00 its 0.75 fraction
+	if row[3].Type != "" || row[3].Style != styleDate || row[3].Value != "45444.75" {
+		t.Errorf("expected a date, got %+v", row[3])
+	}
+	if cells := sheet.Rows[2].Cells; len(cells) != 3 {
+		t.Errorf("expected the nil due date left empty, got %+v", cells)
+	}
+
+	if sheet := readSheet(t, parts, "xl/worksheets/sheet2.xml"); len(sheet.Rows) != 1 || sheet.AutoFilter.Ref != "A1:A1" {
+		t.Errorf("expected the header only, got %+v", sheet)
+	}
+}
+
+func TestWriter_sheetNames(t *testing.T) {
+	w := NewWriter(ioutil.Discard)
+	names := []string{}
+	for _, name := range []string{"a/b", "A/B", "", strings.Repeat("x", 40), strings.Repeat("x", 40)} {
+		name, _ := w.AddSheet(name, nil)
+		names = append(names, name)
+	}
+	w.Close()
+	expected := []string{"a_b", "A_B (2)", "Sheet", strings.Repeat("x", 31), strings.Repeat("x", 27) + " (2)"}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("expected sheet name %s, got %s", expected[i], names[i])
+		}
+	}
+}
+
+func TestWriter_error(t *testing.T) {
+	w := NewWriter(ioutil.Discard)
+	if err := w.WriteRow("Task1"); err != ErrNoSheet {
+		t.Errorf("expected ErrNoSheet, got %v", err)
+	}
+	w.AddSheet("Sheet", nil)
+	w.sheets[0].rows = MaxRows
+	if err := w.WriteRow("Task1"); err != ErrTooManyRows {
+		t.Errorf("expected ErrTooManyRows, got %v", err)
+	}
+}
+
+func TestCellRef(t *testing.T) {
+	for ref, cell := range map[string][2]int{"A1": {0, 0}, "Z2": {25, 1}, "AA10": {26, 9}, "AZ1": {51, 0}, "XFD1": {16383, 0}} {
+		if got := CellRef(cell[0], cell[1]); got != ref {
+			t.Errorf("expected %s, got %s", ref, got)
+		}
+	}
+}